@@ -0,0 +1,63 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"path/filepath"
+
+	"github.com/samzong/share-ai-platform/internal/database"
+	"github.com/samzong/share-ai-platform/internal/services"
+	"github.com/samzong/share-ai-platform/internal/storage"
+	"github.com/spf13/viper"
+)
+
+func init() {
+	// 与服务使用同一份配置文件
+	viper.SetConfigName("config")
+	viper.SetConfigType("yaml")
+	viper.AddConfigPath("./backend/config")
+	viper.AddConfigPath("./config")
+	viper.AddConfigPath(filepath.Join("..", "config"))
+	viper.AddConfigPath(filepath.Join("..", "..", "config"))
+
+	if err := viper.ReadInConfig(); err != nil {
+		log.Fatalf("Error reading config file: %v", err)
+	}
+}
+
+// gc removes uploaded files that no row references and clears references to missing files
+func main() {
+	dryRun := flag.Bool("dry-run", false, "only report what would be removed")
+	flag.Parse()
+
+	if err := database.InitDB(); err != nil {
+		log.Fatalf("Error initializing database: %v", err)
+	}
+	if err := storage.Init(); err != nil {
+		log.Fatalf("Error initializing storage: %v", err)
+	}
+
+	report, err := services.NewStorageGCService().Run(context.Background(), *dryRun)
+	if err != nil {
+		log.Fatalf("Error running storage GC: %v", err)
+	}
+
+	action := "Removed"
+	if report.DryRun {
+		action = "Would remove"
+	}
+	fmt.Printf("Scanned %d objects\n", report.Scanned)
+	for _, key := range report.Orphaned {
+		fmt.Printf("%s orphaned object %s\n", action, key)
+	}
+	for _, ref := range report.Missing {
+		fmt.Printf("%s missing reference %s.%s of %s -> %s\n", action, ref.Table, ref.Column, ref.ID, ref.Key)
+	}
+	for _, key := range report.Stale {
+		fmt.Printf("%s stale tracking record %s\n", action, key)
+	}
+	fmt.Printf("%s %d orphaned objects, %d missing references, %d stale records\n",
+		action, len(report.Orphaned), len(report.Missing), len(report.Stale))
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/samzong/share-ai-platform/internal/middleware"
+	"github.com/spf13/viper"
+)
+
+const usage = `Usage: keys <command> [flags]
+
+Commands:
+  rotate   Generate a new signing key. Running servers publish it in the JWKS
+           immediately and start signing with it after the activation delay.
+  list     List the signing keys and show which one is used for signing.
+  prune    Remove old keys, keeping the newest ones. Only prune a key after
+           every token it signed has expired.
+`
+
+func init() {
+	// 与服务使用同一份配置文件
+	viper.SetConfigName("config")
+	viper.SetConfigType("yaml")
+	viper.AddConfigPath("./backend/config")
+	viper.AddConfigPath("./config")
+	viper.AddConfigPath(filepath.Join("..", "config"))
+	viper.AddConfigPath(filepath.Join("..", "..", "config"))
+
+	if err := viper.ReadInConfig(); err != nil {
+		log.Fatalf("Error reading config file: %v", err)
+	}
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	dir := viper.GetString("server.jwt_keys_dir")
+	if dir == "" {
+		dir = "keys"
+	}
+
+	switch os.Args[1] {
+	case "rotate":
+		fs := flag.NewFlagSet("rotate", flag.ExitOnError)
+		algorithm := fs.String("algorithm", viper.GetString("server.jwt_algorithm"), "signing algorithm (EdDSA or RS256)")
+		fs.Parse(os.Args[2:])
+
+		if *algorithm == "" {
+			*algorithm = middleware.AlgorithmEdDSA
+		}
+
+		key, err := middleware.GenerateSigningKey(dir, *algorithm)
+		if err != nil {
+			log.Fatalf("Error generating signing key: %v", err)
+		}
+		log.Printf("Generated %s signing key %s in %s", key.Algorithm, key.KID, dir)
+
+	case "list":
+		ks, err := middleware.LoadKeySet(dir)
+		if err != nil {
+			log.Fatalf("Error loading signing keys: %v", err)
+		}
+		// 与服务相同的规则选择签名密钥，新密钥在激活延迟之前只用于验证
+		current := ks.Current()
+		for _, key := range ks.Keys() {
+			marker := ""
+			if key == current {
+				marker = " (signing)"
+			}
+			fmt.Printf("%s\t%s\t%s%s\n", key.KID, key.Algorithm, key.CreatedAt.Format(time.RFC3339), marker)
+		}
+
+	case "prune":
+		fs := flag.NewFlagSet("prune", flag.ExitOnError)
+		keep := fs.Int("keep", 2, "number of newest keys to keep")
+		fs.Parse(os.Args[2:])
+
+		removed, err := middleware.PruneSigningKeys(dir, *keep)
+		if err != nil {
+			log.Fatalf("Error pruning signing keys: %v", err)
+		}
+		for _, kid := range removed {
+			log.Printf("Removed signing key %s", kid)
+		}
+		log.Printf("Pruned %d signing keys", len(removed))
+
+	default:
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+}
@@ -1,14 +1,25 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log"
+	"net/http"
+	"os"
+	"os/signal"
 	"path/filepath"
+	"syscall"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	_ "github.com/samzong/share-ai-platform/docs" // 导入 swagger docs
 	"github.com/samzong/share-ai-platform/internal/api"
+	"github.com/samzong/share-ai-platform/internal/cache"
 	"github.com/samzong/share-ai-platform/internal/database"
+	"github.com/samzong/share-ai-platform/internal/middleware"
+	"github.com/samzong/share-ai-platform/internal/services"
+	"github.com/samzong/share-ai-platform/internal/storage"
 	"github.com/spf13/viper"
 )
 
@@ -43,6 +54,51 @@ func main() {
 		log.Fatalf("Error initializing database: %v", err)
 	}
 
+	// 初始化缓存，Redis 不可用时降级为进程内缓存
+	cache.Init(database.GetRedis())
+
+	// 加载 JWT 签名密钥
+	if err := middleware.InitKeys(); err != nil {
+		log.Fatalf("Error initializing signing keys: %v", err)
+	}
+
+	// 初始化文件存储后端
+	if err := storage.Init(); err != nil {
+		log.Fatalf("Error initializing storage: %v", err)
+	}
+
+	// 定期清理没有引用的上传文件
+	if interval := viper.GetInt("storage.gc_interval"); interval > 0 {
+		services.StartStorageGC(time.Duration(interval) * time.Second)
+	}
+
+	// 定期删除已过期令牌的吊销记录
+	services.StartTokenCleanup(time.Hour)
+
+	// 定期按收藏记录校准镜像收藏数
+	if interval := viper.GetInt("stars.reconcile_interval"); interval > 0 {
+		services.StartStarReconcile(time.Duration(interval) * time.Second)
+	}
+
+	// 批量写入镜像事件，并定期汇总为每日统计
+	if interval := viper.GetInt("events.flush_interval"); interval > 0 {
+		services.StartEventFlush(time.Duration(interval) * time.Second)
+	}
+	if interval := viper.GetInt("events.rollup_interval"); interval > 0 {
+		retention := time.Duration(viper.GetInt("events.retention_days")) * 24 * time.Hour
+		services.StartEventRollup(time.Duration(interval)*time.Second, retention)
+	}
+
+	// 定期离线计算相似镜像
+	if interval := viper.GetInt("recommendations.rebuild_interval"); interval > 0 {
+		services.StartSimilarityRebuild(time.Duration(interval) * time.Second)
+	}
+
+	// 定期重新计算热门镜像与标签排行
+	if interval := viper.GetInt("trending.refresh_interval"); interval > 0 {
+		services.StartTrendingRefresh(time.Duration(interval) * time.Second)
+	}
+
 	// 设置 Gin 模式
 	gin.SetMode(viper.GetString("server.mode"))
 
@@ -56,8 +112,32 @@ func main() {
 
 	// 启动服务器
 	port := viper.GetString("server.port")
-	if err := r.Run(fmt.Sprintf(":%s", port)); err != nil {
-		log.Fatalf("Error starting server: %v", err)
+	srv := &http.Server{Addr: fmt.Sprintf(":%s", port), Handler: r}
+	go func() {
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatalf("Error starting server: %v", err)
+		}
+	}()
+
+	// 收到退出信号后停止接收请求，等待进行中的请求完成
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	<-quit
+	log.Println("Shutting down server...")
+
+	timeout := 8 * time.Second
+	if seconds := viper.GetInt("server.shutdown_timeout"); seconds > 0 {
+		timeout = time.Duration(seconds) * time.Second
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	if err := srv.Shutdown(ctx); err != nil {
+		log.Printf("Error shutting down server: %v", err)
+	}
+
+	// 写入尚在内存中的镜像事件，避免重启丢失
+	if err := services.FlushEvents(); err != nil {
+		log.Printf("Error writing image events on shutdown: %v", err)
 	}
 }
 
@@ -1,11 +1,15 @@
 package main
 
 import (
+	"context"
+	"flag"
+	"fmt"
 	"log"
+	"os"
 	"path/filepath"
 
 	"github.com/samzong/share-ai-platform/internal/database"
-	"github.com/samzong/share-ai-platform/internal/models"
+	"github.com/samzong/share-ai-platform/internal/migrations"
 	"github.com/spf13/viper"
 )
 
@@ -27,24 +31,91 @@ func init() {
 	log.Printf("Using config file: %s", absPath)
 }
 
+func usage() {
+	fmt.Fprintf(os.Stderr, `Usage: migrate [-n steps] <command>
+
+Commands:
+  status  list migrations and whether they have been applied
+  up      apply pending migrations (all of them unless -n is given)
+  down    revert the newest applied migrations (one unless -n is given)
+  redo    revert and reapply the newest applied migration
+
+`)
+	flag.PrintDefaults()
+}
+
 func main() {
+	steps := flag.Int("n", 0, "number of migrations to apply or revert")
+	flag.Usage = usage
+	flag.Parse()
+
+	command := "up"
+	if flag.NArg() > 0 {
+		command = flag.Arg(0)
+	}
+
 	// 初始化数据库连接
 	if err := database.InitDB(); err != nil {
 		log.Fatalf("Error initializing database: %v", err)
 	}
+	sqlDB, err := database.GetDB().DB()
+	if err != nil {
+		log.Fatalf("Error getting database instance: %v", err)
+	}
+
+	dialect := migrations.Dialect(database.Driver())
+	all, err := migrations.Embedded(dialect)
+	if err != nil {
+		log.Fatalf("Error loading migrations: %v", err)
+	}
+	migrator := migrations.New(sqlDB, dialect, all)
+	ctx := context.Background()
 
-	// 获取数据库连接
-	db := database.GetDB()
-
-	// 自动迁移模型
-	if err := db.AutoMigrate(
-		&models.User{},
-		&models.Image{},
-		&models.Label{},
-		&models.Collection{},
-	); err != nil {
-		log.Fatalf("Error migrating database: %v", err)
+	switch command {
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			log.Fatalf("Error reading migration status: %v", err)
+		}
+		for _, s := range statuses {
+			applied := "pending"
+			if s.AppliedAt != nil {
+				applied = "applied " + s.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Printf("%04d_%-30s %s\n", s.Version, s.Name, applied)
+		}
+	case "up":
+		done, err := migrator.Up(ctx, *steps)
+		printMigrations("Applied", done)
+		if err != nil {
+			log.Fatalf("Error migrating database: %v", err)
+		}
+		if len(done) == 0 {
+			log.Println("Database is up to date")
+		}
+	case "down":
+		done, err := migrator.Down(ctx, *steps)
+		printMigrations("Reverted", done)
+		if err != nil {
+			log.Fatalf("Error reverting migrations: %v", err)
+		}
+		if len(done) == 0 {
+			log.Println("No applied migrations to revert")
+		}
+	case "redo":
+		mig, err := migrator.Redo(ctx)
+		if err != nil {
+			log.Fatalf("Error redoing migration: %v", err)
+		}
+		printMigrations("Redid", []migrations.Migration{*mig})
+	default:
+		flag.Usage()
+		os.Exit(2)
 	}
+}
 
-	log.Println("Database migration completed successfully!")
+func printMigrations(action string, done []migrations.Migration) {
+	for _, mig := range done {
+		log.Printf("%s %04d_%s", action, mig.Version, mig.Name)
+	}
 }
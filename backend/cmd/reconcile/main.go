@@ -0,0 +1,51 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"path/filepath"
+
+	"github.com/samzong/share-ai-platform/internal/database"
+	"github.com/samzong/share-ai-platform/internal/services"
+	"github.com/spf13/viper"
+)
+
+func init() {
+	// 与服务使用同一份配置文件
+	viper.SetConfigName("config")
+	viper.SetConfigType("yaml")
+	viper.AddConfigPath("./backend/config")
+	viper.AddConfigPath("./config")
+	viper.AddConfigPath(filepath.Join("..", "config"))
+	viper.AddConfigPath(filepath.Join("..", "..", "config"))
+
+	if err := viper.ReadInConfig(); err != nil {
+		log.Fatalf("Error reading config file: %v", err)
+	}
+}
+
+// reconcile recomputes image star counts from the collections table
+func main() {
+	dryRun := flag.Bool("dry-run", false, "only report drifted star counts")
+	flag.Parse()
+
+	if err := database.InitDB(); err != nil {
+		log.Fatalf("Error initializing database: %v", err)
+	}
+
+	report, err := services.NewStarReconcileService().Run(context.Background(), *dryRun)
+	if err != nil {
+		log.Fatalf("Error reconciling stars: %v", err)
+	}
+
+	action := "Fixed"
+	if report.DryRun {
+		action = "Would fix"
+	}
+	for _, d := range report.Drifted {
+		fmt.Printf("%s %s (%s): stored %d, actual %d\n", action, d.Name, d.ImageID, d.Stars, d.Actual)
+	}
+	fmt.Printf("Scanned %d images, %d drifted\n", report.Scanned, len(report.Drifted))
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"flag"
+	"log"
+	"path/filepath"
+
+	"github.com/samzong/share-ai-platform/internal/database"
+	"github.com/samzong/share-ai-platform/internal/seed"
+	"github.com/spf13/viper"
+)
+
+func init() {
+	// 与服务使用同一份配置文件
+	viper.SetConfigName("config")
+	viper.SetConfigType("yaml")
+	viper.AddConfigPath("./backend/config")
+	viper.AddConfigPath("./config")
+	viper.AddConfigPath(filepath.Join("..", "config"))
+	viper.AddConfigPath(filepath.Join("..", "..", "config"))
+
+	if err := viper.ReadInConfig(); err != nil {
+		log.Fatalf("Error reading config file: %v", err)
+	}
+}
+
+// seed loads fixtures and generates synthetic images. Run the migrations first.
+func main() {
+	file := flag.String("f", "", "fixture file to load, e.g. fixtures/demo.yaml")
+	synthetic := flag.Int("synthetic", 0, "number of synthetic images to generate for load testing")
+	org := flag.String("org", seed.PublicOrgName, "org of the synthetic images")
+	author := flag.String("author", "admin", "username of the synthetic images' author")
+	randSeed := flag.Int64("seed", 1, "random seed of the synthetic images")
+	flag.Parse()
+
+	if err := database.InitDB(); err != nil {
+		log.Fatalf("Error initializing database: %v", err)
+	}
+	db := database.GetDB()
+
+	if err := seed.EnsurePublicOrg(db); err != nil {
+		log.Fatalf("Error creating the public org: %v", err)
+	}
+
+	if *file != "" {
+		fixtures, err := seed.Load(*file)
+		if err != nil {
+			log.Fatalf("Error loading fixtures: %v", err)
+		}
+		if err := seed.Apply(db, fixtures); err != nil {
+			log.Fatalf("Error applying fixtures: %v", err)
+		}
+		log.Printf("Loaded %d users, %d orgs, %d images and %d collections from %s",
+			len(fixtures.Users), len(fixtures.Orgs), len(fixtures.Images), len(fixtures.Collections), *file)
+	}
+
+	if *synthetic > 0 {
+		created, err := seed.GenerateImages(db, seed.SyntheticOptions{
+			Count:  *synthetic,
+			Org:    *org,
+			Author: *author,
+			Seed:   *randSeed,
+		})
+		if err != nil {
+			log.Fatalf("Error generating synthetic images: %v", err)
+		}
+		log.Printf("Created %d synthetic images (%d requested)", created, *synthetic)
+	}
+}
@@ -1,6 +1,7 @@
 package api
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
@@ -49,10 +50,9 @@ func (h *Handler) RegisterRoutes(r *gin.Engine) {
 
 		// Admin routes
 		admin := api.Group("/admin")
-		admin.Use(middleware.AdminMiddleware())
 		{
-			admin.GET("/users", h.ListUsers)
-			admin.PUT("/users/:id/role", h.UpdateUserRole)
+			admin.GET("/users", middleware.RequirePermission(models.PermUsersRead), h.ListUsers)
+			admin.PUT("/users/:id/role", middleware.RequirePermission(models.PermRolesBind), h.UpdateUserRole)
 			// TODO: Implement image management endpoints
 			// admin.POST("/images", h.CreateImage)
 			// admin.PUT("/images/:id", h.UpdateImage)
@@ -104,7 +104,7 @@ func (h *Handler) Login(c *gin.Context) {
 		return
 	}
 
-	user, err := h.userService.Login(&req)
+	user, err := h.userService.Login(c.Request.Context(), &req, c.ClientIP())
 	if err != nil {
 		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: err.Error()})
 		return
@@ -255,6 +255,10 @@ func (h *Handler) GetDeployInfo(c *gin.Context) {
 		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
 		return
 	}
+	if !image.HasAccess {
+		c.JSON(http.StatusForbidden, gin.H{"error": services.ErrImageAccessRequired.Error()})
+		return
+	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"image_id": image.ID,
@@ -272,9 +276,13 @@ func (h *Handler) Deploy(c *gin.Context) {
 
 	req.ImageID = c.Param("id")
 
-	info, err := h.deployService.Deploy(&req)
+	info, err := h.deployService.Deploy(&req, middleware.GetUserID(c))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		status := http.StatusBadRequest
+		if errors.Is(err, services.ErrImageAccessRequired) {
+			status = http.StatusForbidden
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
 		return
 	}
 
@@ -0,0 +1,172 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/samzong/share-ai-platform/internal/middleware"
+	"github.com/samzong/share-ai-platform/internal/services"
+)
+
+type AccessHandler struct {
+	accessService *services.AccessService
+}
+
+func NewAccessHandler() *AccessHandler {
+	return &AccessHandler{
+		accessService: services.NewAccessService(),
+	}
+}
+
+// RequestAccess godoc
+// @Summary 申请镜像访问权限
+// @Description 非组织成员申请访问 require_access 镜像，由组织或项目维护者审批，被拒绝后冷却期内不能再次申请
+// @Tags access-requests
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "容器镜像 ID"
+// @Param request body services.CreateAccessRequest false "申请理由"
+// @Success 201 {object} models.AccessRequest
+// @Failure 400,404 {object} map[string]interface{} "error message"
+// @Router /images/{id}/access-requests [post]
+func (h *AccessHandler) RequestAccess(c *gin.Context) {
+	var req services.CreateAccessRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	userID := middleware.GetUserID(c)
+	request, err := h.accessService.RequestAccess(c.Param("id"), &req, userID)
+	if err != nil {
+		c.JSON(errorStatus(err, http.StatusBadRequest), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, request)
+}
+
+// ListMyRequests godoc
+// @Summary 获取我的访问申请
+// @Description 获取当前用户提交的镜像访问申请及其审批状态
+// @Tags access-requests
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} map[string]interface{} "data: []AccessRequestResponse"
+// @Failure 500 {object} map[string]interface{} "error message"
+// @Router /users/access-requests [get]
+func (h *AccessHandler) ListMyRequests(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+	requests, err := h.accessService.ListMyRequests(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": requests})
+}
+
+// ListOrgRequests godoc
+// @Summary 获取组织的访问申请
+// @Description 组织维护者查看组织内镜像的访问申请，项目维护者只能看到其项目内镜像的申请，可按状态过滤
+// @Tags access-requests
+// @Produce json
+// @Security ApiKeyAuth
+// @Param org_id path string true "组织 ID"
+// @Param status query string false "申请状态：pending/approved/denied"
+// @Success 200 {object} map[string]interface{} "data: []AccessRequestResponse"
+// @Failure 400,403 {object} map[string]interface{} "error message"
+// @Router /orgs/{org_id}/access-requests [get]
+func (h *AccessHandler) ListOrgRequests(c *gin.Context) {
+	var req services.ListAccessRequestsRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID := middleware.GetUserID(c)
+	orgID := middleware.ResolveOrgID(c.Param("org_id"))
+	requests, err := h.accessService.ListOrgRequests(orgID, &req, userID)
+	if err != nil {
+		c.JSON(errorStatus(err, http.StatusBadRequest), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": requests})
+}
+
+// GrantAccess godoc
+// @Summary 直接授权访问镜像
+// @Description 组织维护者无需申请直接授予用户或用户组访问镜像的权限
+// @Tags access-requests
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param org_id path string true "组织 ID"
+// @Param id path string true "容器镜像 ID"
+// @Param request body services.GrantAccessRequest true "被授权的用户或用户组"
+// @Success 201 {object} models.AccessRequest
+// @Failure 400,403,404 {object} map[string]interface{} "error message"
+// @Router /orgs/{org_id}/images/{id}/grants [post]
+func (h *AccessHandler) GrantAccess(c *gin.Context) {
+	var req services.GrantAccessRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID := middleware.GetUserID(c)
+	orgID := middleware.ResolveOrgID(c.Param("org_id"))
+	grant, err := h.accessService.GrantAccess(orgID, c.Param("id"), &req, userID)
+	if err != nil {
+		c.JSON(errorStatus(err, http.StatusBadRequest), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, grant)
+}
+
+// ApproveRequest godoc
+// @Summary 批准访问申请
+// @Description 组织或项目维护者批准镜像访问申请，申请人随即可以查看和部署该镜像
+// @Tags access-requests
+// @Produce json
+// @Security ApiKeyAuth
+// @Param org_id path string true "组织 ID"
+// @Param request_id path string true "申请 ID"
+// @Success 200 {object} models.AccessRequest
+// @Failure 400,403 {object} map[string]interface{} "error message"
+// @Router /orgs/{org_id}/access-requests/{request_id}/approve [post]
+func (h *AccessHandler) ApproveRequest(c *gin.Context) {
+	h.review(c, true)
+}
+
+// DenyRequest godoc
+// @Summary 拒绝访问申请
+// @Description 组织或项目维护者拒绝镜像访问申请；对已批准的申请执行拒绝即撤销授权
+// @Tags access-requests
+// @Produce json
+// @Security ApiKeyAuth
+// @Param org_id path string true "组织 ID"
+// @Param request_id path string true "申请 ID"
+// @Success 200 {object} models.AccessRequest
+// @Failure 400,403 {object} map[string]interface{} "error message"
+// @Router /orgs/{org_id}/access-requests/{request_id}/deny [post]
+func (h *AccessHandler) DenyRequest(c *gin.Context) {
+	h.review(c, false)
+}
+
+func (h *AccessHandler) review(c *gin.Context, approve bool) {
+	userID := middleware.GetUserID(c)
+	orgID := middleware.ResolveOrgID(c.Param("org_id"))
+	request, err := h.accessService.ReviewRequest(orgID, c.Param("request_id"), approve, userID)
+	if err != nil {
+		c.JSON(errorStatus(err, http.StatusBadRequest), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, request)
+}
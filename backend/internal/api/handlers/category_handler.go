@@ -0,0 +1,114 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/samzong/share-ai-platform/internal/middleware"
+	"github.com/samzong/share-ai-platform/internal/services"
+)
+
+type CategoryHandler struct {
+	categoryService *services.CategoryService
+}
+
+func NewCategoryHandler() *CategoryHandler {
+	return &CategoryHandler{
+		categoryService: services.NewCategoryService(),
+	}
+}
+
+// ListCategories godoc
+// @Summary 获取镜像分类树
+// @Description 获取管理员维护的层级分类，可通过 slug 在镜像列表中按分类筛选
+// @Tags categories
+// @Produce json
+// @Success 200 {object} map[string]interface{} "data: []services.CategoryResponse"
+// @Failure 500 {object} map[string]interface{} "error message"
+// @Router /categories [get]
+func (h *CategoryHandler) ListCategories(c *gin.Context) {
+	categories, err := h.categoryService.ListCategories()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": categories})
+}
+
+// CreateCategory godoc
+// @Summary 创建镜像分类
+// @Description 创建顶级分类或子分类，仅管理员可用
+// @Tags categories
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param request body services.CreateCategoryRequest true "分类信息"
+// @Success 201 {object} services.CategoryResponse
+// @Failure 400,403 {object} map[string]interface{} "error message"
+// @Router /categories [post]
+func (h *CategoryHandler) CreateCategory(c *gin.Context) {
+	var req services.CreateCategoryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID := middleware.GetUserID(c)
+	category, err := h.categoryService.CreateCategory(c.Request.Context(), &req, userID)
+	if err != nil {
+		c.JSON(errorStatus(err, http.StatusBadRequest), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, category)
+}
+
+// UpdateCategory godoc
+// @Summary 更新镜像分类
+// @Description 修改分类信息或将其移动到其他上级分类下，仅管理员可用
+// @Tags categories
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "分类 ID"
+// @Param request body services.UpdateCategoryRequest true "分类信息"
+// @Success 200 {object} services.CategoryResponse
+// @Failure 400,403,404 {object} map[string]interface{} "error message"
+// @Router /categories/{id} [put]
+func (h *CategoryHandler) UpdateCategory(c *gin.Context) {
+	var req services.UpdateCategoryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID := middleware.GetUserID(c)
+	category, err := h.categoryService.UpdateCategory(c.Request.Context(), c.Param("id"), &req, userID)
+	if err != nil {
+		c.JSON(errorStatus(err, http.StatusBadRequest), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, category)
+}
+
+// DeleteCategory godoc
+// @Summary 删除镜像分类
+// @Description 删除没有子分类的分类，镜像保留其他分类，仅管理员可用
+// @Tags categories
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "分类 ID"
+// @Success 204 "No Content"
+// @Failure 403,404,409 {object} map[string]interface{} "error message"
+// @Router /categories/{id} [delete]
+func (h *CategoryHandler) DeleteCategory(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+	if err := h.categoryService.DeleteCategory(c.Request.Context(), c.Param("id"), userID); err != nil {
+		c.JSON(errorStatus(err, http.StatusInternalServerError), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
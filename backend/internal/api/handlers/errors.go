@@ -0,0 +1,24 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/samzong/share-ai-platform/internal/authz"
+	"github.com/samzong/share-ai-platform/internal/services"
+)
+
+// errorStatus maps service errors to HTTP status codes, using fallback for everything else
+func errorStatus(err error, fallback int) int {
+	switch {
+	case errors.Is(err, authz.ErrForbidden), errors.Is(err, services.ErrImageAccessRequired):
+		return http.StatusForbidden
+	case errors.Is(err, services.ErrImageNotFound), errors.Is(err, services.ErrReadmeNotFound),
+		errors.Is(err, services.ErrListNotFound), errors.Is(err, services.ErrCategoryNotFound),
+		errors.Is(err, services.ErrLabelNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrCategoryHasChildren):
+		return http.StatusConflict
+	}
+	return fallback
+}
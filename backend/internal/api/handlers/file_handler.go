@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/samzong/share-ai-platform/internal/storage"
+)
+
+type FileHandler struct{}
+
+func NewFileHandler() *FileHandler {
+	return &FileHandler{}
+}
+
+// ServeFile godoc
+// @Summary 获取上传的文件
+// @Description 从存储后端读取头像、README 等上传文件。README 等私有文件需携带签名地址中的 expires 和 signature 参数
+// @Tags files
+// @Produce octet-stream
+// @Param key path string true "文件路径"
+// @Param expires query int false "签名过期时间（Unix 秒）"
+// @Param signature query string false "签名"
+// @Success 200 {file} binary
+// @Failure 403,404 {object} map[string]interface{} "error message"
+// @Router /uploads/{key} [get]
+func (h *FileHandler) ServeFile(c *gin.Context) {
+	store, err := storage.Get()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	key := strings.TrimPrefix(c.Param("key"), "/")
+	private := storage.IsPrivateKey(key)
+	if private {
+		signer, err := storage.Signer()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if err := signer.Verify(key, c.Request.URL.Query()); err != nil {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	body, info, err := store.Get(c.Request.Context(), key)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "file not found"})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	defer body.Close()
+
+	contentType := info.ContentType
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	c.Header("Content-Type", contentType)
+	c.Header("Content-Length", strconv.FormatInt(info.Size, 10))
+	if private {
+		c.Header("Cache-Control", "private, no-store")
+	} else {
+		c.Header("Cache-Control", "public, max-age=3600")
+	}
+	c.Status(http.StatusOK)
+	io.Copy(c.Writer, body)
+}
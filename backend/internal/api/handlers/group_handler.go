@@ -0,0 +1,163 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/samzong/share-ai-platform/internal/middleware"
+	"github.com/samzong/share-ai-platform/internal/services"
+)
+
+type GroupHandler struct {
+	groupService *services.GroupService
+}
+
+func NewGroupHandler() *GroupHandler {
+	return &GroupHandler{
+		groupService: services.NewGroupService(),
+	}
+}
+
+// CreateGroup godoc
+// @Summary 创建用户组
+// @Description 在组织下创建用户组，可指定一个顶级组作为父组（最多嵌套一层）
+// @Tags groups
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param org_id path string true "组织 ID"
+// @Param request body services.CreateGroupRequest true "用户组信息"
+// @Success 201 {object} models.Group
+// @Failure 400,403 {object} map[string]interface{} "error message"
+// @Router /orgs/{org_id}/groups [post]
+func (h *GroupHandler) CreateGroup(c *gin.Context) {
+	var req services.CreateGroupRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID := middleware.GetUserID(c)
+	group, err := h.groupService.CreateGroup(c.Param("org_id"), &req, userID)
+	if err != nil {
+		c.JSON(errorStatus(err, http.StatusBadRequest), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, group)
+}
+
+// ListGroups godoc
+// @Summary 获取用户组列表
+// @Description 获取组织下的所有用户组
+// @Tags groups
+// @Produce json
+// @Security ApiKeyAuth
+// @Param org_id path string true "组织 ID"
+// @Success 200 {object} map[string]interface{} "data: []models.Group"
+// @Failure 403,500 {object} map[string]interface{} "error message"
+// @Router /orgs/{org_id}/groups [get]
+func (h *GroupHandler) ListGroups(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+	groups, err := h.groupService.ListGroups(c.Param("org_id"), userID)
+	if err != nil {
+		c.JSON(errorStatus(err, http.StatusInternalServerError), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": groups})
+}
+
+// DeleteGroup godoc
+// @Summary 删除用户组
+// @Description 删除用户组及其成员关系、角色绑定和访问授权
+// @Tags groups
+// @Produce json
+// @Security ApiKeyAuth
+// @Param org_id path string true "组织 ID"
+// @Param group_id path string true "用户组 ID"
+// @Success 204 "No Content"
+// @Failure 400,403 {object} map[string]interface{} "error message"
+// @Router /orgs/{org_id}/groups/{group_id} [delete]
+func (h *GroupHandler) DeleteGroup(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+	if err := h.groupService.DeleteGroup(c.Param("org_id"), c.Param("group_id"), userID); err != nil {
+		c.JSON(errorStatus(err, http.StatusBadRequest), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// ListMembers godoc
+// @Summary 获取用户组成员
+// @Description 获取用户组的直接成员
+// @Tags groups
+// @Produce json
+// @Security ApiKeyAuth
+// @Param org_id path string true "组织 ID"
+// @Param group_id path string true "用户组 ID"
+// @Success 200 {object} map[string]interface{} "data: []GroupMemberResponse"
+// @Failure 400,403 {object} map[string]interface{} "error message"
+// @Router /orgs/{org_id}/groups/{group_id}/members [get]
+func (h *GroupHandler) ListMembers(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+	members, err := h.groupService.ListMembers(c.Param("org_id"), c.Param("group_id"), userID)
+	if err != nil {
+		c.JSON(errorStatus(err, http.StatusBadRequest), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": members})
+}
+
+// AddMember godoc
+// @Summary 添加用户组成员
+// @Description 将组织成员加入用户组，下一次请求即生效
+// @Tags groups
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param org_id path string true "组织 ID"
+// @Param group_id path string true "用户组 ID"
+// @Param request body services.AddGroupMemberRequest true "成员信息"
+// @Success 204 "No Content"
+// @Failure 400,403 {object} map[string]interface{} "error message"
+// @Router /orgs/{org_id}/groups/{group_id}/members [post]
+func (h *GroupHandler) AddMember(c *gin.Context) {
+	var req services.AddGroupMemberRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID := middleware.GetUserID(c)
+	if err := h.groupService.AddMember(c.Param("org_id"), c.Param("group_id"), &req, userID); err != nil {
+		c.JSON(errorStatus(err, http.StatusBadRequest), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// RemoveMember godoc
+// @Summary 移除用户组成员
+// @Description 将用户移出用户组，下一次请求即生效
+// @Tags groups
+// @Produce json
+// @Security ApiKeyAuth
+// @Param org_id path string true "组织 ID"
+// @Param group_id path string true "用户组 ID"
+// @Param user_id path string true "用户 ID"
+// @Success 204 "No Content"
+// @Failure 400,403 {object} map[string]interface{} "error message"
+// @Router /orgs/{org_id}/groups/{group_id}/members/{user_id} [delete]
+func (h *GroupHandler) RemoveMember(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+	if err := h.groupService.RemoveMember(c.Param("org_id"), c.Param("group_id"), c.Param("user_id"), userID); err != nil {
+		c.JSON(errorStatus(err, http.StatusBadRequest), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
@@ -1,10 +1,13 @@
 package handlers
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
 	"github.com/samzong/share-ai-platform/internal/middleware"
+	"github.com/samzong/share-ai-platform/internal/models"
 	"github.com/samzong/share-ai-platform/internal/services"
 )
 
@@ -27,8 +30,11 @@ func NewImageHandler() *ImageHandler {
 // @Param page query int false "页码，默认 1"
 // @Param page_size query int false "每页数量，默认 10"
 // @Param search query string false "搜索关键词（镜像名称、描述）"
+// @Param project_id query string false "项目 ID"
+// @Param category query string false "分类 slug，包含其全部子分类"
 // @Success 200 {object} map[string]interface{} "data: []ContainerImage, total: int"
 // @Failure 400 {object} map[string]interface{} "error message"
+// @Failure 404 {object} map[string]interface{} "category not found"
 // @Failure 500 {object} map[string]interface{} "error message"
 // @Router /images [get]
 func (h *ImageHandler) ListImages(c *gin.Context) {
@@ -38,15 +44,17 @@ func (h *ImageHandler) ListImages(c *gin.Context) {
 		return
 	}
 
-	// 获取用户 ID（如果已登录）
-	var userID string
-	if id, exists := c.Get("user_id"); exists {
-		userID = id.(string)
+	// 项目下的镜像列表
+	if projectID := c.Param("project_id"); projectID != "" {
+		req.ProjectID = projectID
 	}
 
+	// 获取用户 ID（如果已登录）
+	userID := middleware.GetUserID(c)
+
 	images, total, err := h.imageService.ListImages(c.Request.Context(), &req, userID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(errorStatus(err, http.StatusInternalServerError), gin.H{"error": err.Error()})
 		return
 	}
 
@@ -58,7 +66,7 @@ func (h *ImageHandler) ListImages(c *gin.Context) {
 
 // GetImage godoc
 // @Summary 获取容器镜像详情
-// @Description 根据镜像 ID 获取容器镜像的详细信息，包括镜像配置、版本、使用说明等
+// @Description 根据镜像 ID 获取容器镜像的详细信息，包括镜像配置、版本、使用说明等。私有镜像仅组织成员可见，需申请访问的镜像在获批前隐藏拉取地址
 // @Tags container-images
 // @Accept json
 // @Produce json
@@ -72,28 +80,57 @@ func (h *ImageHandler) GetImage(c *gin.Context) {
 
 	image, err := h.imageService.GetImageByID(c.Request.Context(), imageID, userID)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		c.JSON(errorStatus(err, http.StatusInternalServerError), gin.H{"error": err.Error()})
 		return
 	}
+	h.imageService.RecordEvent(image.ID, models.EventView, eventActor(c))
 
 	c.JSON(http.StatusOK, image)
 }
 
+// GetReadme godoc
+// @Summary 获取镜像 README
+// @Description 返回镜像 README 的原始 markdown 和服务端渲染并过滤后的 HTML，相对链接按 readme_base_url 改写。format=raw 返回 markdown 文本，format=html 返回 HTML 片段
+// @Tags container-images
+// @Produce json,plain,html
+// @Param id path string true "容器镜像 ID"
+// @Param format query string false "返回格式：json（默认）/ raw / html"
+// @Success 200 {object} services.ReadmeResponse
+// @Failure 403,404 {object} map[string]interface{} "error message"
+// @Router /images/{id}/readme [get]
+func (h *ImageHandler) GetReadme(c *gin.Context) {
+	readme, err := h.imageService.GetReadme(c.Request.Context(), c.Param("id"), middleware.GetUserID(c))
+	if err != nil {
+		c.JSON(errorStatus(err, http.StatusInternalServerError), gin.H{"error": err.Error()})
+		return
+	}
+	h.imageService.RecordEvent(readme.ImageID, models.EventReadmeView, eventActor(c))
+
+	switch c.Query("format") {
+	case "raw":
+		c.Data(http.StatusOK, "text/markdown; charset=utf-8", []byte(readme.Markdown))
+	case "html":
+		c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(readme.HTML))
+	default:
+		c.JSON(http.StatusOK, readme)
+	}
+}
+
 // CreateImage godoc
 // @Summary 创建容器镜像
 // @Description 创建一个新的容器镜像，包括基本信息、配置参数、运行环境等详细信息
 // @Tags container-images
-// @Accept json
+// @Accept json,mpfd
 // @Produce json
 // @Security ApiKeyAuth
 // @Param org_id path string true "组织ID，如果不指定则为 'public'"
-// @Param request body services.CreateImageRequest true "镜像信息"
+// @Param request body services.CreateImageRequest true "镜像信息，README 可通过 readme 字段内联提交，或以 multipart 表单的 readme_file 上传"
 // @Success 201 {object} services.ImageResponse
 // @Failure 400 {object} map[string]interface{} "error message"
 // @Router /orgs/{org_id}/images [post]
 func (h *ImageHandler) CreateImage(c *gin.Context) {
 	var req services.CreateImageRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
+	if err := c.ShouldBind(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
@@ -103,10 +140,13 @@ func (h *ImageHandler) CreateImage(c *gin.Context) {
 	if orgID == "" {
 		orgID = "public" // 如果不指定组织ID，则使用 "public"
 	}
+	if projectID := c.Param("project_id"); projectID != "" {
+		req.ProjectID = projectID
+	}
 
 	image, err := h.imageService.CreateImage(c.Request.Context(), &req, userID, orgID)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.JSON(errorStatus(err, http.StatusBadRequest), gin.H{"error": err.Error()})
 		return
 	}
 
@@ -120,11 +160,12 @@ func (h *ImageHandler) CreateImage(c *gin.Context) {
 // @Accept json
 // @Produce json
 // @Security ApiKeyAuth
+// @Param org_id path string true "组织ID，public 表示公共组织"
 // @Param id path string true "容器镜像 ID"
 // @Param request body services.UpdateImageRequest true "更新的镜像信息"
 // @Success 200 {object} services.ImageResponse
-// @Failure 400 {object} map[string]interface{} "error message"
-// @Router /images/{id} [put]
+// @Failure 400,403 {object} map[string]interface{} "error message"
+// @Router /orgs/{org_id}/images/{id} [put]
 func (h *ImageHandler) UpdateImage(c *gin.Context) {
 	var req services.UpdateImageRequest
 	if err := c.ShouldBind(&req); err != nil {
@@ -132,11 +173,12 @@ func (h *ImageHandler) UpdateImage(c *gin.Context) {
 		return
 	}
 
+	orgID := middleware.ResolveOrgID(c.Param("org_id"))
 	imageID := c.Param("id")
 	userID := middleware.GetUserID(c)
-	image, err := h.imageService.UpdateImage(c.Request.Context(), imageID, &req, userID)
+	image, err := h.imageService.UpdateImage(c.Request.Context(), orgID, imageID, &req, userID)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.JSON(errorStatus(err, http.StatusBadRequest), gin.H{"error": err.Error()})
 		return
 	}
 
@@ -150,16 +192,18 @@ func (h *ImageHandler) UpdateImage(c *gin.Context) {
 // @Accept json
 // @Produce json
 // @Security ApiKeyAuth
+// @Param org_id path string true "组织ID，public 表示公共组织"
 // @Param id path string true "容器镜像 ID"
-// @Success 200 {object} map[string]interface{} "success message"
-// @Failure 400 {object} map[string]interface{} "error message"
-// @Router /images/{id} [delete]
+// @Success 204 "No Content"
+// @Failure 400,403 {object} map[string]interface{} "error message"
+// @Router /orgs/{org_id}/images/{id} [delete]
 func (h *ImageHandler) DeleteImage(c *gin.Context) {
+	orgID := middleware.ResolveOrgID(c.Param("org_id"))
 	imageID := c.Param("id")
 	userID := middleware.GetUserID(c)
 
-	if err := h.imageService.DeleteImage(c.Request.Context(), imageID, userID); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if err := h.imageService.DeleteImage(c.Request.Context(), orgID, imageID, userID); err != nil {
+		c.JSON(errorStatus(err, http.StatusBadRequest), gin.H{"error": err.Error()})
 		return
 	}
 
@@ -182,7 +226,7 @@ func (h *ImageHandler) CollectImage(c *gin.Context) {
 	userID := middleware.GetUserID(c)
 
 	if err := h.imageService.CollectImage(userID, imageID); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.JSON(errorStatus(err, http.StatusBadRequest), gin.H{"error": err.Error()})
 		return
 	}
 
@@ -205,7 +249,7 @@ func (h *ImageHandler) UncollectImage(c *gin.Context) {
 	userID := middleware.GetUserID(c)
 
 	if err := h.imageService.UncollectImage(userID, imageID); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.JSON(errorStatus(err, http.StatusBadRequest), gin.H{"error": err.Error()})
 		return
 	}
 
@@ -232,7 +276,7 @@ func (h *ImageHandler) ListFavorites(c *gin.Context) {
 	userID := middleware.GetUserID(c)
 	images, total, err := h.imageService.ListFavorites(c.Request.Context(), &req, userID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(errorStatus(err, http.StatusInternalServerError), gin.H{"error": err.Error()})
 		return
 	}
 
@@ -241,3 +285,71 @@ func (h *ImageHandler) ListFavorites(c *gin.Context) {
 		"total": total,
 	})
 }
+
+// TrackEvent godoc
+// @Summary 上报镜像使用事件
+// @Description 记录前端发生的镜像使用行为，目前只有复制使用命令（usage_copy）。浏览、README 浏览和部署由服务端记录。未登录时可通过 X-Anonymous-ID 请求头区分访客，登录用户可通过 X-Org-ID 请求头标明所在组织
+// @Tags container-images
+// @Accept json
+// @Produce json
+// @Param id path string true "容器镜像 ID"
+// @Param request body services.TrackEventRequest true "事件类型"
+// @Success 202
+// @Failure 400,403,404 {object} map[string]interface{} "error message"
+// @Router /images/{id}/events [post]
+func (h *ImageHandler) TrackEvent(c *gin.Context) {
+	var req services.TrackEventRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.imageService.TrackEvent(c.Param("id"), &req, eventActor(c)); err != nil {
+		c.JSON(errorStatus(err, http.StatusInternalServerError), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusAccepted)
+}
+
+// GetImageStats godoc
+// @Summary 获取镜像使用统计
+// @Description 按天返回镜像的浏览、README 浏览、复制使用命令、部署次数和访客数，统计有几分钟延迟。仅可更新该镜像的用户可用
+// @Tags container-images
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "容器镜像 ID"
+// @Param days query int false "统计最近多少天，默认 30，最多 365"
+// @Success 200 {object} services.ImageStatsResponse
+// @Failure 400,403,404 {object} map[string]interface{} "error message"
+// @Router /images/{id}/stats [get]
+func (h *ImageHandler) GetImageStats(c *gin.Context) {
+	var req services.ImageStatsRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	stats, err := h.imageService.ImageStats(c.Param("id"), &req, middleware.GetUserID(c))
+	if err != nil {
+		c.JSON(errorStatus(err, http.StatusInternalServerError), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, stats)
+}
+
+// eventActor identifies who caused an image event. Without an anonymous ID from the
+// client, anonymous visitors are told apart by a digest of their address and user agent,
+// so that the address itself is never stored.
+func eventActor(c *gin.Context) services.EventActor {
+	if userID := middleware.GetUserID(c); userID != "" {
+		return services.EventActor{UserID: userID, OrgID: c.GetHeader("X-Org-ID")}
+	}
+	anonymousID := c.GetHeader("X-Anonymous-ID")
+	if anonymousID == "" || len(anonymousID) > 64 {
+		sum := sha256.Sum256([]byte(c.ClientIP() + "|" + c.Request.UserAgent()))
+		anonymousID = hex.EncodeToString(sum[:16])
+	}
+	return services.EventActor{AnonymousID: anonymousID}
+}
@@ -0,0 +1,34 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/samzong/share-ai-platform/internal/middleware"
+)
+
+type KeysHandler struct{}
+
+func NewKeysHandler() *KeysHandler {
+	return &KeysHandler{}
+}
+
+// JWKS godoc
+// @Summary JSON Web Key Set
+// @Description Public keys used to verify access tokens issued by this service, identified by kid
+// @Tags auth
+// @Produce json
+// @Success 200 {object} middleware.JWKSet
+// @Failure 500 {object} map[string]interface{} "error message"
+// @Router /.well-known/jwks.json [get]
+func (h *KeysHandler) JWKS(c *gin.Context) {
+	jwks, err := middleware.PublicJWKS()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	// 允许验证方短暂缓存，密钥轮换时新密钥会提前发布
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, jwks)
+}
@@ -0,0 +1,173 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/samzong/share-ai-platform/internal/middleware"
+	"github.com/samzong/share-ai-platform/internal/services"
+)
+
+type LabelHandler struct {
+	labelService *services.LabelService
+}
+
+func NewLabelHandler() *LabelHandler {
+	return &LabelHandler{
+		labelService: services.NewLabelService(),
+	}
+}
+
+// ListLabels godoc
+// @Summary 获取标签列表
+// @Description 获取当前用户可见镜像上的标签及其使用次数，按使用次数降序排列。可查看全部镜像的管理员还会看到未使用的标签
+// @Tags labels
+// @Produce json
+// @Param search query string false "按标签名称筛选"
+// @Param limit query int false "最多返回的标签数"
+// @Success 200 {object} map[string]interface{} "data: []services.LabelResponse"
+// @Failure 400,500 {object} map[string]interface{} "error message"
+// @Router /labels [get]
+func (h *LabelHandler) ListLabels(c *gin.Context) {
+	var req services.LabelListRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID := middleware.GetUserID(c)
+	labels, err := h.labelService.ListLabels(&req, userID)
+	if err != nil {
+		c.JSON(errorStatus(err, http.StatusInternalServerError), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": labels})
+}
+
+// RenameLabel godoc
+// @Summary 重命名标签
+// @Description 修改所有镜像上的标签名称，旧名称保留为别名，仅管理员可用
+// @Tags labels
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "标签 ID"
+// @Param request body services.RenameLabelRequest true "新名称"
+// @Success 200 {object} services.LabelResponse
+// @Failure 400,403,404 {object} map[string]interface{} "error message"
+// @Router /labels/{id} [put]
+func (h *LabelHandler) RenameLabel(c *gin.Context) {
+	var req services.RenameLabelRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID := middleware.GetUserID(c)
+	label, err := h.labelService.RenameLabel(c.Request.Context(), c.Param("id"), &req, userID)
+	if err != nil {
+		c.JSON(errorStatus(err, http.StatusBadRequest), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, label)
+}
+
+// MergeLabels godoc
+// @Summary 合并标签
+// @Description 将来源标签上的镜像转移到目标标签并删除来源标签，来源标签的名称成为目标标签的别名，仅管理员可用
+// @Tags labels
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "目标标签 ID"
+// @Param request body services.MergeLabelsRequest true "来源标签"
+// @Success 200 {object} services.LabelResponse
+// @Failure 400,403,404 {object} map[string]interface{} "error message"
+// @Router /labels/{id}/merge [post]
+func (h *LabelHandler) MergeLabels(c *gin.Context) {
+	var req services.MergeLabelsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID := middleware.GetUserID(c)
+	label, err := h.labelService.MergeLabels(c.Request.Context(), c.Param("id"), &req, userID)
+	if err != nil {
+		c.JSON(errorStatus(err, http.StatusBadRequest), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, label)
+}
+
+// DeleteLabel godoc
+// @Summary 删除标签
+// @Description 从所有镜像上移除标签并删除其别名，仅管理员可用
+// @Tags labels
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "标签 ID"
+// @Success 204 "No Content"
+// @Failure 403,404 {object} map[string]interface{} "error message"
+// @Router /labels/{id} [delete]
+func (h *LabelHandler) DeleteLabel(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+	if err := h.labelService.DeleteLabel(c.Request.Context(), c.Param("id"), userID); err != nil {
+		c.JSON(errorStatus(err, http.StatusInternalServerError), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// AddAlias godoc
+// @Summary 添加标签别名
+// @Description 将其他写法映射到该标签，之后使用该写法创建的镜像会带上该标签，仅管理员可用
+// @Tags labels
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "标签 ID"
+// @Param request body services.LabelAliasRequest true "别名"
+// @Success 200 {object} services.LabelResponse
+// @Failure 400,403,404 {object} map[string]interface{} "error message"
+// @Router /labels/{id}/aliases [post]
+func (h *LabelHandler) AddAlias(c *gin.Context) {
+	var req services.LabelAliasRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID := middleware.GetUserID(c)
+	label, err := h.labelService.AddAlias(c.Request.Context(), c.Param("id"), &req, userID)
+	if err != nil {
+		c.JSON(errorStatus(err, http.StatusBadRequest), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, label)
+}
+
+// RemoveAlias godoc
+// @Summary 删除标签别名
+// @Tags labels
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "标签 ID"
+// @Param alias path string true "别名"
+// @Success 204 "No Content"
+// @Failure 400,403,404 {object} map[string]interface{} "error message"
+// @Router /labels/{id}/aliases/{alias} [delete]
+func (h *LabelHandler) RemoveAlias(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+	if err := h.labelService.RemoveAlias(c.Request.Context(), c.Param("id"), c.Param("alias"), userID); err != nil {
+		c.JSON(errorStatus(err, http.StatusBadRequest), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,300 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/samzong/share-ai-platform/internal/middleware"
+	"github.com/samzong/share-ai-platform/internal/services"
+)
+
+type ListHandler struct {
+	listService *services.ListService
+}
+
+func NewListHandler() *ListHandler {
+	return &ListHandler{
+		listService: services.NewListService(),
+	}
+}
+
+// ListLists godoc
+// @Summary 获取收藏列表
+// @Description 获取当前用户的收藏列表（含默认列表），或通过 owner 获取其他用户对当前用户可见的列表
+// @Tags lists
+// @Produce json
+// @Security ApiKeyAuth
+// @Param owner query string false "列表创建者 ID，默认为当前用户"
+// @Success 200 {object} map[string]interface{} "data: []services.ListResponse"
+// @Failure 500 {object} map[string]interface{} "error message"
+// @Router /lists [get]
+func (h *ListHandler) ListLists(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+	lists, err := h.listService.ListLists(c.Query("owner"), userID)
+	if err != nil {
+		c.JSON(errorStatus(err, http.StatusInternalServerError), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": lists})
+}
+
+// ListFollowing godoc
+// @Summary 获取关注的收藏列表
+// @Description 获取当前用户关注且仍然可见的收藏列表
+// @Tags lists
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} map[string]interface{} "data: []services.ListResponse"
+// @Failure 500 {object} map[string]interface{} "error message"
+// @Router /lists/following [get]
+func (h *ListHandler) ListFollowing(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+	lists, err := h.listService.ListFollowing(userID)
+	if err != nil {
+		c.JSON(errorStatus(err, http.StatusInternalServerError), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": lists})
+}
+
+// CreateList godoc
+// @Summary 创建收藏列表
+// @Description 创建命名收藏列表，可设为私有、共享给所属组织或公开
+// @Tags lists
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param request body services.CreateListRequest true "列表信息"
+// @Success 201 {object} services.ListResponse
+// @Failure 400,403 {object} map[string]interface{} "error message"
+// @Router /lists [post]
+func (h *ListHandler) CreateList(c *gin.Context) {
+	var req services.CreateListRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID := middleware.GetUserID(c)
+	list, err := h.listService.CreateList(&req, userID)
+	if err != nil {
+		c.JSON(errorStatus(err, http.StatusBadRequest), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, list)
+}
+
+// GetList godoc
+// @Summary 获取收藏列表详情
+// @Description 获取收藏列表及其中当前用户可见的镜像，按列表顺序排列
+// @Tags lists
+// @Produce json
+// @Param id path string true "列表 ID"
+// @Success 200 {object} services.ListDetailResponse
+// @Failure 404 {object} map[string]interface{} "error message"
+// @Router /lists/{id} [get]
+func (h *ListHandler) GetList(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+	list, err := h.listService.GetList(c.Param("id"), userID)
+	if err != nil {
+		c.JSON(errorStatus(err, http.StatusInternalServerError), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, list)
+}
+
+// UpdateList godoc
+// @Summary 更新收藏列表
+// @Description 修改自己的收藏列表的名称、描述或可见性
+// @Tags lists
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "列表 ID"
+// @Param request body services.UpdateListRequest true "列表信息"
+// @Success 200 {object} services.ListResponse
+// @Failure 400,403,404 {object} map[string]interface{} "error message"
+// @Router /lists/{id} [put]
+func (h *ListHandler) UpdateList(c *gin.Context) {
+	var req services.UpdateListRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID := middleware.GetUserID(c)
+	list, err := h.listService.UpdateList(c.Param("id"), &req, userID)
+	if err != nil {
+		c.JSON(errorStatus(err, http.StatusBadRequest), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, list)
+}
+
+// DeleteList godoc
+// @Summary 删除收藏列表
+// @Description 删除自己的收藏列表，默认列表不能删除
+// @Tags lists
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "列表 ID"
+// @Success 204 "No Content"
+// @Failure 400,403,404 {object} map[string]interface{} "error message"
+// @Router /lists/{id} [delete]
+func (h *ListHandler) DeleteList(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+	if err := h.listService.DeleteList(c.Param("id"), userID); err != nil {
+		c.JSON(errorStatus(err, http.StatusBadRequest), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// AddItem godoc
+// @Summary 向收藏列表添加镜像
+// @Description 将镜像添加到列表末尾，已在列表中时只更新备注。添加到默认列表即收藏该镜像
+// @Tags lists
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "列表 ID"
+// @Param request body services.AddListItemRequest true "镜像和备注"
+// @Success 204 "No Content"
+// @Failure 400,403,404 {object} map[string]interface{} "error message"
+// @Router /lists/{id}/items [post]
+func (h *ListHandler) AddItem(c *gin.Context) {
+	var req services.AddListItemRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID := middleware.GetUserID(c)
+	if err := h.listService.AddItem(c.Param("id"), &req, userID); err != nil {
+		c.JSON(errorStatus(err, http.StatusBadRequest), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// UpdateItem godoc
+// @Summary 更新列表中镜像的备注
+// @Tags lists
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "列表 ID"
+// @Param image_id path string true "镜像 ID"
+// @Param request body services.UpdateListItemRequest true "备注"
+// @Success 204 "No Content"
+// @Failure 400,403,404 {object} map[string]interface{} "error message"
+// @Router /lists/{id}/items/{image_id} [put]
+func (h *ListHandler) UpdateItem(c *gin.Context) {
+	var req services.UpdateListItemRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID := middleware.GetUserID(c)
+	if err := h.listService.UpdateItem(c.Param("id"), c.Param("image_id"), &req, userID); err != nil {
+		c.JSON(errorStatus(err, http.StatusBadRequest), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// RemoveItem godoc
+// @Summary 从收藏列表移除镜像
+// @Description 从列表中移除镜像，从默认列表移除即取消收藏
+// @Tags lists
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "列表 ID"
+// @Param image_id path string true "镜像 ID"
+// @Success 204 "No Content"
+// @Failure 400,403,404 {object} map[string]interface{} "error message"
+// @Router /lists/{id}/items/{image_id} [delete]
+func (h *ListHandler) RemoveItem(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+	if err := h.listService.RemoveItem(c.Param("id"), c.Param("image_id"), userID); err != nil {
+		c.JSON(errorStatus(err, http.StatusBadRequest), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// Reorder godoc
+// @Summary 调整收藏列表顺序
+// @Description 按给定顺序重新排列列表中的全部镜像
+// @Tags lists
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "列表 ID"
+// @Param request body services.ReorderListRequest true "镜像 ID 的新顺序"
+// @Success 204 "No Content"
+// @Failure 400,403,404 {object} map[string]interface{} "error message"
+// @Router /lists/{id}/order [put]
+func (h *ListHandler) Reorder(c *gin.Context) {
+	var req services.ReorderListRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID := middleware.GetUserID(c)
+	if err := h.listService.Reorder(c.Param("id"), &req, userID); err != nil {
+		c.JSON(errorStatus(err, http.StatusBadRequest), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// Follow godoc
+// @Summary 关注收藏列表
+// @Tags lists
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "列表 ID"
+// @Success 204 "No Content"
+// @Failure 400,404 {object} map[string]interface{} "error message"
+// @Router /lists/{id}/follow [post]
+func (h *ListHandler) Follow(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+	if err := h.listService.Follow(c.Param("id"), userID); err != nil {
+		c.JSON(errorStatus(err, http.StatusBadRequest), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// Unfollow godoc
+// @Summary 取消关注收藏列表
+// @Tags lists
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "列表 ID"
+// @Success 204 "No Content"
+// @Failure 404 {object} map[string]interface{} "error message"
+// @Router /lists/{id}/follow [delete]
+func (h *ListHandler) Unfollow(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+	if err := h.listService.Unfollow(c.Param("id"), userID); err != nil {
+		c.JSON(errorStatus(err, http.StatusBadRequest), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,166 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/samzong/share-ai-platform/internal/middleware"
+	"github.com/samzong/share-ai-platform/internal/services"
+)
+
+type OrgHandler struct {
+	orgService *services.OrgService
+}
+
+func NewOrgHandler() *OrgHandler {
+	return &OrgHandler{
+		orgService: services.NewOrgService(),
+	}
+}
+
+// CreateOrg godoc
+// @Summary 创建组织
+// @Description 创建一个新的企业组织，创建者自动成为 owner
+// @Tags orgs
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param request body services.CreateOrgRequest true "组织信息"
+// @Success 201 {object} models.Organization
+// @Failure 400 {object} map[string]interface{} "error message"
+// @Router /orgs [post]
+func (h *OrgHandler) CreateOrg(c *gin.Context) {
+	var req services.CreateOrgRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID := middleware.GetUserID(c)
+	org, err := h.orgService.CreateOrg(&req, userID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, org)
+}
+
+// GetOrg godoc
+// @Summary 获取组织详情
+// @Description 根据组织 ID 获取组织信息
+// @Tags orgs
+// @Produce json
+// @Security ApiKeyAuth
+// @Param org_id path string true "组织 ID"
+// @Success 200 {object} models.Organization
+// @Failure 404 {object} map[string]interface{} "error message"
+// @Router /orgs/{org_id} [get]
+func (h *OrgHandler) GetOrg(c *gin.Context) {
+	org, err := h.orgService.GetOrg(c.Param("org_id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, org)
+}
+
+// UpdatePolicy godoc
+// @Summary 更新组织安全策略
+// @Description 设置是否要求组织维护者启用两步验证
+// @Tags orgs
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param org_id path string true "组织 ID"
+// @Param request body services.UpdateOrgPolicyRequest true "安全策略"
+// @Success 200 {object} models.Organization
+// @Failure 400 {object} map[string]interface{} "error message"
+// @Router /orgs/{org_id}/policy [put]
+func (h *OrgHandler) UpdatePolicy(c *gin.Context) {
+	var req services.UpdateOrgPolicyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID := middleware.GetUserID(c)
+	org, err := h.orgService.UpdatePolicy(c.Param("org_id"), &req, userID)
+	if err != nil {
+		c.JSON(errorStatus(err, http.StatusBadRequest), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, org)
+}
+
+// ListMembers godoc
+// @Summary 获取组织成员列表
+// @Description 获取组织的成员及其角色
+// @Tags orgs
+// @Produce json
+// @Security ApiKeyAuth
+// @Param org_id path string true "组织 ID"
+// @Success 200 {object} map[string]interface{} "data: []OrgMemberResponse"
+// @Failure 400 {object} map[string]interface{} "error message"
+// @Router /orgs/{org_id}/members [get]
+func (h *OrgHandler) ListMembers(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+	members, err := h.orgService.ListMembers(c.Param("org_id"), userID)
+	if err != nil {
+		c.JSON(errorStatus(err, http.StatusBadRequest), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": members})
+}
+
+// AddMember godoc
+// @Summary 添加组织成员
+// @Description 添加用户到组织或修改其角色
+// @Tags orgs
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param org_id path string true "组织 ID"
+// @Param request body services.AddOrgMemberRequest true "成员信息"
+// @Success 204 "No Content"
+// @Failure 400 {object} map[string]interface{} "error message"
+// @Router /orgs/{org_id}/members [post]
+func (h *OrgHandler) AddMember(c *gin.Context) {
+	var req services.AddOrgMemberRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID := middleware.GetUserID(c)
+	if err := h.orgService.AddMember(c.Param("org_id"), &req, userID); err != nil {
+		c.JSON(errorStatus(err, http.StatusBadRequest), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// RemoveMember godoc
+// @Summary 移除组织成员
+// @Description 将用户从组织中移除
+// @Tags orgs
+// @Produce json
+// @Security ApiKeyAuth
+// @Param org_id path string true "组织 ID"
+// @Param user_id path string true "用户 ID"
+// @Success 204 "No Content"
+// @Failure 400 {object} map[string]interface{} "error message"
+// @Router /orgs/{org_id}/members/{user_id} [delete]
+func (h *OrgHandler) RemoveMember(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+	if err := h.orgService.RemoveMember(c.Param("org_id"), c.Param("user_id"), userID); err != nil {
+		c.JSON(errorStatus(err, http.StatusBadRequest), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
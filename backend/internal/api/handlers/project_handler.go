@@ -0,0 +1,216 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/samzong/share-ai-platform/internal/middleware"
+	"github.com/samzong/share-ai-platform/internal/services"
+)
+
+type ProjectHandler struct {
+	projectService *services.ProjectService
+}
+
+func NewProjectHandler() *ProjectHandler {
+	return &ProjectHandler{
+		projectService: services.NewProjectService(),
+	}
+}
+
+// CreateProject godoc
+// @Summary 创建项目
+// @Description 在组织下创建项目，创建者自动成为项目维护者
+// @Tags projects
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param org_id path string true "组织 ID"
+// @Param request body services.CreateProjectRequest true "项目信息"
+// @Success 201 {object} models.Project
+// @Failure 400,403 {object} map[string]interface{} "error message"
+// @Router /orgs/{org_id}/projects [post]
+func (h *ProjectHandler) CreateProject(c *gin.Context) {
+	var req services.CreateProjectRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID := middleware.GetUserID(c)
+	project, err := h.projectService.CreateProject(middleware.ResolveOrgID(c.Param("org_id")), &req, userID)
+	if err != nil {
+		c.JSON(errorStatus(err, http.StatusBadRequest), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, project)
+}
+
+// ListProjects godoc
+// @Summary 获取项目列表
+// @Description 获取组织下当前用户可见的项目
+// @Tags projects
+// @Produce json
+// @Security ApiKeyAuth
+// @Param org_id path string true "组织 ID"
+// @Success 200 {object} map[string]interface{} "data: []models.Project"
+// @Failure 500 {object} map[string]interface{} "error message"
+// @Router /orgs/{org_id}/projects [get]
+func (h *ProjectHandler) ListProjects(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+	projects, err := h.projectService.ListProjects(middleware.ResolveOrgID(c.Param("org_id")), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": projects})
+}
+
+// GetProject godoc
+// @Summary 获取项目详情
+// @Description 获取项目信息，私有项目仅对组织和项目成员可见
+// @Tags projects
+// @Produce json
+// @Security ApiKeyAuth
+// @Param org_id path string true "组织 ID"
+// @Param project_id path string true "项目 ID"
+// @Success 200 {object} models.Project
+// @Failure 404 {object} map[string]interface{} "error message"
+// @Router /orgs/{org_id}/projects/{project_id} [get]
+func (h *ProjectHandler) GetProject(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+	project, err := h.projectService.GetProject(middleware.ResolveOrgID(c.Param("org_id")), c.Param("project_id"), userID)
+	if err != nil {
+		c.JSON(errorStatus(err, http.StatusNotFound), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, project)
+}
+
+// UpdateProject godoc
+// @Summary 更新项目
+// @Description 修改项目名称、描述或可见性
+// @Tags projects
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param org_id path string true "组织 ID"
+// @Param project_id path string true "项目 ID"
+// @Param request body services.UpdateProjectRequest true "项目信息"
+// @Success 200 {object} models.Project
+// @Failure 400,403 {object} map[string]interface{} "error message"
+// @Router /orgs/{org_id}/projects/{project_id} [put]
+func (h *ProjectHandler) UpdateProject(c *gin.Context) {
+	var req services.UpdateProjectRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID := middleware.GetUserID(c)
+	project, err := h.projectService.UpdateProject(middleware.ResolveOrgID(c.Param("org_id")), c.Param("project_id"), &req, userID)
+	if err != nil {
+		c.JSON(errorStatus(err, http.StatusBadRequest), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, project)
+}
+
+// DeleteProject godoc
+// @Summary 删除项目
+// @Description 删除不包含镜像的项目
+// @Tags projects
+// @Produce json
+// @Security ApiKeyAuth
+// @Param org_id path string true "组织 ID"
+// @Param project_id path string true "项目 ID"
+// @Success 204 "No Content"
+// @Failure 400,403 {object} map[string]interface{} "error message"
+// @Router /orgs/{org_id}/projects/{project_id} [delete]
+func (h *ProjectHandler) DeleteProject(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+	if err := h.projectService.DeleteProject(middleware.ResolveOrgID(c.Param("org_id")), c.Param("project_id"), userID); err != nil {
+		c.JSON(errorStatus(err, http.StatusBadRequest), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// ListMembers godoc
+// @Summary 获取项目成员列表
+// @Description 获取项目的成员及其角色
+// @Tags projects
+// @Produce json
+// @Security ApiKeyAuth
+// @Param org_id path string true "组织 ID"
+// @Param project_id path string true "项目 ID"
+// @Success 200 {object} map[string]interface{} "data: []ProjectMemberResponse"
+// @Failure 404 {object} map[string]interface{} "error message"
+// @Router /orgs/{org_id}/projects/{project_id}/members [get]
+func (h *ProjectHandler) ListMembers(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+	members, err := h.projectService.ListMembers(middleware.ResolveOrgID(c.Param("org_id")), c.Param("project_id"), userID)
+	if err != nil {
+		c.JSON(errorStatus(err, http.StatusNotFound), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": members})
+}
+
+// AddMember godoc
+// @Summary 添加项目成员
+// @Description 添加用户到项目或修改其角色
+// @Tags projects
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param org_id path string true "组织 ID"
+// @Param project_id path string true "项目 ID"
+// @Param request body services.AddProjectMemberRequest true "成员信息"
+// @Success 204 "No Content"
+// @Failure 400,403 {object} map[string]interface{} "error message"
+// @Router /orgs/{org_id}/projects/{project_id}/members [post]
+func (h *ProjectHandler) AddMember(c *gin.Context) {
+	var req services.AddProjectMemberRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID := middleware.GetUserID(c)
+	if err := h.projectService.AddMember(middleware.ResolveOrgID(c.Param("org_id")), c.Param("project_id"), &req, userID); err != nil {
+		c.JSON(errorStatus(err, http.StatusBadRequest), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// RemoveMember godoc
+// @Summary 移除项目成员
+// @Description 将用户从项目中移除
+// @Tags projects
+// @Produce json
+// @Security ApiKeyAuth
+// @Param org_id path string true "组织 ID"
+// @Param project_id path string true "项目 ID"
+// @Param user_id path string true "用户 ID"
+// @Success 204 "No Content"
+// @Failure 400,403 {object} map[string]interface{} "error message"
+// @Router /orgs/{org_id}/projects/{project_id}/members/{user_id} [delete]
+func (h *ProjectHandler) RemoveMember(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+	err := h.projectService.RemoveMember(middleware.ResolveOrgID(c.Param("org_id")), c.Param("project_id"), c.Param("user_id"), userID)
+	if err != nil {
+		c.JSON(errorStatus(err, http.StatusBadRequest), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/samzong/share-ai-platform/internal/middleware"
+	"github.com/samzong/share-ai-platform/internal/services"
+)
+
+type RecommendationHandler struct {
+	recommendationService *services.RecommendationService
+}
+
+func NewRecommendationHandler() *RecommendationHandler {
+	return &RecommendationHandler{
+		recommendationService: services.NewRecommendationService(),
+	}
+}
+
+// SimilarImages godoc
+// @Summary 获取相似镜像
+// @Description 返回与指定镜像相似且当前用户可见的镜像，综合被同一批用户收藏、标签和分类重合以及同一镜像仓库的不同版本计算得分，相似度定期离线计算
+// @Tags recommendations
+// @Produce json
+// @Param id path string true "容器镜像 ID"
+// @Param limit query int false "返回数量，默认 10，最多 50"
+// @Success 200 {object} map[string]interface{} "data: []services.SimilarImageResponse"
+// @Failure 400,404 {object} map[string]interface{} "error message"
+// @Router /images/{id}/similar [get]
+func (h *RecommendationHandler) SimilarImages(c *gin.Context) {
+	var req services.SimilarImagesRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID := middleware.GetUserID(c)
+	images, err := h.recommendationService.SimilarImages(c.Request.Context(), c.Param("id"), &req, userID)
+	if err != nil {
+		c.JSON(errorStatus(err, http.StatusInternalServerError), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": images})
+}
+
+// Recommendations godoc
+// @Summary 获取为我推荐的镜像
+// @Description 根据当前用户最近收藏的镜像推荐与之相似、尚未收藏的镜像。没有收藏时返回空列表
+// @Tags recommendations
+// @Produce json
+// @Security ApiKeyAuth
+// @Param limit query int false "返回数量，默认 10，最多 50"
+// @Success 200 {object} map[string]interface{} "data: []services.SimilarImageResponse"
+// @Failure 400,401 {object} map[string]interface{} "error message"
+// @Router /recommendations [get]
+func (h *RecommendationHandler) Recommendations(c *gin.Context) {
+	var req services.SimilarImagesRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID := middleware.GetUserID(c)
+	images, err := h.recommendationService.Recommendations(c.Request.Context(), &req, userID)
+	if err != nil {
+		c.JSON(errorStatus(err, http.StatusInternalServerError), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": images})
+}
@@ -0,0 +1,191 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/samzong/share-ai-platform/internal/authz"
+	"github.com/samzong/share-ai-platform/internal/middleware"
+	"github.com/samzong/share-ai-platform/internal/models"
+	"github.com/samzong/share-ai-platform/internal/services"
+)
+
+type RoleHandler struct {
+	roleService *services.RoleService
+}
+
+func NewRoleHandler() *RoleHandler {
+	return &RoleHandler{
+		roleService: services.NewRoleService(),
+	}
+}
+
+// bindingScope returns the project or org scope for /orgs/:org_id/... routes and the global scope otherwise
+func bindingScope(c *gin.Context) authz.Scope {
+	if projectID := c.Param("project_id"); projectID != "" {
+		return authz.Project(projectID)
+	}
+	if orgID := c.Param("org_id"); orgID != "" {
+		return authz.Org(middleware.ResolveOrgID(orgID))
+	}
+	if orgID := c.Query("org_id"); orgID != "" {
+		return authz.Org(middleware.ResolveOrgID(orgID))
+	}
+	return authz.Global()
+}
+
+// ListRoles godoc
+// @Summary List roles
+// @Description List built-in and custom roles with their permissions (requires roles.read)
+// @Tags roles
+// @Security ApiKeyAuth
+// @Produce json
+// @Success 200 {object} map[string]interface{} "data: []RoleResponse"
+// @Failure 403,500 {object} map[string]interface{} "error message"
+// @Router /roles [get]
+func (h *RoleHandler) ListRoles(c *gin.Context) {
+	roles, err := h.roleService.ListRoles()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": roles})
+}
+
+// CreateRole godoc
+// @Summary Create custom role
+// @Description Define a custom role as a bundle of permissions (requires roles.manage)
+// @Tags roles
+// @Security ApiKeyAuth
+// @Accept json
+// @Produce json
+// @Param request body services.CreateRoleRequest true "Role definition"
+// @Success 201 {object} services.RoleResponse
+// @Failure 400,403 {object} map[string]interface{} "error message"
+// @Router /roles [post]
+func (h *RoleHandler) CreateRole(c *gin.Context) {
+	var req services.CreateRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID := middleware.GetUserID(c)
+	role, err := h.roleService.CreateRole(&req, userID)
+	if err != nil {
+		c.JSON(errorStatus(err, http.StatusBadRequest), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, role)
+}
+
+// DeleteRole godoc
+// @Summary Delete custom role
+// @Description Delete a custom role and all of its bindings (requires roles.manage)
+// @Tags roles
+// @Security ApiKeyAuth
+// @Produce json
+// @Param name path string true "Role name"
+// @Success 204 "No Content"
+// @Failure 400,403 {object} map[string]interface{} "error message"
+// @Router /roles/{name} [delete]
+func (h *RoleHandler) DeleteRole(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+	if err := h.roleService.DeleteRole(models.Role(c.Param("name")), userID); err != nil {
+		c.JSON(errorStatus(err, http.StatusBadRequest), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// ListBindings godoc
+// @Summary List role bindings
+// @Description List explicit role bindings in the global scope, or in an org via /orgs/{org_id}/role-bindings
+// @Tags roles
+// @Security ApiKeyAuth
+// @Produce json
+// @Success 200 {object} map[string]interface{} "data: []models.RoleBinding"
+// @Failure 403,500 {object} map[string]interface{} "error message"
+// @Router /role-bindings [get]
+func (h *RoleHandler) ListBindings(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+	bindings, err := h.roleService.ListBindings(bindingScope(c), userID)
+	if err != nil {
+		c.JSON(errorStatus(err, http.StatusInternalServerError), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": bindings})
+}
+
+// CreateBinding godoc
+// @Summary Create role binding
+// @Description Grant a role to a user in the global scope, or in an org via /orgs/{org_id}/role-bindings (requires roles.bind and every permission of the role)
+// @Tags roles
+// @Security ApiKeyAuth
+// @Accept json
+// @Produce json
+// @Param request body services.CreateRoleBindingRequest true "Role binding"
+// @Success 201 {object} models.RoleBinding
+// @Failure 400,403 {object} map[string]interface{} "error message"
+// @Router /role-bindings [post]
+func (h *RoleHandler) CreateBinding(c *gin.Context) {
+	var req services.CreateRoleBindingRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID := middleware.GetUserID(c)
+	binding, err := h.roleService.CreateBinding(bindingScope(c), &req, userID)
+	if err != nil {
+		c.JSON(errorStatus(err, http.StatusBadRequest), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, binding)
+}
+
+// DeleteBinding godoc
+// @Summary Delete role binding
+// @Description Revoke a role binding in the global scope, or in an org via /orgs/{org_id}/role-bindings/{id}
+// @Tags roles
+// @Security ApiKeyAuth
+// @Produce json
+// @Param id path string true "Role binding ID"
+// @Success 204 "No Content"
+// @Failure 400,403 {object} map[string]interface{} "error message"
+// @Router /role-bindings/{id} [delete]
+func (h *RoleHandler) DeleteBinding(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+	if err := h.roleService.DeleteBinding(bindingScope(c), c.Param("id"), userID); err != nil {
+		c.JSON(errorStatus(err, http.StatusBadRequest), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// MyPermissions godoc
+// @Summary Get my permissions
+// @Description List the current user's effective permissions, globally or in an org
+// @Tags users
+// @Security ApiKeyAuth
+// @Produce json
+// @Param org_id query string false "Organization ID"
+// @Success 200 {object} services.PermissionsResponse
+// @Failure 500 {object} map[string]interface{} "error message"
+// @Router /users/permissions [get]
+func (h *RoleHandler) MyPermissions(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+	resp, err := h.roleService.MyPermissions(bindingScope(c), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/samzong/share-ai-platform/internal/middleware"
+	"github.com/samzong/share-ai-platform/internal/services"
+)
+
+type TrendingHandler struct {
+	trendingService *services.TrendingService
+}
+
+func NewTrendingHandler() *TrendingHandler {
+	return &TrendingHandler{
+		trendingService: services.NewTrendingService(),
+	}
+}
+
+// TrendingImages godoc
+// @Summary 获取热门镜像
+// @Description 按统计窗口内的浏览、收藏和部署计算时间衰减后的热度，返回当前用户可见的热门镜像，排行定期刷新
+// @Tags trending
+// @Produce json
+// @Param window query string false "统计窗口：24h / 7d（默认）/ 30d"
+// @Param limit query int false "返回数量，默认 20，最多 100"
+// @Success 200 {object} map[string]interface{} "data: []services.TrendingImageResponse"
+// @Failure 400,500 {object} map[string]interface{} "error message"
+// @Router /trending/images [get]
+func (h *TrendingHandler) TrendingImages(c *gin.Context) {
+	var req services.TrendingRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID := middleware.GetUserID(c)
+	images, err := h.trendingService.TrendingImages(c.Request.Context(), &req, userID)
+	if err != nil {
+		c.JSON(errorStatus(err, http.StatusInternalServerError), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": images})
+}
+
+// TrendingLabels godoc
+// @Summary 获取热门标签
+// @Description 返回统计窗口内公开镜像上的标签。feed=hot 按带有该标签的镜像热度之和排序，feed=new 返回窗口内新出现的标签，最新的在前
+// @Tags trending
+// @Produce json
+// @Param window query string false "统计窗口：24h / 7d（默认）/ 30d"
+// @Param feed query string false "hot（默认）/ new"
+// @Param limit query int false "返回数量，默认 20，最多 100"
+// @Success 200 {object} map[string]interface{} "data: []services.TrendingLabelResponse"
+// @Failure 400,500 {object} map[string]interface{} "error message"
+// @Router /trending/labels [get]
+func (h *TrendingHandler) TrendingLabels(c *gin.Context) {
+	var req services.TrendingLabelRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	labels, err := h.trendingService.TrendingLabels(c.Request.Context(), &req)
+	if err != nil {
+		c.JSON(errorStatus(err, http.StatusInternalServerError), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": labels})
+}
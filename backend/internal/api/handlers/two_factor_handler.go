@@ -0,0 +1,159 @@
+package handlers
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/samzong/share-ai-platform/internal/middleware"
+	"github.com/samzong/share-ai-platform/internal/services"
+)
+
+type TwoFactorHandler struct {
+	twoFactorService *services.TwoFactorService
+}
+
+func NewTwoFactorHandler() *TwoFactorHandler {
+	return &TwoFactorHandler{
+		twoFactorService: services.NewTwoFactorService(),
+	}
+}
+
+// Login godoc
+// @Summary Complete two-step login
+// @Description Exchange the challenge token returned by /auth/login and a TOTP or recovery code for an access token
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body services.TwoFactorLoginRequest true "Challenge token and verification code"
+// @Success 200 {object} services.UserResponse
+// @Failure 400,401 {object} map[string]interface{} "error message"
+// @Failure 429 {object} map[string]interface{} "too many failed attempts, see Retry-After"
+// @Router /auth/login/2fa [post]
+func (h *TwoFactorHandler) Login(c *gin.Context) {
+	var req services.TwoFactorLoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	response, err := h.twoFactorService.Login(c.Request.Context(), &req, c.ClientIP())
+	if err != nil {
+		var locked *services.LoginLockedError
+		if errors.As(err, &locked) {
+			c.Header("Retry-After", strconv.Itoa(int(math.Ceil(locked.RetryAfter.Seconds()))))
+			c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// Enroll godoc
+// @Summary Start two-factor enrollment
+// @Description Generate a new TOTP secret and otpauth URI for the current user
+// @Tags users
+// @Security ApiKeyAuth
+// @Produce json
+// @Success 200 {object} services.TwoFactorEnrollResponse
+// @Failure 400 {object} map[string]interface{} "error message"
+// @Router /users/2fa/enroll [post]
+func (h *TwoFactorHandler) Enroll(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+
+	response, err := h.twoFactorService.Enroll(userID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// Activate godoc
+// @Summary Activate two-factor authentication
+// @Description Verify the first TOTP code and enable 2FA, returning one-time recovery codes
+// @Tags users
+// @Security ApiKeyAuth
+// @Accept json
+// @Produce json
+// @Param request body services.TwoFactorCodeRequest true "TOTP code"
+// @Success 200 {object} services.RecoveryCodesResponse
+// @Failure 400 {object} map[string]interface{} "error message"
+// @Router /users/2fa/activate [post]
+func (h *TwoFactorHandler) Activate(c *gin.Context) {
+	var req services.TwoFactorCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID := middleware.GetUserID(c)
+	response, err := h.twoFactorService.Activate(userID, req.Code)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// Disable godoc
+// @Summary Disable two-factor authentication
+// @Description Disable 2FA after confirming the password and a TOTP or recovery code
+// @Tags users
+// @Security ApiKeyAuth
+// @Accept json
+// @Produce json
+// @Param request body services.TwoFactorDisableRequest true "Password and verification code"
+// @Success 204 "No Content"
+// @Failure 400 {object} map[string]interface{} "error message"
+// @Router /users/2fa/disable [post]
+func (h *TwoFactorHandler) Disable(c *gin.Context) {
+	var req services.TwoFactorDisableRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID := middleware.GetUserID(c)
+	if err := h.twoFactorService.Disable(userID, &req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// RegenerateRecoveryCodes godoc
+// @Summary Regenerate recovery codes
+// @Description Invalidate existing recovery codes and return a new set
+// @Tags users
+// @Security ApiKeyAuth
+// @Accept json
+// @Produce json
+// @Param request body services.TwoFactorCodeRequest true "TOTP code"
+// @Success 200 {object} services.RecoveryCodesResponse
+// @Failure 400 {object} map[string]interface{} "error message"
+// @Router /users/2fa/recovery-codes [post]
+func (h *TwoFactorHandler) RegenerateRecoveryCodes(c *gin.Context) {
+	var req services.TwoFactorCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID := middleware.GetUserID(c)
+	response, err := h.twoFactorService.RegenerateRecoveryCodes(userID, req.Code)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
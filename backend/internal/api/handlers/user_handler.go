@@ -1,7 +1,10 @@
 package handlers
 
 import (
+	"errors"
+	"math"
 	"net/http"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 	"github.com/samzong/share-ai-platform/internal/middleware"
@@ -54,6 +57,7 @@ func (h *UserHandler) Register(c *gin.Context) {
 // @Param request body services.LoginRequest true "Login credentials"
 // @Success 200 {object} services.UserResponse
 // @Failure 400 {object} map[string]interface{} "error message"
+// @Failure 429 {object} map[string]interface{} "too many failed attempts, see Retry-After"
 // @Router /auth/login [post]
 func (h *UserHandler) Login(c *gin.Context) {
 	var req services.LoginRequest
@@ -62,8 +66,14 @@ func (h *UserHandler) Login(c *gin.Context) {
 		return
 	}
 
-	response, err := h.userService.Login(&req)
+	response, err := h.userService.Login(c.Request.Context(), &req, c.ClientIP())
 	if err != nil {
+		var locked *services.LoginLockedError
+		if errors.As(err, &locked) {
+			c.Header("Retry-After", strconv.Itoa(int(math.Ceil(locked.RetryAfter.Seconds()))))
+			c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
@@ -142,7 +152,7 @@ func (h *UserHandler) UpdateProfile(c *gin.Context) {
 
 // UpdateUser godoc
 // @Summary Update user
-// @Description Update user's username and email
+// @Description Update user's username and email (self, or requires users.manage)
 // @Tags users
 // @Security ApiKeyAuth
 // @Accept json
@@ -174,7 +184,7 @@ func (h *UserHandler) UpdateUser(c *gin.Context) {
 
 // UpdateUserRole godoc
 // @Summary Update user role
-// @Description Update user's role (admin only)
+// @Description Update user's global role (requires roles.bind)
 // @Tags users
 // @Security ApiKeyAuth
 // @Accept json
@@ -193,17 +203,11 @@ func (h *UserHandler) UpdateUserRole(c *gin.Context) {
 		return
 	}
 
-	// 验证角色是否有效
-	if !models.IsValidRole(req.Role) {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid role"})
-		return
-	}
-
 	adminID := middleware.GetUserID(c)
 	userID := c.Param("id")
 
 	if err := h.userService.UpdateUserRole(adminID, userID, req.Role); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.JSON(errorStatus(err, http.StatusBadRequest), gin.H{"error": err.Error()})
 		return
 	}
 
@@ -212,7 +216,7 @@ func (h *UserHandler) UpdateUserRole(c *gin.Context) {
 
 // ListUsers godoc
 // @Summary List users
-// @Description Get paginated list of users (admin only)
+// @Description Get paginated list of users (requires users.read)
 // @Tags users
 // @Security ApiKeyAuth
 // @Produce json
@@ -237,6 +241,28 @@ func (h *UserHandler) ListUsers(c *gin.Context) {
 	c.JSON(http.StatusOK, resp)
 }
 
+// UnlockUser godoc
+// @Summary Unlock user login
+// @Description Clear a user's failed login counters and lockout (requires users.unlock)
+// @Tags users
+// @Security ApiKeyAuth
+// @Produce json
+// @Param id path string true "User ID"
+// @Success 204 "No Content"
+// @Failure 400,403 {object} map[string]interface{} "error message"
+// @Router /users/{id}/unlock [post]
+func (h *UserHandler) UnlockUser(c *gin.Context) {
+	adminID := middleware.GetUserID(c)
+	userID := c.Param("id")
+
+	if err := h.userService.UnlockUser(c.Request.Context(), adminID, userID, c.ClientIP()); err != nil {
+		c.JSON(errorStatus(err, http.StatusBadRequest), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
 // Request/Response models for Swagger documentation
 type UpdateUserRequest struct {
 	Username string `json:"username" example:"johndoe"`
@@ -7,6 +7,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/samzong/share-ai-platform/internal/api/handlers"
 	"github.com/samzong/share-ai-platform/internal/middleware"
+	"github.com/samzong/share-ai-platform/internal/models"
 	swaggerFiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
 )
@@ -26,8 +27,11 @@ func SetupRouter() *gin.Engine {
 	// Swagger 文档
 	r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
-	// 设置静态文件服务
-	r.Static("/uploads", "./uploads")
+	// 上传文件，从配置的存储后端读取
+	r.GET("/uploads/*key", handlers.NewFileHandler().ServeFile)
+
+	// 公钥集合，供其他内部服务验证本服务签发的 Token
+	r.GET("/.well-known/jwks.json", handlers.NewKeysHandler().JWKS)
 
 	// 健康检查
 	r.GET("/health", func(c *gin.Context) {
@@ -43,34 +47,74 @@ func SetupRouter() *gin.Engine {
 		auth := api.Group("/auth")
 		{
 			userHandler := handlers.NewUserHandler()
+			twoFactorHandler := handlers.NewTwoFactorHandler()
 			auth.POST("/register", userHandler.Register)
 			auth.POST("/login", userHandler.Login)
+			auth.POST("/login/2fa", twoFactorHandler.Login)
 			auth.POST("/logout", middleware.AuthMiddleware(), userHandler.Logout)
 		}
 
+		accessHandler := handlers.NewAccessHandler()
+
 		// 用户相关路由
 		users := api.Group("/users", middleware.AuthMiddleware())
 		{
 			userHandler := handlers.NewUserHandler()
-			users.GET("/profile", userHandler.GetProfile)
-			users.PUT("/profile", userHandler.UpdateProfile)
-			users.PUT("/:id", userHandler.UpdateUser)
-			users.PUT("/:id/role", userHandler.UpdateUserRole)
-			users.GET("", userHandler.ListUsers)
+			roleHandler := handlers.NewRoleHandler()
+			users.GET("/profile", middleware.RequirePermission(models.PermProfileManage), userHandler.GetProfile)
+			users.PUT("/profile", middleware.RequirePermission(models.PermProfileManage), userHandler.UpdateProfile)
+			users.GET("/permissions", roleHandler.MyPermissions)
+			users.GET("/access-requests", middleware.RequirePermission(models.PermProfileManage), accessHandler.ListMyRequests)
+			users.PUT("/:id", middleware.RequireSelfOrPermission("id", models.PermUsersManage), userHandler.UpdateUser)
+			users.PUT("/:id/role", middleware.RequirePermission(models.PermRolesBind), userHandler.UpdateUserRole)
+			users.POST("/:id/unlock", middleware.RequirePermission(models.PermUsersUnlock), userHandler.UnlockUser)
+			users.GET("", middleware.RequirePermission(models.PermUsersRead), userHandler.ListUsers)
+
+			// 两步验证
+			twoFactorHandler := handlers.NewTwoFactorHandler()
+			twoFactor := users.Group("/2fa", middleware.RequirePermission(models.PermProfileManage))
+			{
+				twoFactor.POST("/enroll", twoFactorHandler.Enroll)
+				twoFactor.POST("/activate", twoFactorHandler.Activate)
+				twoFactor.POST("/disable", twoFactorHandler.Disable)
+				twoFactor.POST("/recovery-codes", twoFactorHandler.RegenerateRecoveryCodes)
+			}
+		}
+
+		// 角色与角色绑定路由
+		roleHandler := handlers.NewRoleHandler()
+		roles := api.Group("/roles", middleware.AuthMiddleware())
+		{
+			roles.GET("", middleware.RequirePermission(models.PermRolesRead), roleHandler.ListRoles)
+			roles.POST("", middleware.RequirePermission(models.PermRolesManage), roleHandler.CreateRole)
+			roles.DELETE("/:name", middleware.RequirePermission(models.PermRolesManage), roleHandler.DeleteRole)
+		}
+		bindings := api.Group("/role-bindings", middleware.AuthMiddleware())
+		{
+			bindings.GET("", middleware.RequirePermission(models.PermRolesRead), roleHandler.ListBindings)
+			bindings.POST("", middleware.RequirePermission(models.PermRolesBind), roleHandler.CreateBinding)
+			bindings.DELETE("/:id", middleware.RequirePermission(models.PermRolesBind), roleHandler.DeleteBinding)
 		}
 
 		// 镜像相关路由
 		imageHandler := handlers.NewImageHandler()
+		recommendationHandler := handlers.NewRecommendationHandler()
 		images := api.Group("/images")
 		{
-			images.GET("", imageHandler.ListImages)
-			images.GET("/:id", imageHandler.GetImage)
+			// 登录用户可以额外看到有权访问的私有镜像
+			images.GET("", middleware.OptionalAuthMiddleware(), imageHandler.ListImages)
+			images.GET("/:id", middleware.OptionalAuthMiddleware(), imageHandler.GetImage)
+			images.GET("/:id/readme", middleware.OptionalAuthMiddleware(), imageHandler.GetReadme)
+			images.POST("/:id/events", middleware.OptionalAuthMiddleware(), imageHandler.TrackEvent)
+			images.GET("/:id/similar", middleware.OptionalAuthMiddleware(), recommendationHandler.SimilarImages)
 
 			// 需要认证的路由
-			auth := images.Use(middleware.AuthMiddleware())
+			auth := images.Group("", middleware.AuthMiddleware())
 			{
-				auth.POST("/:id/collect", imageHandler.CollectImage)
-				auth.DELETE("/:id/collect", imageHandler.UncollectImage)
+				auth.POST("/:id/collect", middleware.RequirePermission(models.PermImagesStar), imageHandler.CollectImage)
+				auth.DELETE("/:id/collect", middleware.RequirePermission(models.PermImagesStar), imageHandler.UncollectImage)
+				auth.POST("/:id/access-requests", middleware.RequirePermission(models.PermImagesRequestAccess), accessHandler.RequestAccess)
+				auth.GET("/:id/stats", imageHandler.GetImageStats)
 			}
 		}
 
@@ -78,22 +122,140 @@ func SetupRouter() *gin.Engine {
 		orgs := api.Group("/orgs")
 		{
 			// 公共镜像路由
-			orgs.GET("/public/images", imageHandler.ListImages)
+			orgs.GET("/public/images", middleware.OptionalAuthMiddleware(), imageHandler.ListImages)
 
 			// 需要认证的路由
+			orgHandler := handlers.NewOrgHandler()
+			projectHandler := handlers.NewProjectHandler()
+			groupHandler := handlers.NewGroupHandler()
+			orgScope := middleware.OrgScope("org_id")
+			projectScope := middleware.ProjectScope("project_id")
 			auth := orgs.Use(middleware.AuthMiddleware())
 			{
-				auth.POST("/:org_id/images", imageHandler.CreateImage)
-				auth.PUT("/:org_id/images/:id", imageHandler.UpdateImage)
-				auth.DELETE("/:org_id/images/:id", imageHandler.DeleteImage)
+				auth.POST("", middleware.RequirePermission(models.PermOrgsCreate), orgHandler.CreateOrg)
+				auth.GET("/:org_id", middleware.RequirePermission(models.PermOrgsRead, orgScope), orgHandler.GetOrg)
+				auth.GET("/:org_id/members", middleware.RequirePermission(models.PermOrgMembersRead, orgScope), orgHandler.ListMembers)
+				auth.GET("/:org_id/role-bindings", middleware.RequirePermission(models.PermRolesRead, orgScope), roleHandler.ListBindings)
+				// 项目维护者也可查看和审批其项目内镜像的申请，权限由服务层按镜像作用域检查
+				auth.GET("/:org_id/access-requests", accessHandler.ListOrgRequests)
+
+				// 用户组
+				auth.GET("/:org_id/groups", middleware.RequirePermission(models.PermGroupsRead, orgScope), groupHandler.ListGroups)
+				auth.GET("/:org_id/groups/:group_id/members", middleware.RequirePermission(models.PermGroupsRead, orgScope), groupHandler.ListMembers)
+
+				// 项目
+				auth.GET("/:org_id/projects", middleware.RequirePermission(models.PermOrgsRead, orgScope), projectHandler.ListProjects)
+				auth.GET("/:org_id/projects/:project_id", middleware.RequirePermission(models.PermOrgsRead, orgScope), projectHandler.GetProject)
+				auth.GET("/:org_id/projects/:project_id/members", middleware.RequirePermission(models.PermOrgsRead, orgScope), projectHandler.ListMembers)
+				auth.GET("/:org_id/projects/:project_id/images", middleware.RequirePermission(models.PermOrgsRead, orgScope), imageHandler.ListImages)
+				auth.GET("/:org_id/projects/:project_id/role-bindings", middleware.RequirePermission(models.PermRolesRead, projectScope), roleHandler.ListBindings)
+			}
+
+			// 组织管理操作，受组织两步验证策略约束
+			manage := orgs.Group("/:org_id", middleware.RequireOrgTwoFactor())
+			{
+				manage.PUT("/policy", middleware.RequirePermission(models.PermOrgsManage, orgScope), orgHandler.UpdatePolicy)
+				manage.POST("/members", middleware.RequirePermission(models.PermOrgMembersManage, orgScope), orgHandler.AddMember)
+				manage.DELETE("/members/:user_id", middleware.RequirePermission(models.PermOrgMembersManage, orgScope), orgHandler.RemoveMember)
+				manage.POST("/role-bindings", middleware.RequirePermission(models.PermRolesBind, orgScope), roleHandler.CreateBinding)
+				manage.DELETE("/role-bindings/:id", middleware.RequirePermission(models.PermRolesBind, orgScope), roleHandler.DeleteBinding)
+				manage.POST("/access-requests/:request_id/approve", accessHandler.ApproveRequest)
+				manage.POST("/access-requests/:request_id/deny", accessHandler.DenyRequest)
+				manage.POST("/images", middleware.RequirePermission(models.PermImagesCreate, orgScope), imageHandler.CreateImage)
+				// 作者需要 update/delete 权限，其他人需要 manage 权限，由服务层区分
+				manage.PUT("/images/:id", middleware.RequireAnyPermission(orgScope, models.PermImagesUpdate, models.PermImagesManage), imageHandler.UpdateImage)
+				manage.DELETE("/images/:id", middleware.RequireAnyPermission(orgScope, models.PermImagesDelete, models.PermImagesManage), imageHandler.DeleteImage)
+				manage.POST("/images/:id/grants", middleware.RequirePermission(models.PermImagesManage, orgScope), accessHandler.GrantAccess)
+
+				// 用户组管理
+				manage.POST("/groups", middleware.RequirePermission(models.PermGroupsManage, orgScope), groupHandler.CreateGroup)
+				manage.DELETE("/groups/:group_id", middleware.RequirePermission(models.PermGroupsManage, orgScope), groupHandler.DeleteGroup)
+				manage.POST("/groups/:group_id/members", middleware.RequirePermission(models.PermGroupsManage, orgScope), groupHandler.AddMember)
+				manage.DELETE("/groups/:group_id/members/:user_id", middleware.RequirePermission(models.PermGroupsManage, orgScope), groupHandler.RemoveMember)
+
+				// 项目管理
+				manage.POST("/projects", middleware.RequirePermission(models.PermProjectsCreate, orgScope), projectHandler.CreateProject)
+				manage.PUT("/projects/:project_id", middleware.RequirePermission(models.PermProjectsManage, projectScope), projectHandler.UpdateProject)
+				manage.DELETE("/projects/:project_id", middleware.RequirePermission(models.PermProjectsManage, projectScope), projectHandler.DeleteProject)
+				manage.POST("/projects/:project_id/members", middleware.RequirePermission(models.PermProjectMembersManage, projectScope), projectHandler.AddMember)
+				manage.DELETE("/projects/:project_id/members/:user_id", middleware.RequirePermission(models.PermProjectMembersManage, projectScope), projectHandler.RemoveMember)
+				manage.POST("/projects/:project_id/role-bindings", middleware.RequirePermission(models.PermRolesBind, projectScope), roleHandler.CreateBinding)
+				manage.DELETE("/projects/:project_id/role-bindings/:id", middleware.RequirePermission(models.PermRolesBind, projectScope), roleHandler.DeleteBinding)
+				manage.POST("/projects/:project_id/images", middleware.RequirePermission(models.PermImagesCreate, projectScope), imageHandler.CreateImage)
+				manage.PUT("/projects/:project_id/images/:id", middleware.RequireAnyPermission(projectScope, models.PermImagesUpdate, models.PermImagesManage), imageHandler.UpdateImage)
+				manage.DELETE("/projects/:project_id/images/:id", middleware.RequireAnyPermission(projectScope, models.PermImagesDelete, models.PermImagesManage), imageHandler.DeleteImage)
 			}
 		}
 
+		// 收藏列表路由
+		listHandler := handlers.NewListHandler()
+		lists := api.Group("/lists")
+		{
+			// 公开列表无需登录即可查看
+			lists.GET("/:id", middleware.OptionalAuthMiddleware(), listHandler.GetList)
+
+			auth := lists.Group("", middleware.AuthMiddleware(), middleware.RequirePermission(models.PermListsManage))
+			{
+				auth.GET("", listHandler.ListLists)
+				auth.GET("/following", listHandler.ListFollowing)
+				auth.POST("", listHandler.CreateList)
+				auth.PUT("/:id", listHandler.UpdateList)
+				auth.DELETE("/:id", listHandler.DeleteList)
+				auth.POST("/:id/items", listHandler.AddItem)
+				auth.PUT("/:id/items/:image_id", listHandler.UpdateItem)
+				auth.DELETE("/:id/items/:image_id", listHandler.RemoveItem)
+				auth.PUT("/:id/order", listHandler.Reorder)
+				auth.POST("/:id/follow", listHandler.Follow)
+				auth.DELETE("/:id/follow", listHandler.Unfollow)
+			}
+		}
+
+		// 镜像分类路由
+		categoryHandler := handlers.NewCategoryHandler()
+		categories := api.Group("/categories")
+		{
+			categories.GET("", categoryHandler.ListCategories)
+
+			manage := categories.Group("", middleware.AuthMiddleware(), middleware.RequirePermission(models.PermCategoriesManage))
+			{
+				manage.POST("", categoryHandler.CreateCategory)
+				manage.PUT("/:id", categoryHandler.UpdateCategory)
+				manage.DELETE("/:id", categoryHandler.DeleteCategory)
+			}
+		}
+
+		// 标签路由
+		labelHandler := handlers.NewLabelHandler()
+		labels := api.Group("/labels")
+		{
+			labels.GET("", middleware.OptionalAuthMiddleware(), labelHandler.ListLabels)
+
+			manage := labels.Group("", middleware.AuthMiddleware(), middleware.RequirePermission(models.PermLabelsManage))
+			{
+				manage.PUT("/:id", labelHandler.RenameLabel)
+				manage.DELETE("/:id", labelHandler.DeleteLabel)
+				manage.POST("/:id/merge", labelHandler.MergeLabels)
+				manage.POST("/:id/aliases", labelHandler.AddAlias)
+				manage.DELETE("/:id/aliases/:alias", labelHandler.RemoveAlias)
+			}
+		}
+
+		// 热门镜像与标签路由
+		trendingHandler := handlers.NewTrendingHandler()
+		trending := api.Group("/trending", middleware.OptionalAuthMiddleware())
+		{
+			trending.GET("/images", trendingHandler.TrendingImages)
+			trending.GET("/labels", trendingHandler.TrendingLabels)
+		}
+
 		// 收藏夹路由
-		favorites := api.Group("/favorites").Use(middleware.AuthMiddleware())
+		favorites := api.Group("/favorites").Use(middleware.AuthMiddleware(), middleware.RequirePermission(models.PermFavoritesRead))
 		{
 			favorites.GET("", imageHandler.ListFavorites)
 		}
+
+		// 根据收藏推荐的镜像
+		api.GET("/recommendations", middleware.AuthMiddleware(), middleware.RequirePermission(models.PermFavoritesRead), recommendationHandler.Recommendations)
 	}
 
 	return r
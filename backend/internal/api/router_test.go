@@ -0,0 +1,90 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/samzong/share-ai-platform/internal/database"
+	"github.com/samzong/share-ai-platform/internal/middleware"
+	"github.com/samzong/share-ai-platform/internal/models"
+)
+
+func TestAccessRequestRoutesAllowProjectMaintainers(t *testing.T) {
+	viper.Set("server.jwt_algorithm", "HS256")
+	viper.Set("server.jwt_secret", "test-secret")
+	defer viper.Set("server.jwt_algorithm", "")
+	defer viper.Set("server.jwt_secret", "")
+	require.NoError(t, database.SetupTestDB())
+	defer database.TeardownTestDB()
+	db := database.GetDB()
+
+	newUser := func(name string) string {
+		user := &models.User{Username: name, Email: name + "@example.com", Password: "secret", Role: models.RoleUser}
+		require.NoError(t, db.Create(user).Error)
+		return user.ID
+	}
+	owner, maintainer, requester := newUser("owner"), newUser("maintainer"), newUser("requester")
+
+	org := &models.Organization{Name: "acme"}
+	require.NoError(t, db.Create(org).Error)
+	project := &models.Project{OrgID: org.ID, Name: "rocket"}
+	require.NoError(t, db.Create(project).Error)
+	require.NoError(t, db.Create(&models.OrgMember{OrgID: org.ID, UserID: owner, Role: models.OrgRoleOwner}).Error)
+	require.NoError(t, db.Create(&models.ProjectMember{ProjectID: project.ID, UserID: maintainer, Role: models.ProjectRoleMaintainer}).Error)
+
+	newRequest := func(name string, projectID *string) string {
+		image := &models.Image{OrgID: org.ID, ProjectID: projectID, Name: name, Author: owner, Registry: "docker.io",
+			Repository: name, Tag: "latest", Digest: "sha256:" + name, Platform: "linux/amd64",
+			Visibility: models.VisibilityRequireAccess}
+		require.NoError(t, db.Create(image).Error)
+		request := &models.AccessRequest{ImageID: image.ID, UserID: requester, Status: models.AccessRequestPending}
+		require.NoError(t, db.Create(request).Error)
+		return request.ID
+	}
+	projectRequest := newRequest("project-image", &project.ID)
+	orgRequest := newRequest("org-image", nil)
+
+	gin.SetMode(gin.TestMode)
+	r := SetupRouter()
+	call := func(userID, method, path string) *httptest.ResponseRecorder {
+		token, err := middleware.GenerateToken(userID)
+		require.NoError(t, err)
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(method, path, bytes.NewReader(nil))
+		req.Header.Set("Authorization", "Bearer "+token)
+		r.ServeHTTP(w, req)
+		return w
+	}
+	base := "/api/v1/orgs/" + org.ID + "/access-requests"
+
+	// 项目维护者可以查看并审批其项目内镜像的申请
+	w := call(maintainer, http.MethodGet, base)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var list struct {
+		Data []struct {
+			ID string `json:"id"`
+		} `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &list))
+	require.Len(t, list.Data, 1)
+	assert.Equal(t, projectRequest, list.Data[0].ID)
+
+	w = call(maintainer, http.MethodPost, base+"/"+projectRequest+"/approve")
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	w = call(maintainer, http.MethodPost, base+"/"+orgRequest+"/deny")
+	assert.Equal(t, http.StatusForbidden, w.Code, w.Body.String())
+
+	// 组织维护者可以审批组织内所有申请，无关用户没有权限
+	w = call(owner, http.MethodPost, base+"/"+orgRequest+"/deny")
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	w = call(requester, http.MethodGet, base)
+	assert.Equal(t, http.StatusForbidden, w.Code, w.Body.String())
+}
@@ -0,0 +1,359 @@
+// Package authz resolves the permissions a user holds in a scope from their
+// global role, organization memberships and explicit role bindings.
+package authz
+
+import (
+	"errors"
+	"fmt"
+
+	"gorm.io/gorm"
+
+	"github.com/samzong/share-ai-platform/internal/database"
+	"github.com/samzong/share-ai-platform/internal/models"
+)
+
+// ErrForbidden is returned when a user lacks the required permission
+var ErrForbidden = errors.New("permission denied")
+
+// Scope identifies where a permission is checked
+type Scope struct {
+	Type models.ScopeType `json:"type"`
+	ID   string           `json:"id,omitempty"`
+}
+
+// Global returns the global scope
+func Global() Scope {
+	return Scope{Type: models.ScopeGlobal}
+}
+
+// Org returns the scope of an organization
+func Org(orgID string) Scope {
+	return Scope{Type: models.ScopeOrg, ID: orgID}
+}
+
+// Project returns the scope of a project. Roles held in the project's org also apply.
+func Project(projectID string) Scope {
+	return Scope{Type: models.ScopeProject, ID: projectID}
+}
+
+// Can reports whether the user holds the permission in the scope.
+// Permissions granted at a broader scope also apply to narrower ones.
+func Can(userID string, perm models.Permission, scope Scope) (bool, error) {
+	perms, err := Permissions(userID, scope)
+	if err != nil {
+		return false, err
+	}
+	return covers(perms, perm), nil
+}
+
+// Check returns ErrForbidden if the user does not hold the permission in the scope
+func Check(userID string, perm models.Permission, scope Scope) error {
+	ok, err := Can(userID, perm, scope)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("%w: requires %s", ErrForbidden, perm)
+	}
+	return nil
+}
+
+// Permissions returns every permission the user holds in the scope
+func Permissions(userID string, scope Scope) ([]models.Permission, error) {
+	db := database.GetDB()
+
+	roles, err := rolesInScope(db, userID, scope)
+	if err != nil {
+		return nil, err
+	}
+
+	var perms []models.Permission
+	for _, role := range roles {
+		rolePerms, err := RolePermissions(role)
+		if err != nil {
+			return nil, err
+		}
+		perms = append(perms, rolePerms...)
+	}
+	return perms, nil
+}
+
+// RolePermissions returns the permissions bundled in a built-in or custom role.
+// Unknown roles grant nothing.
+func RolePermissions(role models.Role) ([]models.Permission, error) {
+	if perms, ok := models.BuiltinRoles[role]; ok {
+		return perms, nil
+	}
+
+	var def models.RoleDefinition
+	err := database.GetDB().First(&def, "name = ?", role).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return def.Permissions, nil
+}
+
+// RoleExists reports whether a role is built-in or defined in the roles table
+func RoleExists(role models.Role) (bool, error) {
+	if _, ok := models.BuiltinRoles[role]; ok {
+		return true, nil
+	}
+
+	var count int64
+	if err := database.GetDB().Model(&models.RoleDefinition{}).Where("name = ?", role).Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// CanGrant reports whether the user holds every permission of the role in the scope,
+// which prevents granting more than one has.
+func CanGrant(userID string, role models.Role, scope Scope) (bool, error) {
+	if ok, err := Can(userID, models.PermRolesBind, scope); err != nil || !ok {
+		return false, err
+	}
+
+	granted, err := Permissions(userID, scope)
+	if err != nil {
+		return false, err
+	}
+
+	rolePerms, err := RolePermissions(role)
+	if err != nil {
+		return false, err
+	}
+	for _, perm := range rolePerms {
+		if !covers(granted, perm) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// OrgsWithPermission returns the orgs in which the user holds the permission through
+// an explicit membership or org role binding. all is true when the permission is
+// held globally. The implicit membership of the public org is not included, so
+// private images in the public org stay hidden from ordinary users.
+func OrgsWithPermission(userID string, perm models.Permission) (all bool, orgIDs []string, err error) {
+	ok, err := Can(userID, perm, Global())
+	if err != nil || ok {
+		return ok, nil, err
+	}
+
+	db := database.GetDB()
+
+	var members []models.OrgMember
+	if err := db.Where("user_id = ?", userID).Find(&members).Error; err != nil {
+		return false, nil, err
+	}
+
+	subject, err := bindingSubject(db, userID)
+	if err != nil {
+		return false, nil, err
+	}
+
+	var bindings []models.RoleBinding
+	if err := db.Where(subject).Where("scope_type = ?", models.ScopeOrg).Find(&bindings).Error; err != nil {
+		return false, nil, err
+	}
+
+	grants := make([]scopedRole, 0, len(members)+len(bindings))
+	for _, m := range members {
+		grants = append(grants, scopedRole{m.OrgID, models.OrgRoleName(m.Role)})
+	}
+	for _, b := range bindings {
+		grants = append(grants, scopedRole{b.ScopeID, b.Role})
+	}
+
+	orgIDs, err = scopesCovering(grants, perm)
+	return false, orgIDs, err
+}
+
+// ProjectsWithPermission returns the projects in which the user holds the permission
+// through a project membership or project role binding. Permissions inherited from
+// the org are not included; combine with OrgsWithPermission for those.
+func ProjectsWithPermission(userID string, perm models.Permission) ([]string, error) {
+	db := database.GetDB()
+
+	var members []models.ProjectMember
+	if err := db.Where("user_id = ?", userID).Find(&members).Error; err != nil {
+		return nil, err
+	}
+
+	subject, err := bindingSubject(db, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var bindings []models.RoleBinding
+	if err := db.Where(subject).Where("scope_type = ?", models.ScopeProject).Find(&bindings).Error; err != nil {
+		return nil, err
+	}
+
+	grants := make([]scopedRole, 0, len(members)+len(bindings))
+	for _, m := range members {
+		grants = append(grants, scopedRole{m.ProjectID, models.ProjectRoleName(m.Role)})
+	}
+	for _, b := range bindings {
+		grants = append(grants, scopedRole{b.ScopeID, b.Role})
+	}
+	return scopesCovering(grants, perm)
+}
+
+// rolesInScope collects the roles that apply to the user in the scope
+func rolesInScope(db *gorm.DB, userID string, scope Scope) ([]models.Role, error) {
+	var user models.User
+	if err := db.Select("id", "role").First(&user, "id = ?", userID).Error; err != nil {
+		return nil, err
+	}
+
+	// 用户的全局角色
+	roles := []models.Role{user.Role}
+
+	bindingScopes := [][2]string{{string(models.ScopeGlobal), ""}}
+
+	orgID := ""
+	switch {
+	case scope.Type == models.ScopeOrg:
+		orgID = scope.ID
+	case scope.Type == models.ScopeProject && scope.ID != "":
+		var project models.Project
+		if err := db.Select("id", "org_id").First(&project, "id = ?", scope.ID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return roles, nil
+			}
+			return nil, err
+		}
+		orgID = project.OrgID
+
+		var member models.ProjectMember
+		err := db.First(&member, "project_id = ? AND user_id = ?", scope.ID, userID).Error
+		if err == nil {
+			roles = append(roles, models.ProjectRoleName(member.Role))
+		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+		bindingScopes = append(bindingScopes, [2]string{string(models.ScopeProject), scope.ID})
+	}
+
+	if orgID != "" {
+		orgRoles, err := orgMembershipRoles(db, userID, orgID)
+		if err != nil {
+			return nil, err
+		}
+		roles = append(roles, orgRoles...)
+		bindingScopes = append(bindingScopes, [2]string{string(models.ScopeOrg), orgID})
+	}
+
+	// 显式角色绑定，包括授予用户所在用户组的角色
+	subject, err := bindingSubject(db, userID)
+	if err != nil {
+		return nil, err
+	}
+	query := db.Model(&models.RoleBinding{}).Where(subject)
+	scopeQuery := db.Where("1 = 0")
+	for _, s := range bindingScopes {
+		scopeQuery = scopeQuery.Or("scope_type = ? AND scope_id = ?", s[0], s[1])
+	}
+
+	var bound []models.Role
+	if err := query.Where(scopeQuery).Pluck("role", &bound).Error; err != nil {
+		return nil, err
+	}
+	return append(roles, bound...), nil
+}
+
+// GroupIDs returns the groups the user belongs to, directly or through a child group.
+// Membership is read on every call so changes apply to the next request.
+func GroupIDs(userID string) ([]string, error) {
+	return groupIDs(database.GetDB(), userID)
+}
+
+func groupIDs(db *gorm.DB, userID string) ([]string, error) {
+	var direct []string
+	if err := db.Model(&models.GroupMember{}).Where("user_id = ?", userID).Pluck("group_id", &direct).Error; err != nil {
+		return nil, err
+	}
+	if len(direct) == 0 {
+		return nil, nil
+	}
+
+	var parents []string
+	err := db.Model(&models.Group{}).
+		Where("id IN ? AND parent_id IS NOT NULL", direct).
+		Pluck("parent_id", &parents).Error
+	if err != nil {
+		return nil, err
+	}
+	return append(direct, parents...), nil
+}
+
+// bindingSubject matches role bindings granted to the user or to any of their groups
+func bindingSubject(db *gorm.DB, userID string) (*gorm.DB, error) {
+	groups, err := groupIDs(db, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	subject := db.Where("user_id = ?", userID)
+	if len(groups) > 0 {
+		subject = subject.Or("group_id IN ?", groups)
+	}
+	return subject, nil
+}
+
+// orgMembershipRoles returns the role implied by the user's membership in the org.
+// Every user is implicitly a member of the public org.
+func orgMembershipRoles(db *gorm.DB, userID string, orgID string) ([]models.Role, error) {
+	var member models.OrgMember
+	err := db.First(&member, "org_id = ? AND user_id = ?", orgID, userID).Error
+	if err == nil {
+		return []models.Role{models.OrgRoleName(member.Role)}, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	if orgID == models.PublicOrgID {
+		return []models.Role{models.RoleOrgMember}, nil
+	}
+	return nil, nil
+}
+
+func covers(granted []models.Permission, perm models.Permission) bool {
+	for _, g := range granted {
+		if g.Covers(perm) {
+			return true
+		}
+	}
+	return false
+}
+
+// scopedRole is a role held in a single org or project
+type scopedRole struct {
+	scopeID string
+	role    models.Role
+}
+
+// scopesCovering returns the distinct scope IDs whose role grants the permission
+func scopesCovering(grants []scopedRole, perm models.Permission) ([]string, error) {
+	var ids []string
+	seen := make(map[string]bool)
+	for _, g := range grants {
+		if seen[g.scopeID] {
+			continue
+		}
+		perms, err := RolePermissions(g.role)
+		if err != nil {
+			return nil, err
+		}
+		if covers(perms, perm) {
+			seen[g.scopeID] = true
+			ids = append(ids, g.scopeID)
+		}
+	}
+	return ids, nil
+}
@@ -0,0 +1,218 @@
+package authz
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/samzong/share-ai-platform/internal/database"
+	"github.com/samzong/share-ai-platform/internal/models"
+)
+
+// fixture holds the IDs of the users, orgs and projects created by setupAuthz
+type fixture struct {
+	admin, alice, bob, carol, dave string
+	acme, other                    string
+	rocket, probe                  string
+	parentGroup, childGroup        string
+}
+
+// setupAuthz builds a small tenant graph on a fresh SQLite database:
+//   - alice owns acme
+//   - bob is an acme member and maintains the rocket project in acme
+//   - carol is in a child group whose parent group is bound org_maintainer in other
+//   - dave holds the custom "auditor" role on the probe project in other
+func setupAuthz(t *testing.T) fixture {
+	require.NoError(t, database.SetupTestDB())
+	t.Cleanup(database.TeardownTestDB)
+	db := database.GetDB()
+
+	var f fixture
+	for _, u := range []struct {
+		id   *string
+		name string
+		role models.Role
+	}{
+		{&f.admin, "admin", models.RoleAdmin},
+		{&f.alice, "alice", models.RoleUser},
+		{&f.bob, "bob", models.RoleUser},
+		{&f.carol, "carol", models.RoleUser},
+		{&f.dave, "dave", models.RoleUser},
+	} {
+		user := &models.User{Username: u.name, Email: u.name + "@example.com", Password: "secret", Role: u.role}
+		require.NoError(t, db.Create(user).Error)
+		*u.id = user.ID
+	}
+
+	acme := &models.Organization{Name: "acme"}
+	other := &models.Organization{Name: "other"}
+	require.NoError(t, db.Create(acme).Error)
+	require.NoError(t, db.Create(other).Error)
+	f.acme, f.other = acme.ID, other.ID
+
+	rocket := &models.Project{OrgID: f.acme, Name: "rocket"}
+	probe := &models.Project{OrgID: f.other, Name: "probe"}
+	require.NoError(t, db.Create(rocket).Error)
+	require.NoError(t, db.Create(probe).Error)
+	f.rocket, f.probe = rocket.ID, probe.ID
+
+	require.NoError(t, db.Create(&models.OrgMember{OrgID: f.acme, UserID: f.alice, Role: models.OrgRoleOwner}).Error)
+	require.NoError(t, db.Create(&models.OrgMember{OrgID: f.acme, UserID: f.bob, Role: models.OrgRoleMember}).Error)
+	require.NoError(t, db.Create(&models.ProjectMember{ProjectID: f.rocket, UserID: f.bob, Role: models.ProjectRoleMaintainer}).Error)
+
+	parent := &models.Group{OrgID: f.other, Name: "platform"}
+	require.NoError(t, db.Create(parent).Error)
+	child := &models.Group{OrgID: f.other, Name: "sre", ParentID: &parent.ID}
+	require.NoError(t, db.Create(child).Error)
+	f.parentGroup, f.childGroup = parent.ID, child.ID
+	require.NoError(t, db.Create(&models.GroupMember{GroupID: f.childGroup, UserID: f.carol}).Error)
+	require.NoError(t, db.Create(&models.RoleBinding{
+		GroupID: f.parentGroup, Role: models.RoleOrgMaintainer, ScopeType: models.ScopeOrg, ScopeID: f.other,
+	}).Error)
+
+	require.NoError(t, db.Create(&models.RoleDefinition{
+		Name:        "auditor",
+		Permissions: []models.Permission{models.PermRolesRead, "images.*"},
+	}).Error)
+	require.NoError(t, db.Create(&models.RoleBinding{
+		UserID: f.dave, Role: "auditor", ScopeType: models.ScopeProject, ScopeID: f.probe,
+	}).Error)
+
+	return f
+}
+
+func TestCan(t *testing.T) {
+	f := setupAuthz(t)
+
+	tests := []struct {
+		name  string
+		user  string
+		perm  models.Permission
+		scope Scope
+		want  bool
+	}{
+		{"admin holds everything globally", f.admin, models.PermRolesManage, Global(), true},
+		{"admin holds everything in any org", f.admin, models.PermImagesManage, Org(f.other), true},
+		{"global role applies in every scope", f.bob, models.PermProfileManage, Project(f.probe), true},
+		{"org owner manages images in the org", f.alice, models.PermImagesManage, Org(f.acme), true},
+		{"org owner has no rights in another org", f.alice, models.PermImagesManage, Org(f.other), false},
+		{"org owner has no global rights", f.alice, models.PermImagesManage, Global(), false},
+		{"org role applies to the org's projects", f.alice, models.PermImagesManage, Project(f.rocket), true},
+		{"org role does not apply to other orgs' projects", f.alice, models.PermImagesManage, Project(f.probe), false},
+		{"org member can create images", f.bob, models.PermImagesCreate, Org(f.acme), true},
+		{"org member cannot manage images", f.bob, models.PermImagesManage, Org(f.acme), false},
+		{"project maintainer manages the project", f.bob, models.PermImagesManage, Project(f.rocket), true},
+		{"project role does not widen to other projects", f.bob, models.PermImagesManage, Project(f.probe), false},
+		{"everyone is a member of the public org", f.dave, models.PermImagesRead, Org(models.PublicOrgID), true},
+		{"non-members cannot read a private org", f.dave, models.PermImagesRead, Org(f.acme), false},
+		{"nested group binding applies to child group members", f.carol, models.PermImagesManage, Org(f.other), true},
+		{"nested group binding applies to the org's projects", f.carol, models.PermImagesManage, Project(f.probe), true},
+		{"group binding is limited to its scope", f.carol, models.PermImagesManage, Org(f.acme), false},
+		{"custom role with wildcard applies in its project", f.dave, models.PermImagesUpdate, Project(f.probe), true},
+		{"custom role does not apply to the parent org", f.dave, models.PermImagesUpdate, Org(f.other), false},
+		{"unknown project falls back to global roles", f.alice, models.PermImagesManage, Project("missing"), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Can(tt.user, tt.perm, tt.scope)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestCheckWrapsErrForbidden(t *testing.T) {
+	f := setupAuthz(t)
+
+	assert.NoError(t, Check(f.alice, models.PermOrgsManage, Org(f.acme)))
+	assert.ErrorIs(t, Check(f.bob, models.PermOrgsManage, Org(f.acme)), ErrForbidden)
+}
+
+func TestGroupIDs(t *testing.T) {
+	f := setupAuthz(t)
+
+	groups, err := GroupIDs(f.carol)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{f.childGroup, f.parentGroup}, groups)
+
+	groups, err = GroupIDs(f.alice)
+	require.NoError(t, err)
+	assert.Empty(t, groups)
+}
+
+func TestCanGrant(t *testing.T) {
+	f := setupAuthz(t)
+
+	tests := []struct {
+		name  string
+		user  string
+		role  models.Role
+		scope Scope
+		want  bool
+	}{
+		{"admin grants admin", f.admin, models.RoleAdmin, Global(), true},
+		{"owner grants member in own org", f.alice, models.RoleOrgMember, Org(f.acme), true},
+		{"owner grants owner in own org", f.alice, models.RoleOrgOwner, Org(f.acme), true},
+		{"owner cannot grant admin", f.alice, models.RoleAdmin, Org(f.acme), false},
+		{"owner cannot grant in another org", f.alice, models.RoleOrgMember, Org(f.other), false},
+		{"owner cannot grant globally", f.alice, models.RoleUser, Global(), false},
+		{"member without roles.bind cannot grant", f.bob, models.RoleOrgMember, Org(f.acme), false},
+		{"custom role holder without roles.bind cannot grant it", f.dave, "auditor", Project(f.probe), false},
+		{"owner cannot grant a custom role beyond its permissions", f.alice, "auditor", Org(f.acme), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := CanGrant(tt.user, tt.role, tt.scope)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestOrgsWithPermission(t *testing.T) {
+	f := setupAuthz(t)
+
+	tests := []struct {
+		name    string
+		user    string
+		perm    models.Permission
+		wantAll bool
+		wantIDs []string
+	}{
+		{"admin holds it everywhere", f.admin, models.PermImagesManage, true, nil},
+		{"org owner", f.alice, models.PermImagesManage, false, []string{f.acme}},
+		{"org membership", f.bob, models.PermImagesCreate, false, []string{f.acme}},
+		{"project roles are not org grants", f.bob, models.PermImagesManage, false, nil},
+		{"nested group binding", f.carol, models.PermImagesManage, false, []string{f.other}},
+		{"public org membership is implicit and excluded", f.dave, models.PermImagesRead, false, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			all, ids, err := OrgsWithPermission(tt.user, tt.perm)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantAll, all)
+			assert.ElementsMatch(t, tt.wantIDs, ids)
+		})
+	}
+}
+
+func TestProjectsWithPermission(t *testing.T) {
+	f := setupAuthz(t)
+
+	ids, err := ProjectsWithPermission(f.bob, models.PermImagesManage)
+	require.NoError(t, err)
+	assert.Equal(t, []string{f.rocket}, ids)
+
+	ids, err = ProjectsWithPermission(f.dave, models.PermImagesUpdate)
+	require.NoError(t, err)
+	assert.Equal(t, []string{f.probe}, ids)
+
+	// 组织内继承的权限不在项目列表中
+	ids, err = ProjectsWithPermission(f.alice, models.PermImagesManage)
+	require.NoError(t, err)
+	assert.Empty(t, ids)
+}
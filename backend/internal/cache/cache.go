@@ -0,0 +1,188 @@
+// Package cache provides a key-value cache backed by Redis, with an in-process LRU used
+// when Redis is not configured or unreachable.
+package cache
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/spf13/viper"
+	"golang.org/x/sync/singleflight"
+)
+
+// ErrMiss is returned by Get when the key is not cached
+var ErrMiss = errors.New("cache miss")
+
+const (
+	DriverRedis  = "redis"
+	DriverMemory = "memory"
+	DriverNone   = "none"
+
+	defaultMaxEntries = 10000
+)
+
+// Cache stores byte values with an optional time to live. A zero ttl never expires.
+type Cache interface {
+	Get(ctx context.Context, key string) ([]byte, error)
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	Delete(ctx context.Context, keys ...string) error
+	// Incr increments an integer counter, applying ttl when the counter is created
+	Incr(ctx context.Context, key string, ttl time.Duration) (int64, error)
+}
+
+var (
+	defaultCache  Cache = NewMemory(defaultMaxEntries)
+	countersCache Cache = NewMemory(defaultMaxEntries)
+	loads         singleflight.Group
+)
+
+// Init selects the cache backend from the "cache" configuration. With the redis driver,
+// operations fall back to cache.fallback (memory or none) while Redis is unreachable.
+func Init(rdb *redis.Client) {
+	maxEntries := viper.GetInt("cache.memory.max_entries")
+	if maxEntries <= 0 {
+		maxEntries = defaultMaxEntries
+	}
+
+	driver := viper.GetString("cache.driver")
+	if driver == "" {
+		driver = DriverRedis
+	}
+
+	if rdb != nil {
+		countersCache = NewFallback(NewRedis(rdb), NewMemory(maxEntries))
+	} else {
+		countersCache = NewMemory(maxEntries)
+	}
+
+	var fallback Cache = NewMemory(maxEntries)
+	if viper.GetString("cache.fallback") == DriverNone {
+		fallback = Noop{}
+	}
+
+	switch {
+	case driver == DriverRedis && rdb != nil:
+		defaultCache = NewFallback(NewRedis(rdb), fallback)
+	case driver == DriverNone:
+		defaultCache = Noop{}
+	case driver == DriverRedis:
+		log.Printf("Redis is not configured, using the %T cache", fallback)
+		defaultCache = fallback
+	default:
+		defaultCache = NewMemory(maxEntries)
+	}
+}
+
+// Default returns the configured cache
+func Default() Cache {
+	return defaultCache
+}
+
+// Counters returns the cache for rate-limit counters: Redis when configured, with a bounded
+// in-process LRU while Redis is unreachable. Unlike Default it ignores cache.driver, so
+// limits are enforced even when response caching is disabled.
+func Counters() Cache {
+	return countersCache
+}
+
+// GetOrLoad returns the cached value of key, calling load on a miss and caching its result.
+// Concurrent misses for the same key share a single load.
+func GetOrLoad(ctx context.Context, key string, ttl time.Duration, load func() ([]byte, error)) ([]byte, error) {
+	c := Default()
+	if value, err := c.Get(ctx, key); err == nil {
+		return value, nil
+	}
+
+	value, err, _ := loads.Do(key, func() (interface{}, error) {
+		value, err := load()
+		if err != nil {
+			return nil, err
+		}
+		if err := c.Set(ctx, key, value, ttl); err != nil {
+			log.Printf("Error caching %s: %v", key, err)
+		}
+		return value, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return value.([]byte), nil
+}
+
+// Fallback uses the primary cache and switches to the secondary one for operations that
+// fail, e.g. while Redis is down. Values written during an outage stay in the secondary.
+type Fallback struct {
+	primary   Cache
+	secondary Cache
+
+	mu          sync.Mutex
+	lastWarning time.Time
+}
+
+// fallbackLogPeriod 主缓存不可用时告警日志的最小间隔
+const fallbackLogPeriod = time.Minute
+
+// NewFallback creates a cache that falls back to secondary when primary fails
+func NewFallback(primary, secondary Cache) *Fallback {
+	return &Fallback{primary: primary, secondary: secondary}
+}
+
+func (f *Fallback) warn(err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if time.Since(f.lastWarning) >= fallbackLogPeriod {
+		f.lastWarning = time.Now()
+		log.Printf("Cache unavailable, falling back to %T: %v", f.secondary, err)
+	}
+}
+
+func (f *Fallback) Get(ctx context.Context, key string) ([]byte, error) {
+	value, err := f.primary.Get(ctx, key)
+	if err == nil || errors.Is(err, ErrMiss) {
+		return value, err
+	}
+	f.warn(err)
+	return f.secondary.Get(ctx, key)
+}
+
+func (f *Fallback) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	if err := f.primary.Set(ctx, key, value, ttl); err != nil {
+		f.warn(err)
+		return f.secondary.Set(ctx, key, value, ttl)
+	}
+	return nil
+}
+
+func (f *Fallback) Delete(ctx context.Context, keys ...string) error {
+	// 同时删除备用缓存中的值，避免恢复后再次故障时读到旧值
+	f.secondary.Delete(ctx, keys...)
+	if err := f.primary.Delete(ctx, keys...); err != nil {
+		f.warn(err)
+	}
+	return nil
+}
+
+func (f *Fallback) Incr(ctx context.Context, key string, ttl time.Duration) (int64, error) {
+	n, err := f.primary.Incr(ctx, key, ttl)
+	if err != nil {
+		f.warn(err)
+		return f.secondary.Incr(ctx, key, ttl)
+	}
+	return n, nil
+}
+
+// Noop caches nothing
+type Noop struct{}
+
+func (Noop) Get(ctx context.Context, key string) ([]byte, error) { return nil, ErrMiss }
+func (Noop) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	return nil
+}
+func (Noop) Delete(ctx context.Context, keys ...string) error { return nil }
+func (Noop) Incr(ctx context.Context, key string, ttl time.Duration) (int64, error) {
+	return 0, errors.New("counters are not available without a cache")
+}
@@ -0,0 +1,120 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryLRU(t *testing.T) {
+	ctx := context.Background()
+	m := NewMemory(2)
+
+	m.Set(ctx, "a", []byte("1"), 0)
+	m.Set(ctx, "b", []byte("2"), 0)
+	m.Get(ctx, "a") // a 变为最近使用
+	m.Set(ctx, "c", []byte("3"), 0)
+
+	_, err := m.Get(ctx, "b")
+	assert.ErrorIs(t, err, ErrMiss)
+	value, err := m.Get(ctx, "a")
+	require.NoError(t, err)
+	assert.Equal(t, "1", string(value))
+
+	m.Delete(ctx, "a")
+	_, err = m.Get(ctx, "a")
+	assert.ErrorIs(t, err, ErrMiss)
+}
+
+func TestMemoryTTL(t *testing.T) {
+	ctx := context.Background()
+	m := NewMemory(10)
+
+	m.Set(ctx, "k", []byte("v"), 20*time.Millisecond)
+	_, err := m.Get(ctx, "k")
+	require.NoError(t, err)
+
+	time.Sleep(30 * time.Millisecond)
+	_, err = m.Get(ctx, "k")
+	assert.ErrorIs(t, err, ErrMiss)
+}
+
+func TestMemoryIncr(t *testing.T) {
+	ctx := context.Background()
+	m := NewMemory(10)
+
+	n, _ := m.Incr(ctx, "n", 20*time.Millisecond)
+	assert.Equal(t, int64(1), n)
+	n, _ = m.Incr(ctx, "n", 20*time.Millisecond)
+	assert.Equal(t, int64(2), n)
+
+	// 递增不会延长过期时间
+	time.Sleep(30 * time.Millisecond)
+	n, _ = m.Incr(ctx, "n", 0)
+	assert.Equal(t, int64(1), n)
+}
+
+type brokenCache struct{ Noop }
+
+func (brokenCache) Get(ctx context.Context, key string) ([]byte, error) {
+	return nil, errors.New("connection refused")
+}
+func (brokenCache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	return errors.New("connection refused")
+}
+
+func TestFallback(t *testing.T) {
+	ctx := context.Background()
+	f := NewFallback(brokenCache{}, NewMemory(10))
+
+	require.NoError(t, f.Set(ctx, "k", []byte("v"), 0))
+	value, err := f.Get(ctx, "k")
+	require.NoError(t, err)
+	assert.Equal(t, "v", string(value))
+
+	n, err := f.Incr(ctx, "n", 0)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), n)
+}
+
+func TestGetOrLoadSharesConcurrentLoads(t *testing.T) {
+	defaultCache = NewMemory(10)
+	ctx := context.Background()
+
+	var calls int32
+	release := make(chan struct{})
+	load := func() ([]byte, error) {
+		atomic.AddInt32(&calls, 1)
+		<-release
+		return []byte("page"), nil
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			value, err := GetOrLoad(ctx, "cold", time.Minute, load)
+			assert.NoError(t, err)
+			assert.Equal(t, "page", string(value))
+		}()
+	}
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+
+	// 之后直接命中缓存
+	_, err := GetOrLoad(ctx, "cold", time.Minute, func() ([]byte, error) {
+		t.Fatal("unexpected load")
+		return nil, nil
+	})
+	assert.NoError(t, err)
+}
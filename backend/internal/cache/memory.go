@@ -0,0 +1,124 @@
+package cache
+
+import (
+	"container/list"
+	"context"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Memory is an in-process LRU cache. It is not shared between replicas.
+type Memory struct {
+	mu         sync.Mutex
+	maxEntries int
+	order      *list.List // 最近使用的在前
+	entries    map[string]*list.Element
+}
+
+type memoryEntry struct {
+	key       string
+	value     []byte
+	expiresAt time.Time
+}
+
+// NewMemory creates an LRU cache holding at most maxEntries values
+func NewMemory(maxEntries int) *Memory {
+	return &Memory{
+		maxEntries: maxEntries,
+		order:      list.New(),
+		entries:    make(map[string]*list.Element),
+	}
+}
+
+// lookup returns the live entry of key, dropping it if it has expired. Callers hold mu.
+func (m *Memory) lookup(key string) *memoryEntry {
+	el, ok := m.entries[key]
+	if !ok {
+		return nil
+	}
+	entry := el.Value.(*memoryEntry)
+	if !entry.expiresAt.IsZero() && time.Now().After(entry.expiresAt) {
+		m.order.Remove(el)
+		delete(m.entries, key)
+		return nil
+	}
+	m.order.MoveToFront(el)
+	return entry
+}
+
+// store sets key, evicting the least recently used entries over capacity. Callers hold mu.
+func (m *Memory) store(key string, value []byte, expiresAt time.Time) {
+	if el, ok := m.entries[key]; ok {
+		entry := el.Value.(*memoryEntry)
+		entry.value = value
+		entry.expiresAt = expiresAt
+		m.order.MoveToFront(el)
+		return
+	}
+
+	m.entries[key] = m.order.PushFront(&memoryEntry{key: key, value: value, expiresAt: expiresAt})
+	for m.maxEntries > 0 && m.order.Len() > m.maxEntries {
+		oldest := m.order.Back()
+		m.order.Remove(oldest)
+		delete(m.entries, oldest.Value.(*memoryEntry).key)
+	}
+}
+
+func (m *Memory) Get(ctx context.Context, key string) ([]byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	entry := m.lookup(key)
+	if entry == nil {
+		return nil, ErrMiss
+	}
+	return entry.value, nil
+}
+
+func (m *Memory) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var expiresAt time.Time
+	if ttl > 0 {
+		expiresAt = time.Now().Add(ttl)
+	}
+	m.store(key, value, expiresAt)
+	return nil
+}
+
+func (m *Memory) Delete(ctx context.Context, keys ...string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, key := range keys {
+		if el, ok := m.entries[key]; ok {
+			m.order.Remove(el)
+			delete(m.entries, key)
+		}
+	}
+	return nil
+}
+
+func (m *Memory) Incr(ctx context.Context, key string, ttl time.Duration) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var n int64
+	var expiresAt time.Time
+	if entry := m.lookup(key); entry != nil {
+		current, err := strconv.ParseInt(string(entry.value), 10, 64)
+		if err != nil {
+			return 0, err
+		}
+		n = current
+		expiresAt = entry.expiresAt
+	} else if ttl > 0 {
+		expiresAt = time.Now().Add(ttl)
+	}
+
+	n++
+	m.store(key, []byte(strconv.FormatInt(n, 10)), expiresAt)
+	return n, nil
+}
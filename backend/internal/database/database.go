@@ -1,31 +1,67 @@
 package database
 
 import (
+	"context"
 	"fmt"
 	"log"
+	"strings"
 
+	"github.com/glebarez/sqlite"
 	"github.com/spf13/viper"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
+
+	"github.com/samzong/share-ai-platform/internal/migrations"
+)
+
+// 支持的数据库驱动
+const (
+	DriverPostgres = "postgres"
+	DriverSQLite   = "sqlite"
 )
 
 var db *gorm.DB
 
 // InitDB initializes the database connection
 func InitDB() error {
-	// 初始化 PostgreSQL
-	if err := initPostgres(); err != nil {
-		return err
+	// 初始化数据库，默认使用 PostgreSQL
+	switch Driver() {
+	case DriverPostgres:
+		if err := initPostgres(); err != nil {
+			return err
+		}
+	case DriverSQLite:
+		if err := initSQLite(); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("unsupported database driver %q", Driver())
+	}
+
+	// 本地开发时可以在启动时直接执行迁移，无需单独运行 migrate
+	if viper.GetBool("database.auto_migrate") {
+		if err := Migrate(context.Background()); err != nil {
+			return err
+		}
 	}
 
-	// 初始化 Redis
+	// 初始化 Redis，不可用时缓存降级为进程内存，不阻止服务启动
 	if err := InitRedis(); err != nil {
-		return err
+		log.Printf("Warning: %v, falling back to the in-process cache", err)
 	}
 
 	return nil
 }
 
+// Driver returns the configured database driver, postgres unless set otherwise
+func Driver() string {
+	driver := strings.ToLower(viper.GetString("database.driver"))
+	if driver == "" {
+		return DriverPostgres
+	}
+	return driver
+}
+
 // initPostgres initializes the PostgreSQL connection
 func initPostgres() error {
 	// 构建数据库连接字符串
@@ -59,6 +95,71 @@ func initPostgres() error {
 	return nil
 }
 
+// initSQLite opens the SQLite database file configured in database.path
+func initSQLite() error {
+	path := viper.GetString("database.path")
+	if path == "" {
+		path = "share_ai_platform.db"
+	}
+
+	var err error
+	db, err = OpenSQLite(path)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("SQLite database %s opened", path)
+	return nil
+}
+
+// OpenSQLite opens a SQLite database using the pure Go driver, so no cgo or external
+// server is needed. ":memory:" opens a private in-memory database.
+func OpenSQLite(path string) (*gorm.DB, error) {
+	// 外键约束默认关闭，需要显式开启；busy_timeout 避免并发写入时立即返回 SQLITE_BUSY
+	dsn := path + "?_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)"
+	memory := path == ":memory:"
+	if !memory {
+		dsn += "&_pragma=journal_mode(WAL)"
+	}
+
+	conn, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open sqlite database: %v", err)
+	}
+	sqlDB, err := conn.DB()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get database instance: %v", err)
+	}
+	// 内存数据库每个连接都是独立的库，只能使用一个连接；文件数据库同一时间也只允许一个写入者
+	sqlDB.SetMaxOpenConns(1)
+	return conn, nil
+}
+
+// Migrate applies all pending migrations for the configured driver
+func Migrate(ctx context.Context) error {
+	return MigrateDB(ctx, db, migrations.Dialect(Driver()))
+}
+
+// MigrateDB applies all pending migrations of the dialect to conn
+func MigrateDB(ctx context.Context, conn *gorm.DB, dialect migrations.Dialect) error {
+	all, err := migrations.Embedded(dialect)
+	if err != nil {
+		return err
+	}
+	sqlDB, err := conn.DB()
+	if err != nil {
+		return fmt.Errorf("failed to get database instance: %v", err)
+	}
+	done, err := migrations.New(sqlDB, dialect, all).Up(ctx, 0)
+	for _, mig := range done {
+		log.Printf("Applied migration %04d_%s", mig.Version, mig.Name)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to migrate database: %v", err)
+	}
+	return nil
+}
+
 // GetDB returns the database instance
 func GetDB() *gorm.DB {
 	return db
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"time"
 
 	"github.com/go-redis/redis/v8"
 	"github.com/spf13/viper"
@@ -11,7 +12,8 @@ import (
 
 var RedisClient *redis.Client
 
-// InitRedis initializes the Redis connection
+// InitRedis initializes the Redis connection. The client is kept even when Redis is
+// unreachable so that it reconnects once Redis comes back.
 func InitRedis() error {
 	redisClient := redis.NewClient(&redis.Options{
 		Addr:         fmt.Sprintf("%s:%s", viper.GetString("redis.host"), viper.GetString("redis.port")),
@@ -21,6 +23,8 @@ func InitRedis() error {
 		MinIdleConns: viper.GetInt("redis.min_idle_conns"),
 	})
 
+	RedisClient = redisClient
+
 	// Test the connection
 	ctx := context.Background()
 	_, err := redisClient.Ping(ctx).Result()
@@ -28,7 +32,6 @@ func InitRedis() error {
 		return fmt.Errorf("failed to connect to Redis: %v", err)
 	}
 
-	RedisClient = redisClient
 	log.Println("Redis connection established")
 	return nil
 }
@@ -38,9 +41,9 @@ func GetRedis() *redis.Client {
 	return RedisClient
 }
 
-// Set stores a key-value pair in Redis with expiration
+// Set stores a key-value pair in Redis with an expiration in seconds, 0 meaning no expiration
 func Set(ctx context.Context, key string, value interface{}, expiration int) error {
-	return RedisClient.Set(ctx, key, value, 0).Err()
+	return RedisClient.Set(ctx, key, value, time.Duration(expiration)*time.Second).Err()
 }
 
 // Get retrieves a value from Redis by key
@@ -1,44 +1,25 @@
 package database
 
 import (
-	"fmt"
+	"context"
 	"log"
-	"os"
-	"testing"
 
-	"github.com/spf13/viper"
-	"gorm.io/driver/postgres"
-	"gorm.io/gorm"
+	"github.com/samzong/share-ai-platform/internal/migrations"
 )
 
-// SetupTestDB initializes a test database connection
+// SetupTestDB replaces the database with a migrated in-memory SQLite database, so
+// integration tests run without a PostgreSQL server
 func SetupTestDB() error {
-	// 设置测试数据库配置
-	viper.Set("database.host", "localhost")
-	viper.Set("database.port", 5432)
-	viper.Set("database.user", "postgres")
-	viper.Set("database.password", "postgres")
-	viper.Set("database.dbname", "share_ai_platform_test")
-	viper.Set("database.sslmode", "disable")
-
-	// 构建数据库连接字符串
-	dsn := fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
-		viper.GetString("database.host"),
-		viper.GetInt("database.port"),
-		viper.GetString("database.user"),
-		viper.GetString("database.password"),
-		viper.GetString("database.dbname"),
-		viper.GetString("database.sslmode"),
-	)
-
-	// 连接数据库
-	var err error
-	db, err = gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	conn, err := OpenSQLite(":memory:")
 	if err != nil {
-		return fmt.Errorf("failed to connect to test database: %v", err)
+		return err
+	}
+	if err := MigrateDB(context.Background(), conn, migrations.SQLite); err != nil {
+		return err
 	}
 
-	log.Println("Test database connection established")
+	db = conn
+	log.Println("Test database ready")
 	return nil
 }
 
@@ -52,21 +33,6 @@ func TeardownTestDB() {
 			return
 		}
 		sqlDB.Close()
+		db = nil
 	}
 }
-
-// TestMain is used to setup and teardown the test database
-func TestMain(m *testing.M) {
-	// 设置测试环境
-	if err := SetupTestDB(); err != nil {
-		log.Fatalf("Failed to setup test database: %v", err)
-	}
-
-	// 运行测试
-	code := m.Run()
-
-	// 清理测试环境
-	TeardownTestDB()
-
-	os.Exit(code)
-}
@@ -2,6 +2,8 @@ package middleware
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"net/http"
 	"strings"
@@ -9,129 +11,210 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 	"github.com/spf13/viper"
 
 	"github.com/samzong/share-ai-platform/internal/database"
 	"github.com/samzong/share-ai-platform/internal/models"
+	"github.com/samzong/share-ai-platform/internal/repository"
 )
 
 // Claims represents the JWT claims
 type Claims struct {
 	UserID string `json:"user_id"`
+	// Purpose marks restricted tokens (e.g. a pending 2FA challenge); empty for access tokens
+	Purpose string `json:"purpose,omitempty"`
 	jwt.RegisteredClaims
 }
 
+// PurposeTwoFactor marks a token that only proves the password step of a 2FA login
+const PurposeTwoFactor = "2fa"
+
 // TokenExpiration is the duration for which a token is valid
 var TokenExpiration = time.Hour * 24
 
+// ChallengeExpiration is the duration for which a 2FA challenge token is valid
+var ChallengeExpiration = time.Minute * 5
+
 // GenerateToken generates a new JWT token for a user
 func GenerateToken(userID string) (string, error) {
+	return signToken(userID, "", TokenExpiration)
+}
+
+// GenerateChallengeToken generates a short-lived token that can only be exchanged for
+// an access token by completing the second login factor
+func GenerateChallengeToken(userID string) (string, error) {
+	return signToken(userID, PurposeTwoFactor, ChallengeExpiration)
+}
+
+// ParseChallengeToken validates a 2FA challenge token and returns its claims
+func ParseChallengeToken(tokenString string) (*Claims, error) {
+	claims, err := parseToken(tokenString)
+	if err != nil {
+		return nil, err
+	}
+	if claims.Purpose != PurposeTwoFactor {
+		return nil, fmt.Errorf("not a two-factor challenge token")
+	}
+	return claims, nil
+}
+
+func signToken(userID, purpose string, expiration time.Duration) (string, error) {
 	// Create the Claims
 	claims := Claims{
-		userID,
-		jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(TokenExpiration)),
+		UserID:  userID,
+		Purpose: purpose,
+		RegisteredClaims: jwt.RegisteredClaims{
+			// 唯一 ID 保证同一秒内签发的令牌互不相同，吊销记录按令牌摘要区分
+			ID:        uuid.NewString(),
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(expiration)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 			NotBefore: jwt.NewNumericDate(time.Now()),
 		},
 	}
 
-	// Create token
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	if signingAlgorithm() == AlgorithmHS256 {
+		token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+		return token.SignedString([]byte(viper.GetString("server.jwt_secret")))
+	}
 
-	// Generate encoded token
-	return token.SignedString([]byte(viper.GetString("server.jwt_secret")))
-}
+	ks, err := getKeySet()
+	if err != nil {
+		return "", err
+	}
 
-// AuthMiddleware verifies the JWT token and sets the user in the context
-func AuthMiddleware() gin.HandlerFunc {
-	return func(c *gin.Context) {
-		authHeader := c.GetHeader("Authorization")
-		if authHeader == "" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Authorization header is required"})
-			c.Abort()
-			return
-		}
+	key := ks.Current()
+	if key == nil {
+		return "", fmt.Errorf("no signing key available")
+	}
 
-		// Check if the Authorization header has the correct format
-		parts := strings.Split(authHeader, " ")
-		if len(parts) != 2 || parts[0] != "Bearer" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid authorization header format"})
-			c.Abort()
-			return
-		}
+	// Create token, the kid header tells verifiers which public key to use
+	token := jwt.NewWithClaims(key.method(), claims)
+	token.Header["kid"] = key.KID
 
-		tokenString := parts[1]
+	// Generate encoded token
+	return token.SignedString(key.signer)
+}
 
-		// Check if token is in blacklist
-		exists, err := database.GetRedis().Exists(c, "blacklist:"+tokenString).Result()
-		if err == nil && exists > 0 {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Token has been invalidated"})
-			c.Abort()
-			return
-		}
+func parseToken(tokenString string) (*Claims, error) {
+	claims := &Claims{}
+	token, err := jwt.ParseWithClaims(tokenString, claims, verificationKey,
+		jwt.WithValidMethods([]string{AlgorithmEdDSA, AlgorithmRS256, AlgorithmHS256}))
+	if err != nil {
+		return nil, err
+	}
+	if !token.Valid {
+		return nil, fmt.Errorf("invalid token")
+	}
+	return claims, nil
+}
 
-		// Parse and validate the token
-		claims := &Claims{}
-		token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-			}
-			return []byte(viper.GetString("server.jwt_secret")), nil
-		})
-
-		if err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired token"})
-			c.Abort()
-			return
+// verificationKey resolves the public key for a token from its kid header.
+// HS256 tokens are only accepted in legacy mode or while migrating away from it.
+func verificationKey(token *jwt.Token) (interface{}, error) {
+	if _, ok := token.Method.(*jwt.SigningMethodHMAC); ok {
+		if signingAlgorithm() != AlgorithmHS256 && !viper.GetBool("server.jwt_accept_legacy") {
+			return nil, fmt.Errorf("legacy HS256 tokens are not accepted")
 		}
+		return []byte(viper.GetString("server.jwt_secret")), nil
+	}
 
-		if !token.Valid {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
-			c.Abort()
-			return
-		}
+	ks, err := getKeySet()
+	if err != nil {
+		return nil, err
+	}
 
-		// Get user from database
-		var user models.User
-		if err := database.GetDB().First(&user, "id = ?", claims.UserID).Error; err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
+	kid, _ := token.Header["kid"].(string)
+	key := ks.Lookup(kid)
+	if key == nil {
+		return nil, fmt.Errorf("unknown signing key: %s", kid)
+	}
+	if token.Method.Alg() != key.Algorithm {
+		return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+	}
+	return key.Public(), nil
+}
+
+// AuthMiddleware verifies the JWT token and sets the user in the context
+func AuthMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if msg := authenticate(c); msg != "" {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": msg})
 			c.Abort()
 			return
 		}
-
-		// Set user ID and role in context
-		c.Set("user_id", claims.UserID)
-		c.Set("user_role", user.Role)
-		c.Set("token", tokenString)
 		c.Next()
 	}
 }
 
-// AdminMiddleware ensures the user has admin role
-func AdminMiddleware() gin.HandlerFunc {
+// OptionalAuthMiddleware sets the user in the context when a valid token is sent,
+// and otherwise lets the request through anonymously
+func OptionalAuthMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		role, exists := c.Get("user_role")
-		if !exists {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "User role not found"})
-			c.Abort()
-			return
+		if c.GetHeader("Authorization") != "" {
+			authenticate(c)
 		}
+		c.Next()
+	}
+}
 
-		if role != models.RoleAdmin {
-			c.JSON(http.StatusForbidden, gin.H{"error": "Admin access required"})
-			c.Abort()
-			return
-		}
+// authenticate verifies the bearer token and sets the user in the context.
+// It returns the reason for rejecting the request, or "" on success.
+func authenticate(c *gin.Context) string {
+	authHeader := c.GetHeader("Authorization")
+	if authHeader == "" {
+		return "Authorization header is required"
+	}
 
-		c.Next()
+	// Check if the Authorization header has the correct format
+	parts := strings.Split(authHeader, " ")
+	if len(parts) != 2 || parts[0] != "Bearer" {
+		return "Invalid authorization header format"
+	}
+
+	tokenString := parts[1]
+
+	// Parse and validate the token before touching the database, so that forged or
+	// expired tokens are rejected without a revocation lookup
+	claims, err := parseToken(tokenString)
+	if err != nil {
+		return "Invalid or expired token"
+	}
+
+	// Check if token has been revoked. Without the token store the request is rejected
+	// rather than risking a logged-out token being accepted.
+	revoked, err := repository.NewTokenRepo(database.GetDB()).IsRevoked(TokenDigest(tokenString))
+	if err != nil {
+		return "Unable to verify token"
+	}
+	if revoked {
+		return "Token has been invalidated"
 	}
+
+	// Challenge tokens only grant access to the second login step
+	if claims.Purpose != "" {
+		return "Two-factor authentication not completed"
+	}
+
+	// Get user from database
+	var user models.User
+	if err := database.GetDB().First(&user, "id = ?", claims.UserID).Error; err != nil {
+		return "User not found"
+	}
+
+	// Set user ID and role in context
+	c.Set("user_id", claims.UserID)
+	c.Set("user_role", user.Role)
+	c.Set("user_totp_enabled", user.TOTPEnabled)
+	c.Set("token", tokenString)
+	return ""
 }
 
-// GetUserID retrieves the user ID from the context
+// GetUserID retrieves the user ID from the context, or "" for anonymous requests
 func GetUserID(c *gin.Context) string {
 	userID, _ := c.Get("user_id")
-	return userID.(string)
+	id, _ := userID.(string)
+	return id
 }
 
 // GetUserRole retrieves the user role from the context
@@ -140,6 +223,12 @@ func GetUserRole(c *gin.Context) models.Role {
 	return role.(models.Role)
 }
 
+// TokenDigest returns the SHA-256 digest under which the state of a token is stored
+func TokenDigest(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
 // GetTokenFromContext retrieves the token from the context
 func GetTokenFromContext(c context.Context) string {
 	if gc, ok := c.(*gin.Context); ok {
@@ -0,0 +1,68 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/samzong/share-ai-platform/internal/database"
+	"github.com/samzong/share-ai-platform/internal/models"
+	"github.com/samzong/share-ai-platform/internal/repository"
+)
+
+func TestAuthMiddlewareRejectsRevokedTokens(t *testing.T) {
+	viper.Set("server.jwt_algorithm", "HS256")
+	viper.Set("server.jwt_secret", "test-secret")
+	defer viper.Set("server.jwt_algorithm", "")
+	defer viper.Set("server.jwt_secret", "")
+	require.NoError(t, database.SetupTestDB())
+	defer database.TeardownTestDB()
+
+	repos := repository.NewGorm(database.GetDB())
+	user := &models.User{Username: "alice", Email: "alice@example.com", Password: "password123"}
+	require.NoError(t, repos.Users.Create(user))
+	token, err := GenerateToken(user.ID)
+	require.NoError(t, err)
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/me", AuthMiddleware(), func(c *gin.Context) { c.Status(http.StatusNoContent) })
+	call := func(token string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/me", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		r.ServeHTTP(w, req)
+		return w
+	}
+	status := func() int { return call(token).Code }
+
+	assert.Equal(t, http.StatusNoContent, status())
+
+	_, err = repos.Tokens.Revoke(TokenDigest(token), user.ID, time.Now().Add(TokenExpiration))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusUnauthorized, status())
+
+	// 同一秒内签发的新令牌不受吊销影响
+	token, err = GenerateToken(user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusNoContent, status())
+
+	// 无法确认令牌是否已吊销时拒绝请求
+	sqlDB, err := database.GetDB().DB()
+	require.NoError(t, err)
+	require.NoError(t, sqlDB.Close())
+	w := call(token)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Contains(t, w.Body.String(), "Unable to verify token")
+
+	// 伪造的令牌在查询吊销记录之前即被拒绝
+	w = call("not-a-token")
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Contains(t, w.Body.String(), "Invalid or expired token")
+}
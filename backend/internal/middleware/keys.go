@@ -0,0 +1,398 @@
+package middleware
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"log"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/spf13/viper"
+)
+
+const (
+	AlgorithmRS256 = "RS256"
+	AlgorithmEdDSA = "EdDSA"
+	AlgorithmHS256 = "HS256" // 旧版共享密钥签名，仅用于兼容
+
+	kidTimeFormat = "20060102T150405Z"
+	rsaKeyBits    = 2048
+)
+
+// SigningKey is a private key used to sign access tokens, identified by its kid
+type SigningKey struct {
+	KID       string
+	Algorithm string
+	CreatedAt time.Time
+	signer    crypto.Signer
+}
+
+// Public returns the public half of the signing key
+func (k *SigningKey) Public() crypto.PublicKey {
+	return k.signer.Public()
+}
+
+func (k *SigningKey) method() jwt.SigningMethod {
+	if k.Algorithm == AlgorithmRS256 {
+		return jwt.SigningMethodRS256
+	}
+	return jwt.SigningMethodEdDSA
+}
+
+// JWK is a single public key in JSON Web Key format
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+}
+
+// JWKSet is the document served at /.well-known/jwks.json
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWK returns the public key in JSON Web Key format
+func (k *SigningKey) JWK() JWK {
+	jwk := JWK{Kid: k.KID, Use: "sig", Alg: k.Algorithm}
+	switch pub := k.Public().(type) {
+	case ed25519.PublicKey:
+		jwk.Kty = "OKP"
+		jwk.Crv = "Ed25519"
+		jwk.X = base64.RawURLEncoding.EncodeToString(pub)
+	case *rsa.PublicKey:
+		jwk.Kty = "RSA"
+		jwk.N = base64.RawURLEncoding.EncodeToString(pub.N.Bytes())
+		jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+	}
+	return jwk
+}
+
+// KeySet holds every key accepted for verification; the signing key is chosen among them
+type KeySet struct {
+	mu              sync.RWMutex
+	dir             string
+	keys            []*SigningKey // 按创建时间升序排列
+	activationDelay time.Duration
+}
+
+var (
+	keySet     *KeySet
+	keySetOnce sync.Once
+	keySetErr  error
+)
+
+// InitKeys loads the signing keys from server.jwt_keys_dir and starts periodic reloading.
+// An empty key directory is seeded with a freshly generated key.
+func InitKeys() error {
+	keySetOnce.Do(func() {
+		keySet, keySetErr = newKeySet()
+	})
+	return keySetErr
+}
+
+func getKeySet() (*KeySet, error) {
+	if err := InitKeys(); err != nil {
+		return nil, err
+	}
+	return keySet, nil
+}
+
+func newKeySet() (*KeySet, error) {
+	if signingAlgorithm() == AlgorithmHS256 {
+		log.Println("JWT signing uses legacy HS256 shared secret")
+		return &KeySet{}, nil
+	}
+
+	dir := viper.GetString("server.jwt_keys_dir")
+	if dir == "" {
+		dir = "keys"
+	}
+
+	ks, err := LoadKeySet(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(ks.keys) == 0 {
+		log.Printf("No JWT signing keys found in %s, generating a new %s key", dir, signingAlgorithm())
+		if _, err := GenerateSigningKey(dir, signingAlgorithm()); err != nil {
+			return nil, err
+		}
+		if err := ks.Reload(); err != nil {
+			return nil, err
+		}
+	}
+
+	go func() {
+		ticker := time.NewTicker(keyReloadInterval())
+		defer ticker.Stop()
+		for range ticker.C {
+			if err := ks.Reload(); err != nil {
+				log.Printf("Error reloading JWT signing keys: %v", err)
+			}
+		}
+	}()
+
+	return ks, nil
+}
+
+// LoadKeySet reads the keys in dir once, using the configured activation delay.
+// Unlike InitKeys it does not reload them periodically.
+func LoadKeySet(dir string) (*KeySet, error) {
+	ks := &KeySet{dir: dir, activationDelay: keyActivationDelay()}
+	if err := ks.Reload(); err != nil {
+		return nil, err
+	}
+	return ks, nil
+}
+
+func keyReloadInterval() time.Duration {
+	interval := time.Duration(viper.GetInt("server.jwt_key_reload_interval")) * time.Second
+	if interval <= 0 {
+		return time.Minute
+	}
+	return interval
+}
+
+func keyActivationDelay() time.Duration {
+	// 新密钥在所有副本都加载之前只用于验证，不用于签名
+	if !viper.IsSet("server.jwt_key_activation_delay") {
+		return 2 * keyReloadInterval()
+	}
+	return time.Duration(viper.GetInt("server.jwt_key_activation_delay")) * time.Second
+}
+
+// Reload re-reads the key directory, keeping the previous keys if it fails
+func (ks *KeySet) Reload() error {
+	keys, err := LoadSigningKeys(ks.dir)
+	if err != nil {
+		return err
+	}
+
+	ks.mu.Lock()
+	ks.keys = keys
+	ks.mu.Unlock()
+	return nil
+}
+
+// Lookup returns the key with the given kid
+func (ks *KeySet) Lookup(kid string) *SigningKey {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+
+	for _, key := range ks.keys {
+		if key.KID == kid {
+			return key
+		}
+	}
+	return nil
+}
+
+// Keys returns the loaded keys, oldest first
+func (ks *KeySet) Keys() []*SigningKey {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+
+	return append([]*SigningKey(nil), ks.keys...)
+}
+
+// Current returns the newest key that has passed its activation delay.
+// If no key is old enough yet (e.g. on first start) the newest key is used.
+func (ks *KeySet) Current() *SigningKey {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+
+	if len(ks.keys) == 0 {
+		return nil
+	}
+
+	cutoff := time.Now().Add(-ks.activationDelay)
+	for i := len(ks.keys) - 1; i >= 0; i-- {
+		if !ks.keys[i].CreatedAt.After(cutoff) {
+			return ks.keys[i]
+		}
+	}
+	return ks.keys[len(ks.keys)-1]
+}
+
+// JWKS returns the public keys of every key accepted for verification
+func (ks *KeySet) JWKS() JWKSet {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+
+	set := JWKSet{Keys: make([]JWK, 0, len(ks.keys))}
+	for _, key := range ks.keys {
+		set.Keys = append(set.Keys, key.JWK())
+	}
+	return set
+}
+
+// PublicJWKS returns the JWKS document for the configured key set
+func PublicJWKS() (JWKSet, error) {
+	ks, err := getKeySet()
+	if err != nil {
+		return JWKSet{}, err
+	}
+	return ks.JWKS(), nil
+}
+
+func signingAlgorithm() string {
+	alg := viper.GetString("server.jwt_algorithm")
+	if alg == "" {
+		return AlgorithmEdDSA
+	}
+	return alg
+}
+
+// GenerateSigningKey creates a new key and writes it to dir as <kid>.pem
+func GenerateSigningKey(dir string, algorithm string) (*SigningKey, error) {
+	var signer crypto.Signer
+	switch algorithm {
+	case AlgorithmEdDSA:
+		_, priv, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate ed25519 key: %v", err)
+		}
+		signer = priv
+	case AlgorithmRS256:
+		priv, err := rsa.GenerateKey(rand.Reader, rsaKeyBits)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate rsa key: %v", err)
+		}
+		signer = priv
+	default:
+		return nil, fmt.Errorf("unsupported signing algorithm: %s", algorithm)
+	}
+
+	suffix := make([]byte, 4)
+	if _, err := rand.Read(suffix); err != nil {
+		return nil, err
+	}
+	now := time.Now().UTC()
+	kid := now.Format(kidTimeFormat) + "-" + hex.EncodeToString(suffix)
+
+	der, err := x509.MarshalPKCS8PrivateKey(signer)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode private key: %v", err)
+	}
+
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create key directory: %v", err)
+	}
+
+	data := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+	if err := os.WriteFile(filepath.Join(dir, kid+".pem"), data, 0600); err != nil {
+		return nil, fmt.Errorf("failed to write private key: %v", err)
+	}
+
+	return &SigningKey{KID: kid, Algorithm: algorithm, CreatedAt: now.Truncate(time.Second), signer: signer}, nil
+}
+
+// LoadSigningKeys reads every <kid>.pem key in dir, sorted oldest first
+func LoadSigningKeys(dir string) ([]*SigningKey, error) {
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read key directory: %v", err)
+	}
+
+	var keys []*SigningKey
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".pem" {
+			continue
+		}
+
+		key, err := loadSigningKey(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].CreatedAt.Equal(keys[j].CreatedAt) {
+			return keys[i].KID < keys[j].KID
+		}
+		return keys[i].CreatedAt.Before(keys[j].CreatedAt)
+	})
+	return keys, nil
+}
+
+func loadSigningKey(path string) (*SigningKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read key %s: %v", path, err)
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("invalid PEM in %s", path)
+	}
+
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse key %s: %v", path, err)
+	}
+
+	key := &SigningKey{KID: strings.TrimSuffix(filepath.Base(path), ".pem")}
+	switch priv := parsed.(type) {
+	case ed25519.PrivateKey:
+		key.Algorithm = AlgorithmEdDSA
+		key.signer = priv
+	case *rsa.PrivateKey:
+		key.Algorithm = AlgorithmRS256
+		key.signer = priv
+	default:
+		return nil, fmt.Errorf("unsupported key type in %s", path)
+	}
+
+	// kid 以创建时间开头；无法解析时退回到文件修改时间
+	if created, err := time.Parse(kidTimeFormat, strings.SplitN(key.KID, "-", 2)[0]); err == nil {
+		key.CreatedAt = created
+	} else if info, err := os.Stat(path); err == nil {
+		key.CreatedAt = info.ModTime().UTC()
+	}
+
+	return key, nil
+}
+
+// PruneSigningKeys removes all but the newest keep keys and returns the removed kids
+func PruneSigningKeys(dir string, keep int) ([]string, error) {
+	if keep < 1 {
+		return nil, fmt.Errorf("must keep at least one key")
+	}
+
+	keys, err := LoadSigningKeys(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var removed []string
+	for i := 0; i < len(keys)-keep; i++ {
+		if err := os.Remove(filepath.Join(dir, keys[i].KID+".pem")); err != nil {
+			return removed, fmt.Errorf("failed to remove key %s: %v", keys[i].KID, err)
+		}
+		removed = append(removed, keys[i].KID)
+	}
+	return removed, nil
+}
@@ -0,0 +1,93 @@
+package middleware
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKeySetRotation(t *testing.T) {
+	dir := t.TempDir()
+
+	oldKey, err := GenerateSigningKey(dir, AlgorithmEdDSA)
+	assert.NoError(t, err)
+
+	ks := &KeySet{dir: dir, activationDelay: time.Hour}
+	assert.NoError(t, ks.Reload())
+	assert.Equal(t, oldKey.KID, ks.Current().KID)
+
+	// 新密钥立即发布到 JWKS，但在激活延迟之前仍使用旧密钥签名
+	time.Sleep(time.Second)
+	newKey, err := GenerateSigningKey(dir, AlgorithmRS256)
+	assert.NoError(t, err)
+	assert.NoError(t, ks.Reload())
+
+	ks.keys[0].CreatedAt = time.Now().Add(-2 * time.Hour)
+	assert.Equal(t, oldKey.KID, ks.Current().KID)
+	assert.NotNil(t, ks.Lookup(newKey.KID))
+
+	jwks := ks.JWKS()
+	assert.Len(t, jwks.Keys, 2)
+	assert.Equal(t, "OKP", jwks.Keys[0].Kty)
+	assert.Equal(t, "RSA", jwks.Keys[1].Kty)
+
+	// 激活延迟过后切换到新密钥，旧密钥仍可用于验证
+	ks.activationDelay = 0
+	assert.Equal(t, newKey.KID, ks.Current().KID)
+	assert.NotNil(t, ks.Lookup(oldKey.KID))
+
+	removed, err := PruneSigningKeys(dir, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{oldKey.KID}, removed)
+	assert.NoError(t, ks.Reload())
+	assert.Nil(t, ks.Lookup(oldKey.KID))
+}
+
+func TestLoadKeySetUsesActivationDelay(t *testing.T) {
+	dir := t.TempDir()
+
+	// 早已激活的旧密钥
+	key, err := GenerateSigningKey(dir, AlgorithmEdDSA)
+	assert.NoError(t, err)
+	oldKID := "20200101T000000Z-00000000"
+	assert.NoError(t, os.Rename(filepath.Join(dir, key.KID+".pem"), filepath.Join(dir, oldKID+".pem")))
+
+	newKey, err := GenerateSigningKey(dir, AlgorithmEdDSA)
+	assert.NoError(t, err)
+
+	// 默认激活延迟内最新的密钥尚未用于签名
+	ks, err := LoadKeySet(dir)
+	assert.NoError(t, err)
+	keys := ks.Keys()
+	assert.Len(t, keys, 2)
+	assert.Equal(t, newKey.KID, keys[1].KID)
+	assert.Equal(t, oldKID, ks.Current().KID)
+}
+
+func TestSignAndParseToken(t *testing.T) {
+	keySetOnce.Do(func() {})
+	keySet = &KeySet{dir: t.TempDir()}
+	_, err := GenerateSigningKey(keySet.dir, AlgorithmEdDSA)
+	assert.NoError(t, err)
+	assert.NoError(t, keySet.Reload())
+
+	token, err := GenerateToken("user-1")
+	assert.NoError(t, err)
+
+	claims, err := parseToken(token)
+	assert.NoError(t, err)
+	assert.Equal(t, "user-1", claims.UserID)
+	assert.Empty(t, claims.Purpose)
+
+	challenge, err := GenerateChallengeToken("user-1")
+	assert.NoError(t, err)
+	claims, err = ParseChallengeToken(challenge)
+	assert.NoError(t, err)
+	assert.Equal(t, PurposeTwoFactor, claims.Purpose)
+
+	_, err = ParseChallengeToken(token)
+	assert.Error(t, err)
+}
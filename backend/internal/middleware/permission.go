@@ -0,0 +1,100 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/samzong/share-ai-platform/internal/authz"
+	"github.com/samzong/share-ai-platform/internal/models"
+)
+
+// ScopeFunc resolves the authorization scope of a request
+type ScopeFunc func(c *gin.Context) authz.Scope
+
+// GlobalScope checks permissions in the global scope
+func GlobalScope() ScopeFunc {
+	return func(c *gin.Context) authz.Scope {
+		return authz.Global()
+	}
+}
+
+// OrgScope checks permissions in the organization named by a route parameter
+func OrgScope(param string) ScopeFunc {
+	return func(c *gin.Context) authz.Scope {
+		return authz.Org(ResolveOrgID(c.Param(param)))
+	}
+}
+
+// ProjectScope checks permissions in the project named by a route parameter.
+// Roles held in the project's org apply as well.
+func ProjectScope(param string) ScopeFunc {
+	return func(c *gin.Context) authz.Scope {
+		return authz.Project(c.Param(param))
+	}
+}
+
+// ResolveOrgID maps the "public" alias to the reserved public org ID
+func ResolveOrgID(orgID string) string {
+	if orgID == "" || orgID == "public" {
+		return models.PublicOrgID
+	}
+	return orgID
+}
+
+// RequirePermission rejects the request unless the user holds the permission in the scope.
+// Without a ScopeFunc the global scope is used. It must run after AuthMiddleware.
+func RequirePermission(perm models.Permission, scope ...ScopeFunc) gin.HandlerFunc {
+	resolve := GlobalScope()
+	if len(scope) > 0 {
+		resolve = scope[0]
+	}
+
+	return RequireAnyPermission(resolve, perm)
+}
+
+// RequireAnyPermission rejects the request unless the user holds at least one of
+// the permissions in the scope
+func RequireAnyPermission(scope ScopeFunc, perms ...models.Permission) gin.HandlerFunc {
+	names := make([]string, len(perms))
+	for i, perm := range perms {
+		names[i] = string(perm)
+	}
+	required := strings.Join(names, " or ")
+
+	return func(c *gin.Context) {
+		userID := GetUserID(c)
+		resolved := scope(c)
+
+		for _, perm := range perms {
+			ok, err := authz.Can(userID, perm, resolved)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check permissions"})
+				c.Abort()
+				return
+			}
+			if ok {
+				c.Next()
+				return
+			}
+		}
+
+		c.JSON(http.StatusForbidden, gin.H{"error": "Permission denied: requires " + required})
+		c.Abort()
+	}
+}
+
+// RequireSelfOrPermission lets users act on themselves (the :param route parameter
+// equals their ID) and otherwise requires the permission in the global scope
+func RequireSelfOrPermission(param string, perm models.Permission) gin.HandlerFunc {
+	check := RequirePermission(perm)
+
+	return func(c *gin.Context) {
+		if c.Param(param) == GetUserID(c) {
+			c.Next()
+			return
+		}
+		check(c)
+	}
+}
@@ -0,0 +1,49 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/samzong/share-ai-platform/internal/database"
+	"github.com/samzong/share-ai-platform/internal/models"
+)
+
+// RequireOrgTwoFactor enforces the org policy that maintainers must have 2FA enabled.
+// It must run after AuthMiddleware on routes with an :org_id parameter.
+func RequireOrgTwoFactor() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		orgID := c.Param("org_id")
+		if orgID == "" || orgID == "public" {
+			c.Next()
+			return
+		}
+
+		if enabled, _ := c.Get("user_totp_enabled"); enabled == true {
+			c.Next()
+			return
+		}
+
+		db := database.GetDB()
+
+		var org models.Organization
+		if err := db.First(&org, "id = ?", orgID).Error; err != nil || !org.Require2FA {
+			c.Next()
+			return
+		}
+
+		var member models.OrgMember
+		if err := db.First(&member, "org_id = ? AND user_id = ?", orgID, GetUserID(c)).Error; err != nil {
+			c.Next()
+			return
+		}
+
+		if member.IsMaintainer() {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Organization requires two-factor authentication for maintainers"})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,76 @@
+package migrations
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadOrdersByVersion(t *testing.T) {
+	fsys := fstest.MapFS{
+		"0002_add_index.up.sql":   {Data: []byte("CREATE INDEX a ON t (c);")},
+		"0002_add_index.down.sql": {Data: []byte("DROP INDEX a;")},
+		"0001_init.up.sql":        {Data: []byte("CREATE TABLE t (c int);")},
+		"0001_init.down.sql":      {Data: []byte("DROP TABLE t;")},
+		"README.md":               {Data: []byte("ignored")},
+	}
+
+	migrations, err := Load(fsys)
+	require.NoError(t, err)
+	require.Len(t, migrations, 2)
+	assert.Equal(t, int64(1), migrations[0].Version)
+	assert.Equal(t, "init", migrations[0].Name)
+	assert.Equal(t, "CREATE TABLE t (c int);", migrations[0].Up)
+	assert.Equal(t, "DROP TABLE t;", migrations[0].Down)
+	assert.Equal(t, int64(2), migrations[1].Version)
+}
+
+func TestLoadRejectsInvalidSets(t *testing.T) {
+	tests := []struct {
+		name string
+		fsys fstest.MapFS
+	}{
+		{
+			name: "missing down",
+			fsys: fstest.MapFS{"0001_init.up.sql": {Data: []byte("SELECT 1;")}},
+		},
+		{
+			name: "bad file name",
+			fsys: fstest.MapFS{"init.sql": {Data: []byte("SELECT 1;")}},
+		},
+		{
+			name: "conflicting names",
+			fsys: fstest.MapFS{
+				"0001_init.up.sql":    {Data: []byte("SELECT 1;")},
+				"0001_other.down.sql": {Data: []byte("SELECT 1;")},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Load(tt.fsys)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestEmbeddedMigrations(t *testing.T) {
+	postgres, err := Embedded(Postgres)
+	require.NoError(t, err)
+	sqlite, err := Embedded(SQLite)
+	require.NoError(t, err)
+
+	// 每个版本在所有数据库上都必须存在
+	require.Equal(t, len(postgres), len(sqlite))
+	for i := range postgres {
+		assert.Equal(t, postgres[i].Version, sqlite[i].Version)
+		assert.Equal(t, postgres[i].Name, sqlite[i].Name)
+	}
+	assert.Equal(t, "baseline", postgres[0].Name)
+
+	_, err = Embedded("mysql")
+	assert.Error(t, err)
+}
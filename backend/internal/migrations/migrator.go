@@ -0,0 +1,225 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"regexp"
+	"time"
+)
+
+var placeholderRegex = regexp.MustCompile(`\$\d+`)
+
+// Dialect selects the SQL flavour of the migrations and of the bookkeeping queries
+type Dialect string
+
+const (
+	Postgres Dialect = "postgres"
+	SQLite   Dialect = "sqlite"
+)
+
+// lockID 是迁移使用的 Postgres advisory lock 键，多个副本同时启动时只有一个执行迁移
+const lockID int64 = 0x5348415245414931 // "SHAREAI1"
+
+// Status reports whether a migration has been applied
+type Status struct {
+	Version   int64      `json:"version"`
+	Name      string     `json:"name"`
+	AppliedAt *time.Time `json:"applied_at,omitempty"` // 为空表示尚未执行
+}
+
+// Migrator applies migrations to a database. On Postgres all changes happen while
+// holding an advisory lock; SQLite databases are local to one process and need none.
+// Every migration runs in its own transaction together with the update of schema_migrations.
+type Migrator struct {
+	db         *sql.DB
+	dialect    Dialect
+	migrations []Migration
+}
+
+// New returns a Migrator for the given migrations
+func New(db *sql.DB, dialect Dialect, migrations []Migration) *Migrator {
+	return &Migrator{db: db, dialect: dialect, migrations: migrations}
+}
+
+// Status lists every known migration and when it was applied
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	var statuses []Status
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		applied, err := appliedVersions(ctx, conn)
+		if err != nil {
+			return err
+		}
+		for _, mig := range m.migrations {
+			status := Status{Version: mig.Version, Name: mig.Name}
+			if at, ok := applied[mig.Version]; ok {
+				status.AppliedAt = &at
+			}
+			statuses = append(statuses, status)
+		}
+		return nil
+	})
+	return statuses, err
+}
+
+// Up applies up to steps pending migrations in version order, all of them when steps <= 0
+func (m *Migrator) Up(ctx context.Context, steps int) ([]Migration, error) {
+	var done []Migration
+	err := m.withLock(ctx, func(conn *sql.Conn) (err error) {
+		done, err = m.up(ctx, conn, m.migrations, steps)
+		return err
+	})
+	return done, err
+}
+
+// Down reverts the last steps applied migrations, newest first. At least one migration is reverted.
+func (m *Migrator) Down(ctx context.Context, steps int) ([]Migration, error) {
+	if steps <= 0 {
+		steps = 1
+	}
+
+	var done []Migration
+	err := m.withLock(ctx, func(conn *sql.Conn) (err error) {
+		done, err = m.down(ctx, conn, steps)
+		return err
+	})
+	return done, err
+}
+
+// Redo reverts and reapplies the newest applied migration
+func (m *Migrator) Redo(ctx context.Context) (*Migration, error) {
+	var redone *Migration
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		reverted, err := m.down(ctx, conn, 1)
+		if err != nil {
+			return err
+		}
+		if len(reverted) == 0 {
+			return fmt.Errorf("no applied migration to redo")
+		}
+
+		// 只重新执行刚回滚的版本，不顺带执行其它待执行的迁移
+		if _, err := m.up(ctx, conn, reverted, 1); err != nil {
+			return err
+		}
+		redone = &reverted[0]
+		return nil
+	})
+	return redone, err
+}
+
+func (m *Migrator) up(ctx context.Context, conn *sql.Conn, migrations []Migration, steps int) ([]Migration, error) {
+	applied, err := appliedVersions(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+
+	var done []Migration
+	for _, mig := range migrations {
+		if steps > 0 && len(done) >= steps {
+			break
+		}
+		if _, ok := applied[mig.Version]; ok {
+			continue
+		}
+		if err := apply(ctx, conn, mig.Up, m.bind("INSERT INTO schema_migrations (version, name, applied_at) VALUES ($1, $2, $3)"),
+			mig.Version, mig.Name, time.Now().UTC()); err != nil {
+			return done, fmt.Errorf("migration %d_%s failed: %v", mig.Version, mig.Name, err)
+		}
+		done = append(done, mig)
+	}
+	return done, nil
+}
+
+func (m *Migrator) down(ctx context.Context, conn *sql.Conn, steps int) ([]Migration, error) {
+	applied, err := appliedVersions(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+
+	var done []Migration
+	for i := len(m.migrations) - 1; i >= 0 && len(done) < steps; i-- {
+		mig := m.migrations[i]
+		if _, ok := applied[mig.Version]; !ok {
+			continue
+		}
+		if err := apply(ctx, conn, mig.Down, m.bind("DELETE FROM schema_migrations WHERE version = $1"), mig.Version); err != nil {
+			return done, fmt.Errorf("reverting migration %d_%s failed: %v", mig.Version, mig.Name, err)
+		}
+		done = append(done, mig)
+	}
+	return done, nil
+}
+
+// withLock runs fn on a single connection holding the migration advisory lock.
+// Session-level advisory locks belong to a connection, so everything must use conn.
+func (m *Migrator) withLock(ctx context.Context, fn func(conn *sql.Conn) error) error {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	appliedAtType := "timestamptz"
+	if m.dialect == Postgres {
+		if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", lockID); err != nil {
+			return fmt.Errorf("failed to acquire migration lock: %v", err)
+		}
+		defer conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", lockID)
+	} else {
+		appliedAtType = "datetime"
+	}
+
+	if _, err := conn.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version    bigint PRIMARY KEY,
+		name       varchar(255) NOT NULL,
+		applied_at `+appliedAtType+` NOT NULL
+	)`); err != nil {
+		return fmt.Errorf("failed to create schema_migrations: %v", err)
+	}
+	return fn(conn)
+}
+
+// bind rewrites $n placeholders for drivers that only accept ?
+func (m *Migrator) bind(query string) string {
+	if m.dialect == Postgres {
+		return query
+	}
+	return placeholderRegex.ReplaceAllString(query, "?")
+}
+
+func appliedVersions(ctx context.Context, conn *sql.Conn) (map[int64]time.Time, error) {
+	rows, err := conn.QueryContext(ctx, "SELECT version, applied_at FROM schema_migrations")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applied := make(map[int64]time.Time)
+	for rows.Next() {
+		var version int64
+		var at time.Time
+		if err := rows.Scan(&version, &at); err != nil {
+			return nil, err
+		}
+		applied[version] = at
+	}
+	return applied, rows.Err()
+}
+
+// apply runs a migration script and the matching schema_migrations change in one transaction
+func apply(ctx context.Context, conn *sql.Conn, script string, record string, args ...interface{}) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, script); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, record, args...); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
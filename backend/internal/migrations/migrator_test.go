@@ -0,0 +1,165 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	_ "github.com/glebarez/go-sqlite"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMigratorOnSQLite(t *testing.T) {
+	db, err := sql.Open("sqlite", ":memory:")
+	require.NoError(t, err)
+	db.SetMaxOpenConns(1)
+	defer db.Close()
+
+	all, err := Embedded(SQLite)
+	require.NoError(t, err)
+	m := New(db, SQLite, all)
+	ctx := context.Background()
+
+	done, err := m.Up(ctx, 0)
+	require.NoError(t, err)
+	assert.Len(t, done, len(all))
+
+	statuses, err := m.Status(ctx)
+	require.NoError(t, err)
+	for _, s := range statuses {
+		assert.NotNil(t, s.AppliedAt, "migration %d should be applied", s.Version)
+	}
+	_, err = db.Exec(`INSERT INTO "groups" (id, org_id, name) VALUES ('g1', 'o1', 'ops')`)
+	require.NoError(t, err)
+
+	redone, err := m.Redo(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, all[len(all)-1].Version, redone.Version)
+
+	done, err = m.Down(ctx, len(all))
+	require.NoError(t, err)
+	assert.Len(t, done, len(all))
+
+	var count int
+	require.NoError(t, db.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = 'images'").Scan(&count))
+	assert.Zero(t, count)
+}
+
+func TestListsMigrationMovesCollectionsOnSQLite(t *testing.T) {
+	db, err := sql.Open("sqlite", ":memory:")
+	require.NoError(t, err)
+	db.SetMaxOpenConns(1)
+	defer db.Close()
+
+	all, err := Embedded(SQLite)
+	require.NoError(t, err)
+	m := New(db, SQLite, all)
+	ctx := context.Background()
+
+	_, err = m.Up(ctx, 2)
+	require.NoError(t, err)
+	_, err = db.Exec(`INSERT INTO collections (user_id, image_id, created_at) VALUES
+		('u1', 'i2', '2024-01-02'), ('u1', 'i1', '2024-01-01'), ('u2', 'i1', '2024-01-03')`)
+	require.NoError(t, err)
+
+	_, err = m.Up(ctx, 1)
+	require.NoError(t, err)
+
+	var lists int
+	require.NoError(t, db.QueryRow("SELECT COUNT(*) FROM lists WHERE is_default AND name = 'Favorites'").Scan(&lists))
+	assert.Equal(t, 2, lists)
+
+	rows, err := db.Query(`SELECT list_items.image_id FROM list_items
+		JOIN lists ON lists.id = list_items.list_id WHERE lists.owner_id = 'u1' ORDER BY list_items.position`)
+	require.NoError(t, err)
+	defer rows.Close()
+	var order []string
+	for rows.Next() {
+		var id string
+		require.NoError(t, rows.Scan(&id))
+		order = append(order, id)
+	}
+	assert.Equal(t, []string{"i1", "i2"}, order)
+}
+
+func TestLabelGovernanceMigrationMergesDuplicatesOnSQLite(t *testing.T) {
+	db, err := sql.Open("sqlite", ":memory:")
+	require.NoError(t, err)
+	db.SetMaxOpenConns(1)
+	defer db.Close()
+
+	all, err := Embedded(SQLite)
+	require.NoError(t, err)
+	m := New(db, SQLite, all)
+	ctx := context.Background()
+
+	_, err = m.Up(ctx, 4)
+	require.NoError(t, err)
+	_, err = db.Exec(`INSERT INTO labels (id, name, created_at) VALUES
+		('l1', 'LLM', '2024-01-01'), ('l2', 'llm ', '2024-01-02'), ('l3', 'gpu', '2024-01-03')`)
+	require.NoError(t, err)
+	_, err = db.Exec(`INSERT INTO images (id, org_id, name, author, registry, namespace, repository, tag, digest, platform)
+		VALUES ('i1', 'o', 'a', 'u', 'r', 'n', 'a', 't', 'd', 'p'), ('i2', 'o', 'b', 'u', 'r', 'n', 'b', 't', 'd', 'p')`)
+	require.NoError(t, err)
+	_, err = db.Exec(`INSERT INTO image_labels (image_id, label_id) VALUES ('i1', 'l1'), ('i1', 'l2'), ('i2', 'l2')`)
+	require.NoError(t, err)
+
+	_, err = m.Up(ctx, 1)
+	require.NoError(t, err)
+
+	var labels int
+	require.NoError(t, db.QueryRow("SELECT COUNT(*) FROM labels WHERE normalized = 'llm'").Scan(&labels))
+	assert.Equal(t, 1, labels)
+	var images int
+	require.NoError(t, db.QueryRow("SELECT COUNT(*) FROM image_labels WHERE label_id = 'l1'").Scan(&images))
+	assert.Equal(t, 2, images)
+}
+
+func TestMigratorAdoptsReleasedSchemaOnSQLite(t *testing.T) {
+	db, err := sql.Open("sqlite", ":memory:")
+	require.NoError(t, err)
+	db.SetMaxOpenConns(1)
+	defer db.Close()
+
+	// 引入版本化迁移前的发布版本由 AutoMigrate 建表，只有这几张表
+	_, err = db.Exec(`
+		CREATE TABLE users (id text PRIMARY KEY, username text NOT NULL, email text NOT NULL, password text NOT NULL,
+			nickname text, avatar text, role text NOT NULL DEFAULT 'user',
+			created_at datetime NOT NULL DEFAULT CURRENT_TIMESTAMP, updated_at datetime NOT NULL DEFAULT CURRENT_TIMESTAMP);
+		CREATE UNIQUE INDEX idx_users_username ON users (username);
+		CREATE UNIQUE INDEX idx_users_email ON users (email);
+		CREATE TABLE images (id text PRIMARY KEY, org_id text NOT NULL, name text NOT NULL, description text,
+			author text NOT NULL, registry text NOT NULL, namespace text NOT NULL, repository text NOT NULL,
+			tag text NOT NULL, digest text NOT NULL, size integer DEFAULT 0, readme_path text, stars integer DEFAULT 0,
+			visibility text NOT NULL DEFAULT 'public', platform text NOT NULL, created_at datetime, updated_at datetime);
+		CREATE TABLE labels (id text PRIMARY KEY, name text NOT NULL, created_at datetime, updated_at datetime);
+		CREATE UNIQUE INDEX idx_labels_name ON labels (name);
+		CREATE TABLE image_labels (image_id text, label_id text, PRIMARY KEY (image_id, label_id));
+		CREATE TABLE collections (user_id text NOT NULL, image_id text NOT NULL, created_at datetime, updated_at datetime);
+		INSERT INTO users (id, username, email, password) VALUES ('u1', 'alice', 'alice@example.com', 'hash');
+		INSERT INTO images (id, org_id, name, author, registry, namespace, repository, tag, digest, platform)
+			VALUES ('i1', 'o', 'a', 'u1', 'r', 'n', 'a', 't', 'd', 'p');`)
+	require.NoError(t, err)
+
+	all, err := Embedded(SQLite)
+	require.NoError(t, err)
+	done, err := New(db, SQLite, all).Up(context.Background(), 0)
+	require.NoError(t, err)
+	assert.Len(t, done, len(all))
+
+	// 发布之后新增的列在已有表上补齐，已有数据保留
+	var totpEnabled bool
+	require.NoError(t, db.QueryRow("SELECT totp_enabled FROM users WHERE id = 'u1'").Scan(&totpEnabled))
+	assert.False(t, totpEnabled)
+	var projectID, readmeBase sql.NullString
+	require.NoError(t, db.QueryRow("SELECT project_id, readme_base_url FROM images WHERE id = 'i1'").Scan(&projectID, &readmeBase))
+	assert.False(t, projectID.Valid)
+	assert.False(t, readmeBase.Valid)
+
+	for _, table := range []string{"organizations", "projects", "access_requests", "role_bindings", "audit_logs", "stored_objects"} {
+		var count int
+		require.NoError(t, db.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = ?", table).Scan(&count))
+		assert.Equal(t, 1, count, "table %s should exist", table)
+	}
+}
@@ -0,0 +1,58 @@
+-- 初始表结构，与引入版本化迁移前最后一个发布版本中 AutoMigrate 生成的结构一致。
+-- 使用 IF NOT EXISTS，由该版本 AutoMigrate 建好的数据库可以直接标记为已迁移；
+-- 之后新增的表和列由后续迁移添加。
+
+CREATE TABLE IF NOT EXISTS users (
+    id         uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    username   varchar(50)  NOT NULL,
+    email      varchar(100) NOT NULL,
+    password   varchar(100) NOT NULL,
+    nickname   varchar(50),
+    avatar     varchar(255),
+    role       varchar(20)  NOT NULL DEFAULT 'user',
+    created_at timestamptz  NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at timestamptz  NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_username ON users (username);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_email ON users (email);
+
+CREATE TABLE IF NOT EXISTS images (
+    id          uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    org_id      uuid        NOT NULL,
+    name        text        NOT NULL,
+    description text,
+    author      uuid        NOT NULL,
+    registry    text        NOT NULL,
+    namespace   text        NOT NULL,
+    repository  text        NOT NULL,
+    tag         text        NOT NULL,
+    digest      text        NOT NULL,
+    size        bigint      DEFAULT 0,
+    readme_path text,
+    stars       bigint      DEFAULT 0,
+    visibility  varchar(10) NOT NULL DEFAULT 'public',
+    platform    text        NOT NULL,
+    created_at  timestamptz,
+    updated_at  timestamptz
+);
+
+CREATE TABLE IF NOT EXISTS labels (
+    id         uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    name       text NOT NULL,
+    created_at timestamptz,
+    updated_at timestamptz
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_labels_name ON labels (name);
+
+CREATE TABLE IF NOT EXISTS image_labels (
+    image_id uuid REFERENCES images (id) ON DELETE CASCADE,
+    label_id uuid REFERENCES labels (id) ON DELETE CASCADE,
+    PRIMARY KEY (image_id, label_id)
+);
+
+CREATE TABLE IF NOT EXISTS collections (
+    user_id    uuid NOT NULL,
+    image_id   uuid NOT NULL,
+    created_at timestamptz,
+    updated_at timestamptz
+);
@@ -0,0 +1 @@
+ALTER TABLE collections DROP CONSTRAINT IF EXISTS collections_pkey;
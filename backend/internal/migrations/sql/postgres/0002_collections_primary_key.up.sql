@@ -0,0 +1,9 @@
+-- 收藏表原先没有主键，并发收藏可能写入重复行。先删除重复行，每个用户和镜像只保留一条，
+-- 再以 (user_id, image_id) 作为主键。images.stars 的偏差由收藏数校准任务修正。
+DELETE FROM collections a
+USING collections b
+WHERE a.user_id = b.user_id
+  AND a.image_id = b.image_id
+  AND a.ctid > b.ctid;
+
+ALTER TABLE collections ADD CONSTRAINT collections_pkey PRIMARY KEY (user_id, image_id);
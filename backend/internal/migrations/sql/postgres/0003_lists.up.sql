@@ -0,0 +1,46 @@
+CREATE TABLE IF NOT EXISTS lists (
+    id          uuid         PRIMARY KEY DEFAULT gen_random_uuid(),
+    owner_id    uuid         NOT NULL,
+    name        varchar(100) NOT NULL,
+    description text,
+    visibility  varchar(20)  NOT NULL DEFAULT 'private',
+    org_id      uuid,
+    is_default  boolean      NOT NULL DEFAULT false,
+    created_at  timestamptz,
+    updated_at  timestamptz
+);
+CREATE INDEX IF NOT EXISTS idx_lists_owner_id ON lists (owner_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_lists_owner_name ON lists (owner_id, name);
+-- 每个用户最多一个默认列表
+CREATE UNIQUE INDEX IF NOT EXISTS idx_lists_owner_default ON lists (owner_id) WHERE is_default;
+
+CREATE TABLE IF NOT EXISTS list_items (
+    list_id    uuid    NOT NULL REFERENCES lists (id) ON DELETE CASCADE,
+    image_id   uuid    NOT NULL,
+    position   integer NOT NULL DEFAULT 0,
+    note       text,
+    created_at timestamptz,
+    updated_at timestamptz,
+    PRIMARY KEY (list_id, image_id)
+);
+CREATE INDEX IF NOT EXISTS idx_list_items_image_id ON list_items (image_id);
+
+CREATE TABLE IF NOT EXISTS list_follows (
+    list_id    uuid NOT NULL REFERENCES lists (id) ON DELETE CASCADE,
+    user_id    uuid NOT NULL,
+    created_at timestamptz,
+    PRIMARY KEY (list_id, user_id)
+);
+CREATE INDEX IF NOT EXISTS idx_list_follows_user_id ON list_follows (user_id);
+
+-- 已有的收藏迁移到每个用户的默认列表，按收藏时间排序
+INSERT INTO lists (owner_id, name, visibility, is_default, created_at, updated_at)
+SELECT DISTINCT user_id, 'Favorites', 'private', true, now(), now()
+FROM collections;
+
+INSERT INTO list_items (list_id, image_id, position, created_at, updated_at)
+SELECT lists.id, collections.image_id,
+       ROW_NUMBER() OVER (PARTITION BY collections.user_id ORDER BY collections.created_at, collections.image_id) - 1,
+       collections.created_at, collections.created_at
+FROM collections
+JOIN lists ON lists.owner_id = collections.user_id AND lists.is_default;
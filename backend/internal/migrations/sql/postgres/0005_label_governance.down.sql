@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS label_aliases;
+DROP INDEX IF EXISTS idx_labels_normalized;
+ALTER TABLE labels DROP COLUMN IF EXISTS normalized;
@@ -0,0 +1,32 @@
+-- 标签按不区分大小写的规范化名称去重
+ALTER TABLE labels ADD COLUMN IF NOT EXISTS normalized text;
+UPDATE labels SET name = btrim(regexp_replace(name, '\s+', ' ', 'g'));
+UPDATE labels SET normalized = lower(name);
+
+-- 规范化后重名的标签合并到最早创建的那个
+CREATE TEMPORARY TABLE label_merges AS
+SELECT labels.id AS old_id,
+       (SELECT keep.id FROM labels keep
+        WHERE keep.normalized = labels.normalized
+        ORDER BY keep.created_at, keep.id LIMIT 1) AS new_id
+FROM labels;
+DELETE FROM label_merges WHERE old_id = new_id;
+
+INSERT INTO image_labels (image_id, label_id)
+SELECT image_labels.image_id, label_merges.new_id
+FROM image_labels
+JOIN label_merges ON label_merges.old_id = image_labels.label_id
+ON CONFLICT DO NOTHING;
+DELETE FROM image_labels WHERE label_id IN (SELECT old_id FROM label_merges);
+DELETE FROM labels WHERE id IN (SELECT old_id FROM label_merges);
+DROP TABLE label_merges;
+
+ALTER TABLE labels ALTER COLUMN normalized SET NOT NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_labels_normalized ON labels (normalized);
+
+CREATE TABLE IF NOT EXISTS label_aliases (
+    alias      text PRIMARY KEY,
+    label_id   uuid NOT NULL REFERENCES labels (id) ON DELETE CASCADE,
+    created_at timestamptz
+);
+CREATE INDEX IF NOT EXISTS idx_label_aliases_label_id ON label_aliases (label_id);
@@ -0,0 +1,4 @@
+ALTER TABLE image_daily_stats DROP COLUMN IF EXISTS visitors;
+ALTER TABLE image_daily_stats DROP COLUMN IF EXISTS usage_copies;
+ALTER TABLE image_daily_stats DROP COLUMN IF EXISTS readme_views;
+DROP TABLE IF EXISTS image_events;
@@ -0,0 +1,16 @@
+-- 镜像的浏览、README 浏览、复制使用命令和部署事件，定期汇总到 image_daily_stats。
+-- 不引用 images，这样批量写入不会因为其中某个镜像已被删除而整批失败，汇总时忽略已删除镜像的事件
+CREATE TABLE IF NOT EXISTS image_events (
+    id           uuid        PRIMARY KEY,
+    image_id     uuid        NOT NULL,
+    type         varchar(32) NOT NULL,
+    user_id      uuid,
+    org_id       uuid,
+    anonymous_id varchar(64),
+    created_at   timestamptz NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_image_events_created_at ON image_events (created_at);
+
+ALTER TABLE image_daily_stats ADD COLUMN IF NOT EXISTS readme_views bigint NOT NULL DEFAULT 0;
+ALTER TABLE image_daily_stats ADD COLUMN IF NOT EXISTS usage_copies bigint NOT NULL DEFAULT 0;
+ALTER TABLE image_daily_stats ADD COLUMN IF NOT EXISTS visitors bigint NOT NULL DEFAULT 0;
@@ -0,0 +1,7 @@
+-- 离线计算的相似镜像，每个镜像只保留得分最高的若干个，详情页和个性化推荐直接读取
+CREATE TABLE IF NOT EXISTS image_similarities (
+    image_id   uuid             NOT NULL REFERENCES images (id) ON DELETE CASCADE,
+    similar_id uuid             NOT NULL REFERENCES images (id) ON DELETE CASCADE,
+    score      double precision NOT NULL,
+    PRIMARY KEY (image_id, similar_id)
+);
@@ -0,0 +1,9 @@
+-- 令牌吊销状态和两步验证挑战的尝试次数，只保存令牌摘要
+CREATE TABLE IF NOT EXISTS token_states (
+    token_hash varchar(64) PRIMARY KEY,
+    user_id    uuid        NOT NULL,
+    revoked    boolean     NOT NULL DEFAULT false,
+    attempts   integer     NOT NULL DEFAULT 0,
+    expires_at timestamptz NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_token_states_expires_at ON token_states (expires_at);
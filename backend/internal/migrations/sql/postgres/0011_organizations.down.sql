@@ -0,0 +1,12 @@
+-- visibility 保持 varchar(20)，缩短会使 require_access 的镜像无法回滚
+DROP TABLE IF EXISTS role_bindings;
+DROP TABLE IF EXISTS roles;
+DROP TABLE IF EXISTS access_requests;
+DROP INDEX IF EXISTS idx_images_project_id;
+ALTER TABLE images DROP COLUMN IF EXISTS project_id;
+DROP TABLE IF EXISTS group_members;
+DROP TABLE IF EXISTS groups;
+DROP TABLE IF EXISTS project_members;
+DROP TABLE IF EXISTS projects;
+DROP TABLE IF EXISTS org_members;
+DROP TABLE IF EXISTS organizations;
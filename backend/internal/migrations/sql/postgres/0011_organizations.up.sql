@@ -0,0 +1,104 @@
+-- 组织、项目、用户组、角色绑定和镜像访问申请
+CREATE TABLE IF NOT EXISTS organizations (
+    id          uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    name        varchar(50) NOT NULL,
+    description text,
+    require_2fa boolean     NOT NULL DEFAULT false,
+    created_by  uuid,
+    created_at  timestamptz,
+    updated_at  timestamptz
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_organizations_name ON organizations (name);
+
+CREATE TABLE IF NOT EXISTS org_members (
+    org_id     uuid,
+    user_id    uuid,
+    role       varchar(20) NOT NULL DEFAULT 'member',
+    created_at timestamptz,
+    updated_at timestamptz,
+    PRIMARY KEY (org_id, user_id)
+);
+
+CREATE TABLE IF NOT EXISTS projects (
+    id          uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    org_id      uuid        NOT NULL,
+    name        varchar(50) NOT NULL,
+    description text,
+    visibility  varchar(20) NOT NULL DEFAULT 'public',
+    created_by  uuid,
+    created_at  timestamptz,
+    updated_at  timestamptz
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_projects_org_name ON projects (org_id, name);
+
+CREATE TABLE IF NOT EXISTS project_members (
+    project_id uuid,
+    user_id    uuid,
+    role       varchar(20) NOT NULL DEFAULT 'member',
+    created_at timestamptz,
+    updated_at timestamptz,
+    PRIMARY KEY (project_id, user_id)
+);
+
+CREATE TABLE IF NOT EXISTS groups (
+    id          uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    org_id      uuid        NOT NULL,
+    name        varchar(50) NOT NULL,
+    description text,
+    parent_id   uuid,
+    created_by  uuid,
+    created_at  timestamptz,
+    updated_at  timestamptz
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_groups_org_name ON groups (org_id, name);
+CREATE INDEX IF NOT EXISTS idx_groups_parent_id ON groups (parent_id);
+
+CREATE TABLE IF NOT EXISTS group_members (
+    group_id   uuid,
+    user_id    uuid,
+    created_at timestamptz,
+    PRIMARY KEY (group_id, user_id)
+);
+
+-- 镜像可归属项目；require_access 超出了原来 varchar(10) 的长度
+ALTER TABLE images ADD COLUMN IF NOT EXISTS project_id uuid;
+CREATE INDEX IF NOT EXISTS idx_images_project_id ON images (project_id);
+ALTER TABLE images ALTER COLUMN visibility TYPE varchar(20);
+
+CREATE TABLE IF NOT EXISTS access_requests (
+    id          uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    image_id    uuid        NOT NULL,
+    user_id     varchar(36),
+    group_id    varchar(36),
+    reason      text,
+    status      varchar(20) NOT NULL DEFAULT 'pending',
+    reviewed_by varchar(36),
+    reviewed_at timestamptz,
+    created_at  timestamptz,
+    updated_at  timestamptz
+);
+CREATE INDEX IF NOT EXISTS idx_access_requests_image_id ON access_requests (image_id);
+CREATE INDEX IF NOT EXISTS idx_access_requests_user_id ON access_requests (user_id);
+CREATE INDEX IF NOT EXISTS idx_access_requests_group_id ON access_requests (group_id);
+
+CREATE TABLE IF NOT EXISTS roles (
+    name        varchar(50) PRIMARY KEY,
+    description text,
+    permissions text,
+    created_at  timestamptz,
+    updated_at  timestamptz
+);
+
+CREATE TABLE IF NOT EXISTS role_bindings (
+    id         uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id    varchar(36),
+    group_id   varchar(36),
+    role       varchar(50) NOT NULL,
+    scope_type varchar(20) NOT NULL DEFAULT 'global',
+    scope_id   varchar(36),
+    created_by varchar(36),
+    created_at timestamptz
+);
+CREATE INDEX IF NOT EXISTS idx_role_bindings_user_id ON role_bindings (user_id);
+CREATE INDEX IF NOT EXISTS idx_role_bindings_group_id ON role_bindings (group_id);
+CREATE INDEX IF NOT EXISTS idx_role_bindings_scope_id ON role_bindings (scope_id);
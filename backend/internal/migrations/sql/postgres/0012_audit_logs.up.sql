@@ -0,0 +1,13 @@
+-- 登录锁定与管理员解锁等安全相关操作的审计日志
+CREATE TABLE IF NOT EXISTS audit_logs (
+    id         uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    action     varchar(50) NOT NULL,
+    actor_id   varchar(36),
+    target     varchar(255),
+    ip         varchar(45),
+    detail     text,
+    created_at timestamptz
+);
+CREATE INDEX IF NOT EXISTS idx_audit_logs_action ON audit_logs (action);
+CREATE INDEX IF NOT EXISTS idx_audit_logs_target ON audit_logs (target);
+CREATE INDEX IF NOT EXISTS idx_audit_logs_created_at ON audit_logs (created_at);
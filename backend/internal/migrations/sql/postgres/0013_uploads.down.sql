@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS stored_objects;
+ALTER TABLE images DROP COLUMN IF EXISTS readme_base_url;
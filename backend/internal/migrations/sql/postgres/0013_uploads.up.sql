@@ -0,0 +1,9 @@
+-- README 相对链接的基准地址，以及供存储清理识别的上传文件记录
+ALTER TABLE images ADD COLUMN IF NOT EXISTS readme_base_url text;
+
+CREATE TABLE IF NOT EXISTS stored_objects (
+    object_key varchar(255) PRIMARY KEY,
+    kind       varchar(20) NOT NULL,
+    created_at timestamptz
+);
+CREATE INDEX IF NOT EXISTS idx_stored_objects_created_at ON stored_objects (created_at);
@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS collections;
+DROP TABLE IF EXISTS image_labels;
+DROP TABLE IF EXISTS labels;
+DROP TABLE IF EXISTS images;
+DROP TABLE IF EXISTS users;
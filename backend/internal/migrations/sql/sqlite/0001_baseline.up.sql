@@ -0,0 +1,57 @@
+-- SQLite 版本的初始表结构，与 postgres/0001_baseline.up.sql 对应。
+-- 主键由应用生成，因此不设置默认值。
+
+CREATE TABLE IF NOT EXISTS users (
+    id         text     PRIMARY KEY,
+    username   text     NOT NULL,
+    email      text     NOT NULL,
+    password   text     NOT NULL,
+    nickname   text,
+    avatar     text,
+    role       text     NOT NULL DEFAULT 'user',
+    created_at datetime NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at datetime NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_username ON users (username);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_email ON users (email);
+
+CREATE TABLE IF NOT EXISTS images (
+    id          text    PRIMARY KEY,
+    org_id      text    NOT NULL,
+    name        text    NOT NULL,
+    description text,
+    author      text    NOT NULL,
+    registry    text    NOT NULL,
+    namespace   text    NOT NULL,
+    repository  text    NOT NULL,
+    tag         text    NOT NULL,
+    digest      text    NOT NULL,
+    size        integer DEFAULT 0,
+    readme_path text,
+    stars       integer DEFAULT 0,
+    visibility  text    NOT NULL DEFAULT 'public',
+    platform    text    NOT NULL,
+    created_at  datetime,
+    updated_at  datetime
+);
+
+CREATE TABLE IF NOT EXISTS labels (
+    id         text PRIMARY KEY,
+    name       text NOT NULL,
+    created_at datetime,
+    updated_at datetime
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_labels_name ON labels (name);
+
+CREATE TABLE IF NOT EXISTS image_labels (
+    image_id text REFERENCES images (id) ON DELETE CASCADE,
+    label_id text REFERENCES labels (id) ON DELETE CASCADE,
+    PRIMARY KEY (image_id, label_id)
+);
+
+CREATE TABLE IF NOT EXISTS collections (
+    user_id    text NOT NULL,
+    image_id   text NOT NULL,
+    created_at datetime,
+    updated_at datetime
+);
@@ -0,0 +1,12 @@
+CREATE TABLE collections_old (
+    user_id    text NOT NULL,
+    image_id   text NOT NULL,
+    created_at datetime,
+    updated_at datetime
+);
+
+INSERT INTO collections_old (user_id, image_id, created_at, updated_at)
+SELECT user_id, image_id, created_at, updated_at FROM collections;
+
+DROP TABLE collections;
+ALTER TABLE collections_old RENAME TO collections;
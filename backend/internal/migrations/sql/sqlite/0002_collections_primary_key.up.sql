@@ -0,0 +1,14 @@
+-- SQLite 不支持为已有表添加主键，需要重建收藏表，重复行只保留最早的一条
+CREATE TABLE collections_new (
+    user_id    text NOT NULL,
+    image_id   text NOT NULL,
+    created_at datetime,
+    updated_at datetime,
+    PRIMARY KEY (user_id, image_id)
+);
+
+INSERT OR IGNORE INTO collections_new (user_id, image_id, created_at, updated_at)
+SELECT user_id, image_id, created_at, updated_at FROM collections ORDER BY created_at;
+
+DROP TABLE collections;
+ALTER TABLE collections_new RENAME TO collections;
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS list_follows;
+DROP TABLE IF EXISTS list_items;
+DROP TABLE IF EXISTS lists;
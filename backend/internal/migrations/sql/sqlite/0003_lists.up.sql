@@ -0,0 +1,48 @@
+CREATE TABLE IF NOT EXISTS lists (
+    id          text     PRIMARY KEY,
+    owner_id    text     NOT NULL,
+    name        text     NOT NULL,
+    description text,
+    visibility  text     NOT NULL DEFAULT 'private',
+    org_id      text,
+    is_default  boolean  NOT NULL DEFAULT false,
+    created_at  datetime,
+    updated_at  datetime
+);
+CREATE INDEX IF NOT EXISTS idx_lists_owner_id ON lists (owner_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_lists_owner_name ON lists (owner_id, name);
+-- 每个用户最多一个默认列表
+CREATE UNIQUE INDEX IF NOT EXISTS idx_lists_owner_default ON lists (owner_id) WHERE is_default;
+
+CREATE TABLE IF NOT EXISTS list_items (
+    list_id    text    NOT NULL REFERENCES lists (id) ON DELETE CASCADE,
+    image_id   text    NOT NULL,
+    position   integer NOT NULL DEFAULT 0,
+    note       text,
+    created_at datetime,
+    updated_at datetime,
+    PRIMARY KEY (list_id, image_id)
+);
+CREATE INDEX IF NOT EXISTS idx_list_items_image_id ON list_items (image_id);
+
+CREATE TABLE IF NOT EXISTS list_follows (
+    list_id    text NOT NULL REFERENCES lists (id) ON DELETE CASCADE,
+    user_id    text NOT NULL,
+    created_at datetime,
+    PRIMARY KEY (list_id, user_id)
+);
+CREATE INDEX IF NOT EXISTS idx_list_follows_user_id ON list_follows (user_id);
+
+-- 已有的收藏迁移到每个用户的默认列表，按收藏时间排序。SQLite 没有 UUID 函数，用随机字节拼出 v4 UUID
+INSERT INTO lists (id, owner_id, name, visibility, is_default, created_at, updated_at)
+SELECT lower(hex(randomblob(4)) || '-' || hex(randomblob(2)) || '-4' || substr(hex(randomblob(2)), 2) || '-' ||
+             substr('89ab', 1 + (abs(random()) % 4), 1) || substr(hex(randomblob(2)), 2) || '-' || hex(randomblob(6))),
+       user_id, 'Favorites', 'private', true, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP
+FROM (SELECT DISTINCT user_id FROM collections);
+
+INSERT INTO list_items (list_id, image_id, position, created_at, updated_at)
+SELECT lists.id, collections.image_id,
+       ROW_NUMBER() OVER (PARTITION BY collections.user_id ORDER BY collections.created_at, collections.image_id) - 1,
+       collections.created_at, collections.created_at
+FROM collections
+JOIN lists ON lists.owner_id = collections.user_id AND lists.is_default;
@@ -0,0 +1,20 @@
+CREATE TABLE IF NOT EXISTS categories (
+    id          text         PRIMARY KEY,
+    parent_id   text         REFERENCES categories (id),
+    name        varchar(100) NOT NULL,
+    slug        varchar(100) NOT NULL,
+    icon        text,
+    description text,
+    position    integer      NOT NULL DEFAULT 0,
+    created_at  datetime,
+    updated_at  datetime
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_categories_slug ON categories (slug);
+CREATE INDEX IF NOT EXISTS idx_categories_parent_id ON categories (parent_id);
+
+CREATE TABLE IF NOT EXISTS image_categories (
+    image_id    text REFERENCES images (id) ON DELETE CASCADE,
+    category_id text REFERENCES categories (id) ON DELETE CASCADE,
+    PRIMARY KEY (image_id, category_id)
+);
+CREATE INDEX IF NOT EXISTS idx_image_categories_category_id ON image_categories (category_id);
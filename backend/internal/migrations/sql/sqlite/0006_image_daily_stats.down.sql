@@ -0,0 +1 @@
+DROP TABLE IF EXISTS image_daily_stats;
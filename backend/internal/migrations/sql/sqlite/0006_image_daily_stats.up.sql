@@ -0,0 +1,9 @@
+-- 镜像每天的浏览和部署次数，用于计算热门镜像和热门标签
+CREATE TABLE IF NOT EXISTS image_daily_stats (
+    image_id text     NOT NULL REFERENCES images (id) ON DELETE CASCADE,
+    day      datetime NOT NULL,
+    views    integer  NOT NULL DEFAULT 0,
+    deploys  integer  NOT NULL DEFAULT 0,
+    PRIMARY KEY (image_id, day)
+);
+CREATE INDEX IF NOT EXISTS idx_image_daily_stats_day ON image_daily_stats (day);
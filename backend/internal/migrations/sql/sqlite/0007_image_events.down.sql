@@ -0,0 +1,4 @@
+ALTER TABLE image_daily_stats DROP COLUMN visitors;
+ALTER TABLE image_daily_stats DROP COLUMN usage_copies;
+ALTER TABLE image_daily_stats DROP COLUMN readme_views;
+DROP TABLE IF EXISTS image_events;
@@ -0,0 +1,16 @@
+-- 镜像的浏览、README 浏览、复制使用命令和部署事件，定期汇总到 image_daily_stats。
+-- 不引用 images，这样批量写入不会因为其中某个镜像已被删除而整批失败，汇总时忽略已删除镜像的事件
+CREATE TABLE IF NOT EXISTS image_events (
+    id           text     PRIMARY KEY,
+    image_id     text     NOT NULL,
+    type         text     NOT NULL,
+    user_id      text,
+    org_id       text,
+    anonymous_id text,
+    created_at   datetime NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_image_events_created_at ON image_events (created_at);
+
+ALTER TABLE image_daily_stats ADD COLUMN readme_views integer NOT NULL DEFAULT 0;
+ALTER TABLE image_daily_stats ADD COLUMN usage_copies integer NOT NULL DEFAULT 0;
+ALTER TABLE image_daily_stats ADD COLUMN visitors integer NOT NULL DEFAULT 0;
@@ -0,0 +1,13 @@
+-- 两步验证：用户的 TOTP 密钥和恢复码
+ALTER TABLE users ADD COLUMN totp_secret text;
+ALTER TABLE users ADD COLUMN totp_enabled boolean NOT NULL DEFAULT false;
+ALTER TABLE users ADD COLUMN totp_last_step integer NOT NULL DEFAULT 0;
+
+CREATE TABLE IF NOT EXISTS recovery_codes (
+    id         text PRIMARY KEY,
+    user_id    text NOT NULL,
+    code_hash  text NOT NULL,
+    used_at    datetime,
+    created_at datetime
+);
+CREATE INDEX IF NOT EXISTS idx_recovery_codes_user_id ON recovery_codes (user_id);
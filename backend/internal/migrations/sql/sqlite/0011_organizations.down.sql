@@ -0,0 +1,11 @@
+DROP TABLE IF EXISTS role_bindings;
+DROP TABLE IF EXISTS roles;
+DROP TABLE IF EXISTS access_requests;
+DROP INDEX IF EXISTS idx_images_project_id;
+ALTER TABLE images DROP COLUMN project_id;
+DROP TABLE IF EXISTS group_members;
+DROP TABLE IF EXISTS "groups";
+DROP TABLE IF EXISTS project_members;
+DROP TABLE IF EXISTS projects;
+DROP TABLE IF EXISTS org_members;
+DROP TABLE IF EXISTS organizations;
@@ -0,0 +1,103 @@
+-- 组织、项目、用户组、角色绑定和镜像访问申请
+CREATE TABLE IF NOT EXISTS organizations (
+    id          text    PRIMARY KEY,
+    name        text    NOT NULL,
+    description text,
+    require_2fa boolean NOT NULL DEFAULT false,
+    created_by  text,
+    created_at  datetime,
+    updated_at  datetime
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_organizations_name ON organizations (name);
+
+CREATE TABLE IF NOT EXISTS org_members (
+    org_id     text,
+    user_id    text,
+    role       text NOT NULL DEFAULT 'member',
+    created_at datetime,
+    updated_at datetime,
+    PRIMARY KEY (org_id, user_id)
+);
+
+CREATE TABLE IF NOT EXISTS projects (
+    id          text PRIMARY KEY,
+    org_id      text NOT NULL,
+    name        text NOT NULL,
+    description text,
+    visibility  text NOT NULL DEFAULT 'public',
+    created_by  text,
+    created_at  datetime,
+    updated_at  datetime
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_projects_org_name ON projects (org_id, name);
+
+CREATE TABLE IF NOT EXISTS project_members (
+    project_id text,
+    user_id    text,
+    role       text NOT NULL DEFAULT 'member',
+    created_at datetime,
+    updated_at datetime,
+    PRIMARY KEY (project_id, user_id)
+);
+
+CREATE TABLE IF NOT EXISTS "groups" (
+    id          text PRIMARY KEY,
+    org_id      text NOT NULL,
+    name        text NOT NULL,
+    description text,
+    parent_id   text,
+    created_by  text,
+    created_at  datetime,
+    updated_at  datetime
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_groups_org_name ON "groups" (org_id, name);
+CREATE INDEX IF NOT EXISTS idx_groups_parent_id ON "groups" (parent_id);
+
+CREATE TABLE IF NOT EXISTS group_members (
+    group_id   text,
+    user_id    text,
+    created_at datetime,
+    PRIMARY KEY (group_id, user_id)
+);
+
+-- 镜像可归属项目
+ALTER TABLE images ADD COLUMN project_id text;
+CREATE INDEX IF NOT EXISTS idx_images_project_id ON images (project_id);
+
+CREATE TABLE IF NOT EXISTS access_requests (
+    id          text PRIMARY KEY,
+    image_id    text NOT NULL,
+    user_id     text,
+    group_id    text,
+    reason      text,
+    status      text NOT NULL DEFAULT 'pending',
+    reviewed_by text,
+    reviewed_at datetime,
+    created_at  datetime,
+    updated_at  datetime
+);
+CREATE INDEX IF NOT EXISTS idx_access_requests_image_id ON access_requests (image_id);
+CREATE INDEX IF NOT EXISTS idx_access_requests_user_id ON access_requests (user_id);
+CREATE INDEX IF NOT EXISTS idx_access_requests_group_id ON access_requests (group_id);
+
+CREATE TABLE IF NOT EXISTS roles (
+    name        text PRIMARY KEY,
+    description text,
+    permissions text,
+    created_at  datetime,
+    updated_at  datetime
+);
+
+CREATE TABLE IF NOT EXISTS role_bindings (
+    id         text PRIMARY KEY,
+    user_id    text,
+    group_id   text,
+    role       text NOT NULL,
+    scope_type text NOT NULL DEFAULT 'global',
+    scope_id   text,
+    created_by text,
+    created_at datetime
+);
+CREATE INDEX IF NOT EXISTS idx_role_bindings_user_id ON role_bindings (user_id);
+CREATE INDEX IF NOT EXISTS idx_role_bindings_group_id ON role_bindings (group_id);
+CREATE INDEX IF NOT EXISTS idx_role_bindings_scope_id ON role_bindings (scope_id);
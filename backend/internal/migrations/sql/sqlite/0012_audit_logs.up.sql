@@ -0,0 +1,13 @@
+-- 登录锁定与管理员解锁等安全相关操作的审计日志
+CREATE TABLE IF NOT EXISTS audit_logs (
+    id         text PRIMARY KEY,
+    action     text NOT NULL,
+    actor_id   text,
+    target     text,
+    ip         text,
+    detail     text,
+    created_at datetime
+);
+CREATE INDEX IF NOT EXISTS idx_audit_logs_action ON audit_logs (action);
+CREATE INDEX IF NOT EXISTS idx_audit_logs_target ON audit_logs (target);
+CREATE INDEX IF NOT EXISTS idx_audit_logs_created_at ON audit_logs (created_at);
@@ -0,0 +1,33 @@
+package models
+
+import (
+	"time"
+)
+
+type AccessRequestStatus string
+
+const (
+	AccessRequestPending  AccessRequestStatus = "pending"
+	AccessRequestApproved AccessRequestStatus = "approved"
+	AccessRequestDenied   AccessRequestStatus = "denied"
+)
+
+// AccessRequest 表示非组织成员对 require_access 镜像的访问申请，批准后即为访问授权。
+// 维护者也可以直接为用户或用户组创建已批准的授权。
+type AccessRequest struct {
+	ID         string              `json:"id" gorm:"type:uuid;primary_key"`                           // 申请唯一标识符
+	ImageID    string              `json:"image_id" gorm:"type:uuid;not null;index"`                  // 镜像ID
+	UserID     string              `json:"user_id,omitempty" gorm:"type:varchar(36);index"`           // 申请人ID
+	GroupID    string              `json:"group_id,omitempty" gorm:"type:varchar(36);index"`          // 被授权的用户组ID，由维护者直接授权时使用
+	Reason     string              `json:"reason" gorm:"type:text"`                                   // 申请理由
+	Status     AccessRequestStatus `json:"status" gorm:"type:varchar(20);not null;default:'pending'"` // 申请状态
+	ReviewedBy string              `json:"reviewed_by,omitempty" gorm:"type:varchar(36)"`             // 审批人ID
+	ReviewedAt *time.Time          `json:"reviewed_at,omitempty"`                                     // 审批时间
+	CreatedAt  time.Time           `json:"created_at"`                                                // 申请时间
+	UpdatedAt  time.Time           `json:"updated_at"`                                                // 更新时间
+}
+
+// TableName - Set the table name for the AccessRequest model
+func (AccessRequest) TableName() string {
+	return "access_requests"
+}
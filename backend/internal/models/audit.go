@@ -0,0 +1,27 @@
+package models
+
+import (
+	"time"
+)
+
+// 审计事件类型
+const (
+	AuditLoginLockout = "login.lockout" // 登录失败次数过多导致锁定
+	AuditLoginUnlock  = "login.unlock"  // 管理员解除登录锁定
+)
+
+// AuditLog 表示一条安全审计记录
+type AuditLog struct {
+	ID        string    `json:"id" gorm:"type:uuid;primary_key"`               // 记录唯一标识符
+	Action    string    `json:"action" gorm:"type:varchar(50);not null;index"` // 事件类型
+	ActorID   string    `json:"actor_id" gorm:"type:varchar(36)"`              // 操作者ID，系统触发时为空
+	Target    string    `json:"target" gorm:"type:varchar(255);index"`         // 操作对象，例如用户名或 IP
+	IP        string    `json:"ip" gorm:"type:varchar(45)"`                    // 客户端 IP
+	Detail    string    `json:"detail" gorm:"type:text"`                       // 事件详情
+	CreatedAt time.Time `json:"created_at" gorm:"index"`                       // 发生时间
+}
+
+// TableName - Set the table name for the AuditLog model
+func (AuditLog) TableName() string {
+	return "audit_logs"
+}
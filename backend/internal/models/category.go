@@ -0,0 +1,31 @@
+package models
+
+import (
+	"regexp"
+	"time"
+)
+
+// Category 表示管理员维护的层级分类（例如 NLP > Text Generation）。与用户随意创建的标签不同，
+// 分类由管理员统一整理，镜像可以归属多个分类
+type Category struct {
+	ID          string    `json:"id" gorm:"type:uuid;primary_key"`                    // 分类唯一标识符
+	ParentID    *string   `json:"parent_id,omitempty" gorm:"type:uuid;index"`         // 上级分类ID，为空表示顶级分类
+	Name        string    `json:"name" gorm:"type:varchar(100);not null"`             // 分类显示名称
+	Slug        string    `json:"slug" gorm:"type:varchar(100);uniqueIndex;not null"` // 全局唯一的 URL 标识，用于筛选
+	Icon        string    `json:"icon"`                                               // 图标名称或地址
+	Description string    `json:"description"`                                        // 分类描述
+	Position    int       `json:"position" gorm:"not null;default:0"`                 // 同级分类中的排序
+	CreatedAt   time.Time `json:"created_at"`                                         // 创建时间
+	UpdatedAt   time.Time `json:"updated_at"`                                         // 更新时间
+}
+
+func (Category) TableName() string {
+	return "categories"
+}
+
+var slugRegex = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+
+// IsValidSlug checks that a slug is lowercase letters and digits separated by single hyphens
+func IsValidSlug(slug string) bool {
+	return len(slug) <= 100 && slugRegex.MatchString(slug)
+}
@@ -0,0 +1,33 @@
+package models
+
+import (
+	"time"
+)
+
+// Group 表示组织内的用户组，用于按部门或团队授权。最多嵌套一层，子组成员同时继承父组的授权。
+type Group struct {
+	ID          string    `json:"id" gorm:"type:uuid;primary_key"`                                       // 用户组唯一标识符
+	OrgID       string    `json:"org_id" gorm:"type:uuid;not null;uniqueIndex:idx_groups_org_name"`      // 所属组织ID
+	Name        string    `json:"name" gorm:"type:varchar(50);not null;uniqueIndex:idx_groups_org_name"` // 用户组名称，组织内唯一
+	Description string    `json:"description"`                                                           // 用户组描述
+	ParentID    *string   `json:"parent_id,omitempty" gorm:"type:uuid;index"`                            // 父组ID，为空表示顶级组
+	CreatedBy   string    `json:"created_by" gorm:"type:uuid"`                                           // 创建者ID
+	CreatedAt   time.Time `json:"created_at"`                                                            // 创建时间
+	UpdatedAt   time.Time `json:"updated_at"`                                                            // 更新时间
+}
+
+// GroupMember 表示用户在用户组中的成员身份
+type GroupMember struct {
+	GroupID   string    `json:"group_id" gorm:"type:uuid;primaryKey"` // 用户组ID
+	UserID    string    `json:"user_id" gorm:"type:uuid;primaryKey"`  // 用户ID
+	CreatedAt time.Time `json:"created_at"`                           // 加入时间
+}
+
+// TableName - Set the table names for the models
+func (Group) TableName() string {
+	return "groups"
+}
+
+func (GroupMember) TableName() string {
+	return "group_members"
+}
@@ -0,0 +1,91 @@
+package models
+
+import (
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// 主键在应用中生成而不是依赖数据库默认值（如 gen_random_uuid()），
+// 这样 PostgreSQL 和 SQLite 上的行为一致
+
+// newID assigns a random UUID unless the caller already chose an ID
+func newID(id *string) {
+	if *id == "" {
+		*id = uuid.NewString()
+	}
+}
+
+// BeforeCreate - GORM hook that assigns the ID
+func (o *Organization) BeforeCreate(tx *gorm.DB) error {
+	newID(&o.ID)
+	return nil
+}
+
+// BeforeCreate - GORM hook that assigns the ID
+func (p *Project) BeforeCreate(tx *gorm.DB) error {
+	newID(&p.ID)
+	return nil
+}
+
+// BeforeCreate - GORM hook that assigns the ID
+func (g *Group) BeforeCreate(tx *gorm.DB) error {
+	newID(&g.ID)
+	return nil
+}
+
+// BeforeCreate - GORM hook that assigns the ID
+func (i *Image) BeforeCreate(tx *gorm.DB) error {
+	newID(&i.ID)
+	return nil
+}
+
+// BeforeCreate - GORM hook that assigns the ID and the normalized name
+func (l *Label) BeforeCreate(tx *gorm.DB) error {
+	newID(&l.ID)
+	if l.Normalized == "" {
+		l.Normalized = LabelKey(l.Name)
+	}
+	return nil
+}
+
+// BeforeCreate - GORM hook that assigns the ID
+func (c *Category) BeforeCreate(tx *gorm.DB) error {
+	newID(&c.ID)
+	return nil
+}
+
+// BeforeCreate - GORM hook that assigns the ID
+func (b *RoleBinding) BeforeCreate(tx *gorm.DB) error {
+	newID(&b.ID)
+	return nil
+}
+
+// BeforeCreate - GORM hook that assigns the ID
+func (a *AuditLog) BeforeCreate(tx *gorm.DB) error {
+	newID(&a.ID)
+	return nil
+}
+
+// BeforeCreate - GORM hook that assigns the ID
+func (r *AccessRequest) BeforeCreate(tx *gorm.DB) error {
+	newID(&r.ID)
+	return nil
+}
+
+// BeforeCreate - GORM hook that assigns the ID
+func (c *RecoveryCode) BeforeCreate(tx *gorm.DB) error {
+	newID(&c.ID)
+	return nil
+}
+
+// BeforeCreate - GORM hook that assigns the ID
+func (l *List) BeforeCreate(tx *gorm.DB) error {
+	newID(&l.ID)
+	return nil
+}
+
+// BeforeCreate - GORM hook that assigns the ID
+func (e *ImageEvent) BeforeCreate(tx *gorm.DB) error {
+	newID(&e.ID)
+	return nil
+}
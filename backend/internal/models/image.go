@@ -1,45 +1,74 @@
 package models
 
 import (
+	"strings"
 	"time"
 )
 
+// 镜像可见性
+const (
+	VisibilityPublic        = "public"         // 所有人可见
+	VisibilityPrivate       = "private"        // 仅组织成员可见
+	VisibilityRequireAccess = "require_access" // 所有人可见基本信息，拉取地址需申请并经维护者批准
+)
+
 // Image 表示一个容器镜像
 type Image struct {
-	ID          string    `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`         // 镜像唯一标识符
-	OrgID       string    `json:"org_id" gorm:"type:uuid;not null"`                                  // 组织ID
-	Name        string    `json:"name" gorm:"not null"`                                              // 镜像显示名称
-	Description string    `json:"description"`                                                       // 镜像描述
-	Author      string    `json:"author" gorm:"type:uuid;not null"`                                  // 创建者ID
-	Registry    string    `json:"registry" gorm:"not null"`                                          // 镜像仓库服务器（例如：docker.io）
-	Namespace   string    `json:"namespace" gorm:"not null"`                                         // 命名空间/组织（例如：library）
-	Repository  string    `json:"repository" gorm:"not null"`                                        // 镜像名称（例如：nginx）
-	Tag         string    `json:"tag" gorm:"not null"`                                               // 版本标签（例如：latest）
-	Digest      string    `json:"digest" gorm:"not null"`                                            // 镜像内容哈希值
-	Size        int64     `json:"size" gorm:"default:0"`                                             // 镜像大小（字节）
-	ReadmePath  string    `json:"readme_path"`                                                       // README文件路径
-	Stars       int       `json:"stars" gorm:"default:0"`                                            // 收藏数（通过 Collection 表关联计算）
-	Visibility  string    `json:"visibility" gorm:"type:varchar(10);not null;default:'public'"`      // 可见性：public/private
-	Platform    string    `json:"platform" gorm:"not null"`                                          // 平台架构（例如：linux/amd64）
-	Labels      []Label   `json:"labels" gorm:"many2many:image_labels;constraint:OnDelete:CASCADE;"` // 标签列表，用于分类和搜索
-	CreatedAt   time.Time `json:"created_at"`                                                        // 创建时间
-	UpdatedAt   time.Time `json:"updated_at"`                                                        // 更新时间
+	ID          string     `json:"id" gorm:"type:uuid;primary_key"`                                           // 镜像唯一标识符
+	OrgID       string     `json:"org_id" gorm:"type:uuid;not null"`                                          // 组织ID
+	ProjectID   *string    `json:"project_id,omitempty" gorm:"type:uuid;index"`                               // 所属项目ID，为空表示直接归属组织
+	Name        string     `json:"name" gorm:"not null"`                                                      // 镜像显示名称
+	Description string     `json:"description"`                                                               // 镜像描述
+	Author      string     `json:"author" gorm:"type:uuid;not null"`                                          // 创建者ID
+	Registry    string     `json:"registry" gorm:"not null"`                                                  // 镜像仓库服务器（例如：docker.io）
+	Namespace   string     `json:"namespace" gorm:"not null"`                                                 // 命名空间/组织（例如：library）
+	Repository  string     `json:"repository" gorm:"not null"`                                                // 镜像名称（例如：nginx）
+	Tag         string     `json:"tag" gorm:"not null"`                                                       // 版本标签（例如：latest）
+	Digest      string     `json:"digest" gorm:"not null"`                                                    // 镜像内容哈希值
+	Size        int64      `json:"size" gorm:"default:0"`                                                     // 镜像大小（字节）
+	ReadmePath  string     `json:"readme_path"`                                                               // README文件路径
+	ReadmeBase  string     `json:"readme_base_url" gorm:"column:readme_base_url"`                             // README 中相对链接的基准地址，例如源码仓库的 raw 地址
+	Stars       int        `json:"stars" gorm:"default:0"`                                                    // 收藏数（通过 Collection 表关联计算）
+	Visibility  string     `json:"visibility" gorm:"type:varchar(20);not null;default:'public'"`              // 可见性：public/private/require_access
+	Platform    string     `json:"platform" gorm:"not null"`                                                  // 平台架构（例如：linux/amd64）
+	Labels      []Label    `json:"labels" gorm:"many2many:image_labels;constraint:OnDelete:CASCADE;"`         // 标签列表，用于分类和搜索
+	Categories  []Category `json:"categories" gorm:"many2many:image_categories;constraint:OnDelete:CASCADE;"` // 所属分类，由管理员维护
+	CreatedAt   time.Time  `json:"created_at"`                                                                // 创建时间
+	UpdatedAt   time.Time  `json:"updated_at"`                                                                // 更新时间
 }
 
 // Label 表示镜像的分类标签
 type Label struct {
-	ID        string    `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"` // 标签唯一标识符
-	Name      string    `json:"name" gorm:"uniqueIndex;not null"`                          // 标签名称
-	CreatedAt time.Time `json:"created_at"`                                                // 创建时间
-	UpdatedAt time.Time `json:"updated_at"`                                                // 更新时间
+	ID         string    `json:"id" gorm:"type:uuid;primary_key"`  // 标签唯一标识符
+	Name       string    `json:"name" gorm:"uniqueIndex;not null"` // 标签显示名称
+	Normalized string    `json:"-" gorm:"uniqueIndex;not null"`    // 规范化后的名称，用于不区分大小写地查找和去重
+	CreatedAt  time.Time `json:"created_at"`                       // 创建时间
+	UpdatedAt  time.Time `json:"updated_at"`                       // 更新时间
+}
+
+// LabelAlias 将其他写法映射到规范标签，例如把 "pytorch" 映射到 "PyTorch"
+type LabelAlias struct {
+	Alias     string    `json:"alias" gorm:"primaryKey"`                  // 规范化后的别名
+	LabelID   string    `json:"label_id" gorm:"type:uuid;not null;index"` // 规范标签ID
+	CreatedAt time.Time `json:"created_at"`                               // 创建时间
+}
+
+// NormalizeLabelName trims a label name and collapses runs of whitespace into single spaces
+func NormalizeLabelName(name string) string {
+	return strings.Join(strings.Fields(name), " ")
+}
+
+// LabelKey returns the case-insensitive key labels and aliases are looked up by
+func LabelKey(name string) string {
+	return strings.ToLower(NormalizeLabelName(name))
 }
 
 // Collection 表示用户收藏的镜像
 type Collection struct {
-	UserID    string    `json:"user_id" gorm:"type:uuid;not null"`  // 用户ID
-	ImageID   string    `json:"image_id" gorm:"type:uuid;not null"` // 镜像ID
-	CreatedAt time.Time `json:"created_at"`                         // 创建时间
-	UpdatedAt time.Time `json:"updated_at"`                         // 更新时间
+	UserID    string    `json:"user_id" gorm:"type:uuid;primaryKey"`  // 用户ID，与镜像ID组成主键，同一用户只能收藏一次
+	ImageID   string    `json:"image_id" gorm:"type:uuid;primaryKey"` // 镜像ID
+	CreatedAt time.Time `json:"created_at"`                           // 创建时间
+	UpdatedAt time.Time `json:"updated_at"`                           // 更新时间
 }
 
 // TableName - Set the table names for the models
@@ -51,6 +80,10 @@ func (Label) TableName() string {
 	return "labels"
 }
 
+func (LabelAlias) TableName() string {
+	return "label_aliases"
+}
+
 func (Collection) TableName() string {
 	return "collections"
 }
@@ -0,0 +1,63 @@
+package models
+
+import (
+	"time"
+)
+
+// 收藏列表可见性
+const (
+	ListVisibilityPrivate = "private" // 仅创建者可见
+	ListVisibilityOrg     = "org"     // 指定组织的成员可见
+	ListVisibilityPublic  = "public"  // 所有人可见
+)
+
+// DefaultListName 是每个用户默认收藏列表的名称，收藏镜像即加入该列表
+const DefaultListName = "Favorites"
+
+// List 表示用户整理的命名收藏列表
+type List struct {
+	ID          string    `json:"id" gorm:"type:uuid;primary_key"`                               // 列表唯一标识符
+	OwnerID     string    `json:"owner_id" gorm:"type:uuid;not null;index"`                      // 创建者ID
+	Name        string    `json:"name" gorm:"type:varchar(100);not null"`                        // 列表名称，同一用户下唯一
+	Description string    `json:"description"`                                                   // 列表描述
+	Visibility  string    `json:"visibility" gorm:"type:varchar(20);not null;default:'private'"` // 可见性：private/org/public
+	OrgID       *string   `json:"org_id,omitempty" gorm:"type:uuid"`                             // 共享给的组织，仅 org 可见性使用
+	IsDefault   bool      `json:"is_default" gorm:"not null;default:false"`                      // 是否为默认收藏列表，与镜像收藏同步
+	CreatedAt   time.Time `json:"created_at"`                                                    // 创建时间
+	UpdatedAt   time.Time `json:"updated_at"`                                                    // 更新时间
+}
+
+// ListItem 表示列表中的一个镜像
+type ListItem struct {
+	ListID    string    `json:"list_id" gorm:"type:uuid;primaryKey"`  // 列表ID
+	ImageID   string    `json:"image_id" gorm:"type:uuid;primaryKey"` // 镜像ID
+	Position  int       `json:"position" gorm:"not null;default:0"`   // 在列表中的顺序，从 0 开始
+	Note      string    `json:"note"`                                 // 备注
+	CreatedAt time.Time `json:"created_at"`                           // 加入时间
+	UpdatedAt time.Time `json:"updated_at"`                           // 更新时间
+}
+
+// ListFollow 表示用户关注了某个列表
+type ListFollow struct {
+	ListID    string    `json:"list_id" gorm:"type:uuid;primaryKey"` // 列表ID
+	UserID    string    `json:"user_id" gorm:"type:uuid;primaryKey"` // 关注者ID
+	CreatedAt time.Time `json:"created_at"`                          // 关注时间
+}
+
+// TableName - Set the table names for the models
+func (List) TableName() string {
+	return "lists"
+}
+
+func (ListItem) TableName() string {
+	return "list_items"
+}
+
+func (ListFollow) TableName() string {
+	return "list_follows"
+}
+
+// IsValidListVisibility checks if a list visibility is valid
+func IsValidListVisibility(visibility string) bool {
+	return visibility == ListVisibilityPrivate || visibility == ListVisibilityOrg || visibility == ListVisibilityPublic
+}
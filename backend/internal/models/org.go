@@ -0,0 +1,55 @@
+package models
+
+import (
+	"time"
+)
+
+// PublicOrgID 是 public 组织的保留 ID，开源版本的所有镜像都归属于该组织
+const PublicOrgID = "00000000-0000-0000-0000-000000000000"
+
+type OrgRole string
+
+const (
+	OrgRoleOwner      OrgRole = "owner"
+	OrgRoleMaintainer OrgRole = "maintainer"
+	OrgRoleMember     OrgRole = "member"
+)
+
+// Organization 表示一个企业组织
+type Organization struct {
+	ID          string    `json:"id" gorm:"type:uuid;primary_key"`                              // 组织唯一标识符
+	Name        string    `json:"name" gorm:"type:varchar(50);uniqueIndex;not null"`            // 组织名称
+	Description string    `json:"description"`                                                  // 组织描述
+	Require2FA  bool      `json:"require_2fa" gorm:"column:require_2fa;not null;default:false"` // 是否要求维护者启用两步验证
+	CreatedBy   string    `json:"created_by" gorm:"type:uuid"`                                  // 创建者ID
+	CreatedAt   time.Time `json:"created_at"`                                                   // 创建时间
+	UpdatedAt   time.Time `json:"updated_at"`                                                   // 更新时间
+}
+
+// OrgMember 表示用户在组织中的成员身份
+type OrgMember struct {
+	OrgID     string    `json:"org_id" gorm:"type:uuid;primaryKey"`                     // 组织ID
+	UserID    string    `json:"user_id" gorm:"type:uuid;primaryKey"`                    // 用户ID
+	Role      OrgRole   `json:"role" gorm:"type:varchar(20);not null;default:'member'"` // 组织内角色
+	CreatedAt time.Time `json:"created_at"`                                             // 加入时间
+	UpdatedAt time.Time `json:"updated_at"`                                             // 更新时间
+}
+
+// IsMaintainer - Check if the member can maintain the organization
+func (m *OrgMember) IsMaintainer() bool {
+	return m.Role == OrgRoleOwner || m.Role == OrgRoleMaintainer
+}
+
+// TableName - Set the table names for the models
+func (Organization) TableName() string {
+	return "organizations"
+}
+
+func (OrgMember) TableName() string {
+	return "org_members"
+}
+
+// IsValidOrgRole checks if an org role is valid
+func IsValidOrgRole(role OrgRole) bool {
+	return role == OrgRoleOwner || role == OrgRoleMaintainer || role == OrgRoleMember
+}
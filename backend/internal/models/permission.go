@@ -0,0 +1,225 @@
+package models
+
+import (
+	"strings"
+	"time"
+)
+
+// Permission 表示一项可授权的操作，格式为 <资源>.<动作>
+type Permission string
+
+const (
+	PermAll Permission = "*" // 所有权限，仅授予管理员
+
+	// 个人操作
+	PermProfileManage Permission = "profile.manage" // 管理自己的资料和两步验证
+	PermFavoritesRead Permission = "favorites.read" // 查看自己的收藏夹
+	PermImagesStar    Permission = "images.star"    // 收藏镜像
+	PermListsManage   Permission = "lists.manage"   // 创建和整理自己的收藏列表，关注他人的列表
+
+	// 用户与角色管理
+	PermUsersRead   Permission = "users.read"   // 查看用户列表
+	PermUsersManage Permission = "users.manage" // 修改其他用户的资料
+	PermUsersUnlock Permission = "users.unlock" // 解除登录锁定
+	PermRolesRead   Permission = "roles.read"   // 查看角色和角色绑定
+	PermRolesManage Permission = "roles.manage" // 创建和删除自定义角色
+	PermRolesBind   Permission = "roles.bind"   // 在作用域内为用户绑定角色
+
+	// 组织管理
+	PermOrgsCreate       Permission = "orgs.create"         // 创建组织
+	PermOrgsRead         Permission = "orgs.read"           // 查看组织信息
+	PermOrgsManage       Permission = "orgs.manage"         // 修改组织设置和安全策略
+	PermOrgMembersRead   Permission = "orgs.members.read"   // 查看组织成员
+	PermOrgMembersManage Permission = "orgs.members.manage" // 添加和移除组织成员
+
+	// 用户组管理
+	PermGroupsRead   Permission = "groups.read"   // 查看组织内的用户组及成员
+	PermGroupsManage Permission = "groups.manage" // 创建和删除用户组，管理组成员
+
+	// 项目管理
+	PermProjectsCreate       Permission = "projects.create"         // 在组织内创建项目
+	PermProjectsManage       Permission = "projects.manage"         // 修改和删除项目
+	PermProjectMembersManage Permission = "projects.members.manage" // 添加和移除项目成员
+
+	// 镜像管理
+	PermImagesRead          Permission = "images.read"           // 查看作用域内的非公开镜像
+	PermImagesRequestAccess Permission = "images.access.request" // 申请访问需审批的镜像
+	PermImagesCreate        Permission = "images.create"         // 在作用域内创建镜像
+	PermImagesUpdate        Permission = "images.update"         // 修改自己创建的镜像
+	PermImagesDelete        Permission = "images.delete"         // 删除自己创建的镜像
+	PermImagesManage        Permission = "images.manage"         // 修改和删除作用域内的任意镜像，审批访问申请
+
+	// 分类管理
+	PermCategoriesManage Permission = "categories.manage" // 创建、修改和删除镜像分类
+
+	// 标签管理
+	PermLabelsManage Permission = "labels.manage" // 重命名、合并和删除标签，维护标签别名
+)
+
+// AllPermissions lists every permission that can be put in a custom role
+var AllPermissions = []Permission{
+	PermProfileManage, PermFavoritesRead, PermImagesStar, PermListsManage,
+	PermUsersRead, PermUsersManage, PermUsersUnlock,
+	PermRolesRead, PermRolesManage, PermRolesBind,
+	PermOrgsCreate, PermOrgsRead, PermOrgsManage, PermOrgMembersRead, PermOrgMembersManage,
+	PermGroupsRead, PermGroupsManage,
+	PermProjectsCreate, PermProjectsManage, PermProjectMembersManage,
+	PermImagesRead, PermImagesRequestAccess,
+	PermImagesCreate, PermImagesUpdate, PermImagesDelete, PermImagesManage,
+	PermCategoriesManage, PermLabelsManage,
+}
+
+// IsValidPermission checks if a permission is known, "*" or a "<resource>.*" wildcard
+func IsValidPermission(perm Permission) bool {
+	for _, known := range AllPermissions {
+		if perm.Covers(known) {
+			return true
+		}
+	}
+	return false
+}
+
+// Covers reports whether the granted permission includes the requested one.
+// "*" grants everything and "images.*" grants every images permission.
+func (p Permission) Covers(requested Permission) bool {
+	if p == PermAll || p == requested {
+		return true
+	}
+	if strings.HasSuffix(string(p), ".*") {
+		return strings.HasPrefix(string(requested), strings.TrimSuffix(string(p), "*"))
+	}
+	return false
+}
+
+// ScopeType 表示角色绑定的作用范围
+type ScopeType string
+
+const (
+	ScopeGlobal  ScopeType = "global"
+	ScopeOrg     ScopeType = "org"
+	ScopeProject ScopeType = "project"
+)
+
+// 组织和项目内置角色，分别由 OrgMember.Role 和 ProjectMember.Role 隐式绑定
+const (
+	RoleOrgOwner          Role = "org_owner"
+	RoleOrgMaintainer     Role = "org_maintainer"
+	RoleOrgMember         Role = "org_member"
+	RoleProjectMaintainer Role = "project_maintainer"
+	RoleProjectMember     Role = "project_member"
+)
+
+// BuiltinRoles 是内置角色及其权限集合
+var BuiltinRoles = map[Role][]Permission{
+	RoleAdmin: {PermAll},
+	RoleUser: {
+		PermProfileManage,
+		PermFavoritesRead,
+		PermImagesStar,
+		PermListsManage,
+		PermImagesRequestAccess,
+		PermOrgsCreate,
+		PermOrgsRead,
+	},
+	RoleOrgOwner: {
+		PermOrgsRead,
+		PermOrgsManage,
+		PermOrgMembersRead,
+		PermOrgMembersManage,
+		PermRolesRead,
+		PermRolesBind,
+		PermGroupsRead,
+		PermGroupsManage,
+		PermProjectsCreate,
+		PermProjectsManage,
+		PermProjectMembersManage,
+		PermImagesRead,
+		PermImagesCreate,
+		PermImagesUpdate,
+		PermImagesDelete,
+		PermImagesManage,
+	},
+	RoleOrgMaintainer: {
+		PermOrgsRead,
+		PermOrgMembersRead,
+		PermGroupsRead,
+		PermGroupsManage,
+		PermProjectsCreate,
+		PermProjectsManage,
+		PermProjectMembersManage,
+		PermImagesRead,
+		PermImagesCreate,
+		PermImagesUpdate,
+		PermImagesDelete,
+		PermImagesManage,
+	},
+	RoleOrgMember: {
+		PermOrgsRead,
+		PermOrgMembersRead,
+		PermGroupsRead,
+		PermImagesRead,
+		PermImagesCreate,
+		PermImagesUpdate,
+		PermImagesDelete,
+	},
+	RoleProjectMaintainer: {
+		PermProjectsManage,
+		PermProjectMembersManage,
+		PermImagesRead,
+		PermImagesCreate,
+		PermImagesUpdate,
+		PermImagesDelete,
+		PermImagesManage,
+	},
+	RoleProjectMember: {
+		PermImagesRead,
+		PermImagesCreate,
+		PermImagesUpdate,
+		PermImagesDelete,
+	},
+}
+
+// OrgRoleName returns the built-in role bundle implied by an org membership role
+func OrgRoleName(role OrgRole) Role {
+	return Role("org_" + string(role))
+}
+
+// ProjectRoleName returns the built-in role bundle implied by a project membership role
+func ProjectRoleName(role ProjectRole) Role {
+	return Role("project_" + string(role))
+}
+
+// RoleDefinition 表示管理员自定义的角色（权限集合）
+type RoleDefinition struct {
+	Name        Role         `json:"name" gorm:"type:varchar(50);primaryKey"` // 角色名称
+	Description string       `json:"description"`                             // 角色描述
+	Permissions []Permission `json:"permissions" gorm:"serializer:json"`      // 角色包含的权限
+	CreatedAt   time.Time    `json:"created_at"`                              // 创建时间
+	UpdatedAt   time.Time    `json:"updated_at"`                              // 更新时间
+}
+
+// RoleBinding 表示在某个作用域内授予用户或用户组的角色
+type RoleBinding struct {
+	ID        string    `json:"id" gorm:"type:uuid;primary_key"`                              // 绑定唯一标识符
+	UserID    string    `json:"user_id,omitempty" gorm:"type:varchar(36);index"`              // 用户ID，与 GroupID 二选一
+	GroupID   string    `json:"group_id,omitempty" gorm:"type:varchar(36);index"`             // 用户组ID，组成员均获得该角色
+	Role      Role      `json:"role" gorm:"type:varchar(50);not null"`                        // 角色名称
+	ScopeType ScopeType `json:"scope_type" gorm:"type:varchar(20);not null;default:'global'"` // 作用域类型
+	ScopeID   string    `json:"scope_id" gorm:"type:varchar(36);index"`                       // 作用域ID，全局作用域为空
+	CreatedBy string    `json:"created_by" gorm:"type:varchar(36)"`                           // 授权人ID
+	CreatedAt time.Time `json:"created_at"`                                                   // 创建时间
+}
+
+// TableName - Set the table names for the models
+func (RoleDefinition) TableName() string {
+	return "roles"
+}
+
+func (RoleBinding) TableName() string {
+	return "role_bindings"
+}
+
+// IsValidScopeType checks if a scope type is valid
+func IsValidScopeType(scope ScopeType) bool {
+	return scope == ScopeGlobal || scope == ScopeOrg || scope == ScopeProject
+}
@@ -0,0 +1,38 @@
+package models
+
+import "testing"
+
+func TestPermissionCovers(t *testing.T) {
+	tests := []struct {
+		granted   Permission
+		requested Permission
+		want      bool
+	}{
+		{PermAll, PermUsersManage, true},
+		{PermImagesCreate, PermImagesCreate, true},
+		{PermImagesCreate, PermImagesManage, false},
+		{"images.*", PermImagesDelete, true},
+		{"orgs.*", PermOrgMembersManage, true},
+		{"orgs.*", PermImagesCreate, false},
+		{"images.*", PermImagesStar, true},
+	}
+
+	for _, tt := range tests {
+		if got := tt.granted.Covers(tt.requested); got != tt.want {
+			t.Errorf("%s.Covers(%s) = %v, want %v", tt.granted, tt.requested, got, tt.want)
+		}
+	}
+}
+
+func TestIsValidPermission(t *testing.T) {
+	for _, perm := range []Permission{PermAll, PermRolesBind, "orgs.*"} {
+		if !IsValidPermission(perm) {
+			t.Errorf("IsValidPermission(%s) = false, want true", perm)
+		}
+	}
+	for _, perm := range []Permission{"", "images.fly", "nothing.*"} {
+		if IsValidPermission(perm) {
+			t.Errorf("IsValidPermission(%s) = true, want false", perm)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package models
+
+import (
+	"time"
+)
+
+type ProjectRole string
+
+const (
+	ProjectRoleMaintainer ProjectRole = "maintainer"
+	ProjectRoleMember     ProjectRole = "member"
+)
+
+// Project 表示组织下的项目，用于对镜像进行二级分组
+type Project struct {
+	ID          string    `json:"id" gorm:"type:uuid;primary_key"`                                         // 项目唯一标识符
+	OrgID       string    `json:"org_id" gorm:"type:uuid;not null;uniqueIndex:idx_projects_org_name"`      // 所属组织ID
+	Name        string    `json:"name" gorm:"type:varchar(50);not null;uniqueIndex:idx_projects_org_name"` // 项目名称，组织内唯一
+	Description string    `json:"description"`                                                             // 项目描述
+	Visibility  string    `json:"visibility" gorm:"type:varchar(20);not null;default:'public'"`            // 可见性：public/private/require_access，约束项目内所有镜像
+	CreatedBy   string    `json:"created_by" gorm:"type:uuid"`                                             // 创建者ID
+	CreatedAt   time.Time `json:"created_at"`                                                              // 创建时间
+	UpdatedAt   time.Time `json:"updated_at"`                                                              // 更新时间
+}
+
+// ProjectMember 表示用户在项目中的成员身份
+type ProjectMember struct {
+	ProjectID string      `json:"project_id" gorm:"type:uuid;primaryKey"`                 // 项目ID
+	UserID    string      `json:"user_id" gorm:"type:uuid;primaryKey"`                    // 用户ID
+	Role      ProjectRole `json:"role" gorm:"type:varchar(20);not null;default:'member'"` // 项目内角色
+	CreatedAt time.Time   `json:"created_at"`                                             // 加入时间
+	UpdatedAt time.Time   `json:"updated_at"`                                             // 更新时间
+}
+
+// TableName - Set the table names for the models
+func (Project) TableName() string {
+	return "projects"
+}
+
+func (ProjectMember) TableName() string {
+	return "project_members"
+}
+
+// IsValidProjectRole checks if a project role is valid
+func IsValidProjectRole(role ProjectRole) bool {
+	return role == ProjectRoleMaintainer || role == ProjectRoleMember
+}
+
+// IsValidVisibility checks if an image or project visibility is valid
+func IsValidVisibility(visibility string) bool {
+	return visibility == VisibilityPublic || visibility == VisibilityPrivate || visibility == VisibilityRequireAccess
+}
+
+// StricterVisibility returns the more restrictive of two visibilities,
+// ordered public < require_access < private
+func StricterVisibility(a, b string) string {
+	rank := func(v string) int {
+		switch v {
+		case VisibilityPrivate:
+			return 2
+		case VisibilityRequireAccess:
+			return 1
+		default:
+			return 0
+		}
+	}
+	if rank(b) > rank(a) {
+		return b
+	}
+	return a
+}
@@ -0,0 +1,12 @@
+package models
+
+// ImageSimilarity 离线计算的相似镜像，每个镜像只保留得分最高的若干个
+type ImageSimilarity struct {
+	ImageID   string  `json:"image_id" gorm:"type:uuid;primaryKey"`   // 镜像ID
+	SimilarID string  `json:"similar_id" gorm:"type:uuid;primaryKey"` // 相似镜像ID
+	Score     float64 `json:"score" gorm:"not null"`                  // 综合得分，0 到 1
+}
+
+func (ImageSimilarity) TableName() string {
+	return "image_similarities"
+}
@@ -0,0 +1,74 @@
+package models
+
+import (
+	"time"
+)
+
+// 镜像事件类型
+const (
+	EventView       = "view"        // 浏览镜像详情
+	EventReadmeView = "readme_view" // 浏览 README
+	EventUsageCopy  = "usage_copy"  // 复制拉取或使用命令
+	EventDeploy     = "deploy"      // 部署镜像
+)
+
+// ImageEvent 记录一次镜像使用行为，定期汇总到 ImageDailyStat
+type ImageEvent struct {
+	ID          string    `json:"id" gorm:"type:uuid;primaryKey"`                 // 事件ID
+	ImageID     string    `json:"image_id" gorm:"type:uuid;not null"`             // 镜像ID
+	Type        string    `json:"type" gorm:"type:varchar(32);not null"`          // 事件类型：view/readme_view/usage_copy/deploy
+	UserID      *string   `json:"user_id,omitempty" gorm:"type:uuid"`             // 登录用户ID
+	OrgID       *string   `json:"org_id,omitempty" gorm:"type:uuid"`              // 用户当时所在的组织ID
+	AnonymousID *string   `json:"anonymous_id,omitempty" gorm:"type:varchar(64)"` // 未登录访客的匿名标识
+	CreatedAt   time.Time `json:"created_at" gorm:"not null;index"`               // 发生时间
+}
+
+func (ImageEvent) TableName() string {
+	return "image_events"
+}
+
+// Visitor returns the key that identifies who caused the event when counting unique visitors
+func (e *ImageEvent) Visitor() string {
+	switch {
+	case e.UserID != nil:
+		return "user:" + *e.UserID
+	case e.AnonymousID != nil:
+		return "anonymous:" + *e.AnonymousID
+	default:
+		return ""
+	}
+}
+
+// ImageDailyStat 按 UTC 日期汇总镜像的事件次数，用于热门排行和镜像作者查看使用趋势
+type ImageDailyStat struct {
+	ImageID     string    `json:"image_id" gorm:"type:uuid;primaryKey"`   // 镜像ID
+	Day         time.Time `json:"day" gorm:"type:date;primaryKey"`        // UTC 日期
+	Views       int64     `json:"views" gorm:"not null;default:0"`        // 浏览次数
+	ReadmeViews int64     `json:"readme_views" gorm:"not null;default:0"` // README 浏览次数
+	UsageCopies int64     `json:"usage_copies" gorm:"not null;default:0"` // 复制使用命令次数
+	Deploys     int64     `json:"deploys" gorm:"not null;default:0"`      // 部署次数
+	Visitors    int64     `json:"visitors" gorm:"not null;default:0"`     // 当天产生过事件的不同用户和匿名访客数
+}
+
+func (ImageDailyStat) TableName() string {
+	return "image_daily_stats"
+}
+
+// Count adds an event to the counters
+func (s *ImageDailyStat) Count(eventType string) {
+	switch eventType {
+	case EventView:
+		s.Views++
+	case EventReadmeView:
+		s.ReadmeViews++
+	case EventUsageCopy:
+		s.UsageCopies++
+	case EventDeploy:
+		s.Deploys++
+	}
+}
+
+// StatDay returns the UTC day a moment is counted in
+func StatDay(t time.Time) time.Time {
+	return t.UTC().Truncate(24 * time.Hour)
+}
@@ -0,0 +1,24 @@
+package models
+
+import (
+	"time"
+)
+
+// 存储对象类型
+const (
+	StoredObjectAvatar = "avatar" // 用户头像（含各尺寸变体）
+	StoredObjectReadme = "readme" // 镜像 README
+)
+
+// StoredObject 记录写入存储后端的每个文件。文件由 users.avatar、images.readme_path 引用，
+// 没有引用的文件由存储 GC 清理
+type StoredObject struct {
+	Key       string    `json:"key" gorm:"column:object_key;type:varchar(255);primary_key"` // 存储对象键，例如 readme/20240101_<uuid>.md
+	Kind      string    `json:"kind" gorm:"type:varchar(20);not null"`                      // 对象类型：avatar/readme
+	CreatedAt time.Time `json:"created_at" gorm:"index"`                                    // 上传时间
+}
+
+// TableName - Set the table name for the StoredObject model
+func (StoredObject) TableName() string {
+	return "stored_objects"
+}
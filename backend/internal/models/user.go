@@ -7,6 +7,7 @@ import (
 	"gorm.io/gorm"
 )
 
+// Role 是角色名称，对应内置角色或 roles 表中的自定义角色
 type Role string
 
 const (
@@ -16,19 +17,35 @@ const (
 
 // User 表示系统用户
 type User struct {
-	ID        string    `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
-	Username  string    `json:"username" gorm:"type:varchar(50);uniqueIndex;not null"`
-	Email     string    `json:"email" gorm:"type:varchar(100);uniqueIndex;not null"`
-	Password  string    `json:"-" gorm:"type:varchar(100);not null"` // "-" means this field will not be included in JSON
-	Nickname  string    `json:"nickname" gorm:"type:varchar(50)"`    // 昵称
-	Avatar    string    `json:"avatar" gorm:"type:varchar(255)"`     // 头像URL
-	Role      Role      `json:"role" gorm:"type:varchar(20);not null;default:'user'"`
+	ID       string `json:"id" gorm:"type:uuid;primary_key"`
+	Username string `json:"username" gorm:"type:varchar(50);uniqueIndex;not null"`
+	Email    string `json:"email" gorm:"type:varchar(100);uniqueIndex;not null"`
+	Password string `json:"-" gorm:"type:varchar(100);not null"` // "-" means this field will not be included in JSON
+	Nickname string `json:"nickname" gorm:"type:varchar(50)"`    // 昵称
+	Avatar   string `json:"avatar" gorm:"type:varchar(255)"`     // 头像URL
+	Role     Role   `json:"role" gorm:"type:varchar(20);not null;default:'user'"`
+
+	TOTPSecret   string `json:"-" gorm:"type:varchar(64)"`                  // TOTP 密钥（Base32），启用前为待验证状态
+	TOTPEnabled  bool   `json:"totp_enabled" gorm:"not null;default:false"` // 是否已启用两步验证
+	TOTPLastStep int64  `json:"-" gorm:"not null;default:0"`                // 最近一次使用的 TOTP 周期，防止验证码重放
+
 	CreatedAt time.Time `json:"created_at" gorm:"not null;default:CURRENT_TIMESTAMP"`
 	UpdatedAt time.Time `json:"updated_at" gorm:"not null;default:CURRENT_TIMESTAMP"`
 }
 
+// RecoveryCode 表示两步验证的恢复码，仅保存哈希值
+type RecoveryCode struct {
+	ID        string     `json:"id" gorm:"type:uuid;primary_key"`
+	UserID    string     `json:"user_id" gorm:"type:uuid;not null;index"`
+	CodeHash  string     `json:"-" gorm:"type:varchar(64);not null"`
+	UsedAt    *time.Time `json:"used_at"` // 使用时间，未使用时为空
+	CreatedAt time.Time  `json:"created_at"`
+}
+
 // BeforeCreate - GORM hook that runs before creating a new user
 func (u *User) BeforeCreate(tx *gorm.DB) error {
+	newID(&u.ID)
+
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(u.Password), bcrypt.DefaultCost)
 	if err != nil {
 		return err
@@ -63,7 +80,21 @@ func (User) TableName() string {
 	return "users"
 }
 
-// IsValidRole checks if a role is valid
-func IsValidRole(role Role) bool {
-	return role == RoleUser || role == RoleAdmin
+// TableName - Set the table name for the RecoveryCode model
+func (RecoveryCode) TableName() string {
+	return "recovery_codes"
+}
+
+// TokenState 记录令牌的吊销状态和两步验证挑战的尝试次数，只保存令牌的 SHA-256 摘要。
+// 这些状态决定令牌能否使用，因此保存在数据库中而不是可能被淘汰的缓存里
+type TokenState struct {
+	TokenHash string    `json:"-" gorm:"type:varchar(64);primaryKey"`  // 令牌的 SHA-256 摘要
+	UserID    string    `json:"user_id" gorm:"type:uuid;not null"`     // 令牌所属用户
+	Revoked   bool      `json:"revoked" gorm:"not null;default:false"` // 已注销的访问令牌或已使用的挑战令牌
+	Attempts  int       `json:"attempts" gorm:"not null;default:0"`    // 挑战令牌的验证尝试次数
+	ExpiresAt time.Time `json:"expires_at" gorm:"not null;index"`      // 令牌过期时间，过期后记录可以删除
+}
+
+func (TokenState) TableName() string {
+	return "token_states"
 }
@@ -0,0 +1,82 @@
+package repository
+
+import (
+	"gorm.io/gorm"
+
+	"github.com/samzong/share-ai-platform/internal/models"
+)
+
+type categoryRepo struct {
+	db *gorm.DB
+}
+
+// NewCategoryRepo returns a CategoryRepo backed by the database
+func NewCategoryRepo(db *gorm.DB) CategoryRepo {
+	return &categoryRepo{db: db}
+}
+
+func (r *categoryRepo) FindByID(id string) (*models.Category, error) {
+	var category models.Category
+	if err := r.db.First(&category, "id = ?", id).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return &category, nil
+}
+
+func (r *categoryRepo) List() ([]models.Category, error) {
+	var categories []models.Category
+	err := r.db.Order("position ASC, name ASC").Find(&categories).Error
+	return categories, err
+}
+
+func (r *categoryRepo) FindBySlugs(slugs []string) ([]models.Category, error) {
+	var categories []models.Category
+	if len(slugs) == 0 {
+		return categories, nil
+	}
+	err := r.db.Where("slug IN ?", slugs).Order("position ASC, name ASC").Find(&categories).Error
+	return categories, err
+}
+
+func (r *categoryRepo) Create(category *models.Category) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := checkCategorySlug(tx, category); err != nil {
+			return err
+		}
+		return tx.Create(category).Error
+	})
+}
+
+func (r *categoryRepo) Save(category *models.Category) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := checkCategorySlug(tx, category); err != nil {
+			return err
+		}
+		return tx.Save(category).Error
+	})
+}
+
+// checkCategorySlug returns ErrDuplicate when another category has the same slug
+func checkCategorySlug(tx *gorm.DB, category *models.Category) error {
+	var count int64
+	query := tx.Model(&models.Category{}).Where("slug = ?", category.Slug)
+	if category.ID != "" {
+		query = query.Where("id <> ?", category.ID)
+	}
+	if err := query.Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrDuplicate
+	}
+	return nil
+}
+
+func (r *categoryRepo) Delete(category *models.Category) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Table("image_categories").Where("category_id = ?", category.ID).Delete(nil).Error; err != nil {
+			return err
+		}
+		return tx.Delete(category).Error
+	})
+}
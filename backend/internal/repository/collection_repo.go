@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/samzong/share-ai-platform/internal/models"
+)
+
+type collectionRepo struct {
+	db *gorm.DB
+}
+
+// NewCollectionRepo returns a CollectionRepo backed by the database
+func NewCollectionRepo(db *gorm.DB) CollectionRepo {
+	return &collectionRepo{db: db}
+}
+
+func (r *collectionRepo) Exists(userID string, imageID string) (bool, error) {
+	var count int64
+	err := r.db.Model(&models.Collection{}).Where("user_id = ? AND image_id = ?", userID, imageID).Count(&count).Error
+	return count > 0, err
+}
+
+func (r *collectionRepo) Add(collection *models.Collection) (bool, error) {
+	added := false
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		// 主键冲突说明已经收藏过，不重复计数
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(collection)
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		added = true
+
+		list, err := defaultList(tx, collection.UserID)
+		if err != nil {
+			return err
+		}
+		if _, err := appendItem(tx, &models.ListItem{ListID: list.ID, ImageID: collection.ImageID}); err != nil {
+			return err
+		}
+		// 在数据库中自增，避免并发收藏时读取-修改-写入丢失更新；不修改 updated_at
+		return tx.Model(&models.Image{}).Where("id = ?", collection.ImageID).
+			UpdateColumn("stars", gorm.Expr("stars + 1")).Error
+	})
+	return added, err
+}
+
+func (r *collectionRepo) Remove(userID string, imageID string) (bool, error) {
+	removed := false
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("user_id = ? AND image_id = ?", userID, imageID).Delete(&models.Collection{})
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		removed = true
+
+		defaults := tx.Model(&models.List{}).Select("id").Where("owner_id = ? AND is_default = ?", userID, true)
+		if err := tx.Where("list_id IN (?) AND image_id = ?", defaults, imageID).Delete(&models.ListItem{}).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.Image{}).Where("id = ? AND stars > 0", imageID).
+			UpdateColumn("stars", gorm.Expr("stars - 1")).Error
+	})
+	return removed, err
+}
+
+func (r *collectionRepo) StarredImageIDs(userID string, imageIDs []string) ([]string, error) {
+	var starred []string
+	if len(imageIDs) == 0 {
+		return starred, nil
+	}
+	err := r.db.Model(&models.Collection{}).Where("user_id = ? AND image_id IN ?", userID, imageIDs).Pluck("image_id", &starred).Error
+	return starred, err
+}
+
+func (r *collectionRepo) CreatedSince(since time.Time) ([]models.Collection, error) {
+	var collections []models.Collection
+	err := r.db.Select("user_id", "image_id", "created_at").Where("created_at >= ?", since).Find(&collections).Error
+	return collections, err
+}
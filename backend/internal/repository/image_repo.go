@@ -0,0 +1,187 @@
+package repository
+
+import (
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/samzong/share-ai-platform/internal/models"
+)
+
+type imageRepo struct {
+	db *gorm.DB
+}
+
+// NewImageRepo returns an ImageRepo backed by the database
+func NewImageRepo(db *gorm.DB) ImageRepo {
+	return &imageRepo{db: db}
+}
+
+func (r *imageRepo) FindByID(id string) (*models.Image, error) {
+	var image models.Image
+	if err := r.db.Preload("Labels").Preload("Categories").First(&image, "id = ?", id).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return &image, nil
+}
+
+func (r *imageRepo) FindInOrg(orgID string, id string) (*models.Image, error) {
+	var image models.Image
+	if err := r.db.First(&image, "id = ? AND org_id = ?", id, orgID).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return &image, nil
+}
+
+func (r *imageRepo) List(filter ImageFilter) ([]models.Image, int64, error) {
+	query := scopeImages(r.db, r.db.Model(&models.Image{}), filter.Scope)
+
+	if filter.IDs != nil {
+		query = query.Where("images.id IN ?", filter.IDs)
+	}
+	if filter.StarredBy != "" {
+		starred := r.db.Model(&models.Collection{}).Select("image_id").Where("user_id = ?", filter.StarredBy)
+		query = query.Where("images.id IN (?)", starred)
+	}
+	if filter.Search != "" {
+		// LOWER + LIKE 代替 PostgreSQL 特有的 ILIKE，在 SQLite 上同样不区分大小写
+		pattern := "%" + strings.ToLower(filter.Search) + "%"
+		query = query.Where("LOWER(images.name) LIKE ? OR LOWER(images.description) LIKE ?", pattern, pattern)
+	}
+	if filter.ProjectID != "" {
+		query = query.Where("images.project_id = ?", filter.ProjectID)
+	}
+	// 必须带有全部指定标签
+	if len(filter.Labels) > 0 {
+		labelled := r.db.Table("image_labels").
+			Select("image_id").
+			Where("label_id IN ?", filter.Labels).
+			Group("image_id").
+			Having("COUNT(DISTINCT label_id) = ?", len(filter.Labels))
+		query = query.Where("images.id IN (?)", labelled)
+	}
+	if len(filter.Categories) > 0 {
+		categorized := r.db.Table("image_categories").Select("image_id").Where("category_id IN ?", filter.Categories)
+		query = query.Where("images.id IN (?)", categorized)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	switch filter.Sort {
+	case "stars":
+		query = query.Order("images.stars DESC")
+	case "updated_at":
+		query = query.Order("images.updated_at DESC")
+	default:
+		query = query.Order("images.created_at DESC")
+	}
+
+	var images []models.Image
+	if err := query.Offset(filter.Offset).Limit(filter.Limit).Preload("Labels").Preload("Categories").Find(&images).Error; err != nil {
+		return nil, 0, err
+	}
+	return images, total, nil
+}
+
+// scopeImages restricts an images query to the images visible in the scope
+func scopeImages(db *gorm.DB, query *gorm.DB, scope ImageScope) *gorm.DB {
+	if scope.All {
+		return query
+	}
+
+	privateProjects := db.Model(&models.Project{}).Select("id").Where("visibility = ?", models.VisibilityPrivate)
+	visible := db.Where("images.visibility <> ? AND (images.project_id IS NULL OR images.project_id NOT IN (?))",
+		models.VisibilityPrivate, privateProjects)
+	if scope.UserID != "" {
+		visible = visible.Or("images.author = ?", scope.UserID)
+		if len(scope.OrgIDs) > 0 {
+			visible = visible.Or("images.org_id IN ?", scope.OrgIDs)
+		}
+		if len(scope.ProjectIDs) > 0 {
+			visible = visible.Or("images.project_id IN ?", scope.ProjectIDs)
+		}
+	}
+	return query.Where(visible)
+}
+
+func (r *imageRepo) Create(image *models.Image) error {
+	return r.db.Create(image).Error
+}
+
+func (r *imageRepo) Update(image *models.Image, labels []models.Label, categories []models.Category) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit(clause.Associations).Save(image).Error; err != nil {
+			return err
+		}
+		if labels != nil {
+			if err := tx.Model(image).Association("Labels").Replace(labels); err != nil {
+				return err
+			}
+			image.Labels = labels
+		}
+		if categories != nil {
+			if err := tx.Model(image).Association("Categories").Replace(categories); err != nil {
+				return err
+			}
+			image.Categories = categories
+		}
+		return nil
+	})
+}
+
+func (r *imageRepo) Delete(image *models.Image) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("image_id = ?", image.ID).Delete(&models.Collection{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("image_id = ?", image.ID).Delete(&models.ListItem{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(image).Association("Labels").Clear(); err != nil {
+			return err
+		}
+		if err := tx.Model(image).Association("Categories").Clear(); err != nil {
+			return err
+		}
+		return tx.Delete(image).Error
+	})
+}
+
+func (r *imageRepo) ProjectVisibilities(projectIDs []string) (map[string]string, error) {
+	visibilities := make(map[string]string, len(projectIDs))
+	if len(projectIDs) == 0 {
+		return visibilities, nil
+	}
+
+	var projects []models.Project
+	if err := r.db.Select("id", "visibility").Where("id IN ?", projectIDs).Find(&projects).Error; err != nil {
+		return nil, err
+	}
+	for _, p := range projects {
+		visibilities[p.ID] = p.Visibility
+	}
+	return visibilities, nil
+}
+
+func (r *imageRepo) GrantedImageIDs(userID string, groupIDs []string, imageIDs []string) ([]string, error) {
+	var granted []string
+	if len(imageIDs) == 0 {
+		return granted, nil
+	}
+
+	// 授权可以直接给用户，也可以给用户所在的用户组
+	subject := r.db.Where("user_id = ?", userID)
+	if len(groupIDs) > 0 {
+		subject = subject.Or("group_id IN ?", groupIDs)
+	}
+
+	err := r.db.Model(&models.AccessRequest{}).
+		Where(subject).
+		Where("status = ? AND image_id IN ?", models.AccessRequestApproved, imageIDs).
+		Pluck("image_id", &granted).Error
+	return granted, err
+}
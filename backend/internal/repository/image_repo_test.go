@@ -0,0 +1,130 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/samzong/share-ai-platform/internal/database"
+	"github.com/samzong/share-ai-platform/internal/migrations"
+	"github.com/samzong/share-ai-platform/internal/models"
+)
+
+// newSQLiteRepos returns gorm repositories over a migrated in-memory SQLite database
+func newSQLiteRepos(t *testing.T) *Repositories {
+	db, err := database.OpenSQLite(":memory:")
+	require.NoError(t, err)
+	require.NoError(t, database.MigrateDB(context.Background(), db, migrations.SQLite))
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	return NewGorm(db)
+}
+
+func TestGormImageListOnSQLite(t *testing.T) {
+	repos := newSQLiteRepos(t)
+
+	owner := &models.User{Username: "owner", Email: "owner@example.com", Password: "secret"}
+	require.NoError(t, repos.Users.Create(owner))
+	assert.NotEmpty(t, owner.ID)
+
+	labels, err := repos.Labels.FindOrCreate([]string{"llm", "gpu"})
+	require.NoError(t, err)
+
+	newImage := func(name, description, visibility string, labels []models.Label) *models.Image {
+		img := &models.Image{
+			OrgID: "org", Name: name, Description: description, Author: owner.ID,
+			Registry: "docker.io", Namespace: "library", Repository: name, Tag: "latest",
+			Digest: "sha256:" + name, Visibility: visibility, Platform: "linux/amd64", Labels: labels,
+		}
+		require.NoError(t, repos.Images.Create(img))
+		return img
+	}
+	llama := newImage("Llama", "Large language model", models.VisibilityPublic, labels)
+	newImage("whisper", "Speech to text", models.VisibilityPublic, labels[1:])
+	newImage("secret", "Internal LLM", models.VisibilityPrivate, labels)
+
+	// 搜索不区分大小写
+	images, total, err := repos.Images.List(ImageFilter{Search: "llAMa", Limit: 10})
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, total)
+	require.Len(t, images, 1)
+	assert.Equal(t, llama.ID, images[0].ID)
+	assert.Len(t, images[0].Labels, 2)
+
+	// 匿名用户看不到私有镜像，作者可以看到
+	_, total, err = repos.Images.List(ImageFilter{Labels: []string{labels[0].ID, labels[1].ID}, Limit: 10})
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, total)
+	_, total, err = repos.Images.List(ImageFilter{Scope: ImageScope{UserID: owner.ID}, Labels: []string{labels[0].ID, labels[1].ID}, Limit: 10})
+	require.NoError(t, err)
+	assert.EqualValues(t, 2, total)
+
+	added, err := repos.Collections.Add(&models.Collection{UserID: owner.ID, ImageID: llama.ID})
+	require.NoError(t, err)
+	assert.True(t, added)
+	images, _, err = repos.Images.List(ImageFilter{StarredBy: owner.ID, Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, images, 1)
+	assert.Equal(t, llama.ID, images[0].ID)
+
+	require.NoError(t, repos.Images.Delete(llama))
+	_, err = repos.Images.FindByID(llama.ID)
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestGormCollectionsCountStarsOnce(t *testing.T) {
+	repos := newSQLiteRepos(t)
+	image := &models.Image{OrgID: "org", Name: "nginx", Author: "author", Registry: "docker.io",
+		Namespace: "library", Repository: "nginx", Tag: "latest", Digest: "sha256:nginx", Platform: "linux/amd64"}
+	require.NoError(t, repos.Images.Create(image))
+
+	for _, userID := range []string{"u1", "u1", "u2"} {
+		_, err := repos.Collections.Add(&models.Collection{UserID: userID, ImageID: image.ID})
+		require.NoError(t, err)
+	}
+	stored, err := repos.Images.FindByID(image.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 2, stored.Stars)
+
+	for _, userID := range []string{"u1", "u1"} {
+		_, err := repos.Collections.Remove(userID, image.ID)
+		require.NoError(t, err)
+	}
+	stored, err = repos.Images.FindByID(image.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, stored.Stars)
+}
+
+func TestGormImageCategoriesOnSQLite(t *testing.T) {
+	repos := newSQLiteRepos(t)
+	nlp := &models.Category{Name: "NLP", Slug: "nlp"}
+	require.NoError(t, repos.Categories.Create(nlp))
+	assert.ErrorIs(t, repos.Categories.Create(&models.Category{Name: "Other NLP", Slug: "nlp"}), ErrDuplicate)
+	vision := &models.Category{Name: "Vision", Slug: "vision"}
+	require.NoError(t, repos.Categories.Create(vision))
+
+	image := &models.Image{OrgID: "org", Name: "bert", Author: "author", Registry: "docker.io",
+		Namespace: "library", Repository: "bert", Tag: "latest", Digest: "sha256:bert", Platform: "linux/amd64",
+		Categories: []models.Category{*nlp}}
+	require.NoError(t, repos.Images.Create(image))
+
+	_, total, err := repos.Images.List(ImageFilter{Categories: []string{nlp.ID, vision.ID}, Limit: 10})
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, total)
+
+	require.NoError(t, repos.Images.Update(image, nil, []models.Category{*vision}))
+	stored, err := repos.Images.FindByID(image.ID)
+	require.NoError(t, err)
+	require.Len(t, stored.Categories, 1)
+	assert.Equal(t, "vision", stored.Categories[0].Slug)
+
+	require.NoError(t, repos.Categories.Delete(vision))
+	_, total, err = repos.Images.List(ImageFilter{Categories: []string{vision.ID}, Limit: 10})
+	require.NoError(t, err)
+	assert.Zero(t, total)
+}
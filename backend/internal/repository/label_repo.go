@@ -0,0 +1,257 @@
+package repository
+
+import (
+	"errors"
+	"sort"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/samzong/share-ai-platform/internal/models"
+)
+
+type labelRepo struct {
+	db *gorm.DB
+}
+
+// NewLabelRepo returns a LabelRepo backed by the database
+func NewLabelRepo(db *gorm.DB) LabelRepo {
+	return &labelRepo{db: db}
+}
+
+func (r *labelRepo) FindByID(id string) (*models.Label, error) {
+	var label models.Label
+	if err := r.db.First(&label, "id = ?", id).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return &label, nil
+}
+
+func (r *labelRepo) FindOrCreate(names []string) ([]models.Label, error) {
+	return r.resolve(names, true)
+}
+
+func (r *labelRepo) Resolve(names []string) ([]models.Label, error) {
+	return r.resolve(names, false)
+}
+
+func (r *labelRepo) resolve(names []string, create bool) ([]models.Label, error) {
+	labels := make([]models.Label, 0, len(names))
+	seen := make(map[string]bool, len(names))
+	for _, name := range names {
+		key := models.LabelKey(name)
+		if key == "" {
+			continue
+		}
+
+		label, err := findLabel(r.db, key)
+		if errors.Is(err, ErrNotFound) && create {
+			label = &models.Label{}
+			err = r.db.Where("normalized = ?", key).
+				FirstOrCreate(label, models.Label{Name: models.NormalizeLabelName(name), Normalized: key}).Error
+		}
+		if errors.Is(err, ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if !seen[label.ID] {
+			seen[label.ID] = true
+			labels = append(labels, *label)
+		}
+	}
+	return labels, nil
+}
+
+// findLabel returns the label whose normalized name or alias is key. It uses Find
+// rather than First because a miss is expected and should not be logged as an error.
+func findLabel(tx *gorm.DB, key string) (*models.Label, error) {
+	var labels []models.Label
+	if err := tx.Where("normalized = ?", key).Limit(1).Find(&labels).Error; err != nil {
+		return nil, err
+	}
+	if len(labels) == 0 {
+		err := tx.Joins("JOIN label_aliases ON label_aliases.label_id = labels.id").
+			Where("label_aliases.alias = ?", key).
+			Limit(1).Find(&labels).Error
+		if err != nil {
+			return nil, err
+		}
+	}
+	if len(labels) == 0 {
+		return nil, ErrNotFound
+	}
+	return &labels[0], nil
+}
+
+func (r *labelRepo) Usage(scope ImageScope, search string) ([]LabelUsage, error) {
+	visible := scopeImages(r.db, r.db.Model(&models.Image{}).Select("images.id"), scope)
+	var counts []struct {
+		LabelID string
+		Images  int64
+	}
+	err := r.db.Table("image_labels").
+		Select("label_id, COUNT(*) AS images").
+		Where("image_id IN (?)", visible).
+		Group("label_id").
+		Scan(&counts).Error
+	if err != nil {
+		return nil, err
+	}
+	byLabel := make(map[string]int64, len(counts))
+	for _, c := range counts {
+		byLabel[c.LabelID] = c.Images
+	}
+
+	query := r.db.Model(&models.Label{})
+	if key := models.LabelKey(search); key != "" {
+		query = query.Where("normalized LIKE ?", "%"+key+"%")
+	}
+	var labels []models.Label
+	if err := query.Find(&labels).Error; err != nil {
+		return nil, err
+	}
+
+	return labelUsage(labels, byLabel, scope.All), nil
+}
+
+// labelUsage pairs labels with their image counts, most used first, dropping unused
+// labels unless includeUnused is set
+func labelUsage(labels []models.Label, counts map[string]int64, includeUnused bool) []LabelUsage {
+	usage := make([]LabelUsage, 0, len(labels))
+	for _, label := range labels {
+		if counts[label.ID] > 0 || includeUnused {
+			usage = append(usage, LabelUsage{Label: label, Images: counts[label.ID]})
+		}
+	}
+	sort.Slice(usage, func(i, j int) bool {
+		if usage[i].Images != usage[j].Images {
+			return usage[i].Images > usage[j].Images
+		}
+		return usage[i].Label.Normalized < usage[j].Label.Normalized
+	})
+	return usage
+}
+
+func (r *labelRepo) Aliases(labelIDs []string) (map[string][]string, error) {
+	aliases := make(map[string][]string, len(labelIDs))
+	if len(labelIDs) == 0 {
+		return aliases, nil
+	}
+
+	var rows []models.LabelAlias
+	if err := r.db.Where("label_id IN ?", labelIDs).Order("alias ASC").Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		aliases[row.LabelID] = append(aliases[row.LabelID], row.Alias)
+	}
+	return aliases, nil
+}
+
+func (r *labelRepo) Rename(label *models.Label, name string) error {
+	key := models.LabelKey(name)
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if key != label.Normalized {
+			if err := checkLabelKey(tx, key, label.ID); err != nil {
+				return err
+			}
+			// 新名称原本是该标签的别名时不再需要保留
+			if err := tx.Where("alias = ?", key).Delete(&models.LabelAlias{}).Error; err != nil {
+				return err
+			}
+			alias := models.LabelAlias{Alias: label.Normalized, LabelID: label.ID}
+			if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&alias).Error; err != nil {
+				return err
+			}
+		}
+
+		label.Name = models.NormalizeLabelName(name)
+		label.Normalized = key
+		return tx.Save(label).Error
+	})
+}
+
+// checkLabelKey returns ErrDuplicate when key is the name of another label or an alias
+// of another label
+func checkLabelKey(tx *gorm.DB, key string, labelID string) error {
+	existing, err := findLabel(tx, key)
+	if errors.Is(err, ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if existing.ID != labelID {
+		return ErrDuplicate
+	}
+	return nil
+}
+
+func (r *labelRepo) Merge(target *models.Label, sources []models.Label) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		for _, source := range sources {
+			if source.ID == target.ID {
+				continue
+			}
+			// 同时带有两个标签的镜像只保留一条关联
+			err := tx.Exec(`INSERT INTO image_labels (image_id, label_id)
+				SELECT image_id, ? FROM image_labels WHERE label_id = ?
+				ON CONFLICT DO NOTHING`, target.ID, source.ID).Error
+			if err != nil {
+				return err
+			}
+			if err := tx.Table("image_labels").Where("label_id = ?", source.ID).Delete(nil).Error; err != nil {
+				return err
+			}
+			err = tx.Model(&models.LabelAlias{}).Where("label_id = ?", source.ID).Update("label_id", target.ID).Error
+			if err != nil {
+				return err
+			}
+			alias := models.LabelAlias{Alias: source.Normalized, LabelID: target.ID}
+			if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&alias).Error; err != nil {
+				return err
+			}
+			if err := tx.Delete(&models.Label{}, "id = ?", source.ID).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (r *labelRepo) AddAlias(labelID string, alias string) error {
+	key := models.LabelKey(alias)
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		existing, err := findLabel(tx, key)
+		if err == nil {
+			if existing.ID == labelID && existing.Normalized != key {
+				return nil
+			}
+			return ErrDuplicate
+		}
+		if !errors.Is(err, ErrNotFound) {
+			return err
+		}
+		return tx.Create(&models.LabelAlias{Alias: key, LabelID: labelID}).Error
+	})
+}
+
+func (r *labelRepo) RemoveAlias(labelID string, alias string) (bool, error) {
+	result := r.db.Where("label_id = ? AND alias = ?", labelID, models.LabelKey(alias)).Delete(&models.LabelAlias{})
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *labelRepo) Delete(label *models.Label) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Table("image_labels").Where("label_id = ?", label.ID).Delete(nil).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("label_id = ?", label.ID).Delete(&models.LabelAlias{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(label).Error
+	})
+}
@@ -0,0 +1,51 @@
+package repository
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/samzong/share-ai-platform/internal/models"
+)
+
+func TestGormLabelMergeOnSQLite(t *testing.T) {
+	repos := newSQLiteRepos(t)
+	labels, err := repos.Labels.FindOrCreate([]string{"PyTorch", "pytorch ", "torch"})
+	require.NoError(t, err)
+	require.Len(t, labels, 2)
+	target, source := labels[0], labels[1]
+
+	newImage := func(name string, labels ...models.Label) *models.Image {
+		image := &models.Image{OrgID: "org", Name: name, Author: "author", Registry: "docker.io",
+			Namespace: "library", Repository: name, Tag: "latest", Digest: "sha256:" + name,
+			Platform: "linux/amd64", Labels: labels}
+		require.NoError(t, repos.Images.Create(image))
+		return image
+	}
+	both := newImage("both", target, source)
+	newImage("legacy", source)
+
+	require.NoError(t, repos.Labels.AddAlias(source.ID, "pt"))
+	require.NoError(t, repos.Labels.Merge(&target, []models.Label{source}))
+
+	stored, err := repos.Images.FindByID(both.ID)
+	require.NoError(t, err)
+	require.Len(t, stored.Labels, 1)
+	assert.Equal(t, target.ID, stored.Labels[0].ID)
+
+	aliases, err := repos.Labels.Aliases([]string{target.ID})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"pt", "torch"}, aliases[target.ID])
+	resolved, err := repos.Labels.Resolve([]string{"Torch"})
+	require.NoError(t, err)
+	require.Len(t, resolved, 1)
+	assert.Equal(t, target.ID, resolved[0].ID)
+
+	usage, err := repos.Labels.Usage(ImageScope{}, "py")
+	require.NoError(t, err)
+	require.Len(t, usage, 1)
+	assert.Equal(t, int64(2), usage[0].Images)
+
+	assert.ErrorIs(t, repos.Labels.Rename(&models.Label{ID: "other", Normalized: "other"}, "TORCH"), ErrDuplicate)
+}
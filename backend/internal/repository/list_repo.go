@@ -0,0 +1,210 @@
+package repository
+
+import (
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/samzong/share-ai-platform/internal/models"
+)
+
+type listRepo struct {
+	db *gorm.DB
+}
+
+// NewListRepo returns a ListRepo backed by the database
+func NewListRepo(db *gorm.DB) ListRepo {
+	return &listRepo{db: db}
+}
+
+func (r *listRepo) FindByID(id string) (*models.List, error) {
+	var list models.List
+	if err := r.db.Where("id = ?", id).First(&list).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return &list, nil
+}
+
+func (r *listRepo) Default(userID string) (*models.List, error) {
+	return defaultList(r.db, userID)
+}
+
+// defaultList returns the user's default list, creating it when missing
+func defaultList(db *gorm.DB, userID string) (*models.List, error) {
+	list := models.List{}
+	err := db.Where("owner_id = ? AND is_default = ?", userID, true).
+		Attrs(models.List{OwnerID: userID, Name: models.DefaultListName, Visibility: models.ListVisibilityPrivate, IsDefault: true}).
+		FirstOrCreate(&list).Error
+	if err != nil {
+		return nil, err
+	}
+	return &list, nil
+}
+
+func (r *listRepo) ListByOwner(ownerID string) ([]models.List, error) {
+	var lists []models.List
+	err := r.db.Where("owner_id = ?", ownerID).Order("is_default DESC, name ASC").Find(&lists).Error
+	return lists, err
+}
+
+func (r *listRepo) Following(userID string) ([]models.List, error) {
+	var lists []models.List
+	err := r.db.Joins("JOIN list_follows ON list_follows.list_id = lists.id").
+		Where("list_follows.user_id = ?", userID).
+		Order("list_follows.created_at DESC").
+		Find(&lists).Error
+	return lists, err
+}
+
+func (r *listRepo) Create(list *models.List) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := checkListName(tx, list); err != nil {
+			return err
+		}
+		return tx.Create(list).Error
+	})
+}
+
+func (r *listRepo) Save(list *models.List) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := checkListName(tx, list); err != nil {
+			return err
+		}
+		return tx.Save(list).Error
+	})
+}
+
+// checkListName returns ErrDuplicate when another list of the owner has the same name
+func checkListName(tx *gorm.DB, list *models.List) error {
+	var count int64
+	query := tx.Model(&models.List{}).Where("owner_id = ? AND name = ?", list.OwnerID, list.Name)
+	if list.ID != "" {
+		query = query.Where("id <> ?", list.ID)
+	}
+	if err := query.Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrDuplicate
+	}
+	return nil
+}
+
+func (r *listRepo) Delete(list *models.List) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("list_id = ?", list.ID).Delete(&models.ListItem{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("list_id = ?", list.ID).Delete(&models.ListFollow{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(list).Error
+	})
+}
+
+func (r *listRepo) Items(listID string) ([]models.ListItem, error) {
+	var items []models.ListItem
+	err := r.db.Where("list_id = ?", listID).Order("position ASC, created_at ASC").Find(&items).Error
+	return items, err
+}
+
+func (r *listRepo) AddItem(item *models.ListItem) (bool, error) {
+	added := false
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		added, err = appendItem(tx, item)
+		return err
+	})
+	return added, err
+}
+
+// appendItem inserts the item after the last item of its list unless the image is already in it
+func appendItem(tx *gorm.DB, item *models.ListItem) (bool, error) {
+	var last *int
+	if err := tx.Model(&models.ListItem{}).Where("list_id = ?", item.ListID).Select("MAX(position)").Scan(&last).Error; err != nil {
+		return false, err
+	}
+	item.Position = 0
+	if last != nil {
+		item.Position = *last + 1
+	}
+	result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(item)
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *listRepo) SetNote(listID string, imageID string, note string) error {
+	result := r.db.Model(&models.ListItem{}).Where("list_id = ? AND image_id = ?", listID, imageID).Update("note", note)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *listRepo) RemoveItem(listID string, imageID string) (bool, error) {
+	result := r.db.Where("list_id = ? AND image_id = ?", listID, imageID).Delete(&models.ListItem{})
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *listRepo) Reorder(listID string, imageIDs []string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		for i, imageID := range imageIDs {
+			err := tx.Model(&models.ListItem{}).Where("list_id = ? AND image_id = ?", listID, imageID).
+				UpdateColumn("position", i).Error
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (r *listRepo) Follow(listID string, userID string) error {
+	return r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&models.ListFollow{ListID: listID, UserID: userID}).Error
+}
+
+func (r *listRepo) Unfollow(listID string, userID string) error {
+	return r.db.Where("list_id = ? AND user_id = ?", listID, userID).Delete(&models.ListFollow{}).Error
+}
+
+func (r *listRepo) FollowedIDs(userID string, listIDs []string) ([]string, error) {
+	var followed []string
+	if len(listIDs) == 0 {
+		return followed, nil
+	}
+	err := r.db.Model(&models.ListFollow{}).Where("user_id = ? AND list_id IN ?", userID, listIDs).Pluck("list_id", &followed).Error
+	return followed, err
+}
+
+func (r *listRepo) Counts(listIDs []string) (map[string]ListCounts, error) {
+	counts := make(map[string]ListCounts, len(listIDs))
+	if len(listIDs) == 0 {
+		return counts, nil
+	}
+
+	type row struct {
+		ListID string
+		Count  int64
+	}
+	var items, followers []row
+	if err := r.db.Model(&models.ListItem{}).Select("list_id, COUNT(*) AS count").
+		Where("list_id IN ?", listIDs).Group("list_id").Scan(&items).Error; err != nil {
+		return nil, err
+	}
+	if err := r.db.Model(&models.ListFollow{}).Select("list_id, COUNT(*) AS count").
+		Where("list_id IN ?", listIDs).Group("list_id").Scan(&followers).Error; err != nil {
+		return nil, err
+	}
+	for _, item := range items {
+		c := counts[item.ListID]
+		c.Items = item.Count
+		counts[item.ListID] = c
+	}
+	for _, follower := range followers {
+		c := counts[follower.ListID]
+		c.Followers = follower.Count
+		counts[follower.ListID] = c
+	}
+	return counts, nil
+}
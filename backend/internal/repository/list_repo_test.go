@@ -0,0 +1,62 @@
+package repository
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/samzong/share-ai-platform/internal/models"
+)
+
+func TestGormListsOnSQLite(t *testing.T) {
+	repos := newSQLiteRepos(t)
+	var images []*models.Image
+	for _, name := range []string{"nginx", "redis"} {
+		image := &models.Image{OrgID: "org", Name: name, Author: "author", Registry: "docker.io",
+			Namespace: "library", Repository: name, Tag: "latest", Digest: "sha256:" + name, Platform: "linux/amd64"}
+		require.NoError(t, repos.Images.Create(image))
+		images = append(images, image)
+	}
+
+	// 收藏写入默认列表
+	_, err := repos.Collections.Add(&models.Collection{UserID: "u1", ImageID: images[0].ID})
+	require.NoError(t, err)
+	favorites, err := repos.Lists.Default("u1")
+	require.NoError(t, err)
+	items, err := repos.Lists.Items(favorites.ID)
+	require.NoError(t, err)
+	require.Len(t, items, 1)
+	assert.Equal(t, images[0].ID, items[0].ImageID)
+
+	list := &models.List{OwnerID: "u1", Name: "Serving", Visibility: models.ListVisibilityPublic}
+	require.NoError(t, repos.Lists.Create(list))
+	assert.ErrorIs(t, repos.Lists.Create(&models.List{OwnerID: "u1", Name: "Serving"}), ErrDuplicate)
+
+	for _, image := range images {
+		added, err := repos.Lists.AddItem(&models.ListItem{ListID: list.ID, ImageID: image.ID})
+		require.NoError(t, err)
+		assert.True(t, added)
+	}
+	added, err := repos.Lists.AddItem(&models.ListItem{ListID: list.ID, ImageID: images[0].ID})
+	require.NoError(t, err)
+	assert.False(t, added)
+
+	require.NoError(t, repos.Lists.Reorder(list.ID, []string{images[1].ID, images[0].ID}))
+	items, err = repos.Lists.Items(list.ID)
+	require.NoError(t, err)
+	require.Len(t, items, 2)
+	assert.Equal(t, images[1].ID, items[0].ImageID)
+
+	require.NoError(t, repos.Lists.Follow(list.ID, "u2"))
+	require.NoError(t, repos.Lists.Follow(list.ID, "u2"))
+	counts, err := repos.Lists.Counts([]string{list.ID, favorites.ID})
+	require.NoError(t, err)
+	assert.Equal(t, ListCounts{Items: 2, Followers: 1}, counts[list.ID])
+	assert.Equal(t, ListCounts{Items: 1}, counts[favorites.ID])
+
+	require.NoError(t, repos.Lists.Delete(list))
+	following, err := repos.Lists.Following("u2")
+	require.NoError(t, err)
+	assert.Empty(t, following)
+}
@@ -0,0 +1,1224 @@
+package repository
+
+import (
+	"errors"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/samzong/share-ai-platform/internal/models"
+)
+
+// Memory holds in-memory repositories sharing one store. It is meant for tests that
+// should not depend on a database.
+type Memory struct {
+	Repositories
+
+	mu          sync.Mutex
+	users       map[string]models.User
+	images      map[string]models.Image
+	labels      map[string]models.Label
+	aliases     map[string]string // 规范化的别名 -> 标签ID
+	categories  map[string]models.Category
+	collections map[string]models.Collection
+	lists       map[string]models.List
+	listItems   map[string]models.ListItem   // 键为 列表ID/镜像ID
+	listFollows map[string]models.ListFollow // 键为 列表ID/用户ID
+	orgs        map[string]bool              // 已存在的组织ID
+	projects    map[string]models.Project    // 项目ID -> 项目，镜像继承其可见性
+	grants      map[string]bool              // 已获批的访问，键为 主体ID/镜像ID
+	events      []models.ImageEvent
+	dailyStats  map[string]models.ImageDailyStat    // 键为 镜像ID/日期
+	similar     map[string][]models.ImageSimilarity // 镜像ID -> 相似镜像
+	tokens      map[string]models.TokenState        // 令牌摘要 -> 状态
+	objects     map[string]models.StoredObject      // 对象键 -> 跟踪记录
+}
+
+// NewMemory returns empty in-memory repositories
+func NewMemory() *Memory {
+	m := &Memory{
+		users:       make(map[string]models.User),
+		images:      make(map[string]models.Image),
+		labels:      make(map[string]models.Label),
+		aliases:     make(map[string]string),
+		categories:  make(map[string]models.Category),
+		collections: make(map[string]models.Collection),
+		lists:       make(map[string]models.List),
+		listItems:   make(map[string]models.ListItem),
+		listFollows: make(map[string]models.ListFollow),
+		orgs:        make(map[string]bool),
+		projects:    make(map[string]models.Project),
+		grants:      make(map[string]bool),
+		dailyStats:  make(map[string]models.ImageDailyStat),
+		similar:     make(map[string][]models.ImageSimilarity),
+		tokens:      make(map[string]models.TokenState),
+		objects:     make(map[string]models.StoredObject),
+	}
+	m.Repositories = Repositories{
+		Users:        memoryUsers{m},
+		Images:       memoryImages{m},
+		Labels:       memoryLabels{m},
+		Categories:   memoryCategories{m},
+		Collections:  memoryCollections{m},
+		Lists:        memoryLists{m},
+		Stats:        memoryStats{m},
+		Similarities: memorySimilarities{m},
+		Tokens:       memoryTokens{m},
+		Orgs:         memoryOrgs{m},
+		Objects:      memoryObjects{m},
+	}
+	return m
+}
+
+// AddOrg records that an organization exists
+func (m *Memory) AddOrg(orgID string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.orgs[orgID] = true
+}
+
+// AddProject records a project, whose visibility the images in it inherit
+func (m *Memory) AddProject(project models.Project) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.projects[project.ID] = project
+}
+
+// SetProjectVisibility records a project's visibility, which the images in it inherit
+func (m *Memory) SetProjectVisibility(projectID string, visibility string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	project := m.projects[projectID]
+	project.ID = projectID
+	project.Visibility = visibility
+	m.projects[projectID] = project
+}
+
+// Grant records an approved access request of a user or group to an image
+func (m *Memory) Grant(subjectID string, imageID string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.grants[subjectID+"/"+imageID] = true
+}
+
+type memoryUsers struct{ m *Memory }
+
+func (r memoryUsers) FindByID(id string) (*models.User, error) {
+	return r.find(func(u *models.User) bool { return u.ID == id })
+}
+
+func (r memoryUsers) FindByUsername(username string) (*models.User, error) {
+	return r.find(func(u *models.User) bool { return u.Username == username })
+}
+
+func (r memoryUsers) FindByEmail(email string) (*models.User, error) {
+	return r.find(func(u *models.User) bool { return u.Email == email })
+}
+
+func (r memoryUsers) find(match func(*models.User) bool) (*models.User, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	for _, user := range r.m.users {
+		if match(&user) {
+			return &user, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (r memoryUsers) Create(user *models.User) error {
+	// 与数据库一致，创建前哈希密码并填充默认值
+	if err := user.BeforeCreate(nil); err != nil {
+		return err
+	}
+
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	for _, existing := range r.m.users {
+		if existing.Username == user.Username || existing.Email == user.Email {
+			return errors.New("duplicate user")
+		}
+	}
+	if user.ID == "" {
+		user.ID = uuid.NewString()
+	}
+	now := time.Now()
+	user.CreatedAt, user.UpdatedAt = now, now
+	r.m.users[user.ID] = *user
+	return nil
+}
+
+func (r memoryUsers) Save(user *models.User) error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	if _, ok := r.m.users[user.ID]; !ok {
+		return ErrNotFound
+	}
+	user.UpdatedAt = time.Now()
+	r.m.users[user.ID] = *user
+	return nil
+}
+
+func (r memoryUsers) List(offset, limit int) ([]models.User, int64, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	users := make([]models.User, 0, len(r.m.users))
+	for _, user := range r.m.users {
+		users = append(users, user)
+	}
+	sort.Slice(users, func(i, j int) bool { return users[i].CreatedAt.Before(users[j].CreatedAt) })
+	return page(users, offset, limit), int64(len(users)), nil
+}
+
+type memoryImages struct{ m *Memory }
+
+func (r memoryImages) FindByID(id string) (*models.Image, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	image, ok := r.m.images[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &image, nil
+}
+
+func (r memoryImages) FindInOrg(orgID string, id string) (*models.Image, error) {
+	image, err := r.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if image.OrgID != orgID {
+		return nil, ErrNotFound
+	}
+	image.Labels = nil
+	image.Categories = nil
+	return image, nil
+}
+
+func (r memoryImages) List(filter ImageFilter) ([]models.Image, int64, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+
+	var images []models.Image
+	for _, image := range r.m.images {
+		if r.visible(&image, filter.Scope) && r.matches(&image, &filter) {
+			images = append(images, image)
+		}
+	}
+
+	sort.Slice(images, func(i, j int) bool {
+		a, b := images[i], images[j]
+		switch filter.Sort {
+		case "stars":
+			return a.Stars > b.Stars
+		case "updated_at":
+			return a.UpdatedAt.After(b.UpdatedAt)
+		default:
+			return a.CreatedAt.After(b.CreatedAt)
+		}
+	})
+	return page(images, filter.Offset, filter.Limit), int64(len(images)), nil
+}
+
+func (r memoryImages) visible(image *models.Image, scope ImageScope) bool {
+	if scope.All {
+		return true
+	}
+	inPrivateProject := image.ProjectID != nil && r.m.projects[*image.ProjectID].Visibility == models.VisibilityPrivate
+	if image.Visibility != models.VisibilityPrivate && !inPrivateProject {
+		return true
+	}
+	if scope.UserID == "" {
+		return false
+	}
+	if image.Author == scope.UserID || contains(scope.OrgIDs, image.OrgID) {
+		return true
+	}
+	return image.ProjectID != nil && contains(scope.ProjectIDs, *image.ProjectID)
+}
+
+func (r memoryImages) matches(image *models.Image, filter *ImageFilter) bool {
+	if filter.IDs != nil && !contains(filter.IDs, image.ID) {
+		return false
+	}
+	if filter.StarredBy != "" {
+		if _, ok := r.m.collections[pairKey(filter.StarredBy, image.ID)]; !ok {
+			return false
+		}
+	}
+	if filter.Search != "" {
+		search := strings.ToLower(filter.Search)
+		if !strings.Contains(strings.ToLower(image.Name), search) && !strings.Contains(strings.ToLower(image.Description), search) {
+			return false
+		}
+	}
+	if filter.ProjectID != "" && (image.ProjectID == nil || *image.ProjectID != filter.ProjectID) {
+		return false
+	}
+	for _, id := range filter.Labels {
+		found := false
+		for _, label := range image.Labels {
+			found = found || label.ID == id
+		}
+		if !found {
+			return false
+		}
+	}
+	if len(filter.Categories) > 0 {
+		found := false
+		for _, category := range image.Categories {
+			found = found || contains(filter.Categories, category.ID)
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+func (r memoryImages) Create(image *models.Image) error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	if image.ID == "" {
+		image.ID = uuid.NewString()
+	}
+	if image.Visibility == "" {
+		image.Visibility = models.VisibilityPublic
+	}
+	now := time.Now()
+	image.CreatedAt, image.UpdatedAt = now, now
+	r.m.images[image.ID] = *image
+	return nil
+}
+
+func (r memoryImages) Update(image *models.Image, labels []models.Label, categories []models.Category) error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	existing, ok := r.m.images[image.ID]
+	if !ok {
+		return ErrNotFound
+	}
+	if labels != nil {
+		image.Labels = labels
+	} else {
+		image.Labels = existing.Labels
+	}
+	if categories != nil {
+		image.Categories = categories
+	} else {
+		image.Categories = existing.Categories
+	}
+	image.UpdatedAt = time.Now()
+	r.m.images[image.ID] = *image
+	return nil
+}
+
+func (r memoryImages) Delete(image *models.Image) error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	for key, c := range r.m.collections {
+		if c.ImageID == image.ID {
+			delete(r.m.collections, key)
+		}
+	}
+	for key, item := range r.m.listItems {
+		if item.ImageID == image.ID {
+			delete(r.m.listItems, key)
+		}
+	}
+	for key, stat := range r.m.dailyStats {
+		if stat.ImageID == image.ID {
+			delete(r.m.dailyStats, key)
+		}
+	}
+	delete(r.m.similar, image.ID)
+	for id, similar := range r.m.similar {
+		r.m.similar[id] = slices.DeleteFunc(similar, func(s models.ImageSimilarity) bool { return s.SimilarID == image.ID })
+	}
+	delete(r.m.images, image.ID)
+	return nil
+}
+
+func (r memoryImages) ProjectVisibilities(projectIDs []string) (map[string]string, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	visibilities := make(map[string]string, len(projectIDs))
+	for _, id := range projectIDs {
+		if project, ok := r.m.projects[id]; ok {
+			visibilities[id] = project.Visibility
+		}
+	}
+	return visibilities, nil
+}
+
+func (r memoryImages) GrantedImageIDs(userID string, groupIDs []string, imageIDs []string) ([]string, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	var granted []string
+	for _, imageID := range imageIDs {
+		for _, subject := range append([]string{userID}, groupIDs...) {
+			if r.m.grants[subject+"/"+imageID] {
+				granted = append(granted, imageID)
+				break
+			}
+		}
+	}
+	return granted, nil
+}
+
+type memoryLabels struct{ m *Memory }
+
+func (r memoryLabels) FindByID(id string) (*models.Label, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	label, ok := r.m.labels[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &label, nil
+}
+
+func (r memoryLabels) FindOrCreate(names []string) ([]models.Label, error) {
+	return r.resolve(names, true), nil
+}
+
+func (r memoryLabels) Resolve(names []string) ([]models.Label, error) {
+	return r.resolve(names, false), nil
+}
+
+func (r memoryLabels) resolve(names []string, create bool) []models.Label {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	labels := make([]models.Label, 0, len(names))
+	seen := make(map[string]bool, len(names))
+	for _, name := range names {
+		key := models.LabelKey(name)
+		if key == "" {
+			continue
+		}
+		label, ok := r.findLocked(key)
+		if !ok && create {
+			now := time.Now()
+			label = models.Label{ID: uuid.NewString(), Name: models.NormalizeLabelName(name), Normalized: key, CreatedAt: now, UpdatedAt: now}
+			r.m.labels[label.ID] = label
+			ok = true
+		}
+		if ok && !seen[label.ID] {
+			seen[label.ID] = true
+			labels = append(labels, label)
+		}
+	}
+	return labels
+}
+
+func (r memoryLabels) findLocked(key string) (models.Label, bool) {
+	for _, label := range r.m.labels {
+		if label.Normalized == key {
+			return label, true
+		}
+	}
+	label, ok := r.m.labels[r.m.aliases[key]]
+	return label, ok
+}
+
+func (r memoryLabels) Usage(scope ImageScope, search string) ([]LabelUsage, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	counts := make(map[string]int64)
+	for _, image := range r.m.images {
+		if !(memoryImages{r.m}).visible(&image, scope) {
+			continue
+		}
+		for _, label := range image.Labels {
+			counts[label.ID]++
+		}
+	}
+
+	key := models.LabelKey(search)
+	var labels []models.Label
+	for _, label := range r.m.labels {
+		if strings.Contains(label.Normalized, key) {
+			labels = append(labels, label)
+		}
+	}
+	return labelUsage(labels, counts, scope.All), nil
+}
+
+func (r memoryLabels) Aliases(labelIDs []string) (map[string][]string, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	aliases := make(map[string][]string, len(labelIDs))
+	for alias, labelID := range r.m.aliases {
+		if contains(labelIDs, labelID) {
+			aliases[labelID] = append(aliases[labelID], alias)
+		}
+	}
+	for _, list := range aliases {
+		sort.Strings(list)
+	}
+	return aliases, nil
+}
+
+func (r memoryLabels) Rename(label *models.Label, name string) error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	key := models.LabelKey(name)
+	if key != label.Normalized {
+		if existing, ok := r.findLocked(key); ok && existing.ID != label.ID {
+			return ErrDuplicate
+		}
+		delete(r.m.aliases, key)
+		if _, ok := r.m.aliases[label.Normalized]; !ok {
+			r.m.aliases[label.Normalized] = label.ID
+		}
+	}
+	label.Name = models.NormalizeLabelName(name)
+	label.Normalized = key
+	label.UpdatedAt = time.Now()
+	r.m.labels[label.ID] = *label
+	r.replaceOnImagesLocked(label.ID, label)
+	return nil
+}
+
+func (r memoryLabels) Merge(target *models.Label, sources []models.Label) error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	for _, source := range sources {
+		if source.ID == target.ID {
+			continue
+		}
+		r.replaceOnImagesLocked(source.ID, target)
+		for alias, labelID := range r.m.aliases {
+			if labelID == source.ID {
+				r.m.aliases[alias] = target.ID
+			}
+		}
+		if _, ok := r.m.aliases[source.Normalized]; !ok {
+			r.m.aliases[source.Normalized] = target.ID
+		}
+		delete(r.m.labels, source.ID)
+	}
+	return nil
+}
+
+// replaceOnImagesLocked replaces the label with ID oldID on every image with label,
+// or removes it when label is nil, without duplicating a label an image already has
+func (r memoryLabels) replaceOnImagesLocked(oldID string, label *models.Label) {
+	for id, image := range r.m.images {
+		labels := make([]models.Label, 0, len(image.Labels))
+		for _, l := range image.Labels {
+			if l.ID == oldID {
+				if label == nil {
+					continue
+				}
+				l = *label
+			}
+			duplicate := false
+			for _, kept := range labels {
+				duplicate = duplicate || kept.ID == l.ID
+			}
+			if !duplicate {
+				labels = append(labels, l)
+			}
+		}
+		image.Labels = labels
+		r.m.images[id] = image
+	}
+}
+
+func (r memoryLabels) AddAlias(labelID string, alias string) error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	key := models.LabelKey(alias)
+	if existing, ok := r.findLocked(key); ok {
+		if existing.ID == labelID && existing.Normalized != key {
+			return nil
+		}
+		return ErrDuplicate
+	}
+	r.m.aliases[key] = labelID
+	return nil
+}
+
+func (r memoryLabels) RemoveAlias(labelID string, alias string) (bool, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	key := models.LabelKey(alias)
+	if r.m.aliases[key] != labelID {
+		return false, nil
+	}
+	delete(r.m.aliases, key)
+	return true, nil
+}
+
+func (r memoryLabels) Delete(label *models.Label) error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	r.replaceOnImagesLocked(label.ID, nil)
+	for alias, labelID := range r.m.aliases {
+		if labelID == label.ID {
+			delete(r.m.aliases, alias)
+		}
+	}
+	delete(r.m.labels, label.ID)
+	return nil
+}
+
+type memoryCategories struct{ m *Memory }
+
+func (r memoryCategories) FindByID(id string) (*models.Category, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	category, ok := r.m.categories[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &category, nil
+}
+
+func (r memoryCategories) List() ([]models.Category, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	categories := make([]models.Category, 0, len(r.m.categories))
+	for _, category := range r.m.categories {
+		categories = append(categories, category)
+	}
+	sortCategories(categories)
+	return categories, nil
+}
+
+func (r memoryCategories) FindBySlugs(slugs []string) ([]models.Category, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	var categories []models.Category
+	for _, category := range r.m.categories {
+		if contains(slugs, category.Slug) {
+			categories = append(categories, category)
+		}
+	}
+	sortCategories(categories)
+	return categories, nil
+}
+
+func sortCategories(categories []models.Category) {
+	sort.Slice(categories, func(i, j int) bool {
+		if categories[i].Position != categories[j].Position {
+			return categories[i].Position < categories[j].Position
+		}
+		return categories[i].Name < categories[j].Name
+	})
+}
+
+func (r memoryCategories) Create(category *models.Category) error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	if r.slugTakenLocked(category) {
+		return ErrDuplicate
+	}
+	if category.ID == "" {
+		category.ID = uuid.NewString()
+	}
+	now := time.Now()
+	category.CreatedAt, category.UpdatedAt = now, now
+	r.m.categories[category.ID] = *category
+	return nil
+}
+
+func (r memoryCategories) Save(category *models.Category) error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	if _, ok := r.m.categories[category.ID]; !ok {
+		return ErrNotFound
+	}
+	if r.slugTakenLocked(category) {
+		return ErrDuplicate
+	}
+	category.UpdatedAt = time.Now()
+	r.m.categories[category.ID] = *category
+	return nil
+}
+
+func (r memoryCategories) slugTakenLocked(category *models.Category) bool {
+	for _, other := range r.m.categories {
+		if other.Slug == category.Slug && other.ID != category.ID {
+			return true
+		}
+	}
+	return false
+}
+
+func (r memoryCategories) Delete(category *models.Category) error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	for id, image := range r.m.images {
+		kept := image.Categories[:0:0]
+		for _, c := range image.Categories {
+			if c.ID != category.ID {
+				kept = append(kept, c)
+			}
+		}
+		image.Categories = kept
+		r.m.images[id] = image
+	}
+	delete(r.m.categories, category.ID)
+	return nil
+}
+
+type memoryCollections struct{ m *Memory }
+
+func (r memoryCollections) Exists(userID string, imageID string) (bool, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	_, ok := r.m.collections[pairKey(userID, imageID)]
+	return ok, nil
+}
+
+func (r memoryCollections) Add(collection *models.Collection) (bool, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	key := pairKey(collection.UserID, collection.ImageID)
+	if _, ok := r.m.collections[key]; ok {
+		return false, nil
+	}
+	now := time.Now()
+	collection.CreatedAt, collection.UpdatedAt = now, now
+	r.m.collections[key] = *collection
+	r.m.appendItemLocked(&models.ListItem{ListID: r.m.defaultListLocked(collection.UserID).ID, ImageID: collection.ImageID})
+	r.m.addStars(collection.ImageID, 1)
+	return true, nil
+}
+
+func (r memoryCollections) Remove(userID string, imageID string) (bool, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	key := pairKey(userID, imageID)
+	if _, ok := r.m.collections[key]; !ok {
+		return false, nil
+	}
+	delete(r.m.collections, key)
+	delete(r.m.listItems, pairKey(r.m.defaultListLocked(userID).ID, imageID))
+	r.m.addStars(imageID, -1)
+	return true, nil
+}
+
+// addStars adjusts the stars of an image, never below zero. The caller holds the lock.
+func (m *Memory) addStars(imageID string, delta int) {
+	image, ok := m.images[imageID]
+	if !ok {
+		return
+	}
+	image.Stars += delta
+	if image.Stars < 0 {
+		image.Stars = 0
+	}
+	m.images[imageID] = image
+}
+
+func (r memoryCollections) StarredImageIDs(userID string, imageIDs []string) ([]string, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	var starred []string
+	for _, id := range imageIDs {
+		if _, ok := r.m.collections[pairKey(userID, id)]; ok {
+			starred = append(starred, id)
+		}
+	}
+	return starred, nil
+}
+
+func (r memoryCollections) CreatedSince(since time.Time) ([]models.Collection, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	var collections []models.Collection
+	for _, c := range r.m.collections {
+		if !c.CreatedAt.Before(since) {
+			collections = append(collections, c)
+		}
+	}
+	return collections, nil
+}
+
+type memoryLists struct{ m *Memory }
+
+func (r memoryLists) FindByID(id string) (*models.List, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	list, ok := r.m.lists[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &list, nil
+}
+
+func (r memoryLists) Default(userID string) (*models.List, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	list := r.m.defaultListLocked(userID)
+	return &list, nil
+}
+
+// defaultListLocked returns the user's default list, creating it when missing. The caller holds the lock.
+func (m *Memory) defaultListLocked(userID string) models.List {
+	for _, list := range m.lists {
+		if list.OwnerID == userID && list.IsDefault {
+			return list
+		}
+	}
+	now := time.Now()
+	list := models.List{ID: uuid.NewString(), OwnerID: userID, Name: models.DefaultListName,
+		Visibility: models.ListVisibilityPrivate, IsDefault: true, CreatedAt: now, UpdatedAt: now}
+	m.lists[list.ID] = list
+	return list
+}
+
+func (r memoryLists) ListByOwner(ownerID string) ([]models.List, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	var lists []models.List
+	for _, list := range r.m.lists {
+		if list.OwnerID == ownerID {
+			lists = append(lists, list)
+		}
+	}
+	sort.Slice(lists, func(i, j int) bool {
+		if lists[i].IsDefault != lists[j].IsDefault {
+			return lists[i].IsDefault
+		}
+		return lists[i].Name < lists[j].Name
+	})
+	return lists, nil
+}
+
+func (r memoryLists) Following(userID string) ([]models.List, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	var follows []models.ListFollow
+	for _, follow := range r.m.listFollows {
+		if follow.UserID == userID {
+			follows = append(follows, follow)
+		}
+	}
+	sort.Slice(follows, func(i, j int) bool { return follows[i].CreatedAt.After(follows[j].CreatedAt) })
+	lists := make([]models.List, 0, len(follows))
+	for _, follow := range follows {
+		lists = append(lists, r.m.lists[follow.ListID])
+	}
+	return lists, nil
+}
+
+func (r memoryLists) Create(list *models.List) error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	if r.nameTaken(list) {
+		return ErrDuplicate
+	}
+	if list.ID == "" {
+		list.ID = uuid.NewString()
+	}
+	now := time.Now()
+	list.CreatedAt, list.UpdatedAt = now, now
+	r.m.lists[list.ID] = *list
+	return nil
+}
+
+func (r memoryLists) Save(list *models.List) error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	if _, ok := r.m.lists[list.ID]; !ok {
+		return ErrNotFound
+	}
+	if r.nameTaken(list) {
+		return ErrDuplicate
+	}
+	list.UpdatedAt = time.Now()
+	r.m.lists[list.ID] = *list
+	return nil
+}
+
+func (r memoryLists) nameTaken(list *models.List) bool {
+	for _, other := range r.m.lists {
+		if other.ID != list.ID && other.OwnerID == list.OwnerID && other.Name == list.Name {
+			return true
+		}
+	}
+	return false
+}
+
+func (r memoryLists) Delete(list *models.List) error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	for key, item := range r.m.listItems {
+		if item.ListID == list.ID {
+			delete(r.m.listItems, key)
+		}
+	}
+	for key, follow := range r.m.listFollows {
+		if follow.ListID == list.ID {
+			delete(r.m.listFollows, key)
+		}
+	}
+	delete(r.m.lists, list.ID)
+	return nil
+}
+
+func (r memoryLists) Items(listID string) ([]models.ListItem, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	return r.m.itemsLocked(listID), nil
+}
+
+func (m *Memory) itemsLocked(listID string) []models.ListItem {
+	var items []models.ListItem
+	for _, item := range m.listItems {
+		if item.ListID == listID {
+			items = append(items, item)
+		}
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].Position < items[j].Position })
+	return items
+}
+
+func (r memoryLists) AddItem(item *models.ListItem) (bool, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	return r.m.appendItemLocked(item), nil
+}
+
+// appendItemLocked adds the item after the last item of its list. The caller holds the lock.
+func (m *Memory) appendItemLocked(item *models.ListItem) bool {
+	key := pairKey(item.ListID, item.ImageID)
+	if _, ok := m.listItems[key]; ok {
+		return false
+	}
+	item.Position = 0
+	for _, other := range m.itemsLocked(item.ListID) {
+		if other.Position >= item.Position {
+			item.Position = other.Position + 1
+		}
+	}
+	now := time.Now()
+	item.CreatedAt, item.UpdatedAt = now, now
+	m.listItems[key] = *item
+	return true
+}
+
+func (r memoryLists) SetNote(listID string, imageID string, note string) error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	key := pairKey(listID, imageID)
+	item, ok := r.m.listItems[key]
+	if !ok {
+		return ErrNotFound
+	}
+	item.Note = note
+	item.UpdatedAt = time.Now()
+	r.m.listItems[key] = item
+	return nil
+}
+
+func (r memoryLists) RemoveItem(listID string, imageID string) (bool, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	key := pairKey(listID, imageID)
+	_, ok := r.m.listItems[key]
+	delete(r.m.listItems, key)
+	return ok, nil
+}
+
+func (r memoryLists) Reorder(listID string, imageIDs []string) error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	for i, imageID := range imageIDs {
+		key := pairKey(listID, imageID)
+		if item, ok := r.m.listItems[key]; ok {
+			item.Position = i
+			r.m.listItems[key] = item
+		}
+	}
+	return nil
+}
+
+func (r memoryLists) Follow(listID string, userID string) error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	key := pairKey(listID, userID)
+	if _, ok := r.m.listFollows[key]; !ok {
+		r.m.listFollows[key] = models.ListFollow{ListID: listID, UserID: userID, CreatedAt: time.Now()}
+	}
+	return nil
+}
+
+func (r memoryLists) Unfollow(listID string, userID string) error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	delete(r.m.listFollows, pairKey(listID, userID))
+	return nil
+}
+
+func (r memoryLists) FollowedIDs(userID string, listIDs []string) ([]string, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	var followed []string
+	for _, id := range listIDs {
+		if _, ok := r.m.listFollows[pairKey(id, userID)]; ok {
+			followed = append(followed, id)
+		}
+	}
+	return followed, nil
+}
+
+func (r memoryLists) Counts(listIDs []string) (map[string]ListCounts, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	counts := make(map[string]ListCounts, len(listIDs))
+	for _, item := range r.m.listItems {
+		if contains(listIDs, item.ListID) {
+			c := counts[item.ListID]
+			c.Items++
+			counts[item.ListID] = c
+		}
+	}
+	for _, follow := range r.m.listFollows {
+		if contains(listIDs, follow.ListID) {
+			c := counts[follow.ListID]
+			c.Followers++
+			counts[follow.ListID] = c
+		}
+	}
+	return counts, nil
+}
+
+// pairKey builds the map key of a record identified by two IDs
+func pairKey(a string, b string) string {
+	return a + "/" + b
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func page[T any](items []T, offset, limit int) []T {
+	if offset >= len(items) {
+		return []T{}
+	}
+	items = items[offset:]
+	if limit > 0 && limit < len(items) {
+		items = items[:limit]
+	}
+	return items
+}
+
+type memoryStats struct{ m *Memory }
+
+func (r memoryStats) AddEvents(events []models.ImageEvent) error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	for _, event := range events {
+		if event.ID == "" {
+			event.ID = uuid.NewString()
+		}
+		r.m.events = append(r.m.events, event)
+	}
+	return nil
+}
+
+func (r memoryStats) Rollup(since time.Time) error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	day := models.StatDay(since)
+	rollup := newDailyRollup()
+	for i, event := range r.m.events {
+		if _, ok := r.m.images[event.ImageID]; ok && !event.CreatedAt.Before(day) {
+			rollup.add(&r.m.events[i])
+		}
+	}
+	for _, stat := range rollup.result() {
+		r.m.dailyStats[pairKey(stat.ImageID, stat.Day.Format(time.DateOnly))] = stat
+	}
+	return nil
+}
+
+func (r memoryStats) PruneEvents(before time.Time) (int64, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	kept := r.m.events[:0]
+	for _, event := range r.m.events {
+		if !event.CreatedAt.Before(before) {
+			kept = append(kept, event)
+		}
+	}
+	pruned := int64(len(r.m.events) - len(kept))
+	r.m.events = kept
+	return pruned, nil
+}
+
+func (r memoryStats) Since(since time.Time) ([]models.ImageDailyStat, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	day := models.StatDay(since)
+	var stats []models.ImageDailyStat
+	for _, stat := range r.m.dailyStats {
+		if !stat.Day.Before(day) {
+			stats = append(stats, stat)
+		}
+	}
+	return stats, nil
+}
+
+func (r memoryStats) Series(imageID string, since time.Time) ([]models.ImageDailyStat, error) {
+	stats, err := r.Since(since)
+	if err != nil {
+		return nil, err
+	}
+	var series []models.ImageDailyStat
+	for _, stat := range stats {
+		if stat.ImageID == imageID {
+			series = append(series, stat)
+		}
+	}
+	sort.Slice(series, func(i, j int) bool { return series[i].Day.Before(series[j].Day) })
+	return series, nil
+}
+
+func (r memoryStats) Deploys(imageIDs []string) (map[string]int64, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	deploys := make(map[string]int64, len(imageIDs))
+	for _, stat := range r.m.dailyStats {
+		if contains(imageIDs, stat.ImageID) {
+			deploys[stat.ImageID] += stat.Deploys
+		}
+	}
+	return deploys, nil
+}
+
+type memorySimilarities struct{ m *Memory }
+
+func (r memorySimilarities) Replace(similarities []models.ImageSimilarity) error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	r.m.similar = make(map[string][]models.ImageSimilarity)
+	for _, s := range similarities {
+		r.m.similar[s.ImageID] = append(r.m.similar[s.ImageID], s)
+	}
+	return nil
+}
+
+func (r memorySimilarities) Similar(imageIDs []string) ([]models.ImageSimilarity, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	var similar []models.ImageSimilarity
+	for _, id := range imageIDs {
+		similar = append(similar, r.m.similar[id]...)
+	}
+	sort.SliceStable(similar, func(i, j int) bool { return similar[i].Score > similar[j].Score })
+	return similar, nil
+}
+
+type memoryTokens struct{ m *Memory }
+
+func (r memoryTokens) Revoke(tokenHash string, userID string, expiresAt time.Time) (bool, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	state, ok := r.m.tokens[tokenHash]
+	if ok && state.Revoked {
+		return false, nil
+	}
+	if !ok {
+		state = models.TokenState{TokenHash: tokenHash, UserID: userID, ExpiresAt: expiresAt}
+	}
+	state.Revoked = true
+	r.m.tokens[tokenHash] = state
+	return true, nil
+}
+
+func (r memoryTokens) IsRevoked(tokenHash string) (bool, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	return r.m.tokens[tokenHash].Revoked, nil
+}
+
+func (r memoryTokens) CountAttempt(tokenHash string, userID string, expiresAt time.Time) (int, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	state, ok := r.m.tokens[tokenHash]
+	if !ok {
+		state = models.TokenState{TokenHash: tokenHash, UserID: userID, ExpiresAt: expiresAt}
+	}
+	state.Attempts++
+	r.m.tokens[tokenHash] = state
+	return state.Attempts, nil
+}
+
+func (r memoryTokens) DeleteExpired(before time.Time) (int64, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	var deleted int64
+	for hash, state := range r.m.tokens {
+		if state.ExpiresAt.Before(before) {
+			delete(r.m.tokens, hash)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+type memoryOrgs struct{ m *Memory }
+
+func (r memoryOrgs) Exists(orgID string) (bool, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	return r.m.orgs[orgID], nil
+}
+
+func (r memoryOrgs) FindProject(orgID string, projectID string) (*models.Project, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	project, ok := r.m.projects[projectID]
+	if !ok || project.OrgID != orgID {
+		return nil, ErrNotFound
+	}
+	return &project, nil
+}
+
+type memoryObjects struct{ m *Memory }
+
+func (r memoryObjects) Track(kind string, keys ...string) error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	for _, key := range keys {
+		if _, ok := r.m.objects[key]; !ok {
+			r.m.objects[key] = models.StoredObject{Key: key, Kind: kind, CreatedAt: time.Now()}
+		}
+	}
+	return nil
+}
+
+func (r memoryObjects) Untrack(keys ...string) error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	for _, key := range keys {
+		delete(r.m.objects, key)
+	}
+	return nil
+}
+
+func (r memoryObjects) List() ([]models.StoredObject, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	objects := make([]models.StoredObject, 0, len(r.m.objects))
+	for _, obj := range r.m.objects {
+		objects = append(objects, obj)
+	}
+	sort.Slice(objects, func(i, j int) bool { return objects[i].Key < objects[j].Key })
+	return objects, nil
+}
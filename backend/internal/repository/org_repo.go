@@ -0,0 +1,32 @@
+package repository
+
+import (
+	"gorm.io/gorm"
+
+	"github.com/samzong/share-ai-platform/internal/models"
+)
+
+type orgRepo struct {
+	db *gorm.DB
+}
+
+// NewOrgRepo returns an OrgRepo backed by the database
+func NewOrgRepo(db *gorm.DB) OrgRepo {
+	return &orgRepo{db: db}
+}
+
+func (r *orgRepo) Exists(orgID string) (bool, error) {
+	var count int64
+	if err := r.db.Model(&models.Organization{}).Where("id = ?", orgID).Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+func (r *orgRepo) FindProject(orgID string, projectID string) (*models.Project, error) {
+	var project models.Project
+	if err := r.db.First(&project, "id = ? AND org_id = ?", projectID, orgID).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return &project, nil
+}
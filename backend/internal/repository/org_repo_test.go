@@ -0,0 +1,35 @@
+package repository
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/samzong/share-ai-platform/internal/models"
+)
+
+func TestGormOrgsOnSQLite(t *testing.T) {
+	repos := newSQLiteRepos(t)
+	db := repos.Orgs.(*orgRepo).db
+
+	acme := &models.Organization{Name: "acme"}
+	other := &models.Organization{Name: "other"}
+	require.NoError(t, db.Create(acme).Error)
+	require.NoError(t, db.Create(other).Error)
+	project := &models.Project{OrgID: acme.ID, Name: "rocket"}
+	require.NoError(t, db.Create(project).Error)
+
+	exists, err := repos.Orgs.Exists(acme.ID)
+	require.NoError(t, err)
+	assert.True(t, exists)
+	exists, err = repos.Orgs.Exists("00000000-0000-0000-0000-00000000ffff")
+	require.NoError(t, err)
+	assert.False(t, exists)
+
+	found, err := repos.Orgs.FindProject(acme.ID, project.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "rocket", found.Name)
+	_, err = repos.Orgs.FindProject(other.ID, project.ID)
+	assert.ErrorIs(t, err, ErrNotFound)
+}
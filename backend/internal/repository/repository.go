@@ -0,0 +1,285 @@
+// Package repository hides how users, images, labels, categories, collections, lists and uploads are stored
+// behind interfaces, so that services can run against Postgres or in-memory fakes.
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/samzong/share-ai-platform/internal/models"
+)
+
+var (
+	// ErrNotFound is returned when a record does not exist
+	ErrNotFound = errors.New("record not found")
+	// ErrDuplicate is returned when a record violates a uniqueness constraint
+	ErrDuplicate = errors.New("record already exists")
+)
+
+// UserRepo stores users
+type UserRepo interface {
+	FindByID(id string) (*models.User, error)
+	FindByUsername(username string) (*models.User, error)
+	FindByEmail(email string) (*models.User, error)
+	// Create hashes the user's password and assigns an ID before storing it
+	Create(user *models.User) error
+	Save(user *models.User) error
+	// List returns a page of users ordered by creation time and the total number of users
+	List(offset, limit int) ([]models.User, int64, error)
+}
+
+// ImageRepo stores images together with their label and category associations
+type ImageRepo interface {
+	// FindByID returns the image with its labels and categories
+	FindByID(id string) (*models.Image, error)
+	// FindInOrg returns the image only if it belongs to the org
+	FindInOrg(orgID string, id string) (*models.Image, error)
+	// List returns a page of the images matching the filter with their labels and categories,
+	// and the total number of matches
+	List(filter ImageFilter) ([]models.Image, int64, error)
+	// Create stores the image and associates image.Labels and image.Categories
+	Create(image *models.Image) error
+	// Update saves the image. Non-nil labels and categories replace the image's labels and categories.
+	Update(image *models.Image, labels []models.Label, categories []models.Category) error
+	// Delete removes the image with its label and category associations and collections
+	Delete(image *models.Image) error
+
+	// ProjectVisibilities returns the visibility of each of the projects, keyed by project ID
+	ProjectVisibilities(projectIDs []string) (map[string]string, error)
+	// GrantedImageIDs returns the images among imageIDs the user or one of the groups was granted access to
+	GrantedImageIDs(userID string, groupIDs []string, imageIDs []string) ([]string, error)
+}
+
+// LabelRepo stores image labels and the aliases that map other spellings to them. Names
+// are matched by models.LabelKey, so "LLM", " llm " and an alias of the label all resolve
+// to the same label.
+type LabelRepo interface {
+	FindByID(id string) (*models.Label, error)
+	// FindOrCreate returns the labels with the given names, creating missing ones. Names
+	// resolving to the same label return it once.
+	FindOrCreate(names []string) ([]models.Label, error)
+	// Resolve returns the existing labels with the given names, skipping unknown ones
+	Resolve(names []string) ([]models.Label, error)
+	// Usage returns the labels whose name contains search with the number of images in the
+	// scope carrying each, most used first. Labels no image in the scope carries are left
+	// out unless scope.All is set.
+	Usage(scope ImageScope, search string) ([]LabelUsage, error)
+	// Aliases returns the aliases of each of the labels, keyed by label ID
+	Aliases(labelIDs []string) (map[string][]string, error)
+
+	// Rename changes the label's name and keeps the old name as an alias. It fails with
+	// ErrDuplicate when the new name belongs to another label or alias.
+	Rename(label *models.Label, name string) error
+	// Merge moves the images and aliases of the sources to the target, keeps the sources'
+	// names as aliases and deletes the sources, all in one transaction
+	Merge(target *models.Label, sources []models.Label) error
+	// AddAlias maps the alias to the label, failing with ErrDuplicate when it is already
+	// a label name or alias
+	AddAlias(labelID string, alias string) error
+	// RemoveAlias deletes the label's alias and reports whether it existed
+	RemoveAlias(labelID string, alias string) (bool, error)
+	// Delete removes the label from every image together with its aliases
+	Delete(label *models.Label) error
+}
+
+// LabelUsage holds a label and the number of images carrying it
+type LabelUsage struct {
+	Label  models.Label
+	Images int64
+}
+
+// CategoryRepo stores the category tree curated by admins
+type CategoryRepo interface {
+	FindByID(id string) (*models.Category, error)
+	// List returns every category ordered by position and then by name
+	List() ([]models.Category, error)
+	// FindBySlugs returns the categories with the given slugs, skipping unknown ones
+	FindBySlugs(slugs []string) ([]models.Category, error)
+	// Create stores the category, failing with ErrDuplicate when the slug is taken
+	Create(category *models.Category) error
+	// Save updates the category, failing with ErrDuplicate when the slug is taken
+	Save(category *models.Category) error
+	// Delete removes the category and its image associations
+	Delete(category *models.Category) error
+}
+
+// TokenRepo stores the state of issued tokens, keyed by the token's digest
+type TokenRepo interface {
+	// Revoke marks the token as unusable until it expires and reports whether this call
+	// revoked it, so that a token can be used exactly once even under concurrent requests
+	Revoke(tokenHash string, userID string, expiresAt time.Time) (bool, error)
+	// IsRevoked reports whether the token was revoked
+	IsRevoked(tokenHash string) (bool, error)
+	// CountAttempt increments and returns the number of verification attempts made with the token
+	CountAttempt(tokenHash string, userID string, expiresAt time.Time) (int, error)
+	// DeleteExpired removes the state of the tokens that expired before the given time
+	DeleteExpired(before time.Time) (int64, error)
+}
+
+// CollectionRepo stores the images users starred. A user's starred images are also
+// the items of their default list.
+type CollectionRepo interface {
+	Exists(userID string, imageID string) (bool, error)
+	// Add stores the collection, appends the image to the user's default list and
+	// increments the image's stars in one transaction. It reports false and changes
+	// nothing when the user already starred the image.
+	Add(collection *models.Collection) (bool, error)
+	// Remove deletes the collection and its default list item and decrements the image's
+	// stars in one transaction, reporting whether the collection existed
+	Remove(userID string, imageID string) (bool, error)
+	// StarredImageIDs returns the images among imageIDs the user starred
+	StarredImageIDs(userID string, imageIDs []string) ([]string, error)
+	// CreatedSince returns the collections made at or after since
+	CreatedSince(since time.Time) ([]models.Collection, error)
+}
+
+// StatsRepo stores image events and their daily rollups
+type StatsRepo interface {
+	// AddEvents stores a batch of events
+	AddEvents(events []models.ImageEvent) error
+	// Rollup recomputes the daily counters from the UTC day of since onwards from the stored
+	// events. Events of deleted images are ignored.
+	Rollup(since time.Time) error
+	// PruneEvents deletes the events that happened before the given time and returns how many were deleted
+	PruneEvents(before time.Time) (int64, error)
+	// Since returns the counters of every image from the UTC day of since onwards
+	Since(since time.Time) ([]models.ImageDailyStat, error)
+	// Series returns the counters of the image from the UTC day of since onwards, oldest first
+	Series(imageID string, since time.Time) ([]models.ImageDailyStat, error)
+	// Deploys returns the total number of deploys of each of the images, keyed by image ID
+	Deploys(imageIDs []string) (map[string]int64, error)
+}
+
+// SimilarityRepo stores the precomputed similar images of each image
+type SimilarityRepo interface {
+	// Replace swaps every stored similarity for the given ones in one transaction
+	Replace(similarities []models.ImageSimilarity) error
+	// Similar returns the similarities of the images, best match first
+	Similar(imageIDs []string) ([]models.ImageSimilarity, error)
+}
+
+// ListRepo stores named lists, their items and followers
+type ListRepo interface {
+	FindByID(id string) (*models.List, error)
+	// Default returns the user's default list, creating it on first use
+	Default(userID string) (*models.List, error)
+	// ListByOwner returns the owner's lists, the default list first and then by name
+	ListByOwner(ownerID string) ([]models.List, error)
+	// Following returns the lists the user follows, most recently followed first
+	Following(userID string) ([]models.List, error)
+	// Create stores the list, failing with ErrDuplicate when the owner already has a list with the name
+	Create(list *models.List) error
+	// Save updates the list, failing with ErrDuplicate when the owner already has a list with the name
+	Save(list *models.List) error
+	// Delete removes the list with its items and followers
+	Delete(list *models.List) error
+
+	// Items returns the items of the list ordered by position
+	Items(listID string) ([]models.ListItem, error)
+	// AddItem appends the image to the end of the list and reports false when it is already in the list
+	AddItem(item *models.ListItem) (bool, error)
+	// SetNote updates the note of an item
+	SetNote(listID string, imageID string, note string) error
+	// RemoveItem deletes the item and reports whether it existed
+	RemoveItem(listID string, imageID string) (bool, error)
+	// Reorder sets the positions of the items to their index in imageIDs
+	Reorder(listID string, imageIDs []string) error
+
+	// Follow records that the user follows the list; following twice is a no-op
+	Follow(listID string, userID string) error
+	Unfollow(listID string, userID string) error
+	// FollowedIDs returns the lists among listIDs the user follows
+	FollowedIDs(userID string, listIDs []string) ([]string, error)
+	// Counts returns the number of items and followers of each of the lists, keyed by list ID
+	Counts(listIDs []string) (map[string]ListCounts, error)
+}
+
+// OrgRepo reads the organizations and projects images belong to
+type OrgRepo interface {
+	// Exists reports whether the organization exists
+	Exists(orgID string) (bool, error)
+	// FindProject returns the project only if it belongs to the org
+	FindProject(orgID string, projectID string) (*models.Project, error)
+}
+
+// StoredObjectRepo tracks uploaded objects, so that the storage GC also knows uploads
+// whose referencing row was never written
+type StoredObjectRepo interface {
+	// Track records the objects, keeping the upload time of ones already tracked
+	Track(kind string, keys ...string) error
+	// Untrack removes the records of the objects
+	Untrack(keys ...string) error
+	// List returns every tracked object
+	List() ([]models.StoredObject, error)
+}
+
+// ListCounts holds the number of items and followers of a list
+type ListCounts struct {
+	Items     int64
+	Followers int64
+}
+
+// ImageScope describes the images a viewer can see: everything when All is set, otherwise
+// public and require_access images outside private projects, plus for a signed-in user
+// their own images and every image in OrgIDs and ProjectIDs
+type ImageScope struct {
+	All        bool
+	UserID     string
+	OrgIDs     []string
+	ProjectIDs []string
+}
+
+// ImageFilter selects a page of images
+type ImageFilter struct {
+	Scope      ImageScope
+	IDs        []string // 仅返回这些镜像
+	StarredBy  string   // 仅返回该用户收藏的镜像
+	Search     string   // 按名称或描述模糊匹配
+	ProjectID  string   // 仅返回该项目的镜像
+	Labels     []string // 必须同时带有的标签 ID
+	Categories []string // 至少属于其中一个分类（分类 ID）
+	Sort       string   // stars/created_at/updated_at，默认按创建时间倒序
+	Offset     int
+	Limit      int
+}
+
+// Repositories bundles the repositories used by services
+type Repositories struct {
+	Users        UserRepo
+	Images       ImageRepo
+	Labels       LabelRepo
+	Categories   CategoryRepo
+	Collections  CollectionRepo
+	Lists        ListRepo
+	Stats        StatsRepo
+	Similarities SimilarityRepo
+	Tokens       TokenRepo
+	Orgs         OrgRepo
+	Objects      StoredObjectRepo
+}
+
+// NewGorm returns repositories backed by the database
+func NewGorm(db *gorm.DB) *Repositories {
+	return &Repositories{
+		Users:        NewUserRepo(db),
+		Images:       NewImageRepo(db),
+		Labels:       NewLabelRepo(db),
+		Categories:   NewCategoryRepo(db),
+		Collections:  NewCollectionRepo(db),
+		Lists:        NewListRepo(db),
+		Stats:        NewStatsRepo(db),
+		Similarities: NewSimilarityRepo(db),
+		Tokens:       NewTokenRepo(db),
+		Orgs:         NewOrgRepo(db),
+		Objects:      NewStoredObjectRepo(db),
+	}
+}
+
+func notFound(err error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrNotFound
+	}
+	return err
+}
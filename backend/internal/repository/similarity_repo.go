@@ -0,0 +1,37 @@
+package repository
+
+import (
+	"gorm.io/gorm"
+
+	"github.com/samzong/share-ai-platform/internal/models"
+)
+
+type similarityRepo struct {
+	db *gorm.DB
+}
+
+// NewSimilarityRepo returns a SimilarityRepo backed by the database
+func NewSimilarityRepo(db *gorm.DB) SimilarityRepo {
+	return &similarityRepo{db: db}
+}
+
+func (r *similarityRepo) Replace(similarities []models.ImageSimilarity) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("1 = 1").Delete(&models.ImageSimilarity{}).Error; err != nil {
+			return err
+		}
+		if len(similarities) == 0 {
+			return nil
+		}
+		return tx.CreateInBatches(similarities, 1000).Error
+	})
+}
+
+func (r *similarityRepo) Similar(imageIDs []string) ([]models.ImageSimilarity, error) {
+	var similar []models.ImageSimilarity
+	if len(imageIDs) == 0 {
+		return similar, nil
+	}
+	err := r.db.Where("image_id IN ?", imageIDs).Order("score DESC, similar_id").Find(&similar).Error
+	return similar, err
+}
@@ -0,0 +1,42 @@
+package repository
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/samzong/share-ai-platform/internal/models"
+)
+
+func TestGormSimilaritiesOnSQLite(t *testing.T) {
+	repos := newSQLiteRepos(t)
+	newImage := func(name string) *models.Image {
+		image := &models.Image{OrgID: "org", Name: name, Author: "author", Registry: "docker.io",
+			Namespace: "library", Repository: name, Tag: "latest", Digest: "sha256:" + name,
+			Platform: "linux/amd64"}
+		require.NoError(t, repos.Images.Create(image))
+		return image
+	}
+	a, b, c := newImage("a"), newImage("b"), newImage("c")
+
+	require.NoError(t, repos.Similarities.Replace([]models.ImageSimilarity{
+		{ImageID: a.ID, SimilarID: c.ID, Score: 0.9},
+	}))
+	require.NoError(t, repos.Similarities.Replace([]models.ImageSimilarity{
+		{ImageID: a.ID, SimilarID: b.ID, Score: 0.2},
+		{ImageID: a.ID, SimilarID: c.ID, Score: 0.5},
+		{ImageID: b.ID, SimilarID: a.ID, Score: 0.2},
+	}))
+
+	similar, err := repos.Similarities.Similar([]string{a.ID})
+	require.NoError(t, err)
+	require.Len(t, similar, 2, "replace must drop the previous similarities")
+	assert.Equal(t, c.ID, similar[0].SimilarID)
+	assert.InDelta(t, 0.5, similar[0].Score, 1e-9)
+
+	require.NoError(t, repos.Images.Delete(c))
+	similar, err = repos.Similarities.Similar([]string{a.ID, b.ID})
+	require.NoError(t, err)
+	assert.Len(t, similar, 2)
+}
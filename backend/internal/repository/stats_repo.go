@@ -0,0 +1,139 @@
+package repository
+
+import (
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/samzong/share-ai-platform/internal/models"
+)
+
+// rollupBatchSize is how many events are read or counters written at once during a rollup
+const rollupBatchSize = 1000
+
+type statsRepo struct {
+	db *gorm.DB
+}
+
+// NewStatsRepo returns a StatsRepo backed by the database
+func NewStatsRepo(db *gorm.DB) StatsRepo {
+	return &statsRepo{db: db}
+}
+
+func (r *statsRepo) AddEvents(events []models.ImageEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+	return r.db.CreateInBatches(events, rollupBatchSize).Error
+}
+
+func (r *statsRepo) Rollup(since time.Time) error {
+	rollup := newDailyRollup()
+	var batch []models.ImageEvent
+	err := r.db.Where("created_at >= ? AND image_id IN (?)", models.StatDay(since), r.db.Model(&models.Image{}).Select("id")).
+		FindInBatches(&batch, rollupBatchSize, func(tx *gorm.DB, _ int) error {
+			for i := range batch {
+				rollup.add(&batch[i])
+			}
+			return nil
+		}).Error
+	if err != nil {
+		return err
+	}
+
+	stats := rollup.result()
+	if len(stats) == 0 {
+		return nil
+	}
+	// 汇总结果整体替换已有的计数，重复执行得到相同的结果
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "image_id"}, {Name: "day"}},
+		DoUpdates: clause.AssignmentColumns([]string{"views", "readme_views", "usage_copies", "deploys", "visitors"}),
+	}).CreateInBatches(stats, rollupBatchSize).Error
+}
+
+func (r *statsRepo) PruneEvents(before time.Time) (int64, error) {
+	result := r.db.Where("created_at < ?", before).Delete(&models.ImageEvent{})
+	return result.RowsAffected, result.Error
+}
+
+func (r *statsRepo) Since(since time.Time) ([]models.ImageDailyStat, error) {
+	var stats []models.ImageDailyStat
+	err := r.db.Where("day >= ?", models.StatDay(since)).Find(&stats).Error
+	return stats, err
+}
+
+func (r *statsRepo) Series(imageID string, since time.Time) ([]models.ImageDailyStat, error) {
+	var stats []models.ImageDailyStat
+	err := r.db.Where("image_id = ? AND day >= ?", imageID, models.StatDay(since)).Order("day").Find(&stats).Error
+	return stats, err
+}
+
+func (r *statsRepo) Deploys(imageIDs []string) (map[string]int64, error) {
+	deploys := make(map[string]int64, len(imageIDs))
+	if len(imageIDs) == 0 {
+		return deploys, nil
+	}
+	var rows []struct {
+		ImageID string
+		Deploys int64
+	}
+	err := r.db.Model(&models.ImageDailyStat{}).
+		Select("image_id, SUM(deploys) AS deploys").
+		Where("image_id IN ?", imageIDs).
+		Group("image_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		deploys[row.ImageID] = row.Deploys
+	}
+	return deploys, nil
+}
+
+// dailyRollup counts events into per-image, per-day counters
+type dailyRollup struct {
+	stats    map[string]*models.ImageDailyStat
+	visitors map[string]map[string]bool
+}
+
+func newDailyRollup() *dailyRollup {
+	return &dailyRollup{
+		stats:    make(map[string]*models.ImageDailyStat),
+		visitors: make(map[string]map[string]bool),
+	}
+}
+
+func (r *dailyRollup) add(event *models.ImageEvent) {
+	day := models.StatDay(event.CreatedAt)
+	key := pairKey(event.ImageID, day.Format(time.DateOnly))
+	stat, ok := r.stats[key]
+	if !ok {
+		stat = &models.ImageDailyStat{ImageID: event.ImageID, Day: day}
+		r.stats[key] = stat
+		r.visitors[key] = make(map[string]bool)
+	}
+	stat.Count(event.Type)
+	if visitor := event.Visitor(); visitor != "" && !r.visitors[key][visitor] {
+		r.visitors[key][visitor] = true
+		stat.Visitors++
+	}
+}
+
+// result returns the counters ordered by day and image
+func (r *dailyRollup) result() []models.ImageDailyStat {
+	stats := make([]models.ImageDailyStat, 0, len(r.stats))
+	for _, stat := range r.stats {
+		stats = append(stats, *stat)
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		if !stats[i].Day.Equal(stats[j].Day) {
+			return stats[i].Day.Before(stats[j].Day)
+		}
+		return stats[i].ImageID < stats[j].ImageID
+	})
+	return stats
+}
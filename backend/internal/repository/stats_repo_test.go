@@ -0,0 +1,58 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/samzong/share-ai-platform/internal/models"
+)
+
+func TestGormStatsOnSQLite(t *testing.T) {
+	repos := newSQLiteRepos(t)
+	image := &models.Image{OrgID: "org", Name: "stats", Author: "author", Registry: "docker.io",
+		Namespace: "library", Repository: "stats", Tag: "latest", Digest: "sha256:stats",
+		Platform: "linux/amd64"}
+	require.NoError(t, repos.Images.Create(image))
+
+	now := time.Now().UTC()
+	alice, visitor := "alice", "visitor"
+	event := func(imageID string, eventType string, at time.Time) models.ImageEvent {
+		return models.ImageEvent{ImageID: imageID, Type: eventType, UserID: &alice, CreatedAt: at}
+	}
+	require.NoError(t, repos.Stats.AddEvents([]models.ImageEvent{
+		event(image.ID, models.EventView, now),
+		event(image.ID, models.EventView, now),
+		{ImageID: image.ID, Type: models.EventView, AnonymousID: &visitor, CreatedAt: now},
+		event(image.ID, models.EventDeploy, now),
+		event(image.ID, models.EventDeploy, now.Add(-10*24*time.Hour)),
+		event("deleted-image", models.EventView, now),
+	}))
+
+	require.NoError(t, repos.Stats.Rollup(now.Add(-30*24*time.Hour)))
+	// 重复汇总不会重复计数
+	require.NoError(t, repos.Stats.Rollup(now.Add(-24*time.Hour)))
+
+	stats, err := repos.Stats.Since(now.Add(-24 * time.Hour))
+	require.NoError(t, err)
+	require.Len(t, stats, 1, "events of deleted images must be ignored")
+	assert.EqualValues(t, 3, stats[0].Views)
+	assert.EqualValues(t, 1, stats[0].Deploys)
+	assert.EqualValues(t, 2, stats[0].Visitors)
+	assert.True(t, models.StatDay(now).Equal(stats[0].Day))
+
+	series, err := repos.Stats.Series(image.ID, now.Add(-30*24*time.Hour))
+	require.NoError(t, err)
+	require.Len(t, series, 2)
+	assert.True(t, series[0].Day.Before(series[1].Day))
+
+	deploys, err := repos.Stats.Deploys([]string{image.ID, "other"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int64{image.ID: 2}, deploys)
+
+	pruned, err := repos.Stats.PruneEvents(now.Add(-24 * time.Hour))
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, pruned)
+}
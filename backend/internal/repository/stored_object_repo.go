@@ -0,0 +1,42 @@
+package repository
+
+import (
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/samzong/share-ai-platform/internal/models"
+)
+
+type storedObjectRepo struct {
+	db *gorm.DB
+}
+
+// NewStoredObjectRepo returns a StoredObjectRepo backed by the database
+func NewStoredObjectRepo(db *gorm.DB) StoredObjectRepo {
+	return &storedObjectRepo{db: db}
+}
+
+func (r *storedObjectRepo) Track(kind string, keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	objects := make([]models.StoredObject, len(keys))
+	for i, key := range keys {
+		objects[i] = models.StoredObject{Key: key, Kind: kind}
+	}
+	// 已跟踪的对象保留原上传时间
+	return r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&objects).Error
+}
+
+func (r *storedObjectRepo) Untrack(keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	return r.db.Where("object_key IN ?", keys).Delete(&models.StoredObject{}).Error
+}
+
+func (r *storedObjectRepo) List() ([]models.StoredObject, error) {
+	var objects []models.StoredObject
+	err := r.db.Find(&objects).Error
+	return objects, err
+}
@@ -0,0 +1,30 @@
+package repository
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/samzong/share-ai-platform/internal/models"
+)
+
+func TestGormStoredObjectsOnSQLite(t *testing.T) {
+	repos := newSQLiteRepos(t)
+
+	require.NoError(t, repos.Objects.Track(models.StoredObjectAvatar, "avatars/a/64.png", "avatars/a/256.png"))
+	require.NoError(t, repos.Objects.Track(models.StoredObjectReadme, "readme/b.md"))
+	require.NoError(t, repos.Objects.Track(models.StoredObjectReadme, "readme/b.md"), "tracking twice is a no-op")
+
+	objects, err := repos.Objects.List()
+	require.NoError(t, err)
+	assert.Len(t, objects, 3)
+
+	require.NoError(t, repos.Objects.Untrack("avatars/a/64.png", "avatars/a/256.png", "missing"))
+	require.NoError(t, repos.Objects.Untrack())
+	objects, err = repos.Objects.List()
+	require.NoError(t, err)
+	require.Len(t, objects, 1)
+	assert.Equal(t, "readme/b.md", objects[0].Key)
+	assert.Equal(t, models.StoredObjectReadme, objects[0].Kind)
+}
@@ -0,0 +1,58 @@
+package repository
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/samzong/share-ai-platform/internal/models"
+)
+
+type tokenRepo struct {
+	db *gorm.DB
+}
+
+// NewTokenRepo returns a TokenRepo backed by the database
+func NewTokenRepo(db *gorm.DB) TokenRepo {
+	return &tokenRepo{db: db}
+}
+
+func (r *tokenRepo) Revoke(tokenHash string, userID string, expiresAt time.Time) (bool, error) {
+	state := models.TokenState{TokenHash: tokenHash, UserID: userID, Revoked: true, ExpiresAt: expiresAt}
+	// 已吊销的令牌不会被再次更新，影响行数为 0
+	result := r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "token_hash"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{"revoked": true}),
+		Where:     clause.Where{Exprs: []clause.Expression{clause.Eq{Column: clause.Column{Table: "token_states", Name: "revoked"}, Value: false}}},
+	}).Create(&state)
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *tokenRepo) IsRevoked(tokenHash string) (bool, error) {
+	var states []models.TokenState
+	if err := r.db.Where("token_hash = ? AND revoked", tokenHash).Limit(1).Find(&states).Error; err != nil {
+		return false, err
+	}
+	return len(states) > 0, nil
+}
+
+func (r *tokenRepo) CountAttempt(tokenHash string, userID string, expiresAt time.Time) (int, error) {
+	var state models.TokenState
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "token_hash"}},
+			DoUpdates: clause.Assignments(map[string]interface{}{"attempts": gorm.Expr("token_states.attempts + 1")}),
+		}).Create(&models.TokenState{TokenHash: tokenHash, UserID: userID, Attempts: 1, ExpiresAt: expiresAt}).Error
+		if err != nil {
+			return err
+		}
+		return tx.First(&state, "token_hash = ?", tokenHash).Error
+	})
+	return state.Attempts, err
+}
+
+func (r *tokenRepo) DeleteExpired(before time.Time) (int64, error) {
+	result := r.db.Where("expires_at < ?", before).Delete(&models.TokenState{})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,41 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGormTokensOnSQLite(t *testing.T) {
+	repos := newSQLiteRepos(t)
+	expiresAt := time.Now().Add(time.Hour)
+
+	revoked, err := repos.Tokens.IsRevoked("digest")
+	require.NoError(t, err)
+	assert.False(t, revoked)
+
+	for i := 1; i <= 3; i++ {
+		attempts, err := repos.Tokens.CountAttempt("digest", "user", expiresAt)
+		require.NoError(t, err)
+		assert.Equal(t, i, attempts)
+	}
+
+	first, err := repos.Tokens.Revoke("digest", "user", expiresAt)
+	require.NoError(t, err)
+	assert.True(t, first)
+	first, err = repos.Tokens.Revoke("digest", "user", expiresAt)
+	require.NoError(t, err)
+	assert.False(t, first, "a token can only be revoked once")
+
+	revoked, err = repos.Tokens.IsRevoked("digest")
+	require.NoError(t, err)
+	assert.True(t, revoked)
+
+	_, err = repos.Tokens.Revoke("expired", "user", time.Now().Add(-time.Minute))
+	require.NoError(t, err)
+	deleted, err := repos.Tokens.DeleteExpired(time.Now())
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, deleted)
+}
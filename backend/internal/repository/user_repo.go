@@ -0,0 +1,57 @@
+package repository
+
+import (
+	"gorm.io/gorm"
+
+	"github.com/samzong/share-ai-platform/internal/models"
+)
+
+type userRepo struct {
+	db *gorm.DB
+}
+
+// NewUserRepo returns a UserRepo backed by the database
+func NewUserRepo(db *gorm.DB) UserRepo {
+	return &userRepo{db: db}
+}
+
+func (r *userRepo) FindByID(id string) (*models.User, error) {
+	return r.findBy("id = ?", id)
+}
+
+func (r *userRepo) FindByUsername(username string) (*models.User, error) {
+	return r.findBy("username = ?", username)
+}
+
+func (r *userRepo) FindByEmail(email string) (*models.User, error) {
+	return r.findBy("email = ?", email)
+}
+
+func (r *userRepo) findBy(query string, value string) (*models.User, error) {
+	var user models.User
+	if err := r.db.Where(query, value).First(&user).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return &user, nil
+}
+
+func (r *userRepo) Create(user *models.User) error {
+	return r.db.Create(user).Error
+}
+
+func (r *userRepo) Save(user *models.User) error {
+	return r.db.Save(user).Error
+}
+
+func (r *userRepo) List(offset, limit int) ([]models.User, int64, error) {
+	var total int64
+	if err := r.db.Model(&models.User{}).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var users []models.User
+	if err := r.db.Order("created_at ASC").Offset(offset).Limit(limit).Find(&users).Error; err != nil {
+		return nil, 0, err
+	}
+	return users, total, nil
+}
@@ -0,0 +1,374 @@
+// Package seed loads demo and test data from YAML fixtures. Applying the same
+// fixtures again updates the existing rows instead of duplicating them.
+package seed
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+	"gorm.io/gorm"
+
+	"github.com/samzong/share-ai-platform/internal/models"
+	"github.com/samzong/share-ai-platform/internal/repository"
+)
+
+// PublicOrgName 是保留 public 组织的名称，镜像 fixture 中用它引用 public 组织
+const PublicOrgName = "public"
+
+// Fixtures is the content of a fixture file
+type Fixtures struct {
+	Users       []User       `yaml:"users"`
+	Orgs        []Org        `yaml:"orgs"`
+	Labels      []string     `yaml:"labels"`
+	Images      []Image      `yaml:"images"`
+	Collections []Collection `yaml:"collections"`
+}
+
+// User is a user fixture. The password is only set when the user is created.
+type User struct {
+	Username string      `yaml:"username"`
+	Email    string      `yaml:"email"`
+	Password string      `yaml:"password"`
+	Nickname string      `yaml:"nickname"`
+	Role     models.Role `yaml:"role"` // 全局角色，默认 user
+}
+
+// Org is an organization fixture with its members
+type Org struct {
+	Name        string      `yaml:"name"`
+	Description string      `yaml:"description"`
+	Members     []OrgMember `yaml:"members"`
+}
+
+// OrgMember references a user by username
+type OrgMember struct {
+	Username string         `yaml:"username"`
+	Role     models.OrgRole `yaml:"role"` // 默认 member
+}
+
+// Image is an image fixture, identified by its org and name
+type Image struct {
+	Org         string   `yaml:"org"` // 组织名称，默认 public
+	Name        string   `yaml:"name"`
+	Description string   `yaml:"description"`
+	Author      string   `yaml:"author"` // 作者用户名
+	Registry    string   `yaml:"registry"`
+	Namespace   string   `yaml:"namespace"`
+	Repository  string   `yaml:"repository"`
+	Tag         string   `yaml:"tag"`
+	Digest      string   `yaml:"digest"`
+	Size        int64    `yaml:"size"`
+	Visibility  string   `yaml:"visibility"`
+	Platform    string   `yaml:"platform"`
+	Labels      []string `yaml:"labels"`
+}
+
+// Collection stars an image, referenced as "<org>/<image name>", for a user
+type Collection struct {
+	Username string `yaml:"username"`
+	Image    string `yaml:"image"`
+}
+
+// Load reads and validates a fixture file, filling in defaults
+func Load(path string) (*Fixtures, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(data)
+}
+
+// Parse decodes and validates fixtures, filling in defaults
+func Parse(data []byte) (*Fixtures, error) {
+	var f Fixtures
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&f); err != nil {
+		return nil, fmt.Errorf("invalid fixtures: %v", err)
+	}
+
+	for i := range f.Users {
+		u := &f.Users[i]
+		if u.Username == "" || u.Email == "" || u.Password == "" {
+			return nil, fmt.Errorf("user %d: username, email and password are required", i+1)
+		}
+		if u.Role == "" {
+			u.Role = models.RoleUser
+		}
+	}
+	for i := range f.Orgs {
+		o := &f.Orgs[i]
+		if o.Name == "" {
+			return nil, fmt.Errorf("org %d: name is required", i+1)
+		}
+		if o.Name == PublicOrgName {
+			return nil, fmt.Errorf("org %s is reserved and created automatically", PublicOrgName)
+		}
+		for j := range o.Members {
+			if o.Members[j].Role == "" {
+				o.Members[j].Role = models.OrgRoleMember
+			}
+		}
+	}
+	for i := range f.Images {
+		img := &f.Images[i]
+		if img.Name == "" || img.Author == "" {
+			return nil, fmt.Errorf("image %d: name and author are required", i+1)
+		}
+		img.applyDefaults()
+	}
+	for i, c := range f.Collections {
+		if c.Username == "" || !strings.Contains(c.Image, "/") {
+			return nil, fmt.Errorf("collection %d: username and image as <org>/<name> are required", i+1)
+		}
+	}
+	return &f, nil
+}
+
+func (img *Image) applyDefaults() {
+	if img.Org == "" {
+		img.Org = PublicOrgName
+	}
+	if img.Registry == "" {
+		img.Registry = "docker.io"
+	}
+	if img.Namespace == "" {
+		img.Namespace = "library"
+	}
+	if img.Repository == "" {
+		img.Repository = img.Name
+	}
+	if img.Tag == "" {
+		img.Tag = "latest"
+	}
+	if img.Visibility == "" {
+		img.Visibility = models.VisibilityPublic
+	}
+	if img.Platform == "" {
+		img.Platform = "linux/amd64"
+	}
+	// 未指定摘要时生成稳定的假摘要，重复导入结果一致
+	if img.Digest == "" {
+		sum := sha256.Sum256([]byte(img.Org + "/" + img.Name + ":" + img.Tag))
+		img.Digest = "sha256:" + hex.EncodeToString(sum[:])
+	}
+}
+
+// Apply loads the fixtures in a single transaction, creating missing rows and updating
+// existing ones. The reserved public org is always created.
+func Apply(db *gorm.DB, f *Fixtures) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		s := &seeder{tx: tx, users: make(map[string]string), orgs: make(map[string]string)}
+		if err := EnsurePublicOrg(tx); err != nil {
+			return err
+		}
+		s.orgs[PublicOrgName] = models.PublicOrgID
+
+		for _, u := range f.Users {
+			if err := s.user(u); err != nil {
+				return fmt.Errorf("user %s: %v", u.Username, err)
+			}
+		}
+		for _, o := range f.Orgs {
+			if err := s.org(o); err != nil {
+				return fmt.Errorf("org %s: %v", o.Name, err)
+			}
+		}
+		if _, err := s.labels(f.Labels); err != nil {
+			return fmt.Errorf("labels: %v", err)
+		}
+
+		images := make(map[string]string)
+		for _, img := range f.Images {
+			id, err := s.image(img)
+			if err != nil {
+				return fmt.Errorf("image %s/%s: %v", img.Org, img.Name, err)
+			}
+			images[img.Org+"/"+img.Name] = id
+		}
+		for _, c := range f.Collections {
+			if err := s.collection(c, images); err != nil {
+				return fmt.Errorf("collection %s -> %s: %v", c.Username, c.Image, err)
+			}
+		}
+		return s.recountStars()
+	})
+}
+
+// EnsurePublicOrg creates the reserved public org if it does not exist
+func EnsurePublicOrg(db *gorm.DB) error {
+	org := models.Organization{ID: models.PublicOrgID}
+	return db.Where("id = ?", models.PublicOrgID).
+		Attrs(models.Organization{Name: PublicOrgName, Description: "Images shared with everyone"}).
+		FirstOrCreate(&org).Error
+}
+
+// seeder resolves fixture references to IDs while applying fixtures
+type seeder struct {
+	tx      *gorm.DB
+	users   map[string]string // 用户名 -> 用户ID
+	orgs    map[string]string // 组织名称 -> 组织ID
+	starred []string          // 收藏数需要重新统计的镜像
+}
+
+func (s *seeder) user(u User) error {
+	user := models.User{}
+	err := s.tx.Where("username = ?", u.Username).
+		Attrs(models.User{Username: u.Username, Password: u.Password}).
+		Assign(models.User{Email: u.Email, Nickname: u.Nickname, Role: u.Role}).
+		FirstOrCreate(&user).Error
+	if err != nil {
+		return err
+	}
+	s.users[u.Username] = user.ID
+	return nil
+}
+
+func (s *seeder) userID(username string) (string, error) {
+	if id, ok := s.users[username]; ok {
+		return id, nil
+	}
+	var user models.User
+	if err := s.tx.Select("id").Where("username = ?", username).First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return "", fmt.Errorf("unknown user %s", username)
+		}
+		return "", err
+	}
+	s.users[username] = user.ID
+	return user.ID, nil
+}
+
+func (s *seeder) org(o Org) error {
+	org := models.Organization{}
+	err := s.tx.Where("name = ?", o.Name).
+		Attrs(models.Organization{Name: o.Name}).
+		Assign(models.Organization{Description: o.Description}).
+		FirstOrCreate(&org).Error
+	if err != nil {
+		return err
+	}
+	s.orgs[o.Name] = org.ID
+
+	for _, m := range o.Members {
+		userID, err := s.userID(m.Username)
+		if err != nil {
+			return err
+		}
+		member := models.OrgMember{OrgID: org.ID, UserID: userID}
+		if err := s.tx.Where(member).Assign(models.OrgMember{Role: m.Role}).FirstOrCreate(&member).Error; err != nil {
+			return err
+		}
+		if org.CreatedBy == "" && m.Role == models.OrgRoleOwner {
+			if err := s.tx.Model(&org).Update("created_by", userID).Error; err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (s *seeder) orgID(name string) (string, error) {
+	if id, ok := s.orgs[name]; ok {
+		return id, nil
+	}
+	var org models.Organization
+	if err := s.tx.Select("id").Where("name = ?", name).First(&org).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return "", fmt.Errorf("unknown org %s", name)
+		}
+		return "", err
+	}
+	s.orgs[name] = org.ID
+	return org.ID, nil
+}
+
+// labels normalizes the names and resolves aliases the same way images created through
+// the API do
+func (s *seeder) labels(names []string) ([]models.Label, error) {
+	return repository.NewLabelRepo(s.tx).FindOrCreate(names)
+}
+
+func (s *seeder) image(img Image) (string, error) {
+	orgID, err := s.orgID(img.Org)
+	if err != nil {
+		return "", err
+	}
+	authorID, err := s.userID(img.Author)
+	if err != nil {
+		return "", err
+	}
+
+	image := models.Image{}
+	err = s.tx.Where("org_id = ? AND name = ?", orgID, img.Name).
+		Attrs(models.Image{OrgID: orgID, Name: img.Name}).
+		Assign(models.Image{
+			Description: img.Description,
+			Author:      authorID,
+			Registry:    img.Registry,
+			Namespace:   img.Namespace,
+			Repository:  img.Repository,
+			Tag:         img.Tag,
+			Digest:      img.Digest,
+			Size:        img.Size,
+			Visibility:  img.Visibility,
+			Platform:    img.Platform,
+		}).
+		FirstOrCreate(&image).Error
+	if err != nil {
+		return "", err
+	}
+
+	labels, err := s.labels(img.Labels)
+	if err != nil {
+		return "", err
+	}
+	if err := s.tx.Model(&image).Association("Labels").Replace(labels); err != nil {
+		return "", err
+	}
+	return image.ID, nil
+}
+
+func (s *seeder) collection(c Collection, images map[string]string) error {
+	userID, err := s.userID(c.Username)
+	if err != nil {
+		return err
+	}
+
+	imageID, ok := images[c.Image]
+	if !ok {
+		parts := strings.SplitN(c.Image, "/", 2)
+		orgID, err := s.orgID(parts[0])
+		if err != nil {
+			return err
+		}
+		var image models.Image
+		if err := s.tx.Select("id").Where("org_id = ? AND name = ?", orgID, parts[1]).First(&image).Error; err != nil {
+			return fmt.Errorf("unknown image %s", c.Image)
+		}
+		imageID = image.ID
+	}
+
+	// 与用户收藏走同一路径，镜像同时加入用户的默认收藏列表
+	_, err = repository.NewCollectionRepo(s.tx).Add(&models.Collection{UserID: userID, ImageID: imageID})
+	if err != nil {
+		return err
+	}
+	s.starred = append(s.starred, imageID)
+	return nil
+}
+
+// recountStars sets the stars of starred images to their number of collections
+func (s *seeder) recountStars() error {
+	if len(s.starred) == 0 {
+		return nil
+	}
+	counts := s.tx.Model(&models.Collection{}).Select("COUNT(*)").Where("collections.image_id = images.id")
+	return s.tx.Model(&models.Image{}).Where("id IN ?", s.starred).Update("stars", counts).Error
+}
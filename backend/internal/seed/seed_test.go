@@ -0,0 +1,115 @@
+package seed
+
+import (
+	"context"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/samzong/share-ai-platform/internal/database"
+	"github.com/samzong/share-ai-platform/internal/migrations"
+	"github.com/samzong/share-ai-platform/internal/models"
+)
+
+func TestLoadDemoFixtures(t *testing.T) {
+	f, err := Load("../../fixtures/demo.yaml")
+	require.NoError(t, err)
+	require.NotEmpty(t, f.Users)
+	require.NotEmpty(t, f.Images)
+
+	for _, img := range f.Images {
+		assert.NotEmpty(t, img.Org)
+		assert.NotEmpty(t, img.Digest)
+		assert.NotEmpty(t, img.Visibility)
+	}
+}
+
+func TestParseAppliesDefaults(t *testing.T) {
+	f, err := Parse([]byte(`
+users:
+  - {username: u, email: u@example.com, password: secret1}
+orgs:
+  - name: o
+    members: [{username: u}]
+images:
+  - {name: nginx, author: u}
+`))
+	require.NoError(t, err)
+
+	assert.Equal(t, models.RoleUser, f.Users[0].Role)
+	assert.Equal(t, models.OrgRoleMember, f.Orgs[0].Members[0].Role)
+
+	img := f.Images[0]
+	assert.Equal(t, PublicOrgName, img.Org)
+	assert.Equal(t, "docker.io", img.Registry)
+	assert.Equal(t, "nginx", img.Repository)
+	assert.Equal(t, "latest", img.Tag)
+	assert.Equal(t, models.VisibilityPublic, img.Visibility)
+
+	// 摘要是稳定的，重复导入不会改变
+	again, err := Parse([]byte(`images: [{name: nginx, author: u}]`))
+	require.NoError(t, err)
+	assert.Equal(t, img.Digest, again.Images[0].Digest)
+}
+
+func TestParseRejectsInvalidFixtures(t *testing.T) {
+	tests := map[string]string{
+		"unknown field":      `users: [{username: u, email: e@example.com, password: p, admin: true}]`,
+		"missing password":   `users: [{username: u, email: e@example.com}]`,
+		"reserved org":       `orgs: [{name: public}]`,
+		"image without user": `images: [{name: nginx}]`,
+		"bad collection ref": `collections: [{username: u, image: nginx}]`,
+	}
+	for name, data := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := Parse([]byte(data))
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestSyntheticImagesAreDeterministic(t *testing.T) {
+	labels := []models.Label{{ID: "l1", Name: "llm"}, {ID: "l2", Name: "gpu"}}
+	a := syntheticImage(rand.New(rand.NewSource(7)), 3, "org", "author", labels)
+	b := syntheticImage(rand.New(rand.NewSource(7)), 3, "org", "author", labels)
+
+	assert.Equal(t, "synthetic-00003", a.Name)
+	assert.Equal(t, a, b)
+}
+
+func TestApplyIsIdempotentOnSQLite(t *testing.T) {
+	db, err := database.OpenSQLite(":memory:")
+	require.NoError(t, err)
+	require.NoError(t, database.MigrateDB(context.Background(), db, migrations.SQLite))
+
+	f, err := Load("../../fixtures/demo.yaml")
+	require.NoError(t, err)
+	require.NoError(t, Apply(db, f))
+	require.NoError(t, Apply(db, f))
+
+	var images int64
+	require.NoError(t, db.Model(&models.Image{}).Count(&images).Error)
+	assert.EqualValues(t, len(f.Images), images)
+
+	// 收藏同时出现在用户的默认列表中，重复执行不会产生重复条目
+	var items int64
+	require.NoError(t, db.Model(&models.ListItem{}).
+		Joins("JOIN lists ON lists.id = list_items.list_id").
+		Where("lists.is_default = ?", true).Count(&items).Error)
+	assert.EqualValues(t, len(f.Collections), items)
+
+	created, err := GenerateImages(db, SyntheticOptions{Count: 20, Author: f.Users[0].Username, Seed: 1})
+	require.NoError(t, err)
+	assert.Equal(t, 20, created)
+	created, err = GenerateImages(db, SyntheticOptions{Count: 20, Author: f.Users[0].Username, Seed: 1})
+	require.NoError(t, err)
+	assert.Zero(t, created)
+
+	// 合成镜像没有收藏记录，收藏数必须为 0，否则校准任务会将其全部重置
+	var starred int64
+	require.NoError(t, db.Model(&models.Image{}).
+		Where("name LIKE ? AND stars > 0", SyntheticPrefix+"%").Count(&starred).Error)
+	assert.Zero(t, starred)
+}
@@ -0,0 +1,121 @@
+package seed
+
+import (
+	"fmt"
+	"math/rand"
+
+	"gorm.io/gorm"
+
+	"github.com/samzong/share-ai-platform/internal/models"
+)
+
+// SyntheticPrefix 是合成镜像的名称前缀，重复生成时据此跳过已有镜像
+const SyntheticPrefix = "synthetic-"
+
+const syntheticBatchSize = 500
+
+var (
+	syntheticLabels     = []string{"llm", "vision", "speech", "embedding", "training", "inference", "gpu", "cpu"}
+	syntheticPlatforms  = []string{"linux/amd64", "linux/arm64"}
+	syntheticVisibility = []string{models.VisibilityPublic, models.VisibilityPublic, models.VisibilityRequireAccess, models.VisibilityPrivate}
+)
+
+// SyntheticOptions configures the generated images
+type SyntheticOptions struct {
+	Count  int    // 镜像总数，已存在的合成镜像计入其中
+	Org    string // 组织名称，默认 public
+	Author string // 作者用户名
+	Seed   int64  // 随机种子，相同种子生成相同的数据
+}
+
+// GenerateImages creates synthetic images named synthetic-00001 and so on for load
+// testing, with random labels and visibility. Stars stay at 0 because the star
+// reconcile job resets them to the number of collections rows. Images that already
+// exist are kept, so running it again with a larger count only adds the missing ones.
+// It returns the number of images created.
+func GenerateImages(db *gorm.DB, opts SyntheticOptions) (int, error) {
+	if opts.Org == "" {
+		opts.Org = PublicOrgName
+	}
+	if err := EnsurePublicOrg(db); err != nil {
+		return 0, err
+	}
+
+	s := &seeder{tx: db, users: make(map[string]string), orgs: map[string]string{PublicOrgName: models.PublicOrgID}}
+	orgID, err := s.orgID(opts.Org)
+	if err != nil {
+		return 0, err
+	}
+	authorID, err := s.userID(opts.Author)
+	if err != nil {
+		return 0, err
+	}
+	labels, err := s.labels(syntheticLabels)
+	if err != nil {
+		return 0, err
+	}
+
+	var existing []string
+	if err := db.Model(&models.Image{}).Where("org_id = ? AND name LIKE ?", orgID, SyntheticPrefix+"%").Pluck("name", &existing).Error; err != nil {
+		return 0, err
+	}
+	exists := make(map[string]bool, len(existing))
+	for _, name := range existing {
+		exists[name] = true
+	}
+
+	rng := rand.New(rand.NewSource(opts.Seed))
+	var batch []models.Image
+	created := 0
+	for i := 1; i <= opts.Count; i++ {
+		// 每个镜像都消耗相同数量的随机数，保证已有镜像不影响后续镜像的内容
+		img := syntheticImage(rng, i, orgID, authorID, labels)
+		if exists[img.Name] {
+			continue
+		}
+		batch = append(batch, img)
+		if len(batch) == syntheticBatchSize {
+			if err := db.CreateInBatches(batch, syntheticBatchSize).Error; err != nil {
+				return created, err
+			}
+			created += len(batch)
+			batch = nil
+		}
+	}
+	if len(batch) > 0 {
+		if err := db.CreateInBatches(batch, syntheticBatchSize).Error; err != nil {
+			return created, err
+		}
+		created += len(batch)
+	}
+	return created, nil
+}
+
+func syntheticImage(rng *rand.Rand, i int, orgID, authorID string, labels []models.Label) models.Image {
+	name := fmt.Sprintf("%s%05d", SyntheticPrefix, i)
+	img := Image{Org: orgID, Name: name}
+	img.applyDefaults()
+
+	var picked []models.Label
+	for _, label := range labels {
+		if rng.Intn(4) == 0 {
+			picked = append(picked, label)
+		}
+	}
+
+	return models.Image{
+		OrgID:       orgID,
+		Name:        name,
+		Description: fmt.Sprintf("Synthetic image %d for load testing", i),
+		Author:      authorID,
+		Registry:    img.Registry,
+		Namespace:   "synthetic",
+		Repository:  name,
+		Tag:         fmt.Sprintf("v%d.%d", rng.Intn(5), rng.Intn(20)),
+		Digest:      img.Digest,
+		Size:        rng.Int63n(20 << 30),
+		Visibility:  syntheticVisibility[rng.Intn(len(syntheticVisibility))],
+		Platform:    syntheticPlatforms[rng.Intn(len(syntheticPlatforms))],
+		Labels:      picked,
+	}
+}
@@ -0,0 +1,438 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/spf13/viper"
+	"gorm.io/gorm"
+
+	"github.com/samzong/share-ai-platform/internal/authz"
+	"github.com/samzong/share-ai-platform/internal/database"
+	"github.com/samzong/share-ai-platform/internal/models"
+	"github.com/samzong/share-ai-platform/internal/repository"
+)
+
+var (
+	// ErrImageNotFound is returned for missing images and for private images the user cannot see
+	ErrImageNotFound = errors.New("image not found")
+	// ErrImageAccessRequired is returned when a require_access image has not been granted to the user
+	ErrImageAccessRequired = errors.New("access to this image must be requested and approved")
+)
+
+// defaultReapplyCooldown 申请被拒绝后再次申请同一镜像前需等待的时间
+const defaultReapplyCooldown = 7 * 24 * time.Hour
+
+type AccessService struct{}
+
+type CreateAccessRequest struct {
+	Reason string `json:"reason" binding:"max=500"`
+}
+
+type ListAccessRequestsRequest struct {
+	Status models.AccessRequestStatus `form:"status" binding:"omitempty,oneof=pending approved denied"`
+}
+
+type GrantAccessRequest struct {
+	UserID  string `json:"user_id" binding:"required_without=GroupID"`
+	GroupID string `json:"group_id" binding:"required_without=UserID"`
+}
+
+type AccessRequestResponse struct {
+	ID         string                     `json:"id"`
+	ImageID    string                     `json:"image_id"`
+	ImageName  string                     `json:"image_name"`
+	OrgID      string                     `json:"org_id"`
+	UserID     string                     `json:"user_id,omitempty"`
+	Username   string                     `json:"username,omitempty"`
+	GroupID    string                     `json:"group_id,omitempty"`
+	GroupName  string                     `json:"group_name,omitempty"`
+	Reason     string                     `json:"reason"`
+	Status     models.AccessRequestStatus `json:"status"`
+	ReviewedBy string                     `json:"reviewed_by,omitempty"`
+	ReviewedAt *time.Time                 `json:"reviewed_at,omitempty"`
+	CreatedAt  time.Time                  `json:"created_at"`
+}
+
+// NewAccessService creates a new AccessService
+func NewAccessService() *AccessService {
+	return &AccessService{}
+}
+
+// RequestAccess asks the maintainers of the image's org or project for access to a
+// require_access image. After a denial the user must wait for the cooldown to pass.
+func (s *AccessService) RequestAccess(imageID string, req *CreateAccessRequest, userID string) (*models.AccessRequest, error) {
+	db := database.GetDB()
+
+	viewer, err := newImageViewer(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var image models.Image
+	if err := db.First(&image, "id = ?", imageID).Error; err != nil {
+		return nil, ErrImageNotFound
+	}
+	if err := viewer.load(repository.NewImageRepo(db), []models.Image{image}); err != nil {
+		return nil, err
+	}
+	if !viewer.canSee(&image) {
+		return nil, ErrImageNotFound
+	}
+	if viewer.hasAccess(&image) {
+		return nil, errors.New("you already have access to this image")
+	}
+
+	var existing models.AccessRequest
+	err = db.Where("image_id = ? AND user_id = ? AND status IN ?", imageID, userID,
+		[]models.AccessRequestStatus{models.AccessRequestPending, models.AccessRequestApproved}).
+		First(&existing).Error
+	if err == nil {
+		if existing.Status == models.AccessRequestApproved {
+			return nil, errors.New("you already have access to this image")
+		}
+		return nil, errors.New("an access request for this image is already pending")
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	// 被拒绝后在冷却期内不能重复申请
+	var denied models.AccessRequest
+	err = db.Where("image_id = ? AND user_id = ? AND status = ? AND reviewed_at > ?", imageID, userID,
+		models.AccessRequestDenied, time.Now().Add(-reapplyCooldown())).
+		Order("reviewed_at DESC").
+		First(&denied).Error
+	if err == nil {
+		retryAt := denied.ReviewedAt.Add(reapplyCooldown())
+		return nil, fmt.Errorf("your access request was denied; you can request again after %s", retryAt.Format(time.RFC3339))
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	request := &models.AccessRequest{
+		ImageID: imageID,
+		UserID:  userID,
+		Reason:  req.Reason,
+		Status:  models.AccessRequestPending,
+	}
+	if err := db.Create(request).Error; err != nil {
+		return nil, fmt.Errorf("failed to create access request: %v", err)
+	}
+	return request, nil
+}
+
+// ListMyRequests lists the access requests made by the user
+func (s *AccessService) ListMyRequests(userID string) ([]AccessRequestResponse, error) {
+	return s.listRequests(database.GetDB().Where("access_requests.user_id = ?", userID))
+}
+
+// ListOrgRequests lists the access requests for images in an org, optionally filtered by status.
+// Project maintainers without org-wide rights see the requests for images in their projects.
+func (s *AccessService) ListOrgRequests(orgID string, req *ListAccessRequestsRequest, userID string) ([]AccessRequestResponse, error) {
+	query := database.GetDB().Where("images.org_id = ?", orgID)
+	if err := authz.Check(userID, models.PermImagesManage, authz.Org(orgID)); err != nil {
+		if !errors.Is(err, authz.ErrForbidden) {
+			return nil, err
+		}
+		projectIDs, perr := authz.ProjectsWithPermission(userID, models.PermImagesManage)
+		if perr != nil {
+			return nil, perr
+		}
+		if len(projectIDs) == 0 {
+			return nil, err
+		}
+		query = query.Where("images.project_id IN ?", projectIDs)
+	}
+
+	if req.Status != "" {
+		query = query.Where("access_requests.status = ?", req.Status)
+	}
+	return s.listRequests(query)
+}
+
+// GrantAccess lets maintainers grant a user or a group access to an image in the org
+// without a prior request. The grant is recorded as an approved access request.
+func (s *AccessService) GrantAccess(orgID string, imageID string, req *GrantAccessRequest, userID string) (*models.AccessRequest, error) {
+	db := database.GetDB()
+
+	if req.UserID != "" && req.GroupID != "" {
+		return nil, errors.New("grant access to either a user or a group")
+	}
+
+	var image models.Image
+	if err := db.First(&image, "id = ? AND org_id = ?", imageID, orgID).Error; err != nil {
+		return nil, ErrImageNotFound
+	}
+
+	if err := authz.Check(userID, models.PermImagesManage, imageScope(&image)); err != nil {
+		return nil, err
+	}
+
+	grant := models.AccessRequest{
+		ImageID: imageID,
+		Status:  models.AccessRequestApproved,
+	}
+	if req.GroupID != "" {
+		if _, err := getGroup(db, orgID, req.GroupID); err != nil {
+			return nil, err
+		}
+		grant.GroupID = req.GroupID
+	} else {
+		var user models.User
+		if err := db.First(&user, "id = ?", req.UserID).Error; err != nil {
+			return nil, errors.New("user not found")
+		}
+		grant.UserID = req.UserID
+	}
+
+	// 已有待审批的申请时直接批准它，避免重复记录
+	now := time.Now()
+	err := db.Where(&grant, "image_id", "user_id", "group_id").
+		Where("status IN ?", []models.AccessRequestStatus{models.AccessRequestPending, models.AccessRequestApproved}).
+		Assign(models.AccessRequest{Status: models.AccessRequestApproved, ReviewedBy: userID, ReviewedAt: &now}).
+		FirstOrCreate(&grant).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to grant access: %v", err)
+	}
+	return &grant, nil
+}
+
+// ReviewRequest approves or denies an access request for an image in the org. Maintainers
+// of the image's org or project may review it. Denying an approved request revokes the grant.
+func (s *AccessService) ReviewRequest(orgID string, requestID string, approve bool, userID string) (*models.AccessRequest, error) {
+	db := database.GetDB()
+
+	var request models.AccessRequest
+	if err := db.First(&request, "id = ?", requestID).Error; err != nil {
+		return nil, errors.New("access request not found")
+	}
+	var image models.Image
+	if err := db.First(&image, "id = ? AND org_id = ?", request.ImageID, orgID).Error; err != nil {
+		return nil, errors.New("access request not found")
+	}
+
+	// 在镜像所属项目的作用域内检查权限，项目维护者也可以审批
+	if err := authz.Check(userID, models.PermImagesManage, imageScope(&image)); err != nil {
+		return nil, err
+	}
+
+	status := models.AccessRequestDenied
+	if approve {
+		status = models.AccessRequestApproved
+	}
+	if request.Status == status {
+		return nil, fmt.Errorf("access request is already %s", status)
+	}
+	if request.Status == models.AccessRequestDenied {
+		return nil, errors.New("denied access requests cannot be reopened")
+	}
+
+	now := time.Now()
+	err := db.Model(&request).Updates(models.AccessRequest{
+		Status:     status,
+		ReviewedBy: userID,
+		ReviewedAt: &now,
+	}).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to update access request: %v", err)
+	}
+	return &request, nil
+}
+
+// reapplyCooldown returns how long a user must wait after a denial before requesting again
+func reapplyCooldown() time.Duration {
+	if days := viper.GetInt("access_requests.reapply_cooldown_days"); days > 0 {
+		return time.Duration(days) * 24 * time.Hour
+	}
+	return defaultReapplyCooldown
+}
+
+func (s *AccessService) listRequests(query *gorm.DB) ([]AccessRequestResponse, error) {
+	var requests []AccessRequestResponse
+	err := query.Table("access_requests").
+		Select("access_requests.*, images.name AS image_name, images.org_id, users.username, groups.name AS group_name").
+		Joins("JOIN images ON images.id = access_requests.image_id").
+		Joins("LEFT JOIN users ON CAST(users.id AS TEXT) = access_requests.user_id").
+		Joins("LEFT JOIN groups ON CAST(groups.id AS TEXT) = access_requests.group_id").
+		Order("access_requests.created_at DESC").
+		Scan(&requests).Error
+	if err != nil {
+		return nil, err
+	}
+	return requests, nil
+}
+
+// imageViewer captures what a user may see: public images, images they authored,
+// every image in orgs and projects where they hold images.read, and require_access
+// images they were granted. An image in a project takes the stricter of its own
+// and the project's visibility.
+type imageViewer struct {
+	userID     string
+	allOrgs    bool
+	orgIDs     map[string]bool
+	projectIDs map[string]bool
+	groupIDs   []string
+	projects   map[string]string // 项目ID -> 项目可见性
+	granted    map[string]bool   // 已获批访问的镜像
+}
+
+// newImageViewer resolves the orgs and projects readable by the user. An empty userID is an anonymous viewer.
+func newImageViewer(userID string) (*imageViewer, error) {
+	return resolveImageViewer(defaultAuthorizer, userID)
+}
+
+func resolveImageViewer(az Authorizer, userID string) (*imageViewer, error) {
+	v := &imageViewer{
+		userID:     userID,
+		orgIDs:     make(map[string]bool),
+		projectIDs: make(map[string]bool),
+		projects:   make(map[string]string),
+		granted:    make(map[string]bool),
+	}
+	if userID == "" {
+		return v, nil
+	}
+
+	all, orgIDs, err := az.OrgsWithPermission(userID, models.PermImagesRead)
+	if err != nil {
+		return nil, err
+	}
+	v.allOrgs = all
+
+	if v.groupIDs, err = az.GroupIDs(userID); err != nil {
+		return nil, err
+	}
+	for _, id := range orgIDs {
+		v.orgIDs[id] = true
+	}
+
+	if !all {
+		projectIDs, err := az.ProjectsWithPermission(userID, models.PermImagesRead)
+		if err != nil {
+			return nil, err
+		}
+		for _, id := range projectIDs {
+			v.projectIDs[id] = true
+		}
+	}
+	return v, nil
+}
+
+// load fetches the project visibilities and access grants needed to judge the images
+func (v *imageViewer) load(repo repository.ImageRepo, images []models.Image) error {
+	var projectIDs []string
+	for _, img := range images {
+		if img.ProjectID != nil {
+			if _, ok := v.projects[*img.ProjectID]; !ok {
+				projectIDs = append(projectIDs, *img.ProjectID)
+			}
+		}
+	}
+	if len(projectIDs) > 0 {
+		visibilities, err := repo.ProjectVisibilities(projectIDs)
+		if err != nil {
+			return err
+		}
+		for id, visibility := range visibilities {
+			v.projects[id] = visibility
+		}
+	}
+
+	if v.userID == "" {
+		return nil
+	}
+
+	var ids []string
+	for _, img := range images {
+		if v.visibility(&img) == models.VisibilityRequireAccess && !v.isInsider(&img) {
+			ids = append(ids, img.ID)
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	approved, err := repo.GrantedImageIDs(v.userID, v.groupIDs, ids)
+	if err != nil {
+		return err
+	}
+	for _, id := range approved {
+		v.granted[id] = true
+	}
+	return nil
+}
+
+// visibility returns the effective visibility of the image, taking its project into account
+func (v *imageViewer) visibility(image *models.Image) string {
+	if image.ProjectID == nil {
+		return image.Visibility
+	}
+	return models.StricterVisibility(image.Visibility, v.projects[*image.ProjectID])
+}
+
+// isInsider reports whether the user has full access without an access request
+func (v *imageViewer) isInsider(image *models.Image) bool {
+	if v.userID == "" {
+		return false
+	}
+	if v.allOrgs || v.orgIDs[image.OrgID] || image.Author == v.userID {
+		return true
+	}
+	return image.ProjectID != nil && v.projectIDs[*image.ProjectID]
+}
+
+// canSee reports whether the image shows up for the user at all
+func (v *imageViewer) canSee(image *models.Image) bool {
+	return v.visibility(image) != models.VisibilityPrivate || v.isInsider(image)
+}
+
+// hasAccess reports whether the user may pull the image
+func (v *imageViewer) hasAccess(image *models.Image) bool {
+	switch v.visibility(image) {
+	case models.VisibilityPublic:
+		return true
+	case models.VisibilityRequireAccess:
+		return v.isInsider(image) || v.granted[image.ID]
+	default:
+		return v.isInsider(image)
+	}
+}
+
+// listScope describes the images the user can see for repository queries
+func (v *imageViewer) listScope() repository.ImageScope {
+	return repository.ImageScope{
+		All:        v.allOrgs,
+		UserID:     v.userID,
+		OrgIDs:     mapKeys(v.orgIDs),
+		ProjectIDs: mapKeys(v.projectIDs),
+	}
+}
+
+// checkImageAccess returns ErrImageNotFound or ErrImageAccessRequired unless the user may pull the image
+func checkImageAccess(repo repository.ImageRepo, az Authorizer, image *models.Image, userID string) error {
+	viewer, err := resolveImageViewer(az, userID)
+	if err != nil {
+		return err
+	}
+	if err := viewer.load(repo, []models.Image{*image}); err != nil {
+		return err
+	}
+	if !viewer.canSee(image) {
+		return ErrImageNotFound
+	}
+	if !viewer.hasAccess(image) {
+		return ErrImageAccessRequired
+	}
+	return nil
+}
+
+func mapKeys(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	return keys
+}
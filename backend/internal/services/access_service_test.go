@@ -0,0 +1,115 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/samzong/share-ai-platform/internal/authz"
+	"github.com/samzong/share-ai-platform/internal/database"
+	"github.com/samzong/share-ai-platform/internal/models"
+)
+
+// accessFixture holds the IDs created by setupAccess
+type accessFixture struct {
+	owner, maintainer, requester string
+	org                          string
+	projectImage, orgImage       string
+}
+
+// setupAccess creates an org owned by owner with a require_access image directly in the
+// org and one in a project that maintainer maintains. requester belongs to neither.
+func setupAccess(t *testing.T) accessFixture {
+	require.NoError(t, database.SetupTestDB())
+	t.Cleanup(database.TeardownTestDB)
+	db := database.GetDB()
+
+	var f accessFixture
+	for _, u := range []struct {
+		id   *string
+		name string
+	}{
+		{&f.owner, "owner"},
+		{&f.maintainer, "maintainer"},
+		{&f.requester, "requester"},
+	} {
+		user := &models.User{Username: u.name, Email: u.name + "@example.com", Password: "secret", Role: models.RoleUser}
+		require.NoError(t, db.Create(user).Error)
+		*u.id = user.ID
+	}
+
+	org := &models.Organization{Name: "acme"}
+	require.NoError(t, db.Create(org).Error)
+	f.org = org.ID
+	project := &models.Project{OrgID: f.org, Name: "rocket"}
+	require.NoError(t, db.Create(project).Error)
+	require.NoError(t, db.Create(&models.OrgMember{OrgID: f.org, UserID: f.owner, Role: models.OrgRoleOwner}).Error)
+	require.NoError(t, db.Create(&models.ProjectMember{ProjectID: project.ID, UserID: f.maintainer, Role: models.ProjectRoleMaintainer}).Error)
+
+	newImage := func(name string, projectID *string) string {
+		image := &models.Image{OrgID: f.org, ProjectID: projectID, Name: name, Author: f.owner, Registry: "docker.io",
+			Repository: name, Tag: "latest", Digest: "sha256:" + name, Platform: "linux/amd64",
+			Visibility: models.VisibilityRequireAccess}
+		require.NoError(t, db.Create(image).Error)
+		return image.ID
+	}
+	f.projectImage = newImage("project-image", &project.ID)
+	f.orgImage = newImage("org-image", nil)
+	return f
+}
+
+func TestAccessService_DeniedRequestCooldown(t *testing.T) {
+	f := setupAccess(t)
+	service := NewAccessService()
+
+	request, err := service.RequestAccess(f.orgImage, &CreateAccessRequest{Reason: "please"}, f.requester)
+	require.NoError(t, err)
+	_, err = service.ReviewRequest(f.org, request.ID, false, f.owner)
+	require.NoError(t, err)
+
+	// 冷却期内再次申请被拒绝
+	_, err = service.RequestAccess(f.orgImage, &CreateAccessRequest{Reason: "please again"}, f.requester)
+	assert.ErrorContains(t, err, "denied")
+
+	// 冷却期过后可以再次申请
+	expired := time.Now().Add(-defaultReapplyCooldown - time.Hour)
+	require.NoError(t, database.GetDB().Model(&models.AccessRequest{}).
+		Where("id = ?", request.ID).Update("reviewed_at", expired).Error)
+	again, err := service.RequestAccess(f.orgImage, &CreateAccessRequest{Reason: "please again"}, f.requester)
+	require.NoError(t, err)
+	assert.Equal(t, models.AccessRequestPending, again.Status)
+}
+
+func TestAccessService_ProjectMaintainerReviews(t *testing.T) {
+	f := setupAccess(t)
+	service := NewAccessService()
+
+	projectRequest, err := service.RequestAccess(f.projectImage, &CreateAccessRequest{}, f.requester)
+	require.NoError(t, err)
+	orgRequest, err := service.RequestAccess(f.orgImage, &CreateAccessRequest{}, f.requester)
+	require.NoError(t, err)
+
+	// 项目维护者只能看到并审批其项目内镜像的申请
+	requests, err := service.ListOrgRequests(f.org, &ListAccessRequestsRequest{}, f.maintainer)
+	require.NoError(t, err)
+	require.Len(t, requests, 1)
+	assert.Equal(t, projectRequest.ID, requests[0].ID)
+
+	_, err = service.ReviewRequest(f.org, orgRequest.ID, true, f.maintainer)
+	assert.ErrorIs(t, err, authz.ErrForbidden)
+	reviewed, err := service.ReviewRequest(f.org, projectRequest.ID, true, f.maintainer)
+	require.NoError(t, err)
+	assert.Equal(t, models.AccessRequestApproved, reviewed.Status)
+
+	// 组织维护者可以审批组织内所有镜像的申请
+	requests, err = service.ListOrgRequests(f.org, &ListAccessRequestsRequest{}, f.owner)
+	require.NoError(t, err)
+	assert.Len(t, requests, 2)
+	_, err = service.ReviewRequest(f.org, orgRequest.ID, true, f.owner)
+	assert.NoError(t, err)
+
+	_, err = service.ListOrgRequests(f.org, &ListAccessRequestsRequest{}, f.requester)
+	assert.ErrorIs(t, err, authz.ErrForbidden)
+}
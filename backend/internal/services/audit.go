@@ -0,0 +1,24 @@
+package services
+
+import (
+	"log"
+
+	"github.com/samzong/share-ai-platform/internal/database"
+	"github.com/samzong/share-ai-platform/internal/models"
+)
+
+// recordAudit writes an audit log entry. Failures are logged rather than returned
+// so that auditing never blocks the operation being audited.
+func recordAudit(action, actorID, target, ip, detail string) {
+	entry := &models.AuditLog{
+		Action:  action,
+		ActorID: actorID,
+		Target:  target,
+		IP:      ip,
+		Detail:  detail,
+	}
+
+	if err := database.GetDB().Create(entry).Error; err != nil {
+		log.Printf("Error writing audit log %s for %s: %v", action, target, err)
+	}
+}
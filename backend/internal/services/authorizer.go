@@ -0,0 +1,46 @@
+package services
+
+import (
+	"github.com/samzong/share-ai-platform/internal/authz"
+	"github.com/samzong/share-ai-platform/internal/models"
+)
+
+// Authorizer answers the permission questions services ask. The default implementation
+// delegates to the authz package; tests can substitute their own.
+type Authorizer interface {
+	Check(userID string, perm models.Permission, scope authz.Scope) error
+	CanGrant(userID string, role models.Role, scope authz.Scope) (bool, error)
+	RoleExists(role models.Role) (bool, error)
+	OrgsWithPermission(userID string, perm models.Permission) (all bool, orgIDs []string, err error)
+	ProjectsWithPermission(userID string, perm models.Permission) ([]string, error)
+	GroupIDs(userID string) ([]string, error)
+}
+
+// defaultAuthorizer resolves permissions from the database through the authz package
+var defaultAuthorizer Authorizer = authzAuthorizer{}
+
+type authzAuthorizer struct{}
+
+func (authzAuthorizer) Check(userID string, perm models.Permission, scope authz.Scope) error {
+	return authz.Check(userID, perm, scope)
+}
+
+func (authzAuthorizer) CanGrant(userID string, role models.Role, scope authz.Scope) (bool, error) {
+	return authz.CanGrant(userID, role, scope)
+}
+
+func (authzAuthorizer) RoleExists(role models.Role) (bool, error) {
+	return authz.RoleExists(role)
+}
+
+func (authzAuthorizer) OrgsWithPermission(userID string, perm models.Permission) (bool, []string, error) {
+	return authz.OrgsWithPermission(userID, perm)
+}
+
+func (authzAuthorizer) ProjectsWithPermission(userID string, perm models.Permission) ([]string, error) {
+	return authz.ProjectsWithPermission(userID, perm)
+}
+
+func (authzAuthorizer) GroupIDs(userID string) ([]string, error) {
+	return authz.GroupIDs(userID)
+}
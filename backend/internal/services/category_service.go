@@ -0,0 +1,314 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/samzong/share-ai-platform/internal/authz"
+	"github.com/samzong/share-ai-platform/internal/database"
+	"github.com/samzong/share-ai-platform/internal/models"
+	"github.com/samzong/share-ai-platform/internal/repository"
+)
+
+var (
+	// ErrCategoryNotFound is returned when a category does not exist
+	ErrCategoryNotFound = errors.New("category not found")
+	// ErrCategoryHasChildren is returned when deleting a category that still has subcategories
+	ErrCategoryHasChildren = errors.New("category has subcategories")
+)
+
+// maxCategoryDepth limits how deep the category tree can nest
+const maxCategoryDepth = 4
+
+type CategoryService struct {
+	categories repository.CategoryRepo
+	authorizer Authorizer
+}
+
+type CreateCategoryRequest struct {
+	Name        string `json:"name" binding:"required,min=1,max=100"`
+	Slug        string `json:"slug" binding:"omitempty,max=100"` // 为空时由名称生成，名称无法生成时必填
+	Icon        string `json:"icon"`
+	Description string `json:"description"`
+	ParentID    string `json:"parent_id"` // 为空表示顶级分类
+	Position    int    `json:"position"`
+}
+
+type UpdateCategoryRequest struct {
+	Name        *string `json:"name" binding:"omitempty,min=1,max=100"`
+	Slug        *string `json:"slug" binding:"omitempty,max=100"`
+	Icon        *string `json:"icon"`
+	Description *string `json:"description"`
+	ParentID    *string `json:"parent_id"` // 空字符串表示移动为顶级分类
+	Position    *int    `json:"position"`
+}
+
+type CategoryResponse struct {
+	ID          string             `json:"id"`                  // 分类唯一标识符
+	ParentID    *string            `json:"parent_id,omitempty"` // 上级分类ID
+	Name        string             `json:"name"`                // 分类显示名称
+	Slug        string             `json:"slug"`                // URL 标识，用于筛选镜像
+	Icon        string             `json:"icon"`                // 图标
+	Description string             `json:"description"`         // 分类描述
+	Position    int                `json:"position"`            // 同级分类中的排序
+	Children    []CategoryResponse `json:"children"`            // 子分类
+	CreatedAt   time.Time          `json:"created_at"`          // 创建时间
+	UpdatedAt   time.Time          `json:"updated_at"`          // 更新时间
+}
+
+// NewCategoryService creates a new CategoryService backed by the database
+func NewCategoryService() *CategoryService {
+	return NewCategoryServiceWith(repository.NewGorm(database.GetDB()), defaultAuthorizer)
+}
+
+// NewCategoryServiceWith creates a CategoryService on the given repositories and authorizer
+func NewCategoryServiceWith(repos *repository.Repositories, az Authorizer) *CategoryService {
+	return &CategoryService{
+		categories: repos.Categories,
+		authorizer: az,
+	}
+}
+
+// ListCategories returns the category tree, siblings ordered by position and then by name
+func (s *CategoryService) ListCategories() ([]CategoryResponse, error) {
+	categories, err := s.categories.List()
+	if err != nil {
+		return nil, err
+	}
+	return newCategoryTree(categories).responses(""), nil
+}
+
+// CreateCategory adds a category, optionally under a parent
+func (s *CategoryService) CreateCategory(ctx context.Context, req *CreateCategoryRequest, userID string) (*CategoryResponse, error) {
+	if err := s.authorizer.Check(userID, models.PermCategoriesManage, authz.Global()); err != nil {
+		return nil, err
+	}
+
+	category := &models.Category{
+		Name:        req.Name,
+		Slug:        req.Slug,
+		Icon:        req.Icon,
+		Description: req.Description,
+		Position:    req.Position,
+	}
+	if req.ParentID != "" {
+		category.ParentID = &req.ParentID
+	}
+	if err := s.validate(category); err != nil {
+		return nil, err
+	}
+
+	if err := s.categories.Create(category); err != nil {
+		return nil, categorySaveError(err)
+	}
+	invalidateImageCache(ctx)
+
+	response := newCategoryResponse(category)
+	return &response, nil
+}
+
+// UpdateCategory changes a category, including moving it under another parent
+func (s *CategoryService) UpdateCategory(ctx context.Context, id string, req *UpdateCategoryRequest, userID string) (*CategoryResponse, error) {
+	if err := s.authorizer.Check(userID, models.PermCategoriesManage, authz.Global()); err != nil {
+		return nil, err
+	}
+
+	category, err := s.categories.FindByID(id)
+	if err != nil {
+		return nil, ErrCategoryNotFound
+	}
+
+	if req.Name != nil {
+		category.Name = *req.Name
+	}
+	if req.Slug != nil {
+		category.Slug = *req.Slug
+	}
+	if req.Icon != nil {
+		category.Icon = *req.Icon
+	}
+	if req.Description != nil {
+		category.Description = *req.Description
+	}
+	if req.Position != nil {
+		category.Position = *req.Position
+	}
+	if req.ParentID != nil {
+		category.ParentID = nil
+		if *req.ParentID != "" {
+			category.ParentID = req.ParentID
+		}
+	}
+	if err := s.validate(category); err != nil {
+		return nil, err
+	}
+
+	if err := s.categories.Save(category); err != nil {
+		return nil, categorySaveError(err)
+	}
+	invalidateImageCache(ctx)
+
+	response := newCategoryResponse(category)
+	return &response, nil
+}
+
+// DeleteCategory removes a category without subcategories. Images in the category keep
+// their other categories.
+func (s *CategoryService) DeleteCategory(ctx context.Context, id string, userID string) error {
+	if err := s.authorizer.Check(userID, models.PermCategoriesManage, authz.Global()); err != nil {
+		return err
+	}
+
+	categories, err := s.categories.List()
+	if err != nil {
+		return err
+	}
+	tree := newCategoryTree(categories)
+	category, ok := tree.byID[id]
+	if !ok {
+		return ErrCategoryNotFound
+	}
+	if len(tree.children[id]) > 0 {
+		return ErrCategoryHasChildren
+	}
+
+	if err := s.categories.Delete(category); err != nil {
+		return fmt.Errorf("failed to delete category: %v", err)
+	}
+	invalidateImageCache(ctx)
+	return nil
+}
+
+// validate fills in a missing slug and checks that the parent exists, does not create a
+// cycle and keeps the tree within maxCategoryDepth
+func (s *CategoryService) validate(category *models.Category) error {
+	if category.Slug == "" {
+		category.Slug = slugify(category.Name)
+		if category.Slug == "" {
+			return errors.New("slug is required when the name has no latin letters or digits")
+		}
+	}
+	if !models.IsValidSlug(category.Slug) {
+		return errors.New("slug may only contain lowercase letters, digits and single hyphens")
+	}
+
+	categories, err := s.categories.List()
+	if err != nil {
+		return err
+	}
+	tree := newCategoryTree(categories)
+
+	depth := 1
+	for parentID := category.ParentID; parentID != nil; parentID = tree.byID[*parentID].ParentID {
+		if *parentID == category.ID {
+			return errors.New("a category cannot be moved under itself or its subcategories")
+		}
+		if _, ok := tree.byID[*parentID]; !ok {
+			return errors.New("parent category not found")
+		}
+		depth++
+	}
+	if depth+tree.height(category.ID)-1 > maxCategoryDepth {
+		return fmt.Errorf("categories can be nested at most %d levels deep", maxCategoryDepth)
+	}
+	return nil
+}
+
+// slugify derives a slug from a name by lowercasing it and joining runs of latin letters
+// and digits with hyphens
+func slugify(name string) string {
+	words := strings.FieldsFunc(strings.ToLower(name), func(r rune) bool {
+		return (r < 'a' || r > 'z') && (r < '0' || r > '9')
+	})
+	return strings.Join(words, "-")
+}
+
+func categorySaveError(err error) error {
+	if errors.Is(err, repository.ErrDuplicate) {
+		return errors.New("a category with this slug already exists")
+	}
+	return fmt.Errorf("failed to save category: %v", err)
+}
+
+// categoryTree indexes a flat list of categories by ID and by parent
+type categoryTree struct {
+	byID     map[string]*models.Category
+	children map[string][]*models.Category // 上级分类ID -> 子分类，顶级分类的键为空字符串
+}
+
+func newCategoryTree(categories []models.Category) *categoryTree {
+	tree := &categoryTree{
+		byID:     make(map[string]*models.Category, len(categories)),
+		children: make(map[string][]*models.Category),
+	}
+	for i := range categories {
+		category := &categories[i]
+		tree.byID[category.ID] = category
+		parentID := ""
+		if category.ParentID != nil {
+			parentID = *category.ParentID
+		}
+		tree.children[parentID] = append(tree.children[parentID], category)
+	}
+	return tree
+}
+
+// subtree returns the IDs of the category and all of its descendants
+func (t *categoryTree) subtree(id string) []string {
+	ids := []string{id}
+	for i := 0; i < len(ids); i++ {
+		for _, child := range t.children[ids[i]] {
+			ids = append(ids, child.ID)
+		}
+	}
+	return ids
+}
+
+// height returns the number of levels in the subtree rooted at the category
+func (t *categoryTree) height(id string) int {
+	if id == "" {
+		return 1
+	}
+	height := 0
+	for _, child := range t.children[id] {
+		height = max(height, t.height(child.ID))
+	}
+	return height + 1
+}
+
+func (t *categoryTree) responses(parentID string) []CategoryResponse {
+	responses := make([]CategoryResponse, 0, len(t.children[parentID]))
+	for _, category := range t.children[parentID] {
+		response := newCategoryResponse(category)
+		response.Children = t.responses(category.ID)
+		responses = append(responses, response)
+	}
+	return responses
+}
+
+func newCategoryResponse(category *models.Category) CategoryResponse {
+	return CategoryResponse{
+		ID:          category.ID,
+		ParentID:    category.ParentID,
+		Name:        category.Name,
+		Slug:        category.Slug,
+		Icon:        category.Icon,
+		Description: category.Description,
+		Position:    category.Position,
+		Children:    []CategoryResponse{},
+		CreatedAt:   category.CreatedAt,
+		UpdatedAt:   category.UpdatedAt,
+	}
+}
+
+// categorySlugs returns the slugs of the categories
+func categorySlugs(categories []models.Category) []string {
+	slugs := make([]string, len(categories))
+	for i, category := range categories {
+		slugs[i] = category.Slug
+	}
+	return slugs
+}
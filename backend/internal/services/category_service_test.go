@@ -0,0 +1,95 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/samzong/share-ai-platform/internal/authz"
+	"github.com/samzong/share-ai-platform/internal/models"
+	"github.com/samzong/share-ai-platform/internal/repository"
+)
+
+func setupCategoryTest(t *testing.T) (*CategoryService, *ImageService, *repository.Memory) {
+	repos := repository.NewMemory()
+	az := newFakeAuthorizer()
+	az.admins["admin"] = true
+	return NewCategoryServiceWith(&repos.Repositories, az), NewImageServiceWith(&repos.Repositories, az, NewEventRecorder(repos.Stats)), repos
+}
+
+func TestCategoryService_Tree(t *testing.T) {
+	service, _, _ := setupCategoryTest(t)
+	ctx := context.Background()
+
+	_, err := service.CreateCategory(ctx, &CreateCategoryRequest{Name: "NLP"}, "user-1")
+	assert.ErrorIs(t, err, authz.ErrForbidden)
+
+	nlp, err := service.CreateCategory(ctx, &CreateCategoryRequest{Name: "NLP"}, "admin")
+	require.NoError(t, err)
+	assert.Equal(t, "nlp", nlp.Slug)
+	generation, err := service.CreateCategory(ctx, &CreateCategoryRequest{Name: "Text Generation", ParentID: nlp.ID}, "admin")
+	require.NoError(t, err)
+	assert.Equal(t, "text-generation", generation.Slug)
+
+	_, err = service.CreateCategory(ctx, &CreateCategoryRequest{Name: "nlp"}, "admin")
+	assert.Error(t, err, "slugs are unique")
+	_, err = service.CreateCategory(ctx, &CreateCategoryRequest{Name: "文本分类"}, "admin")
+	assert.Error(t, err, "slug is required when it cannot be derived")
+
+	parent := generation.ID
+	_, err = service.UpdateCategory(ctx, nlp.ID, &UpdateCategoryRequest{ParentID: &parent}, "admin")
+	assert.Error(t, err, "a category cannot move under its own subcategory")
+
+	tree, err := service.ListCategories()
+	require.NoError(t, err)
+	require.Len(t, tree, 1)
+	require.Len(t, tree[0].Children, 1)
+	assert.Equal(t, generation.ID, tree[0].Children[0].ID)
+
+	assert.ErrorIs(t, service.DeleteCategory(ctx, nlp.ID, "admin"), ErrCategoryHasChildren)
+	assert.NoError(t, service.DeleteCategory(ctx, generation.ID, "admin"))
+	assert.NoError(t, service.DeleteCategory(ctx, nlp.ID, "admin"))
+}
+
+func TestImageService_ListImagesByCategorySubtree(t *testing.T) {
+	service, images, repos := setupCategoryTest(t)
+	ctx := context.Background()
+
+	nlp, err := service.CreateCategory(ctx, &CreateCategoryRequest{Name: "NLP"}, "admin")
+	require.NoError(t, err)
+	_, err = service.CreateCategory(ctx, &CreateCategoryRequest{Name: "Text Generation", ParentID: nlp.ID}, "admin")
+	require.NoError(t, err)
+	_, err = service.CreateCategory(ctx, &CreateCategoryRequest{Name: "Vision"}, "admin")
+	require.NoError(t, err)
+
+	categorize := func(image *models.Image, slugs ...string) {
+		categories, err := images.resolveCategories(slugs)
+		require.NoError(t, err)
+		require.NoError(t, repos.Images.Update(image, nil, categories))
+	}
+	llama := createTestImage(t, repos, "llama", models.VisibilityPublic)
+	categorize(llama, "text-generation")
+	bert := createTestImage(t, repos, "bert", models.VisibilityPublic)
+	categorize(bert, "nlp")
+	yolo := createTestImage(t, repos, "yolo", models.VisibilityPublic)
+	categorize(yolo, "vision")
+
+	listed, total, err := images.ListImages(ctx, &ImageListRequest{Category: "nlp"}, "")
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), total)
+	for _, img := range listed {
+		assert.NotEqual(t, yolo.ID, img.ID)
+	}
+
+	listed, total, err = images.ListImages(ctx, &ImageListRequest{Category: "text-generation"}, "")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+	assert.Equal(t, []string{"text-generation"}, listed[0].Categories)
+
+	_, _, err = images.ListImages(ctx, &ImageListRequest{Category: "audio"}, "")
+	assert.ErrorIs(t, err, ErrCategoryNotFound)
+	_, err = images.resolveCategories([]string{"audio"})
+	assert.Error(t, err)
+}
@@ -1,13 +1,16 @@
 package services
 
 import (
-	"errors"
-
 	"github.com/samzong/share-ai-platform/internal/database"
 	"github.com/samzong/share-ai-platform/internal/models"
+	"github.com/samzong/share-ai-platform/internal/repository"
 )
 
-type DeployService struct{}
+type DeployService struct {
+	images     repository.ImageRepo
+	events     *EventRecorder
+	authorizer Authorizer
+}
 
 type DeployRequest struct {
 	ImageID string                 `json:"image_id" binding:"required"`
@@ -19,21 +22,32 @@ type DeployResponse struct {
 	Params  map[string]interface{} `json:"params"`
 }
 
-// NewDeployService creates a new DeployService
+// NewDeployService creates a new DeployService backed by the database
 func NewDeployService() *DeployService {
-	return &DeployService{}
+	return NewDeployServiceWith(repository.NewGorm(database.GetDB()), defaultAuthorizer, defaultEventRecorder())
 }
 
-// Deploy prepares deployment information for an image
-func (s *DeployService) Deploy(req *DeployRequest) (*DeployResponse, error) {
-	db := database.GetDB()
+// NewDeployServiceWith creates a DeployService on the given repositories and authorizer,
+// buffering deploy events in events
+func NewDeployServiceWith(repos *repository.Repositories, az Authorizer, events *EventRecorder) *DeployService {
+	return &DeployService{images: repos.Images, events: events, authorizer: az}
+}
 
+// Deploy prepares deployment information for an image the user has access to
+func (s *DeployService) Deploy(req *DeployRequest, userID string) (*DeployResponse, error) {
 	// Verify image exists
-	var image models.Image
-	if err := db.First(&image, "id = ?", req.ImageID).Error; err != nil {
-		return nil, errors.New("image not found")
+	image, err := s.images.FindByID(req.ImageID)
+	if err != nil {
+		return nil, ErrImageNotFound
 	}
 
+	if err := checkImageAccess(s.images, s.authorizer, image, userID); err != nil {
+		return nil, err
+	}
+
+	// 部署次数计入镜像统计和热度
+	s.events.Record(newImageEvent(image.ID, models.EventDeploy, EventActor{UserID: userID}))
+
 	return &DeployResponse{
 		ImageID: req.ImageID,
 		Params:  req.Params,
@@ -0,0 +1,150 @@
+package services
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	"github.com/samzong/share-ai-platform/internal/database"
+	"github.com/samzong/share-ai-platform/internal/models"
+	"github.com/samzong/share-ai-platform/internal/repository"
+)
+
+const (
+	// eventBatchSize is how many buffered events trigger a write before the next flush
+	eventBatchSize = 500
+	// maxPendingEvents bounds the buffer while the database is unavailable; older events are dropped
+	maxPendingEvents = 20 * eventBatchSize
+)
+
+// EventRecorder buffers image events in memory and writes them in batches, so that
+// recording an event never costs a database write on the request path
+type EventRecorder struct {
+	stats repository.StatsRepo
+
+	mu       sync.Mutex
+	pending  []models.ImageEvent
+	flushing bool
+}
+
+// EventActor identifies who caused an event: a logged-in user, optionally working in an
+// org, or an anonymous visitor
+type EventActor struct {
+	UserID      string
+	OrgID       string
+	AnonymousID string
+}
+
+var (
+	defaultEventsOnce sync.Once
+	defaultEvents     *EventRecorder
+)
+
+// NewEventRecorder creates an EventRecorder writing to the given repository
+func NewEventRecorder(stats repository.StatsRepo) *EventRecorder {
+	return &EventRecorder{stats: stats}
+}
+
+// defaultEventRecorder returns the recorder shared by the database-backed services
+func defaultEventRecorder() *EventRecorder {
+	defaultEventsOnce.Do(func() {
+		defaultEvents = NewEventRecorder(repository.NewStatsRepo(database.GetDB()))
+	})
+	return defaultEvents
+}
+
+// StartEventFlush writes the buffered image events every interval in the background
+func StartEventFlush(interval time.Duration) {
+	recorder := defaultEventRecorder()
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			if err := recorder.Flush(); err != nil {
+				log.Printf("Error writing image events: %v", err)
+			}
+		}
+	}()
+}
+
+// FlushEvents writes the events buffered by the database-backed services. It is called on
+// shutdown so that events recorded since the last flush are not lost.
+func FlushEvents() error {
+	return defaultEventRecorder().Flush()
+}
+
+// StartEventRollup rolls the stored image events up into daily counters every interval
+// in the background, deleting events older than retention unless it is zero
+func StartEventRollup(interval time.Duration, retention time.Duration) {
+	stats := repository.NewStatsRepo(database.GetDB())
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			if err := RollupEvents(stats, retention); err != nil {
+				log.Printf("Error rolling up image events: %v", err)
+			}
+			<-ticker.C
+		}
+	}()
+}
+
+// RollupEvents recomputes the daily counters of yesterday and today and deletes the
+// events older than retention
+func RollupEvents(stats repository.StatsRepo, retention time.Duration) error {
+	now := time.Now()
+	// 同时重新计算昨天的统计，把零点前最后一次汇总之后写入的事件计入
+	if err := stats.Rollup(now.Add(-24 * time.Hour)); err != nil {
+		return err
+	}
+	if retention <= 0 {
+		return nil
+	}
+	pruned, err := stats.PruneEvents(models.StatDay(now.Add(-retention)))
+	if err != nil {
+		return err
+	}
+	if pruned > 0 {
+		log.Printf("Deleted %d image events older than %s", pruned, retention)
+	}
+	return nil
+}
+
+// Record buffers an event, starting a write in the background once a batch is full
+func (r *EventRecorder) Record(event models.ImageEvent) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.pending = append(r.pending, event)
+	if len(r.pending) > maxPendingEvents {
+		r.pending = r.pending[len(r.pending)-maxPendingEvents:]
+	}
+	if len(r.pending) >= eventBatchSize && !r.flushing {
+		r.flushing = true
+		go func() {
+			if err := r.Flush(); err != nil {
+				log.Printf("Error writing image events: %v", err)
+			}
+		}()
+	}
+}
+
+// Flush writes the buffered events. Events that fail to be written are kept for the next flush.
+func (r *EventRecorder) Flush() error {
+	r.mu.Lock()
+	events := r.pending
+	r.pending = nil
+	r.mu.Unlock()
+
+	err := r.stats.AddEvents(events)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.flushing = false
+	if err != nil {
+		r.pending = append(events, r.pending...)
+		if len(r.pending) > maxPendingEvents {
+			r.pending = r.pending[len(r.pending)-maxPendingEvents:]
+		}
+	}
+	return err
+}
@@ -0,0 +1,207 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+
+	"gorm.io/gorm"
+
+	"github.com/samzong/share-ai-platform/internal/authz"
+	"github.com/samzong/share-ai-platform/internal/database"
+	"github.com/samzong/share-ai-platform/internal/models"
+)
+
+type GroupService struct{}
+
+type CreateGroupRequest struct {
+	Name        string `json:"name" binding:"required,min=2,max=50"`
+	Description string `json:"description"`
+	ParentID    string `json:"parent_id"`
+}
+
+type AddGroupMemberRequest struct {
+	UserID string `json:"user_id" binding:"required"`
+}
+
+type GroupMemberResponse struct {
+	UserID   string `json:"user_id"`
+	Username string `json:"username"`
+}
+
+// NewGroupService creates a new GroupService
+func NewGroupService() *GroupService {
+	return &GroupService{}
+}
+
+// CreateGroup creates a group in the org, optionally nested under a top-level group
+func (s *GroupService) CreateGroup(orgID string, req *CreateGroupRequest, userID string) (*models.Group, error) {
+	db := database.GetDB()
+
+	var org models.Organization
+	if err := db.First(&org, "id = ?", orgID).Error; err != nil {
+		return nil, errors.New("organization not found")
+	}
+
+	if err := authz.Check(userID, models.PermGroupsManage, authz.Org(orgID)); err != nil {
+		return nil, err
+	}
+
+	var count int64
+	if err := db.Model(&models.Group{}).Where("org_id = ? AND name = ?", orgID, req.Name).Count(&count).Error; err != nil {
+		return nil, err
+	}
+	if count > 0 {
+		return nil, errors.New("group name already exists in the organization")
+	}
+
+	group := &models.Group{
+		OrgID:       orgID,
+		Name:        req.Name,
+		Description: req.Description,
+		CreatedBy:   userID,
+	}
+
+	// 只允许嵌套一层
+	if req.ParentID != "" {
+		parent, err := getGroup(db, orgID, req.ParentID)
+		if err != nil {
+			return nil, errors.New("parent group not found")
+		}
+		if parent.ParentID != nil {
+			return nil, errors.New("groups can only be nested one level deep")
+		}
+		group.ParentID = &parent.ID
+	}
+
+	if err := db.Create(group).Error; err != nil {
+		return nil, fmt.Errorf("failed to create group: %v", err)
+	}
+	return group, nil
+}
+
+// ListGroups lists the groups of an org
+func (s *GroupService) ListGroups(orgID string, userID string) ([]models.Group, error) {
+	if err := authz.Check(userID, models.PermGroupsRead, authz.Org(orgID)); err != nil {
+		return nil, err
+	}
+
+	var groups []models.Group
+	if err := database.GetDB().Where("org_id = ?", orgID).Order("name ASC").Find(&groups).Error; err != nil {
+		return nil, err
+	}
+	return groups, nil
+}
+
+// DeleteGroup deletes a group with its memberships, role bindings and access grants.
+// Groups that still have child groups cannot be deleted.
+func (s *GroupService) DeleteGroup(orgID string, groupID string, userID string) error {
+	db := database.GetDB()
+
+	group, err := getGroup(db, orgID, groupID)
+	if err != nil {
+		return err
+	}
+
+	if err := authz.Check(userID, models.PermGroupsManage, authz.Org(orgID)); err != nil {
+		return err
+	}
+
+	var children int64
+	if err := db.Model(&models.Group{}).Where("parent_id = ?", group.ID).Count(&children).Error; err != nil {
+		return err
+	}
+	if children > 0 {
+		return errors.New("delete the child groups first")
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("group_id = ?", group.ID).Delete(&models.GroupMember{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("group_id = ?", group.ID).Delete(&models.RoleBinding{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("group_id = ?", group.ID).Delete(&models.AccessRequest{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(group).Error
+	})
+}
+
+// ListMembers lists the direct members of a group
+func (s *GroupService) ListMembers(orgID string, groupID string, userID string) ([]GroupMemberResponse, error) {
+	db := database.GetDB()
+
+	group, err := getGroup(db, orgID, groupID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := authz.Check(userID, models.PermGroupsRead, authz.Org(orgID)); err != nil {
+		return nil, err
+	}
+
+	var members []GroupMemberResponse
+	err = db.Table("group_members").
+		Select("group_members.user_id, users.username").
+		Joins("JOIN users ON users.id = group_members.user_id").
+		Where("group_members.group_id = ?", group.ID).
+		Order("users.username ASC").
+		Scan(&members).Error
+	if err != nil {
+		return nil, err
+	}
+	return members, nil
+}
+
+// AddMember adds an org member to the group
+func (s *GroupService) AddMember(orgID string, groupID string, req *AddGroupMemberRequest, userID string) error {
+	db := database.GetDB()
+
+	group, err := getGroup(db, orgID, groupID)
+	if err != nil {
+		return err
+	}
+
+	if err := authz.Check(userID, models.PermGroupsManage, authz.Org(orgID)); err != nil {
+		return err
+	}
+
+	if _, err := getOrgMember(db, orgID, req.UserID); err != nil {
+		return errors.New("user is not a member of the organization")
+	}
+
+	member := models.GroupMember{GroupID: group.ID, UserID: req.UserID}
+	return db.Where(member).FirstOrCreate(&member).Error
+}
+
+// RemoveMember removes a user from the group
+func (s *GroupService) RemoveMember(orgID string, groupID string, memberID string, userID string) error {
+	db := database.GetDB()
+
+	group, err := getGroup(db, orgID, groupID)
+	if err != nil {
+		return err
+	}
+
+	if err := authz.Check(userID, models.PermGroupsManage, authz.Org(orgID)); err != nil {
+		return err
+	}
+
+	result := db.Delete(&models.GroupMember{}, "group_id = ? AND user_id = ?", group.ID, memberID)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("member not found")
+	}
+	return nil
+}
+
+func getGroup(db *gorm.DB, orgID string, groupID string) (*models.Group, error) {
+	var group models.Group
+	if err := db.First(&group, "id = ? AND org_id = ?", groupID, orgID).Error; err != nil {
+		return nil, errors.New("group not found")
+	}
+	return &group, nil
+}
@@ -0,0 +1,102 @@
+package services
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/samzong/share-ai-platform/internal/cache"
+)
+
+const (
+	imageListTTL = 5 * time.Minute
+
+	// imageCacheVersionKey 镜像列表缓存的命名空间版本，任何镜像变更都会递增，使旧缓存整体失效
+	imageCacheVersionKey = "images:cache:version"
+)
+
+// imageListPage is a cached page of image listings without any per-user state
+type imageListPage struct {
+	Images []ImageResponse `json:"images"`
+	Total  int64           `json:"total"`
+}
+
+// cacheScope identifies the set of images the viewer can see. Anonymous users and users
+// who can read every org share cached pages; other users get their own.
+func (v *imageViewer) cacheScope() string {
+	switch {
+	case v.allOrgs:
+		return "all"
+	case v.userID == "":
+		return "anon"
+	}
+
+	orgIDs := mapKeys(v.orgIDs)
+	projectIDs := mapKeys(v.projectIDs)
+	sort.Strings(orgIDs)
+	sort.Strings(projectIDs)
+
+	// 成员关系变化后作用域随之变化，不会读到旧缓存
+	h := sha1.New()
+	fmt.Fprintf(h, "%s|%s|%s", v.userID, strings.Join(orgIDs, ","), strings.Join(projectIDs, ","))
+	return "u:" + hex.EncodeToString(h.Sum(nil))[:16]
+}
+
+// imageListCacheKey builds the cache key of a listing page in the current cache namespace
+func imageListCacheKey(ctx context.Context, viewer *imageViewer, req *ImageListRequest) string {
+	labels := append([]string(nil), req.Labels...)
+	sort.Strings(labels)
+
+	h := sha1.New()
+	fmt.Fprintf(h, "%d|%d|%s|%s|%s|%s|%s", req.Page, req.PageSize, req.Search, strings.Join(labels, ","), req.Sort, req.ProjectID, req.Category)
+	return fmt.Sprintf("images:v%d:list:%s:%s", imageCacheVersion(ctx), viewer.cacheScope(), hex.EncodeToString(h.Sum(nil)))
+}
+
+func imageCacheVersion(ctx context.Context) int64 {
+	value, err := cache.Default().Get(ctx, imageCacheVersionKey)
+	if err != nil {
+		return 0
+	}
+	version, _ := strconv.ParseInt(string(value), 10, 64)
+	return version
+}
+
+// loadImageList returns the cached page under key, running load once on a miss even when
+// many requests miss at the same time
+func loadImageList(ctx context.Context, key string, load func() (*imageListPage, error)) (*imageListPage, error) {
+	data, err := cache.GetOrLoad(ctx, key, imageListTTL, func() ([]byte, error) {
+		page, err := load()
+		if err != nil {
+			return nil, err
+		}
+		return json.Marshal(page)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var page imageListPage
+	if err := json.Unmarshal(data, &page); err != nil {
+		return nil, err
+	}
+	return &page, nil
+}
+
+// invalidateImageCache moves image listings to a new cache namespace. Pages cached under
+// older versions are never read again and expire on their own.
+func invalidateImageCache(ctx context.Context) {
+	// 未启用缓存时没有需要失效的列表
+	if _, ok := cache.Default().(cache.Noop); ok {
+		return
+	}
+	if _, err := cache.Default().Incr(ctx, imageCacheVersionKey, 0); err != nil {
+		log.Printf("Error invalidating image cache: %v", err)
+	}
+}
@@ -0,0 +1,31 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"os"
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/samzong/share-ai-platform/internal/cache"
+)
+
+func TestInvalidateImageCacheWithoutCache(t *testing.T) {
+	viper.Set("cache.driver", cache.DriverNone)
+	cache.Init(nil)
+	defer func() {
+		viper.Set("cache.driver", "")
+		cache.Init(nil)
+	}()
+
+	var logs bytes.Buffer
+	log.SetOutput(&logs)
+	defer log.SetOutput(os.Stderr)
+
+	// 未启用缓存时镜像变更不应记录失效错误
+	invalidateImageCache(context.Background())
+	assert.Empty(t, logs.String())
+}
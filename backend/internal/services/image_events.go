@@ -0,0 +1,142 @@
+package services
+
+import (
+	"time"
+
+	"github.com/samzong/share-ai-platform/internal/authz"
+	"github.com/samzong/share-ai-platform/internal/models"
+)
+
+const defaultImageStatsDays = 30
+
+type TrackEventRequest struct {
+	Type string `json:"type" binding:"required,oneof=usage_copy"` // 事件类型，浏览和部署由服务端记录
+}
+
+type ImageStatsRequest struct {
+	Days int `form:"days" binding:"omitempty,min=1,max=365"` // 统计最近多少天，默认 30
+}
+
+// ImageStatsCounts 一段时间内的事件次数
+type ImageStatsCounts struct {
+	Views       int64 `json:"views"`        // 浏览次数
+	ReadmeViews int64 `json:"readme_views"` // README 浏览次数
+	UsageCopies int64 `json:"usage_copies"` // 复制使用命令次数
+	Deploys     int64 `json:"deploys"`      // 部署次数
+	Visitors    int64 `json:"visitors"`     // 访客数，按天去重，汇总时为每天访客数之和
+}
+
+type ImageStatsPoint struct {
+	Day string `json:"day"` // UTC 日期，格式 2006-01-02
+	ImageStatsCounts
+}
+
+type ImageStatsResponse struct {
+	ImageID string            `json:"image_id"` // 镜像ID
+	Days    int               `json:"days"`     // 统计天数
+	Total   ImageStatsCounts  `json:"total"`    // 统计期间的合计
+	Series  []ImageStatsPoint `json:"series"`   // 每天的统计，包含没有事件的日期，最早的在前
+}
+
+// newImageEvent creates an event that happens now. The creation time is in UTC so that it
+// compares correctly with the UTC days of the rollup on every database.
+func newImageEvent(imageID string, eventType string, actor EventActor) models.ImageEvent {
+	event := models.ImageEvent{ImageID: imageID, Type: eventType, CreatedAt: time.Now().UTC()}
+	switch {
+	case actor.UserID != "":
+		event.UserID = &actor.UserID
+		if actor.OrgID != "" {
+			event.OrgID = &actor.OrgID
+		}
+	case actor.AnonymousID != "":
+		event.AnonymousID = &actor.AnonymousID
+	}
+	return event
+}
+
+// RecordEvent buffers an event of an image the actor has already been shown. The org is
+// only kept when the user can read images in it, so clients cannot attribute usage to
+// orgs they do not belong to.
+func (s *ImageService) RecordEvent(imageID string, eventType string, actor EventActor) {
+	if actor.OrgID != "" {
+		if err := s.authorizer.Check(actor.UserID, models.PermImagesRead, authz.Org(actor.OrgID)); err != nil {
+			actor.OrgID = ""
+		}
+	}
+	s.events.Record(newImageEvent(imageID, eventType, actor))
+}
+
+// TrackEvent records an event reported by the client, such as copying the pull command
+func (s *ImageService) TrackEvent(imageID string, req *TrackEventRequest, actor EventActor) error {
+	image, err := s.images.FindByID(imageID)
+	if err != nil {
+		return ErrImageNotFound
+	}
+	// 只有能看到拉取地址的用户才能复制使用命令
+	if err := checkImageAccess(s.images, s.authorizer, image, actor.UserID); err != nil {
+		return err
+	}
+	s.RecordEvent(image.ID, req.Type, actor)
+	return nil
+}
+
+// ImageStats returns the daily usage of an image to the users who may update it
+func (s *ImageService) ImageStats(imageID string, req *ImageStatsRequest, userID string) (*ImageStatsResponse, error) {
+	image, err := s.images.FindByID(imageID)
+	if err != nil {
+		return nil, ErrImageNotFound
+	}
+	if err := s.checkImageWrite(image, userID, models.PermImagesUpdate); err != nil {
+		return nil, err
+	}
+
+	days := req.Days
+	if days <= 0 {
+		days = defaultImageStatsDays
+	}
+	first := models.StatDay(time.Now()).AddDate(0, 0, -(days - 1))
+	stats, err := s.stats.Series(image.ID, first)
+	if err != nil {
+		return nil, err
+	}
+	byDay := make(map[string]models.ImageDailyStat, len(stats))
+	for _, stat := range stats {
+		byDay[stat.Day.UTC().Format(time.DateOnly)] = stat
+	}
+
+	response := &ImageStatsResponse{ImageID: image.ID, Days: days, Series: make([]ImageStatsPoint, days)}
+	for i := range response.Series {
+		day := first.AddDate(0, 0, i).Format(time.DateOnly)
+		stat := byDay[day]
+		counts := ImageStatsCounts{
+			Views:       stat.Views,
+			ReadmeViews: stat.ReadmeViews,
+			UsageCopies: stat.UsageCopies,
+			Deploys:     stat.Deploys,
+			Visitors:    stat.Visitors,
+		}
+		response.Series[i] = ImageStatsPoint{Day: day, ImageStatsCounts: counts}
+		response.Total.Views += counts.Views
+		response.Total.ReadmeViews += counts.ReadmeViews
+		response.Total.UsageCopies += counts.UsageCopies
+		response.Total.Deploys += counts.Deploys
+		response.Total.Visitors += counts.Visitors
+	}
+	return response, nil
+}
+
+// fillDeploys sets the deploy counts of the images
+func (s *ImageService) fillDeploys(images []ImageResponse) error {
+	ids := make([]string, len(images))
+	for i, image := range images {
+		ids[i] = image.ID
+	}
+	deploys, err := s.stats.Deploys(ids)
+	if err != nil {
+		return err
+	}
+	for i := range images {
+		images[i].Deploys = deploys[images[i].ID]
+	}
+	return nil
+}
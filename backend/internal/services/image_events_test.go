@@ -0,0 +1,80 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/samzong/share-ai-platform/internal/authz"
+	"github.com/samzong/share-ai-platform/internal/models"
+	"github.com/samzong/share-ai-platform/internal/repository"
+)
+
+func TestImageService_EventsAndStats(t *testing.T) {
+	repos := repository.NewMemory()
+	az := newFakeAuthorizer()
+	az.admins["admin"] = true
+	events := NewEventRecorder(repos.Stats)
+	service := NewImageServiceWith(&repos.Repositories, az, events)
+	deploys := NewDeployServiceWith(&repos.Repositories, az, events)
+	image := createTestImage(t, repos, "tracked", models.VisibilityPublic)
+	private := createTestImage(t, repos, "private", models.VisibilityPrivate)
+
+	service.RecordEvent(image.ID, models.EventView, EventActor{AnonymousID: "visitor"})
+	service.RecordEvent(image.ID, models.EventView, EventActor{UserID: "u1", OrgID: "org-1"})
+	service.RecordEvent(image.ID, models.EventReadmeView, EventActor{UserID: "u1"})
+	require.NoError(t, service.TrackEvent(image.ID, &TrackEventRequest{Type: models.EventUsageCopy}, EventActor{UserID: "u1"}))
+	assert.ErrorIs(t, service.TrackEvent(private.ID, &TrackEventRequest{Type: models.EventUsageCopy}, EventActor{AnonymousID: "visitor"}), ErrImageNotFound)
+	_, err := deploys.Deploy(&DeployRequest{ImageID: image.ID}, "admin")
+	require.NoError(t, err)
+
+	require.NoError(t, events.Flush())
+	require.NoError(t, RollupEvents(repos.Stats, 0))
+
+	_, err = service.ImageStats(image.ID, &ImageStatsRequest{}, "stranger")
+	assert.ErrorIs(t, err, authz.ErrForbidden)
+
+	stats, err := service.ImageStats(image.ID, &ImageStatsRequest{Days: 7}, "admin")
+	require.NoError(t, err)
+	require.Len(t, stats.Series, 7)
+	today := stats.Series[6]
+	assert.Equal(t, models.StatDay(time.Now()).Format(time.DateOnly), today.Day)
+	assert.Equal(t, ImageStatsCounts{Views: 2, ReadmeViews: 1, UsageCopies: 1, Deploys: 1, Visitors: 3}, today.ImageStatsCounts)
+	assert.Equal(t, today.ImageStatsCounts, stats.Total)
+	assert.Zero(t, stats.Series[0].Views)
+
+	resp, err := service.GetImageByID(context.Background(), image.ID, "")
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, resp.Deploys)
+	visible, err := service.visibleImages([]string{image.ID}, "")
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, visible[image.ID].Deploys)
+}
+
+func TestEventRecorder_KeepsEventsWhenWriteFails(t *testing.T) {
+	stats := &failingStats{StatsRepo: repository.NewMemory().Stats, fail: true}
+	recorder := NewEventRecorder(stats)
+	recorder.Record(models.ImageEvent{ImageID: "image", Type: models.EventView})
+
+	assert.Error(t, recorder.Flush())
+	stats.fail = false
+	require.NoError(t, recorder.Flush())
+	assert.Equal(t, 1, stats.written)
+}
+
+type failingStats struct {
+	repository.StatsRepo
+	fail    bool
+	written int
+}
+
+func (s *failingStats) AddEvents(events []models.ImageEvent) error {
+	if s.fail {
+		return assert.AnError
+	}
+	s.written += len(events)
+	return s.StatsRepo.AddEvents(events)
+}
@@ -2,62 +2,90 @@ package services
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
 	"fmt"
 	"mime/multipart"
+	"net/url"
+	"slices"
 	"time"
 
+	"github.com/samzong/share-ai-platform/internal/authz"
 	"github.com/samzong/share-ai-platform/internal/database"
 	"github.com/samzong/share-ai-platform/internal/models"
+	"github.com/samzong/share-ai-platform/internal/repository"
+	"github.com/samzong/share-ai-platform/internal/storage"
 	"github.com/samzong/share-ai-platform/internal/utils"
 )
 
-type ImageService struct{}
+// ErrReadmeNotFound is returned when an image has no README
+var ErrReadmeNotFound = errors.New("readme not found")
+
+type ImageService struct {
+	images      repository.ImageRepo
+	labels      repository.LabelRepo
+	categories  repository.CategoryRepo
+	collections repository.CollectionRepo
+	stats       repository.StatsRepo
+	orgs        repository.OrgRepo
+	objects     repository.StoredObjectRepo
+	events      *EventRecorder
+	authorizer  Authorizer
+}
 
 type ImageListRequest struct {
-	Page     int      `form:"page" binding:"omitempty,min=1"`
-	PageSize int      `form:"page_size" binding:"omitempty,min=1,max=100"`
-	Search   string   `form:"search"`
-	Labels   []string `form:"labels"`
-	Sort     string   `form:"sort" binding:"oneof=stars created_at updated_at ''"`
+	Page      int      `form:"page" binding:"omitempty,min=1"`
+	PageSize  int      `form:"page_size" binding:"omitempty,min=1,max=100"`
+	Search    string   `form:"search"`
+	Labels    []string `form:"labels"`
+	Category  string   `form:"category"` // 分类 slug，包含其全部子分类
+	ProjectID string   `form:"project_id"`
+	Sort      string   `form:"sort" binding:"oneof=stars created_at updated_at ''"`
 }
 
 type ImageResponse struct {
-	ID          string    `json:"id"`          // 镜像唯一标识符
-	OrgID       string    `json:"org_id"`      // 组织ID
-	Name        string    `json:"name"`        // 镜像显示名称
-	Description string    `json:"description"` // 镜像描述
-	Author      string    `json:"author"`      // 创建者ID
-	Registry    string    `json:"registry"`    // 镜像仓库服务器
-	Namespace   string    `json:"namespace"`   // 命名空间/组织
-	Repository  string    `json:"repository"`  // 镜像名称
-	Tag         string    `json:"tag"`         // 版本标签
-	Digest      string    `json:"digest"`      // 镜像内容哈希值
-	Size        int64     `json:"size"`        // 镜像大小（字节）
-	ReadmePath  string    `json:"readme_path"` // README文件路径
-	Stars       int       `json:"stars"`       // 收藏数
-	Visibility  string    `json:"visibility"`  // 可见性：public/private
-	Platform    string    `json:"platform"`    // 平台架构
-	Labels      []string  `json:"labels"`      // 标签列表，用于分类和搜索
-	IsStarred   bool      `json:"is_starred"`  // 当前用户是否已收藏
-	CreatedAt   time.Time `json:"created_at"`  // 创建时间
-	UpdatedAt   time.Time `json:"updated_at"`  // 更新时间
+	ID          string    `json:"id"`                   // 镜像唯一标识符
+	OrgID       string    `json:"org_id"`               // 组织ID
+	ProjectID   *string   `json:"project_id,omitempty"` // 项目ID
+	Name        string    `json:"name"`                 // 镜像显示名称
+	Description string    `json:"description"`          // 镜像描述
+	Author      string    `json:"author"`               // 创建者ID
+	Registry    string    `json:"registry"`             // 镜像仓库服务器
+	Namespace   string    `json:"namespace"`            // 命名空间/组织
+	Repository  string    `json:"repository"`           // 镜像名称
+	Tag         string    `json:"tag"`                  // 版本标签
+	Digest      string    `json:"digest"`               // 镜像内容哈希值
+	Size        int64     `json:"size"`                 // 镜像大小（字节）
+	ReadmePath  string    `json:"readme_path"`          // README文件路径
+	ReadmeURL   string    `json:"readme_url"`           // README 下载地址，私有文件为限时签名地址
+	Stars       int       `json:"stars"`                // 收藏数
+	Deploys     int64     `json:"deploys"`              // 部署次数，按每日统计汇总，有几分钟延迟
+	Visibility  string    `json:"visibility"`           // 可见性：public/private/require_access
+	Platform    string    `json:"platform"`             // 平台架构
+	Labels      []string  `json:"labels"`               // 标签列表，用于分类和搜索
+	Categories  []string  `json:"categories"`           // 所属分类的 slug
+	IsStarred   bool      `json:"is_starred"`           // 当前用户是否已收藏
+	HasAccess   bool      `json:"has_access"`           // 当前用户是否可以拉取镜像，否则拉取地址被隐藏
+	CreatedAt   time.Time `json:"created_at"`           // 创建时间
+	UpdatedAt   time.Time `json:"updated_at"`           // 更新时间
 }
 
 type CreateImageRequest struct {
-	Name        string                `json:"name" binding:"required"`
-	Description string                `json:"description"`
-	Registry    string                `json:"registry" binding:"required"`
-	Namespace   string                `json:"namespace"`
-	Repository  string                `json:"repository" binding:"required"`
-	Tag         string                `json:"tag" binding:"required"`
-	Digest      string                `json:"digest" binding:"required"`
-	Size        int64                 `json:"size"`
-	ReadmeFile  *multipart.FileHeader `json:"readme_file,omitempty"`
-	Visibility  string                `json:"visibility" binding:"required,oneof=public private"`
-	Platform    string                `json:"platform" binding:"required"`
-	Labels      []string              `json:"labels,omitempty"`
+	Name          string                `form:"name" json:"name" binding:"required"`
+	Description   string                `form:"description" json:"description"`
+	Registry      string                `form:"registry" json:"registry" binding:"required"`
+	Namespace     string                `form:"namespace" json:"namespace"`
+	Repository    string                `form:"repository" json:"repository" binding:"required"`
+	Tag           string                `form:"tag" json:"tag" binding:"required"`
+	Digest        string                `form:"digest" json:"digest" binding:"required"`
+	Size          int64                 `form:"size" json:"size"`
+	Readme        string                `form:"readme" json:"readme,omitempty"`                   // README markdown 内容，与 readme_file 二选一
+	ReadmeFile    *multipart.FileHeader `form:"readme_file" json:"-" swaggerignore:"true"`        // README markdown 文件，仅 multipart 上传时有效
+	ReadmeBaseURL string                `form:"readme_base_url" json:"readme_base_url,omitempty"` // README 中相对链接的基准地址
+	Visibility    string                `form:"visibility" json:"visibility" binding:"required,oneof=public private require_access"`
+	Platform      string                `form:"platform" json:"platform" binding:"required"`
+	Labels        []string              `form:"labels" json:"labels,omitempty"`
+	Categories    []string              `form:"categories" json:"categories,omitempty"` // 所属分类的 slug
+	ProjectID     string                `form:"project_id" json:"project_id,omitempty"` // 所属项目，为空表示直接归属组织
 }
 
 type LayerInfo struct {
@@ -67,24 +95,50 @@ type LayerInfo struct {
 }
 
 type UpdateImageRequest struct {
-	Name        string                `form:"name" json:"name,omitempty"`
-	Description string                `form:"description" json:"description,omitempty"`
-	Registry    string                `form:"registry" json:"registry,omitempty"`
-	Namespace   string                `form:"namespace" json:"namespace,omitempty"`
-	Repository  string                `form:"repository" json:"repository,omitempty"`
-	Tag         string                `form:"tag" json:"tag,omitempty"`
-	ReadmeFile  *multipart.FileHeader `form:"readme_file" json:"readme_file,omitempty"`
-	Visibility  string                `form:"visibility" binding:"omitempty,oneof=public private" json:"visibility,omitempty"`
-	Platform    string                `form:"platform" json:"platform,omitempty"`
-	Labels      []string              `form:"labels" json:"labels,omitempty"`
+	Name          string                `form:"name" json:"name,omitempty"`
+	Description   string                `form:"description" json:"description,omitempty"`
+	Registry      string                `form:"registry" json:"registry,omitempty"`
+	Namespace     string                `form:"namespace" json:"namespace,omitempty"`
+	Repository    string                `form:"repository" json:"repository,omitempty"`
+	Tag           string                `form:"tag" json:"tag,omitempty"`
+	Readme        *string               `form:"readme" json:"readme,omitempty"` // 新的 README 内容，空字符串表示删除
+	ReadmeFile    *multipart.FileHeader `form:"readme_file" json:"-" swaggerignore:"true"`
+	ReadmeBaseURL *string               `form:"readme_base_url" json:"readme_base_url,omitempty"`
+	Visibility    string                `form:"visibility" binding:"omitempty,oneof=public private require_access" json:"visibility,omitempty"`
+	Platform      string                `form:"platform" json:"platform,omitempty"`
+	Labels        []string              `form:"labels" json:"labels,omitempty"`
+	Categories    []string              `form:"categories" json:"categories,omitempty"` // 新的分类 slug 列表，替换现有分类
+}
+
+type ReadmeResponse struct {
+	ImageID  string `json:"image_id"` // 镜像ID
+	Markdown string `json:"markdown"` // README 原始 markdown
+	HTML     string `json:"html"`     // 服务端渲染并过滤后的 HTML
 }
 
-// NewImageService creates a new ImageService
+// NewImageService creates a new ImageService backed by the database
 func NewImageService() *ImageService {
-	return &ImageService{}
+	return NewImageServiceWith(repository.NewGorm(database.GetDB()), defaultAuthorizer, defaultEventRecorder())
 }
 
-// ListImages retrieves a list of images with pagination and filtering
+// NewImageServiceWith creates an ImageService on the given repositories and authorizer,
+// buffering image events in events
+func NewImageServiceWith(repos *repository.Repositories, az Authorizer, events *EventRecorder) *ImageService {
+	return &ImageService{
+		images:      repos.Images,
+		labels:      repos.Labels,
+		categories:  repos.Categories,
+		collections: repos.Collections,
+		stats:       repos.Stats,
+		orgs:        repos.Orgs,
+		objects:     repos.Objects,
+		events:      events,
+		authorizer:  az,
+	}
+}
+
+// ListImages retrieves a list of images with pagination and filtering.
+// Pages are cached per visibility scope; starred state and pull access are applied per user.
 func (s *ImageService) ListImages(ctx context.Context, req *ImageListRequest, userID string) ([]ImageResponse, int64, error) {
 	// 设置默认值
 	if req.Page <= 0 {
@@ -94,130 +148,206 @@ func (s *ImageService) ListImages(ctx context.Context, req *ImageListRequest, us
 		req.PageSize = 10
 	}
 
-	db := database.GetDB()
-	rdb := database.GetRedis()
-
-	// 构建基础查询
-	query := db.Model(&models.Image{})
-
-	// 应用搜索过滤
-	if req.Search != "" {
-		query = query.Where("name ILIKE ? OR description ILIKE ?", "%"+req.Search+"%", "%"+req.Search+"%")
+	viewer, err := resolveImageViewer(s.authorizer, userID)
+	if err != nil {
+		return nil, 0, err
 	}
 
-	// 应用标签过滤
-	if len(req.Labels) > 0 {
-		query = query.Joins("JOIN image_labels ON images.id = image_labels.image_id").
-			Where("image_labels.label IN ?", req.Labels).
-			Group("images.id").
-			Having("COUNT(DISTINCT image_labels.label) = ?", len(req.Labels))
+	// 优先从缓存获取数据
+	page, err := loadImageList(ctx, imageListCacheKey(ctx, viewer, req), func() (*imageListPage, error) {
+		return s.queryImages(viewer, req)
+	})
+	if err != nil {
+		return nil, 0, err
 	}
 
-	// 获取总数
-	var total int64
-	if err := query.Count(&total).Error; err != nil {
+	response, err := s.applyViewerOverlay(viewer, page.Images)
+	if err != nil {
 		return nil, 0, err
 	}
+	return response, page.Total, nil
+}
 
-	// 尝试从缓存获取数据
-	cacheKey := fmt.Sprintf("images:%d:%d:%s:%v:%s", req.Page, req.PageSize, req.Search, req.Labels, req.Sort)
-	if cached, err := rdb.Get(ctx, cacheKey).Result(); err == nil {
-		var response []ImageResponse
-		if err := json.Unmarshal([]byte(cached), &response); err == nil {
-			return response, total, nil // 返回缓存的数据和实际的总数
-		}
+// queryImages loads a page of the images visible in the viewer's scope. The result holds
+// no per-user state, so it can be shared by every viewer with the same scope.
+func (s *ImageService) queryImages(viewer *imageViewer, req *ImageListRequest) (*imageListPage, error) {
+	categories, err := s.categorySubtree(req.Category)
+	if err != nil {
+		return nil, err
+	}
+	labels, ok, err := s.labelFilter(req.Labels)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return &imageListPage{Images: []ImageResponse{}}, nil
+	}
+
+	images, total, err := s.images.List(repository.ImageFilter{
+		Scope:      viewer.listScope(),
+		Search:     req.Search,
+		ProjectID:  req.ProjectID,
+		Labels:     labels,
+		Categories: categories,
+		Sort:       req.Sort,
+		Offset:     (req.Page - 1) * req.PageSize,
+		Limit:      req.PageSize,
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	// 应用排序
-	switch req.Sort {
-	case "stars":
-		query = query.Order("stars DESC")
-	case "created_at":
-		query = query.Order("created_at DESC")
-	case "updated_at":
-		query = query.Order("updated_at DESC")
-	default:
-		query = query.Order("created_at DESC")
+	// 转换为响应格式
+	response := make([]ImageResponse, len(images))
+	for i := range images {
+		response[i] = newSharedImageResponse(&images[i])
+	}
+	if err := s.fillDeploys(response); err != nil {
+		return nil, err
 	}
 
-	// 应用分页
-	offset := (req.Page - 1) * req.PageSize
-	query = query.Offset(offset).Limit(req.PageSize)
+	return &imageListPage{Images: response, Total: total}, nil
+}
 
-	// 执行查询
-	var images []models.Image
-	if err := query.Preload("Labels").Find(&images).Error; err != nil {
-		return nil, 0, err
+// newSharedImageResponse converts an image to a response without per-user state,
+// which applyViewerOverlay fills in
+func newSharedImageResponse(img *models.Image) ImageResponse {
+	response := ImageResponse{
+		ID:          img.ID,
+		OrgID:       img.OrgID,
+		ProjectID:   img.ProjectID,
+		Name:        img.Name,
+		Description: img.Description,
+		Author:      img.Author,
+		Registry:    img.Registry,
+		Namespace:   img.Namespace,
+		Repository:  img.Repository,
+		Tag:         img.Tag,
+		Digest:      img.Digest,
+		Size:        img.Size,
+		ReadmePath:  img.ReadmePath,
+		Stars:       img.Stars,
+		Labels:      make([]string, len(img.Labels)),
+		Categories:  categorySlugs(img.Categories),
+		CreatedAt:   img.CreatedAt,
+		UpdatedAt:   img.UpdatedAt,
+		Visibility:  img.Visibility,
+		Platform:    img.Platform,
+	}
+	for i, label := range img.Labels {
+		response.Labels[i] = label.Name
 	}
+	return response
+}
 
-	// 转换为响应格式
-	response := make([]ImageResponse, len(images))
-	for i, img := range images {
-		isStarred := false
-		if userID != "" {
-			var count int64
-			if err := db.Model(&models.Collection{}).Where("user_id = ? AND image_id = ?", userID, img.ID).Count(&count).Error; err != nil {
-				return nil, 0, err
-			}
-			isStarred = count > 0
-		}
+// visibleImages returns the images among ids the viewer can see, keyed by image ID
+func (s *ImageService) visibleImages(ids []string, viewerID string) (map[string]ImageResponse, error) {
+	result := make(map[string]ImageResponse, len(ids))
+	if len(ids) == 0 {
+		return result, nil
+	}
 
-		response[i] = ImageResponse{
-			ID:          img.ID,
-			OrgID:       img.OrgID,
-			Name:        img.Name,
-			Description: img.Description,
-			Author:      img.Author,
-			Registry:    img.Registry,
-			Namespace:   img.Namespace,
-			Repository:  img.Repository,
-			Tag:         img.Tag,
-			Digest:      img.Digest,
-			Size:        img.Size,
-			ReadmePath:  img.ReadmePath,
-			Stars:       img.Stars,
-			Labels:      make([]string, len(img.Labels)),
-			IsStarred:   isStarred,
-			CreatedAt:   img.CreatedAt,
-			UpdatedAt:   img.UpdatedAt,
-			Visibility:  img.Visibility,
-			Platform:    img.Platform,
-		}
+	viewer, err := resolveImageViewer(s.authorizer, viewerID)
+	if err != nil {
+		return nil, err
+	}
+	images, _, err := s.images.List(repository.ImageFilter{Scope: viewer.listScope(), IDs: ids, Limit: len(ids)})
+	if err != nil {
+		return nil, err
+	}
+	shared := make([]ImageResponse, len(images))
+	for i := range images {
+		shared[i] = newSharedImageResponse(&images[i])
+	}
+	if err := s.fillDeploys(shared); err != nil {
+		return nil, err
+	}
+	responses, err := s.applyViewerOverlay(viewer, shared)
+	if err != nil {
+		return nil, err
+	}
+	for _, image := range responses {
+		result[image.ID] = image
+	}
+	return result, nil
+}
 
-		for j, label := range img.Labels {
-			response[i].Labels[j] = label.Name
+// applyViewerOverlay copies shared image data and fills in the user's starred state and
+// pull access, redacting pull information the user may not see
+func (s *ImageService) applyViewerOverlay(viewer *imageViewer, shared []ImageResponse) ([]ImageResponse, error) {
+	images := make([]models.Image, len(shared))
+	ids := make([]string, len(shared))
+	for i, img := range shared {
+		images[i] = models.Image{
+			ID:         img.ID,
+			OrgID:      img.OrgID,
+			ProjectID:  img.ProjectID,
+			Author:     img.Author,
+			Visibility: img.Visibility,
 		}
+		ids[i] = img.ID
+	}
+	if err := viewer.load(s.images, images); err != nil {
+		return nil, err
 	}
 
-	// 缓存结果
-	if len(response) > 0 {
-		if cached, err := json.Marshal(response); err == nil {
-			rdb.Set(ctx, cacheKey, cached, time.Minute*5)
+	starred := make(map[string]bool)
+	if viewer.userID != "" && len(ids) > 0 {
+		starredIDs, err := s.collections.StarredImageIDs(viewer.userID, ids)
+		if err != nil {
+			return nil, err
+		}
+		for _, id := range starredIDs {
+			starred[id] = true
 		}
 	}
 
-	return response, total, nil
+	response := make([]ImageResponse, len(shared))
+	for i := range shared {
+		response[i] = shared[i]
+		response[i].IsStarred = starred[shared[i].ID]
+		response[i].HasAccess = viewer.hasAccess(&images[i])
+		response[i].ReadmeURL = utils.GetFileURL(shared[i].ReadmePath)
+		redactPullInfo(&response[i])
+	}
+	return response, nil
 }
 
 // GetImageByID retrieves an image by ID
 func (s *ImageService) GetImageByID(ctx context.Context, id string, userID string) (*ImageResponse, error) {
-	db := database.GetDB()
+	image, err := s.images.FindByID(id)
+	if err != nil {
+		return nil, ErrImageNotFound
+	}
 
-	var image models.Image
-	if err := db.Preload("Labels").First(&image, "id = ?", id).Error; err != nil {
+	viewer, err := resolveImageViewer(s.authorizer, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := viewer.load(s.images, []models.Image{*image}); err != nil {
 		return nil, err
 	}
+	if !viewer.canSee(image) {
+		return nil, ErrImageNotFound
+	}
 
 	// Check if user has starred the image
 	var isStarred bool
 	if userID != "" {
-		var collection models.Collection
-		isStarred = db.Where("user_id = ? AND image_id = ?", userID, id).First(&collection).Error == nil
+		if isStarred, err = s.collections.Exists(userID, id); err != nil {
+			return nil, err
+		}
+	}
+
+	deploys, err := s.stats.Deploys([]string{image.ID})
+	if err != nil {
+		return nil, err
 	}
 
 	response := &ImageResponse{
 		ID:          image.ID,
 		OrgID:       image.OrgID,
+		ProjectID:   image.ProjectID,
 		Name:        image.Name,
 		Description: image.Description,
 		Author:      image.Author,
@@ -228,11 +358,15 @@ func (s *ImageService) GetImageByID(ctx context.Context, id string, userID strin
 		Digest:      image.Digest,
 		Size:        image.Size,
 		ReadmePath:  image.ReadmePath,
+		ReadmeURL:   utils.GetFileURL(image.ReadmePath),
 		Stars:       image.Stars,
+		Deploys:     deploys[image.ID],
 		Visibility:  image.Visibility,
 		Platform:    image.Platform,
 		Labels:      make([]string, len(image.Labels)),
+		Categories:  categorySlugs(image.Categories),
 		IsStarred:   isStarred,
+		HasAccess:   viewer.hasAccess(image),
 		CreatedAt:   image.CreatedAt,
 		UpdatedAt:   image.UpdatedAt,
 	}
@@ -240,71 +374,104 @@ func (s *ImageService) GetImageByID(ctx context.Context, id string, userID strin
 	for i, label := range image.Labels {
 		response.Labels[i] = label.Name
 	}
+	redactPullInfo(response)
 
 	return response, nil
 }
 
+// redactPullInfo hides where to pull an image from users who have not been granted access
+func redactPullInfo(resp *ImageResponse) {
+	if resp.HasAccess {
+		return
+	}
+	resp.Registry = ""
+	resp.Namespace = ""
+	resp.Repository = ""
+	resp.Tag = ""
+	resp.Digest = ""
+	resp.ReadmePath = ""
+	resp.ReadmeURL = ""
+}
+
 // CollectImage adds an image to user's collection
 func (s *ImageService) CollectImage(userID string, imageID string) error {
-	db := database.GetDB()
-
 	// Check if image exists
-	var image models.Image
-	if err := db.First(&image, "id = ?", imageID).Error; err != nil {
-		return errors.New("image not found")
+	image, err := s.images.FindByID(imageID)
+	if err != nil {
+		return ErrImageNotFound
 	}
 
-	// Check if already collected
-	var collection models.Collection
-	if err := db.Where("user_id = ? AND image_id = ?", userID, imageID).First(&collection).Error; err == nil {
-		return errors.New("image already collected")
+	viewer, err := resolveImageViewer(s.authorizer, userID)
+	if err != nil {
+		return err
 	}
-
-	// Add to collection
-	collection = models.Collection{
-		UserID:  userID,
-		ImageID: imageID,
+	if err := viewer.load(s.images, []models.Image{*image}); err != nil {
+		return err
+	}
+	if !viewer.canSee(image) {
+		return ErrImageNotFound
 	}
 
-	if err := db.Create(&collection).Error; err != nil {
+	// 收藏与收藏数在同一事务中更新；重复收藏不报错，也不改变收藏数
+	added, err := s.collections.Add(&models.Collection{UserID: userID, ImageID: imageID})
+	if err != nil {
 		return err
 	}
-
-	// Increment stars count
-	return db.Model(&image).Update("stars", image.Stars+1).Error
+	if added {
+		invalidateImageCache(context.Background())
+	}
+	return nil
 }
 
 // UncollectImage removes an image from user's collection
 func (s *ImageService) UncollectImage(userID string, imageID string) error {
-	db := database.GetDB()
-
 	// Check if image exists
-	var image models.Image
-	if err := db.First(&image, "id = ?", imageID).Error; err != nil {
-		return errors.New("image not found")
+	if _, err := s.images.FindByID(imageID); err != nil {
+		return ErrImageNotFound
 	}
 
-	// Remove from collection
-	result := db.Where("user_id = ? AND image_id = ?", userID, imageID).Delete(&models.Collection{})
-	if result.Error != nil {
-		return result.Error
+	// 未收藏时同样视为成功，便于客户端重试
+	removed, err := s.collections.Remove(userID, imageID)
+	if err != nil {
+		return err
 	}
-
-	if result.RowsAffected == 0 {
-		return errors.New("image not in collection")
+	if removed {
+		invalidateImageCache(context.Background())
 	}
-
-	// Decrement stars count
-	return db.Model(&image).Update("stars", image.Stars-1).Error
+	return nil
 }
 
 // CreateImage creates a new image
 func (s *ImageService) CreateImage(ctx context.Context, req *CreateImageRequest, userID string, orgID string) (*ImageResponse, error) {
-	db := database.GetDB()
-
 	// 处理 public 组织的情况
 	if orgID == "public" {
-		orgID = "00000000-0000-0000-0000-000000000000" // 使用特殊的 UUID 表示 public 组织
+		orgID = models.PublicOrgID // 使用特殊的 UUID 表示 public 组织
+	} else {
+		exists, err := s.orgs.Exists(orgID)
+		if err != nil {
+			return nil, err
+		}
+		if !exists {
+			return nil, errors.New("organization not found")
+		}
+	}
+
+	// 镜像归属项目时，在项目作用域内检查权限
+	scope := authz.Org(orgID)
+	var projectID *string
+	if req.ProjectID != "" {
+		if _, err := s.orgs.FindProject(orgID, req.ProjectID); err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				return nil, errors.New("project not found")
+			}
+			return nil, err
+		}
+		scope = authz.Project(req.ProjectID)
+		projectID = &req.ProjectID
+	}
+
+	if err := s.authorizer.Check(userID, models.PermImagesCreate, scope); err != nil {
+		return nil, err
 	}
 
 	// 创建镜像记录
@@ -319,64 +486,64 @@ func (s *ImageService) CreateImage(ctx context.Context, req *CreateImageRequest,
 		Digest:      req.Digest,
 		Size:        req.Size,
 		OrgID:       orgID,
+		ProjectID:   projectID,
 		Visibility:  req.Visibility,
 		Platform:    req.Platform,
 	}
 
-	// 如果有 README 文件，上传它
-	if req.ReadmeFile != nil {
-		readmePath, err := utils.UploadFile(req.ReadmeFile, "readme")
+	if err := validateReadmeBaseURL(req.ReadmeBaseURL); err != nil {
+		return nil, err
+	}
+	image.ReadmeBase = req.ReadmeBaseURL
+
+	// 保存 README，文件和内联内容二选一
+	if req.ReadmeFile != nil || req.Readme != "" {
+		readmePath, err := storeReadme(s.objects, req.ReadmeFile, req.Readme)
 		if err != nil {
-			return nil, fmt.Errorf("failed to upload readme file: %v", err)
+			return nil, err
 		}
 		image.ReadmePath = readmePath
 	}
 
-	// 开始事务
-	tx := db.Begin()
-	if err := tx.Create(image).Error; err != nil {
-		tx.Rollback()
-		return nil, fmt.Errorf("failed to create image: %v", err)
+	// 创建失败时删除已上传的 README
+	committed := false
+	defer func() {
+		if !committed && image.ReadmePath != "" {
+			releaseObjects(s.objects, image.ReadmePath)
+		}
+	}()
+
+	labels, err := s.labels.FindOrCreate(req.Labels)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create label: %v", err)
 	}
+	image.Labels = labels
 
-	// 处理标签
-	if len(req.Labels) > 0 {
-		for _, labelName := range req.Labels {
-			var label models.Label
-			// 查找或创建标签
-			if err := tx.Where("name = ?", labelName).FirstOrCreate(&label, models.Label{Name: labelName}).Error; err != nil {
-				tx.Rollback()
-				return nil, fmt.Errorf("failed to create label: %v", err)
-			}
-			// 关联标签和镜像
-			if err := tx.Model(image).Association("Labels").Append(&label); err != nil {
-				tx.Rollback()
-				return nil, fmt.Errorf("failed to associate label: %v", err)
-			}
-		}
+	if image.Categories, err = s.resolveCategories(req.Categories); err != nil {
+		return nil, err
 	}
 
-	// 提交事务
-	if err := tx.Commit().Error; err != nil {
-		return nil, fmt.Errorf("failed to commit transaction: %v", err)
+	if err := s.images.Create(image); err != nil {
+		return nil, fmt.Errorf("failed to create image: %v", err)
 	}
+	committed = true
+	invalidateImageCache(ctx)
 
 	// 返回创建的镜像信息
 	return s.GetImageByID(ctx, image.ID, userID)
 }
 
-// UpdateImage updates an existing image
-func (s *ImageService) UpdateImage(ctx context.Context, imageID string, req *UpdateImageRequest, userID string) (*ImageResponse, error) {
-	db := database.GetDB()
-
+// UpdateImage updates an existing image in an organization
+func (s *ImageService) UpdateImage(ctx context.Context, orgID string, imageID string, req *UpdateImageRequest, userID string) (*ImageResponse, error) {
 	// 查找现有镜像
-	var image models.Image
-	if err := db.First(&image, "id = ? AND author = ?", imageID, userID).Error; err != nil {
-		return nil, fmt.Errorf("image not found or not authorized")
+	image, err := s.images.FindInOrg(orgID, imageID)
+	if err != nil {
+		return nil, fmt.Errorf("image not found")
 	}
 
-	// 开始事务
-	tx := db.Begin()
+	if err := s.checkImageWrite(image, userID, models.PermImagesUpdate); err != nil {
+		return nil, err
+	}
 
 	// 更新基本信息
 	if req.Name != "" {
@@ -394,99 +561,236 @@ func (s *ImageService) UpdateImage(ctx context.Context, imageID string, req *Upd
 	if req.Tag != "" {
 		image.Tag = req.Tag
 	}
+	if req.Visibility != "" {
+		image.Visibility = req.Visibility
+	}
 
-	// 如果有新的 README 文件，上传它并删除旧文件
-	if req.ReadmeFile != nil {
-		// 删除旧文件
-		if image.ReadmePath != "" {
-			utils.DeleteFile(image.ReadmePath)
-		}
-		// 上传新文件
-		readmePath, err := utils.UploadFile(req.ReadmeFile, "readme")
-		if err != nil {
-			tx.Rollback()
-			return nil, fmt.Errorf("failed to upload readme file: %v", err)
+	if req.ReadmeBaseURL != nil {
+		if err := validateReadmeBaseURL(*req.ReadmeBaseURL); err != nil {
+			return nil, err
 		}
-		image.ReadmePath = readmePath
+		image.ReadmeBase = *req.ReadmeBaseURL
 	}
 
-	// 更新镜像记录
-	if err := tx.Save(&image).Error; err != nil {
-		tx.Rollback()
-		return nil, fmt.Errorf("failed to update image: %v", err)
+	// 如果有新的 README，保存它，旧文件在提交成功后删除
+	var oldReadme, newReadme string
+	if req.ReadmeFile != nil || req.Readme != nil {
+		var content string
+		if req.Readme != nil {
+			content = *req.Readme
+		}
+		oldReadme = image.ReadmePath
+		image.ReadmePath = ""
+		if req.ReadmeFile != nil || content != "" {
+			readmePath, err := storeReadme(s.objects, req.ReadmeFile, content)
+			if err != nil {
+				return nil, err
+			}
+			image.ReadmePath = readmePath
+			newReadme = readmePath
+		}
 	}
 
-	// 如果提供了新的标签列表，更新标签
+	committed := false
+	defer func() {
+		if committed {
+			releaseObjects(s.objects, oldReadme)
+		} else {
+			releaseObjects(s.objects, newReadme)
+		}
+	}()
+
+	// 如果提供了新的标签列表，替换现有标签
+	var labels []models.Label
 	if len(req.Labels) > 0 {
-		// 清除现有标签
-		if err := tx.Model(&image).Association("Labels").Clear(); err != nil {
-			tx.Rollback()
-			return nil, fmt.Errorf("failed to clear labels: %v", err)
+		if labels, err = s.labels.FindOrCreate(req.Labels); err != nil {
+			return nil, fmt.Errorf("failed to create label: %v", err)
 		}
+	}
 
-		// 添加新标签
-		for _, labelName := range req.Labels {
-			var label models.Label
-			// 查找或创建标签
-			if err := tx.Where("name = ?", labelName).FirstOrCreate(&label, models.Label{Name: labelName}).Error; err != nil {
-				tx.Rollback()
-				return nil, fmt.Errorf("failed to create label: %v", err)
-			}
-			// 关联标签和镜像
-			if err := tx.Model(&image).Association("Labels").Append(&label); err != nil {
-				tx.Rollback()
-				return nil, fmt.Errorf("failed to associate label: %v", err)
-			}
+	var categories []models.Category
+	if len(req.Categories) > 0 {
+		if categories, err = s.resolveCategories(req.Categories); err != nil {
+			return nil, err
 		}
 	}
 
-	// 提交事务
-	if err := tx.Commit().Error; err != nil {
-		return nil, fmt.Errorf("failed to commit transaction: %v", err)
+	// 更新镜像记录
+	if err := s.images.Update(image, labels, categories); err != nil {
+		return nil, fmt.Errorf("failed to update image: %v", err)
 	}
+	committed = true
+	invalidateImageCache(ctx)
 
 	// 返回更新后的镜像信息
 	return s.GetImageByID(ctx, image.ID, userID)
 }
 
-// DeleteImage deletes an image
-func (s *ImageService) DeleteImage(ctx context.Context, imageID string, userID string) error {
-	db := database.GetDB()
-
+// DeleteImage deletes an image from an organization
+func (s *ImageService) DeleteImage(ctx context.Context, orgID string, imageID string, userID string) error {
 	// 查找镜像
-	var image models.Image
-	if err := db.First(&image, "id = ? AND author = ?", imageID, userID).Error; err != nil {
-		return fmt.Errorf("image not found or not authorized")
+	image, err := s.images.FindInOrg(orgID, imageID)
+	if err != nil {
+		return fmt.Errorf("image not found")
 	}
 
-	// 开始事务
-	tx := db.Begin()
+	if err := s.checkImageWrite(image, userID, models.PermImagesDelete); err != nil {
+		return err
+	}
 
-	// 删除相关的收藏记录
-	if err := tx.Where("image_id = ?", imageID).Delete(&models.Collection{}).Error; err != nil {
-		tx.Rollback()
-		return fmt.Errorf("failed to delete collections: %v", err)
+	// 删除镜像及其标签关联和收藏记录
+	if err := s.images.Delete(image); err != nil {
+		return fmt.Errorf("failed to delete image: %v", err)
 	}
 
-	// 清除标签关联
-	if err := tx.Model(&image).Association("Labels").Clear(); err != nil {
-		tx.Rollback()
-		return fmt.Errorf("failed to clear labels: %v", err)
+	invalidateImageCache(ctx)
+
+	// 提交成功后再删除 README 文件
+	releaseObjects(s.objects, image.ReadmePath)
+	return nil
+}
+
+// GetReadme returns an image's README as markdown and as sanitized HTML.
+// Like the pull address, the README is only shown to users with access to the image.
+func (s *ImageService) GetReadme(ctx context.Context, id string, userID string) (*ReadmeResponse, error) {
+	image, err := s.images.FindByID(id)
+	if err != nil {
+		return nil, ErrImageNotFound
+	}
+	if err := checkImageAccess(s.images, s.authorizer, image, userID); err != nil {
+		return nil, err
+	}
+	if image.ReadmePath == "" {
+		return nil, ErrReadmeNotFound
 	}
 
-	// 删除 README 文件
-	if image.ReadmePath != "" {
-		utils.DeleteFile(image.ReadmePath)
+	content, err := utils.ReadFile(ctx, image.ReadmePath, utils.MaxReadmeSize)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return nil, ErrReadmeNotFound
+		}
+		return nil, fmt.Errorf("failed to read readme: %v", err)
 	}
 
-	// 删除镜像记录
-	if err := tx.Delete(&image).Error; err != nil {
-		tx.Rollback()
-		return fmt.Errorf("failed to delete image: %v", err)
+	rendered, err := utils.RenderMarkdown(content, image.ReadmeBase)
+	if err != nil {
+		return nil, err
 	}
 
-	// 提交事务
-	return tx.Commit().Error
+	return &ReadmeResponse{
+		ImageID:  image.ID,
+		Markdown: string(content),
+		HTML:     rendered,
+	}, nil
+}
+
+// storeReadme saves and tracks a README from either an uploaded file or inline markdown
+func storeReadme(objects repository.StoredObjectRepo, file *multipart.FileHeader, content string) (string, error) {
+	if file != nil && content != "" {
+		return "", errors.New("provide either readme or readme_file, not both")
+	}
+
+	var (
+		path string
+		err  error
+	)
+	if file != nil {
+		path, err = utils.UploadReadme(file)
+	} else {
+		path, err = utils.SaveReadme(content)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to save readme: %v", err)
+	}
+	trackObjects(objects, models.StoredObjectReadme, path)
+	return path, nil
+}
+
+// validateReadmeBaseURL accepts an empty value or an absolute http(s) URL
+func validateReadmeBaseURL(raw string) error {
+	if raw == "" {
+		return nil
+	}
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return errors.New("readme_base_url must be an absolute http(s) URL")
+	}
+	return nil
+}
+
+// labelFilter resolves label names, aliases included, to label IDs. It reports false when
+// a name matches no label, as then no image can carry all of them.
+func (s *ImageService) labelFilter(names []string) ([]string, bool, error) {
+	var ids []string
+	for _, name := range names {
+		if models.LabelKey(name) == "" {
+			continue
+		}
+		labels, err := s.labels.Resolve([]string{name})
+		if err != nil {
+			return nil, false, err
+		}
+		if len(labels) == 0 {
+			return nil, false, nil
+		}
+		if !slices.Contains(ids, labels[0].ID) {
+			ids = append(ids, labels[0].ID)
+		}
+	}
+	return ids, true, nil
+}
+
+// categorySubtree returns the IDs of the category with the slug and all of its
+// subcategories, or nil when slug is empty
+func (s *ImageService) categorySubtree(slug string) ([]string, error) {
+	if slug == "" {
+		return nil, nil
+	}
+	categories, err := s.categories.List()
+	if err != nil {
+		return nil, err
+	}
+	for _, category := range categories {
+		if category.Slug == slug {
+			return newCategoryTree(categories).subtree(category.ID), nil
+		}
+	}
+	return nil, ErrCategoryNotFound
+}
+
+// resolveCategories looks up the categories by slug, failing on unknown slugs
+func (s *ImageService) resolveCategories(slugs []string) ([]models.Category, error) {
+	categories, err := s.categories.FindBySlugs(slugs)
+	if err != nil {
+		return nil, err
+	}
+	found := make(map[string]bool, len(categories))
+	for _, category := range categories {
+		found[category.Slug] = true
+	}
+	for _, slug := range slugs {
+		if !found[slug] {
+			return nil, fmt.Errorf("unknown category: %s", slug)
+		}
+	}
+	return categories, nil
+}
+
+// checkImageWrite allows authors with ownPerm in the image's project or org, and anyone with images.manage there
+func (s *ImageService) checkImageWrite(image *models.Image, userID string, ownPerm models.Permission) error {
+	scope := imageScope(image)
+	if image.Author == userID {
+		return s.authorizer.Check(userID, ownPerm, scope)
+	}
+	return s.authorizer.Check(userID, models.PermImagesManage, scope)
+}
+
+// imageScope returns the narrowest authorization scope the image belongs to
+func imageScope(image *models.Image) authz.Scope {
+	if image.ProjectID != nil {
+		return authz.Project(*image.ProjectID)
+	}
+	return authz.Org(image.OrgID)
 }
 
 // ListFavorites retrieves a list of user's favorite images
@@ -499,51 +803,39 @@ func (s *ImageService) ListFavorites(ctx context.Context, req *ImageListRequest,
 		req.PageSize = 10
 	}
 
-	db := database.GetDB()
-
-	// 构建查询
-	query := db.Model(&models.Image{}).
-		Joins("JOIN collections ON collections.image_id = images.id").
-		Where("collections.user_id = ?", userID)
-
-	// 应用搜索过滤
-	if req.Search != "" {
-		query = query.Where("images.name ILIKE ? OR images.description ILIKE ?", "%"+req.Search+"%", "%"+req.Search+"%")
+	viewer, err := resolveImageViewer(s.authorizer, userID)
+	if err != nil {
+		return nil, 0, err
 	}
 
-	// 应用标签过滤
-	if len(req.Labels) > 0 {
-		query = query.Joins("JOIN image_labels ON images.id = image_labels.image_id").
-			Where("image_labels.label IN ?", req.Labels).
-			Group("images.id").
-			Having("COUNT(DISTINCT image_labels.label) = ?", len(req.Labels))
+	categories, err := s.categorySubtree(req.Category)
+	if err != nil {
+		return nil, 0, err
 	}
-
-	// 获取总数
-	var total int64
-	if err := query.Count(&total).Error; err != nil {
+	labels, ok, err := s.labelFilter(req.Labels)
+	if err != nil {
 		return nil, 0, err
 	}
-
-	// 应用排序
-	switch req.Sort {
-	case "stars":
-		query = query.Order("stars DESC")
-	case "created_at":
-		query = query.Order("created_at DESC")
-	case "updated_at":
-		query = query.Order("updated_at DESC")
-	default:
-		query = query.Order("created_at DESC")
+	if !ok {
+		return []ImageResponse{}, 0, nil
+	}
+
+	// 收藏后变为私有且不可见的镜像不再返回
+	images, total, err := s.images.List(repository.ImageFilter{
+		Scope:      viewer.listScope(),
+		StarredBy:  userID,
+		Search:     req.Search,
+		Labels:     labels,
+		Categories: categories,
+		Sort:       req.Sort,
+		Offset:     (req.Page - 1) * req.PageSize,
+		Limit:      req.PageSize,
+	})
+	if err != nil {
+		return nil, 0, err
 	}
 
-	// 应用分页
-	offset := (req.Page - 1) * req.PageSize
-	query = query.Offset(offset).Limit(req.PageSize)
-
-	// 执行查询
-	var images []models.Image
-	if err := query.Preload("Labels").Find(&images).Error; err != nil {
+	if err := viewer.load(s.images, images); err != nil {
 		return nil, 0, err
 	}
 
@@ -553,6 +845,7 @@ func (s *ImageService) ListFavorites(ctx context.Context, req *ImageListRequest,
 		response[i] = ImageResponse{
 			ID:          img.ID,
 			OrgID:       img.OrgID,
+			ProjectID:   img.ProjectID,
 			Name:        img.Name,
 			Description: img.Description,
 			Author:      img.Author,
@@ -563,11 +856,14 @@ func (s *ImageService) ListFavorites(ctx context.Context, req *ImageListRequest,
 			Digest:      img.Digest,
 			Size:        img.Size,
 			ReadmePath:  img.ReadmePath,
+			ReadmeURL:   utils.GetFileURL(img.ReadmePath),
 			Stars:       img.Stars,
 			Visibility:  img.Visibility,
 			Platform:    img.Platform,
 			Labels:      make([]string, len(img.Labels)),
+			Categories:  categorySlugs(img.Categories),
 			IsStarred:   true, // 这是收藏列表，所以一定是已收藏的
+			HasAccess:   viewer.hasAccess(&img),
 			CreatedAt:   img.CreatedAt,
 			UpdatedAt:   img.UpdatedAt,
 		}
@@ -575,6 +871,10 @@ func (s *ImageService) ListFavorites(ctx context.Context, req *ImageListRequest,
 		for j, label := range img.Labels {
 			response[i].Labels[j] = label.Name
 		}
+		redactPullInfo(&response[i])
+	}
+	if err := s.fillDeploys(response); err != nil {
+		return nil, 0, err
 	}
 
 	return response, total, nil
@@ -0,0 +1,268 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/samzong/share-ai-platform/internal/models"
+	"github.com/samzong/share-ai-platform/internal/repository"
+	"github.com/samzong/share-ai-platform/internal/storage"
+)
+
+var (
+	storageRoot     string
+	storageRootOnce sync.Once
+)
+
+// setupStorage points the shared storage backend at a local directory and empties it.
+// The backend is initialized once per process, so every test shares the same root.
+func setupStorage(t *testing.T) string {
+	storageRootOnce.Do(func() {
+		root, err := os.MkdirTemp("", "services-storage-")
+		require.NoError(t, err)
+		storageRoot = root
+		viper.Set("storage.driver", "local")
+		viper.Set("storage.local.root", root)
+		viper.Set("storage.local.base_url", "/uploads")
+		viper.Set("storage.url_signing_secret", "test-secret")
+	})
+	_, err := storage.Get()
+	require.NoError(t, err)
+
+	empty := func() {
+		entries, _ := os.ReadDir(storageRoot)
+		for _, entry := range entries {
+			os.RemoveAll(filepath.Join(storageRoot, entry.Name()))
+		}
+	}
+	empty()
+	t.Cleanup(empty)
+	return storageRoot
+}
+
+// storedKeys returns the keys of the tracked objects
+func storedKeys(t *testing.T, repos *repository.Memory) []string {
+	objects, err := repos.Objects.List()
+	require.NoError(t, err)
+	keys := make([]string, 0, len(objects))
+	for _, obj := range objects {
+		keys = append(keys, obj.Key)
+	}
+	return keys
+}
+
+// assertStored checks whether key exists in storage
+func assertStored(t *testing.T, key string, want bool) {
+	t.Helper()
+	store, err := storage.Get()
+	require.NoError(t, err)
+	_, err = store.Stat(context.Background(), key)
+	if want {
+		assert.NoError(t, err, "expected %s to be stored", key)
+	} else {
+		assert.ErrorIs(t, err, storage.ErrNotFound, "expected %s to be deleted", key)
+	}
+}
+
+func setupImageTest(t *testing.T) (*ImageService, *repository.Memory) {
+	repos := repository.NewMemory()
+	return NewImageServiceWith(&repos.Repositories, newFakeAuthorizer(), NewEventRecorder(repos.Stats)), repos
+}
+
+func createTestImage(t *testing.T, repos *repository.Memory, name string, visibility string) *models.Image {
+	image := &models.Image{
+		OrgID:      "org-1",
+		Author:     "author-1",
+		Name:       name,
+		Registry:   "docker.io",
+		Repository: name,
+		Tag:        "latest",
+		Visibility: visibility,
+	}
+	assert.NoError(t, repos.Images.Create(image))
+	return image
+}
+
+func TestImageService_ListImagesHidesPrivateImages(t *testing.T) {
+	service, repos := setupImageTest(t)
+	public := createTestImage(t, repos, "public", models.VisibilityPublic)
+	createTestImage(t, repos, "private", models.VisibilityPrivate)
+	restricted := createTestImage(t, repos, "restricted", models.VisibilityRequireAccess)
+
+	images, total, err := service.ListImages(context.Background(), &ImageListRequest{}, "")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), total)
+
+	byID := make(map[string]ImageResponse)
+	for _, img := range images {
+		byID[img.ID] = img
+	}
+	assert.True(t, byID[public.ID].HasAccess)
+	assert.Equal(t, "docker.io", byID[public.ID].Registry)
+	assert.False(t, byID[restricted.ID].HasAccess)
+	assert.Empty(t, byID[restricted.ID].Registry)
+}
+
+func TestImageService_CollectImage(t *testing.T) {
+	service, repos := setupImageTest(t)
+	image := createTestImage(t, repos, "nginx", models.VisibilityPublic)
+	private := createTestImage(t, repos, "secret", models.VisibilityPrivate)
+
+	assert.NoError(t, service.CollectImage("user-1", image.ID))
+	assert.NoError(t, service.CollectImage("user-1", image.ID), "collecting twice is a no-op")
+	assert.ErrorIs(t, service.CollectImage("user-1", private.ID), ErrImageNotFound)
+
+	resp, err := service.GetImageByID(context.Background(), image.ID, "user-1")
+	assert.NoError(t, err)
+	assert.True(t, resp.IsStarred)
+	assert.Equal(t, 1, resp.Stars)
+
+	favorites, total, err := service.ListFavorites(context.Background(), &ImageListRequest{}, "user-1")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+	if assert.Len(t, favorites, 1) {
+		assert.Equal(t, image.ID, favorites[0].ID)
+	}
+
+	assert.NoError(t, service.UncollectImage("user-1", image.ID))
+	assert.NoError(t, service.UncollectImage("user-1", image.ID))
+
+	resp, err = service.GetImageByID(context.Background(), image.ID, "user-1")
+	assert.NoError(t, err)
+	assert.False(t, resp.IsStarred)
+	assert.Equal(t, 0, resp.Stars)
+}
+
+func TestImageService_ConcurrentCollectsAreCounted(t *testing.T) {
+	service, repos := setupImageTest(t)
+	image := createTestImage(t, repos, "nginx", models.VisibilityPublic)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(userID string) {
+			defer wg.Done()
+			// 每个用户重复收藏两次，只计一次
+			assert.NoError(t, service.CollectImage(userID, image.ID))
+			assert.NoError(t, service.CollectImage(userID, image.ID))
+		}(fmt.Sprintf("user-%d", i))
+	}
+	wg.Wait()
+
+	stored, err := repos.Images.FindByID(image.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, 20, stored.Stars)
+}
+
+func TestImageService_CreateImageChecksOrgAndProject(t *testing.T) {
+	repos := repository.NewMemory()
+	az := newFakeAuthorizer()
+	az.admins["admin-1"] = true
+	service := NewImageServiceWith(&repos.Repositories, az, NewEventRecorder(repos.Stats))
+
+	repos.AddOrg("org-1")
+	repos.AddOrg("org-2")
+	repos.AddProject(models.Project{ID: "project-1", OrgID: "org-1"})
+
+	newRequest := func(projectID string) *CreateImageRequest {
+		return &CreateImageRequest{
+			Name: "nginx", Registry: "docker.io", Repository: "nginx", Tag: "latest",
+			Digest: "sha256:abc", Visibility: models.VisibilityPublic, Platform: "linux/amd64",
+			ProjectID: projectID,
+		}
+	}
+
+	tests := []struct {
+		name      string
+		orgID     string
+		projectID string
+		wantErr   string
+	}{
+		{"unknown org", "org-missing", "", "organization not found"},
+		{"unknown project", "org-1", "project-missing", "project not found"},
+		{"project of another org", "org-2", "project-1", "project not found"},
+		{"org without project", "org-1", "", ""},
+		{"project in org", "org-1", "project-1", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			image, err := service.CreateImage(context.Background(), newRequest(tt.projectID), "admin-1", tt.orgID)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.orgID, image.OrgID)
+		})
+	}
+}
+
+func TestImageService_ReadmeLifecycle(t *testing.T) {
+	setupStorage(t)
+	repos := repository.NewMemory()
+	az := newFakeAuthorizer()
+	az.admins["admin-1"] = true
+	service := NewImageServiceWith(&repos.Repositories, az, NewEventRecorder(repos.Stats))
+	repos.AddOrg("org-1")
+	ctx := context.Background()
+
+	created, err := service.CreateImage(ctx, &CreateImageRequest{
+		Name: "nginx", Registry: "docker.io", Repository: "nginx", Tag: "latest",
+		Digest: "sha256:abc", Visibility: models.VisibilityPublic, Platform: "linux/amd64",
+		Readme: "# nginx",
+	}, "admin-1", "org-1")
+	require.NoError(t, err)
+	image, err := repos.Images.FindByID(created.ID)
+	require.NoError(t, err)
+	firstReadme := image.ReadmePath
+	require.NotEmpty(t, firstReadme)
+	assertStored(t, firstReadme, true)
+	assert.Equal(t, []string{firstReadme}, storedKeys(t, repos))
+
+	// 替换 README 后旧文件被删除并取消跟踪
+	content := "# nginx v2"
+	_, err = service.UpdateImage(ctx, "org-1", image.ID, &UpdateImageRequest{Readme: &content}, "admin-1")
+	require.NoError(t, err)
+	image, err = repos.Images.FindByID(image.ID)
+	require.NoError(t, err)
+	secondReadme := image.ReadmePath
+	assert.NotEqual(t, firstReadme, secondReadme)
+	assertStored(t, firstReadme, false)
+	assertStored(t, secondReadme, true)
+	assert.Equal(t, []string{secondReadme}, storedKeys(t, repos))
+
+	require.NoError(t, service.DeleteImage(ctx, "org-1", image.ID, "admin-1"))
+	assertStored(t, secondReadme, false)
+	assert.Empty(t, storedKeys(t, repos))
+}
+
+func TestImageService_CreateImageReleasesReadmeOnFailure(t *testing.T) {
+	setupStorage(t)
+	repos := repository.NewMemory()
+	az := newFakeAuthorizer()
+	az.admins["admin-1"] = true
+	service := NewImageServiceWith(&repos.Repositories, az, NewEventRecorder(repos.Stats))
+	repos.AddOrg("org-1")
+
+	_, err := service.CreateImage(context.Background(), &CreateImageRequest{
+		Name: "nginx", Registry: "docker.io", Repository: "nginx", Tag: "latest",
+		Digest: "sha256:abc", Visibility: models.VisibilityPublic, Platform: "linux/amd64",
+		Readme: "# nginx", Categories: []string{"missing"},
+	}, "admin-1", "org-1")
+	require.Error(t, err)
+
+	store, err := storage.Get()
+	require.NoError(t, err)
+	objects, err := store.List(context.Background(), "")
+	require.NoError(t, err)
+	assert.Empty(t, objects)
+	assert.Empty(t, storedKeys(t, repos))
+}
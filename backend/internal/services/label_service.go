@@ -0,0 +1,226 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/samzong/share-ai-platform/internal/authz"
+	"github.com/samzong/share-ai-platform/internal/database"
+	"github.com/samzong/share-ai-platform/internal/models"
+	"github.com/samzong/share-ai-platform/internal/repository"
+)
+
+// ErrLabelNotFound is returned when a label does not exist
+var ErrLabelNotFound = errors.New("label not found")
+
+type LabelService struct {
+	labels     repository.LabelRepo
+	authorizer Authorizer
+}
+
+type LabelListRequest struct {
+	Search string `form:"search"`
+	Limit  int    `form:"limit" binding:"omitempty,min=1,max=500"` // 默认返回全部
+}
+
+type RenameLabelRequest struct {
+	Name string `json:"name" binding:"required,max=100"`
+}
+
+type MergeLabelsRequest struct {
+	SourceIDs []string `json:"source_ids" binding:"required,min=1"` // 合并到目标标签后删除的标签
+}
+
+type LabelAliasRequest struct {
+	Alias string `json:"alias" binding:"required,max=100"`
+}
+
+type LabelResponse struct {
+	ID        string    `json:"id"`               // 标签唯一标识符
+	Name      string    `json:"name"`             // 标签名称
+	Images    int64     `json:"images,omitempty"` // 带有该标签的镜像数，仅统计当前用户可见的镜像，仅在列表中返回
+	Aliases   []string  `json:"aliases"`          // 映射到该标签的其他写法
+	CreatedAt time.Time `json:"created_at"`       // 创建时间
+	UpdatedAt time.Time `json:"updated_at"`       // 更新时间
+}
+
+// NewLabelService creates a new LabelService backed by the database
+func NewLabelService() *LabelService {
+	return NewLabelServiceWith(repository.NewGorm(database.GetDB()), defaultAuthorizer)
+}
+
+// NewLabelServiceWith creates a LabelService on the given repositories and authorizer
+func NewLabelServiceWith(repos *repository.Repositories, az Authorizer) *LabelService {
+	return &LabelService{
+		labels:     repos.Labels,
+		authorizer: az,
+	}
+}
+
+// ListLabels returns the labels on images the user can see with their usage counts, most
+// used first. Users who can see every image also get unused labels.
+func (s *LabelService) ListLabels(req *LabelListRequest, userID string) ([]LabelResponse, error) {
+	viewer, err := resolveImageViewer(s.authorizer, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	usage, err := s.labels.Usage(viewer.listScope(), req.Search)
+	if err != nil {
+		return nil, err
+	}
+	if req.Limit > 0 && len(usage) > req.Limit {
+		usage = usage[:req.Limit]
+	}
+
+	ids := make([]string, len(usage))
+	for i, u := range usage {
+		ids[i] = u.Label.ID
+	}
+	aliases, err := s.labels.Aliases(ids)
+	if err != nil {
+		return nil, err
+	}
+
+	response := make([]LabelResponse, len(usage))
+	for i, u := range usage {
+		response[i] = newLabelResponse(&u.Label, aliases[u.Label.ID])
+		response[i].Images = u.Images
+	}
+	return response, nil
+}
+
+// RenameLabel changes a label's name on every image. The old name becomes an alias so
+// images labelled with it later get the renamed label.
+func (s *LabelService) RenameLabel(ctx context.Context, id string, req *RenameLabelRequest, userID string) (*LabelResponse, error) {
+	label, err := s.managedLabel(id, userID)
+	if err != nil {
+		return nil, err
+	}
+	if models.LabelKey(req.Name) == "" {
+		return nil, errors.New("label name cannot be empty")
+	}
+
+	if err := s.labels.Rename(label, req.Name); err != nil {
+		return nil, labelSaveError(err)
+	}
+	invalidateImageCache(ctx)
+	return s.response(label)
+}
+
+// MergeLabels moves the images of the source labels to the target label and deletes the
+// sources, keeping their names as aliases of the target
+func (s *LabelService) MergeLabels(ctx context.Context, targetID string, req *MergeLabelsRequest, userID string) (*LabelResponse, error) {
+	target, err := s.managedLabel(targetID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	sources := make([]models.Label, 0, len(req.SourceIDs))
+	for _, id := range req.SourceIDs {
+		if id == target.ID {
+			return nil, errors.New("cannot merge a label into itself")
+		}
+		source, err := s.labels.FindByID(id)
+		if err != nil {
+			return nil, ErrLabelNotFound
+		}
+		sources = append(sources, *source)
+	}
+
+	if err := s.labels.Merge(target, sources); err != nil {
+		return nil, fmt.Errorf("failed to merge labels: %v", err)
+	}
+	invalidateImageCache(ctx)
+	return s.response(target)
+}
+
+// DeleteLabel removes a label from every image
+func (s *LabelService) DeleteLabel(ctx context.Context, id string, userID string) error {
+	label, err := s.managedLabel(id, userID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.labels.Delete(label); err != nil {
+		return fmt.Errorf("failed to delete label: %v", err)
+	}
+	invalidateImageCache(ctx)
+	return nil
+}
+
+// AddAlias maps another spelling to a label, so images labelled with it get the label
+func (s *LabelService) AddAlias(ctx context.Context, id string, req *LabelAliasRequest, userID string) (*LabelResponse, error) {
+	label, err := s.managedLabel(id, userID)
+	if err != nil {
+		return nil, err
+	}
+	if models.LabelKey(req.Alias) == "" {
+		return nil, errors.New("alias cannot be empty")
+	}
+
+	if err := s.labels.AddAlias(label.ID, req.Alias); err != nil {
+		return nil, labelSaveError(err)
+	}
+	return s.response(label)
+}
+
+// RemoveAlias deletes an alias of a label. Images already labelled keep the label.
+func (s *LabelService) RemoveAlias(ctx context.Context, id string, alias string, userID string) error {
+	label, err := s.managedLabel(id, userID)
+	if err != nil {
+		return err
+	}
+
+	removed, err := s.labels.RemoveAlias(label.ID, alias)
+	if err != nil {
+		return err
+	}
+	if !removed {
+		return errors.New("alias not found")
+	}
+	return nil
+}
+
+// managedLabel checks that the user may manage labels and returns the label
+func (s *LabelService) managedLabel(id string, userID string) (*models.Label, error) {
+	if err := s.authorizer.Check(userID, models.PermLabelsManage, authz.Global()); err != nil {
+		return nil, err
+	}
+	label, err := s.labels.FindByID(id)
+	if err != nil {
+		return nil, ErrLabelNotFound
+	}
+	return label, nil
+}
+
+func (s *LabelService) response(label *models.Label) (*LabelResponse, error) {
+	aliases, err := s.labels.Aliases([]string{label.ID})
+	if err != nil {
+		return nil, err
+	}
+	response := newLabelResponse(label, aliases[label.ID])
+	return &response, nil
+}
+
+func newLabelResponse(label *models.Label, aliases []string) LabelResponse {
+	if aliases == nil {
+		aliases = []string{}
+	}
+	return LabelResponse{
+		ID:        label.ID,
+		Name:      label.Name,
+		Aliases:   aliases,
+		CreatedAt: label.CreatedAt,
+		UpdatedAt: label.UpdatedAt,
+	}
+}
+
+func labelSaveError(err error) error {
+	if errors.Is(err, repository.ErrDuplicate) {
+		return errors.New("the name is already used by another label or alias, merge the labels instead")
+	}
+	return fmt.Errorf("failed to save label: %v", err)
+}
@@ -0,0 +1,98 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/samzong/share-ai-platform/internal/authz"
+	"github.com/samzong/share-ai-platform/internal/models"
+	"github.com/samzong/share-ai-platform/internal/repository"
+)
+
+func setupLabelTest(t *testing.T) (*LabelService, *ImageService, *repository.Memory) {
+	repos := repository.NewMemory()
+	az := newFakeAuthorizer()
+	az.admins["admin"] = true
+	return NewLabelServiceWith(&repos.Repositories, az), NewImageServiceWith(&repos.Repositories, az, NewEventRecorder(repos.Stats)), repos
+}
+
+func labelImage(t *testing.T, repos *repository.Memory, name string, visibility string, labels ...string) *models.Image {
+	image := createTestImage(t, repos, name, visibility)
+	found, err := repos.Labels.FindOrCreate(labels)
+	require.NoError(t, err)
+	require.NoError(t, repos.Images.Update(image, found, nil))
+	return image
+}
+
+func TestLabelService_NormalizesNames(t *testing.T) {
+	_, _, repos := setupLabelTest(t)
+
+	labels, err := repos.Labels.FindOrCreate([]string{"  Large   Language Model ", "large language model", "LARGE LANGUAGE MODEL", " "})
+	require.NoError(t, err)
+	require.Len(t, labels, 1)
+	assert.Equal(t, "Large Language Model", labels[0].Name)
+}
+
+func TestLabelService_MergeAndAliases(t *testing.T) {
+	service, images, repos := setupLabelTest(t)
+	ctx := context.Background()
+
+	both := labelImage(t, repos, "both", models.VisibilityPublic, "PyTorch", "pytorch-lib")
+	labelImage(t, repos, "legacy", models.VisibilityPublic, "pytorch-lib")
+	labelImage(t, repos, "internal", models.VisibilityPrivate, "PyTorch")
+
+	target, err := repos.Labels.Resolve([]string{"pytorch"})
+	require.NoError(t, err)
+	source, err := repos.Labels.Resolve([]string{"pytorch-lib"})
+	require.NoError(t, err)
+
+	_, err = service.MergeLabels(ctx, target[0].ID, &MergeLabelsRequest{SourceIDs: []string{source[0].ID}}, "user-1")
+	assert.ErrorIs(t, err, authz.ErrForbidden)
+	merged, err := service.MergeLabels(ctx, target[0].ID, &MergeLabelsRequest{SourceIDs: []string{source[0].ID}}, "admin")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"pytorch-lib"}, merged.Aliases)
+
+	// 合并后镜像只保留一个标签，旧名称仍可用于筛选和打标签
+	stored, err := repos.Images.FindByID(both.ID)
+	require.NoError(t, err)
+	assert.Len(t, stored.Labels, 1)
+	listed, total, err := images.ListImages(ctx, &ImageListRequest{Labels: []string{"PyTorch-Lib"}}, "")
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), total)
+	assert.Equal(t, []string{"PyTorch"}, listed[0].Labels)
+	again, err := repos.Labels.FindOrCreate([]string{"pytorch-lib"})
+	require.NoError(t, err)
+	assert.Equal(t, target[0].ID, again[0].ID)
+
+	// 匿名用户只统计可见镜像，管理员统计全部
+	labels, err := service.ListLabels(&LabelListRequest{}, "")
+	require.NoError(t, err)
+	require.Len(t, labels, 1)
+	assert.Equal(t, int64(2), labels[0].Images)
+	labels, err = service.ListLabels(&LabelListRequest{}, "admin")
+	require.NoError(t, err)
+	require.Len(t, labels, 1)
+	assert.Equal(t, int64(3), labels[0].Images)
+
+	renamed, err := service.RenameLabel(ctx, target[0].ID, &RenameLabelRequest{Name: "Torch"}, "admin")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"pytorch", "pytorch-lib"}, renamed.Aliases)
+
+	other := labelImage(t, repos, "tf", models.VisibilityPublic, "TensorFlow")
+	_, err = service.RenameLabel(ctx, other.Labels[0].ID, &RenameLabelRequest{Name: "PyTorch"}, "admin")
+	assert.Error(t, err, "names taken by an alias cannot be reused")
+	aliased, err := service.AddAlias(ctx, other.Labels[0].ID, &LabelAliasRequest{Alias: "TF"}, "admin")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"tf"}, aliased.Aliases)
+	_, err = service.AddAlias(ctx, other.Labels[0].ID, &LabelAliasRequest{Alias: "torch"}, "admin")
+	assert.Error(t, err, "aliases cannot shadow another label")
+	assert.NoError(t, service.RemoveAlias(ctx, other.Labels[0].ID, "tf", "admin"))
+
+	require.NoError(t, service.DeleteLabel(ctx, target[0].ID, "admin"))
+	stored, err = repos.Images.FindByID(both.ID)
+	require.NoError(t, err)
+	assert.Empty(t, stored.Labels)
+}
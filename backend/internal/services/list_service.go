@@ -0,0 +1,499 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/samzong/share-ai-platform/internal/authz"
+	"github.com/samzong/share-ai-platform/internal/database"
+	"github.com/samzong/share-ai-platform/internal/models"
+	"github.com/samzong/share-ai-platform/internal/repository"
+)
+
+// ErrListNotFound is returned when a list does not exist or is not visible to the user
+var ErrListNotFound = errors.New("list not found")
+
+// maxListItems 是单个列表最多包含的镜像数
+const maxListItems = 500
+
+type ListService struct {
+	lists      repository.ListRepo
+	users      repository.UserRepo
+	images     *ImageService
+	authorizer Authorizer
+}
+
+type CreateListRequest struct {
+	Name        string `json:"name" binding:"required,min=1,max=100"`
+	Description string `json:"description"`
+	Visibility  string `json:"visibility" binding:"omitempty,oneof=private org public"` // 默认 private
+	OrgID       string `json:"org_id"`                                                  // 共享给的组织，visibility 为 org 时必填
+}
+
+type UpdateListRequest struct {
+	Name        *string `json:"name" binding:"omitempty,min=1,max=100"`
+	Description *string `json:"description"`
+	Visibility  string  `json:"visibility" binding:"omitempty,oneof=private org public"`
+	OrgID       string  `json:"org_id"` // 修改为 org 可见性时必填
+}
+
+type AddListItemRequest struct {
+	ImageID string `json:"image_id" binding:"required"`
+	Note    string `json:"note"`
+}
+
+type UpdateListItemRequest struct {
+	Note string `json:"note"`
+}
+
+type ReorderListRequest struct {
+	ImageIDs []string `json:"image_ids" binding:"required"` // 列表中全部镜像的新顺序
+}
+
+type ListResponse struct {
+	ID          string    `json:"id"`               // 列表唯一标识符
+	OwnerID     string    `json:"owner_id"`         // 创建者ID
+	OwnerName   string    `json:"owner_name"`       // 创建者用户名
+	Name        string    `json:"name"`             // 列表名称
+	Description string    `json:"description"`      // 列表描述
+	Visibility  string    `json:"visibility"`       // 可见性：private/org/public
+	OrgID       *string   `json:"org_id,omitempty"` // 共享给的组织
+	IsDefault   bool      `json:"is_default"`       // 是否为默认收藏列表
+	ItemCount   int64     `json:"item_count"`       // 镜像数
+	Followers   int64     `json:"followers"`        // 关注人数
+	IsFollowing bool      `json:"is_following"`     // 当前用户是否已关注
+	CreatedAt   time.Time `json:"created_at"`       // 创建时间
+	UpdatedAt   time.Time `json:"updated_at"`       // 更新时间
+}
+
+type ListItemResponse struct {
+	Position int           `json:"position"` // 在列表中的顺序
+	Note     string        `json:"note"`     // 备注
+	AddedAt  time.Time     `json:"added_at"` // 加入时间
+	Image    ImageResponse `json:"image"`    // 镜像信息
+}
+
+type ListDetailResponse struct {
+	ListResponse
+	Items []ListItemResponse `json:"items"` // 当前用户可见的镜像，按顺序排列
+}
+
+// NewListService creates a new ListService backed by the database
+func NewListService() *ListService {
+	return NewListServiceWith(repository.NewGorm(database.GetDB()), defaultAuthorizer, defaultEventRecorder())
+}
+
+// NewListServiceWith creates a ListService on the given repositories and authorizer.
+// events is passed to the ImageService it uses to build image responses.
+func NewListServiceWith(repos *repository.Repositories, az Authorizer, events *EventRecorder) *ListService {
+	return &ListService{
+		lists:      repos.Lists,
+		users:      repos.Users,
+		images:     NewImageServiceWith(repos, az, events),
+		authorizer: az,
+	}
+}
+
+// ListLists returns the lists of the owner that the viewer can see. The viewer's own
+// lists always include the default list.
+func (s *ListService) ListLists(ownerID string, viewerID string) ([]ListResponse, error) {
+	if ownerID == "" || ownerID == viewerID {
+		if _, err := s.lists.Default(viewerID); err != nil {
+			return nil, err
+		}
+		ownerID = viewerID
+	}
+
+	lists, err := s.lists.ListByOwner(ownerID)
+	if err != nil {
+		return nil, err
+	}
+	return s.visibleResponses(lists, viewerID)
+}
+
+// ListFollowing returns the lists the user follows and can still see
+func (s *ListService) ListFollowing(userID string) ([]ListResponse, error) {
+	lists, err := s.lists.Following(userID)
+	if err != nil {
+		return nil, err
+	}
+	return s.visibleResponses(lists, userID)
+}
+
+// CreateList creates a named list for the user
+func (s *ListService) CreateList(req *CreateListRequest, userID string) (*ListResponse, error) {
+	// 先创建默认列表，避免之后与同名列表冲突
+	if _, err := s.lists.Default(userID); err != nil {
+		return nil, err
+	}
+
+	list := &models.List{
+		OwnerID:     userID,
+		Name:        req.Name,
+		Description: req.Description,
+	}
+	if err := s.setVisibility(list, req.Visibility, req.OrgID, userID); err != nil {
+		return nil, err
+	}
+	if err := s.lists.Create(list); err != nil {
+		return nil, listSaveError(err)
+	}
+	return s.response(list, userID)
+}
+
+// GetList returns a list with the items the viewer can see
+func (s *ListService) GetList(id string, viewerID string) (*ListDetailResponse, error) {
+	list, err := s.visibleList(id, viewerID)
+	if err != nil {
+		return nil, err
+	}
+	summary, err := s.response(list, viewerID)
+	if err != nil {
+		return nil, err
+	}
+
+	items, err := s.lists.Items(list.ID)
+	if err != nil {
+		return nil, err
+	}
+	images, err := s.visibleImages(items, viewerID)
+	if err != nil {
+		return nil, err
+	}
+
+	detail := &ListDetailResponse{ListResponse: *summary, Items: []ListItemResponse{}}
+	for _, item := range items {
+		// 查看者无权看到的镜像直接省略
+		image, ok := images[item.ImageID]
+		if !ok {
+			continue
+		}
+		detail.Items = append(detail.Items, ListItemResponse{
+			Position: item.Position,
+			Note:     item.Note,
+			AddedAt:  item.CreatedAt,
+			Image:    image,
+		})
+	}
+	return detail, nil
+}
+
+// UpdateList changes the name, description or visibility of the user's list
+func (s *ListService) UpdateList(id string, req *UpdateListRequest, userID string) (*ListResponse, error) {
+	list, err := s.ownedList(id, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Name != nil {
+		list.Name = *req.Name
+	}
+	if req.Description != nil {
+		list.Description = *req.Description
+	}
+	if req.Visibility != "" {
+		if err := s.setVisibility(list, req.Visibility, req.OrgID, userID); err != nil {
+			return nil, err
+		}
+	}
+	if err := s.lists.Save(list); err != nil {
+		return nil, listSaveError(err)
+	}
+	return s.response(list, userID)
+}
+
+// DeleteList deletes the user's list. The default list cannot be deleted.
+func (s *ListService) DeleteList(id string, userID string) error {
+	list, err := s.ownedList(id, userID)
+	if err != nil {
+		return err
+	}
+	if list.IsDefault {
+		return errors.New("the default list cannot be deleted")
+	}
+	return s.lists.Delete(list)
+}
+
+// AddItem adds an image the user can see to the end of their list, or updates the note
+// when the image is already in it. Adding to the default list stars the image.
+func (s *ListService) AddItem(listID string, req *AddListItemRequest, userID string) error {
+	list, err := s.ownedList(listID, userID)
+	if err != nil {
+		return err
+	}
+
+	counts, err := s.lists.Counts([]string{list.ID})
+	if err != nil {
+		return err
+	}
+	if counts[list.ID].Items >= maxListItems {
+		return fmt.Errorf("a list can hold at most %d images", maxListItems)
+	}
+
+	if list.IsDefault {
+		// 默认列表与收藏同步，收藏时会同时加入默认列表
+		if err := s.images.CollectImage(userID, req.ImageID); err != nil {
+			return err
+		}
+	} else {
+		if _, err := s.images.GetImageByID(context.Background(), req.ImageID, userID); err != nil {
+			return err
+		}
+		if _, err := s.lists.AddItem(&models.ListItem{ListID: list.ID, ImageID: req.ImageID}); err != nil {
+			return err
+		}
+	}
+
+	if req.Note != "" {
+		return s.lists.SetNote(list.ID, req.ImageID, req.Note)
+	}
+	return nil
+}
+
+// UpdateItem changes the note of an image in the user's list
+func (s *ListService) UpdateItem(listID string, imageID string, req *UpdateListItemRequest, userID string) error {
+	list, err := s.ownedList(listID, userID)
+	if err != nil {
+		return err
+	}
+	if err := s.lists.SetNote(list.ID, imageID, req.Note); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return ErrImageNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+// RemoveItem removes an image from the user's list. Removing it from the default list
+// unstars it. Removing an image that is not in the list is a no-op.
+func (s *ListService) RemoveItem(listID string, imageID string, userID string) error {
+	list, err := s.ownedList(listID, userID)
+	if err != nil {
+		return err
+	}
+	if list.IsDefault {
+		err := s.images.UncollectImage(userID, imageID)
+		if errors.Is(err, ErrImageNotFound) {
+			return nil
+		}
+		return err
+	}
+	_, err = s.lists.RemoveItem(list.ID, imageID)
+	return err
+}
+
+// Reorder sets the order of the items in the user's list. The request must name every
+// item of the list exactly once.
+func (s *ListService) Reorder(listID string, req *ReorderListRequest, userID string) error {
+	list, err := s.ownedList(listID, userID)
+	if err != nil {
+		return err
+	}
+
+	items, err := s.lists.Items(list.ID)
+	if err != nil {
+		return err
+	}
+	inList := make(map[string]bool, len(items))
+	for _, item := range items {
+		inList[item.ImageID] = true
+	}
+	seen := make(map[string]bool, len(req.ImageIDs))
+	for _, id := range req.ImageIDs {
+		if !inList[id] || seen[id] {
+			return errors.New("image_ids must name every image of the list exactly once")
+		}
+		seen[id] = true
+	}
+	if len(seen) != len(inList) {
+		return errors.New("image_ids must name every image of the list exactly once")
+	}
+	return s.lists.Reorder(list.ID, req.ImageIDs)
+}
+
+// Follow makes the user follow a list they can see. Following twice is a no-op.
+func (s *ListService) Follow(listID string, userID string) error {
+	list, err := s.visibleList(listID, userID)
+	if err != nil {
+		return err
+	}
+	if list.OwnerID == userID {
+		return errors.New("you cannot follow your own list")
+	}
+	return s.lists.Follow(list.ID, userID)
+}
+
+// Unfollow stops the user following a list
+func (s *ListService) Unfollow(listID string, userID string) error {
+	if _, err := s.lists.FindByID(listID); err != nil {
+		return ErrListNotFound
+	}
+	return s.lists.Unfollow(listID, userID)
+}
+
+// setVisibility validates and applies a visibility. Lists can only be shared with orgs
+// the user belongs to.
+func (s *ListService) setVisibility(list *models.List, visibility string, orgID string, userID string) error {
+	if visibility == "" {
+		visibility = models.ListVisibilityPrivate
+	}
+	if !models.IsValidListVisibility(visibility) {
+		return errors.New("invalid visibility")
+	}
+
+	list.Visibility = visibility
+	list.OrgID = nil
+	if visibility != models.ListVisibilityOrg {
+		return nil
+	}
+	if orgID == "" {
+		return errors.New("org_id is required to share a list with an organization")
+	}
+	if err := s.authorizer.Check(userID, models.PermOrgMembersRead, authz.Org(orgID)); err != nil {
+		if errors.Is(err, authz.ErrForbidden) {
+			return errors.New("lists can only be shared with organizations you belong to")
+		}
+		return err
+	}
+	list.OrgID = &orgID
+	return nil
+}
+
+// canView reports whether the user can see the list: its owner always can, anyone can see
+// public lists and members of the org can see lists shared with it
+func (s *ListService) canView(list *models.List, userID string) (bool, error) {
+	switch {
+	case userID != "" && list.OwnerID == userID:
+		return true, nil
+	case list.Visibility == models.ListVisibilityPublic:
+		return true, nil
+	case list.Visibility == models.ListVisibilityOrg && list.OrgID != nil && userID != "":
+		err := s.authorizer.Check(userID, models.PermOrgMembersRead, authz.Org(*list.OrgID))
+		if errors.Is(err, authz.ErrForbidden) {
+			return false, nil
+		}
+		return err == nil, err
+	}
+	return false, nil
+}
+
+// visibleList returns the list if the user can see it, and ErrListNotFound otherwise
+func (s *ListService) visibleList(id string, userID string) (*models.List, error) {
+	list, err := s.lists.FindByID(id)
+	if err != nil {
+		return nil, ErrListNotFound
+	}
+	ok, err := s.canView(list, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrListNotFound
+	}
+	return list, nil
+}
+
+// ownedList returns the list if the user owns it. Others get ErrForbidden if they can see
+// the list and ErrListNotFound otherwise.
+func (s *ListService) ownedList(id string, userID string) (*models.List, error) {
+	list, err := s.visibleList(id, userID)
+	if err != nil {
+		return nil, err
+	}
+	if list.OwnerID != userID {
+		return nil, authz.ErrForbidden
+	}
+	return list, nil
+}
+
+// visibleImages returns the images of the items the viewer can see, keyed by image ID
+func (s *ListService) visibleImages(items []models.ListItem, viewerID string) (map[string]ImageResponse, error) {
+	ids := make([]string, len(items))
+	for i, item := range items {
+		ids[i] = item.ImageID
+	}
+	return s.images.visibleImages(ids, viewerID)
+}
+
+// visibleResponses converts the lists the viewer can see to responses
+func (s *ListService) visibleResponses(lists []models.List, viewerID string) ([]ListResponse, error) {
+	var visible []models.List
+	for i := range lists {
+		ok, err := s.canView(&lists[i], viewerID)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			visible = append(visible, lists[i])
+		}
+	}
+	return s.responses(visible, viewerID)
+}
+
+func (s *ListService) response(list *models.List, viewerID string) (*ListResponse, error) {
+	responses, err := s.responses([]models.List{*list}, viewerID)
+	if err != nil {
+		return nil, err
+	}
+	return &responses[0], nil
+}
+
+// responses adds owner names, counts and the viewer's follow state to the lists
+func (s *ListService) responses(lists []models.List, viewerID string) ([]ListResponse, error) {
+	ids := make([]string, len(lists))
+	for i, list := range lists {
+		ids[i] = list.ID
+	}
+	counts, err := s.lists.Counts(ids)
+	if err != nil {
+		return nil, err
+	}
+	following := make(map[string]bool)
+	if viewerID != "" {
+		followed, err := s.lists.FollowedIDs(viewerID, ids)
+		if err != nil {
+			return nil, err
+		}
+		for _, id := range followed {
+			following[id] = true
+		}
+	}
+
+	owners := make(map[string]string)
+	responses := make([]ListResponse, len(lists))
+	for i, list := range lists {
+		name, ok := owners[list.OwnerID]
+		if !ok {
+			if owner, err := s.users.FindByID(list.OwnerID); err == nil {
+				name = owner.Username
+			}
+			owners[list.OwnerID] = name
+		}
+		responses[i] = ListResponse{
+			ID:          list.ID,
+			OwnerID:     list.OwnerID,
+			OwnerName:   name,
+			Name:        list.Name,
+			Description: list.Description,
+			Visibility:  list.Visibility,
+			OrgID:       list.OrgID,
+			IsDefault:   list.IsDefault,
+			ItemCount:   counts[list.ID].Items,
+			Followers:   counts[list.ID].Followers,
+			IsFollowing: following[list.ID],
+			CreatedAt:   list.CreatedAt,
+			UpdatedAt:   list.UpdatedAt,
+		}
+	}
+	return responses, nil
+}
+
+func listSaveError(err error) error {
+	if errors.Is(err, repository.ErrDuplicate) {
+		return errors.New("you already have a list with this name")
+	}
+	return err
+}
@@ -0,0 +1,121 @@
+package services
+
+import (
+	"context"
+
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/samzong/share-ai-platform/internal/models"
+	"github.com/samzong/share-ai-platform/internal/repository"
+)
+
+func setupListTest(t *testing.T) (*ListService, *repository.Memory) {
+	repos := repository.NewMemory()
+	return NewListServiceWith(&repos.Repositories, newFakeAuthorizer(), NewEventRecorder(repos.Stats)), repos
+}
+
+func listImageIDs(detail *ListDetailResponse) []string {
+	ids := make([]string, 0, len(detail.Items))
+	for _, item := range detail.Items {
+		ids = append(ids, item.Image.ID)
+	}
+	return ids
+}
+
+func TestListService_DefaultListFollowsCollections(t *testing.T) {
+	service, repos := setupListTest(t)
+	image := createTestImage(t, repos, "nginx", models.VisibilityPublic)
+
+	assert.NoError(t, service.images.CollectImage("user-1", image.ID))
+
+	lists, err := service.ListLists("", "user-1")
+	assert.NoError(t, err)
+	if !assert.Len(t, lists, 1) {
+		return
+	}
+	favorites := lists[0]
+	assert.True(t, favorites.IsDefault)
+	assert.Equal(t, models.DefaultListName, favorites.Name)
+	assert.Equal(t, int64(1), favorites.ItemCount)
+
+	// 从默认列表移除即取消收藏
+	assert.NoError(t, service.RemoveItem(favorites.ID, image.ID, "user-1"))
+	resp, err := service.images.GetImageByID(context.Background(), image.ID, "user-1")
+	assert.NoError(t, err)
+	assert.False(t, resp.IsStarred)
+	assert.Equal(t, 0, resp.Stars)
+
+	assert.Error(t, service.DeleteList(favorites.ID, "user-1"), "default list cannot be deleted")
+}
+
+func TestListService_ItemsOrderAndNotes(t *testing.T) {
+	service, repos := setupListTest(t)
+	first := createTestImage(t, repos, "first", models.VisibilityPublic)
+	second := createTestImage(t, repos, "second", models.VisibilityPublic)
+
+	list, err := service.CreateList(&CreateListRequest{Name: "Inference"}, "user-1")
+	assert.NoError(t, err)
+	_, err = service.CreateList(&CreateListRequest{Name: "Inference"}, "user-1")
+	assert.Error(t, err, "list names are unique per owner")
+
+	assert.NoError(t, service.AddItem(list.ID, &AddListItemRequest{ImageID: first.ID}, "user-1"))
+	assert.NoError(t, service.AddItem(list.ID, &AddListItemRequest{ImageID: second.ID}, "user-1"))
+	assert.NoError(t, service.UpdateItem(list.ID, second.ID, &UpdateListItemRequest{Note: "GPU only"}, "user-1"))
+
+	err = service.Reorder(list.ID, &ReorderListRequest{ImageIDs: []string{second.ID}}, "user-1")
+	assert.Error(t, err, "reorder must cover every item")
+	assert.NoError(t, service.Reorder(list.ID, &ReorderListRequest{ImageIDs: []string{second.ID, first.ID}}, "user-1"))
+
+	detail, err := service.GetList(list.ID, "user-1")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{second.ID, first.ID}, listImageIDs(detail))
+	assert.Equal(t, "GPU only", detail.Items[0].Note)
+
+	// 命名列表不影响收藏数
+	resp, err := service.images.GetImageByID(context.Background(), first.ID, "user-1")
+	assert.NoError(t, err)
+	assert.Equal(t, 0, resp.Stars)
+}
+
+func TestListService_VisibilityAndFollow(t *testing.T) {
+	service, repos := setupListTest(t)
+	public := createTestImage(t, repos, "public", models.VisibilityPublic)
+	private := createTestImage(t, repos, "private", models.VisibilityPrivate)
+
+	list, err := service.CreateList(&CreateListRequest{Name: "Mine"}, "owner")
+	assert.NoError(t, err)
+	assert.NoError(t, service.AddItem(list.ID, &AddListItemRequest{ImageID: public.ID}, "owner"))
+
+	_, err = service.GetList(list.ID, "viewer")
+	assert.ErrorIs(t, err, ErrListNotFound)
+	assert.ErrorIs(t, service.Follow(list.ID, "viewer"), ErrListNotFound)
+
+	visibility := models.ListVisibilityPublic
+	_, err = service.UpdateList(list.ID, &UpdateListRequest{Visibility: visibility}, "owner")
+	assert.NoError(t, err)
+	_, err = service.UpdateList(list.ID, &UpdateListRequest{Description: &visibility}, "viewer")
+	assert.Error(t, err, "only the owner can edit a list")
+
+	// 列表公开后，其中的私有镜像依然对他人隐藏
+	_, err = repos.Lists.AddItem(&models.ListItem{ListID: list.ID, ImageID: private.ID})
+	assert.NoError(t, err)
+	detail, err := service.GetList(list.ID, "viewer")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{public.ID}, listImageIDs(detail))
+
+	assert.Error(t, service.Follow(list.ID, "owner"), "cannot follow your own list")
+	assert.NoError(t, service.Follow(list.ID, "viewer"))
+	following, err := service.ListFollowing("viewer")
+	assert.NoError(t, err)
+	if assert.Len(t, following, 1) {
+		assert.True(t, following[0].IsFollowing)
+		assert.Equal(t, int64(1), following[0].Followers)
+	}
+
+	assert.NoError(t, service.Unfollow(list.ID, "viewer"))
+	following, err = service.ListFollowing("viewer")
+	assert.NoError(t, err)
+	assert.Empty(t, following)
+}
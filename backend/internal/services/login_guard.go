@@ -0,0 +1,216 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+
+	"github.com/samzong/share-ai-platform/internal/cache"
+	"github.com/samzong/share-ai-platform/internal/models"
+)
+
+const (
+	maxBackoff      = 30 * time.Second // 单次失败后的最长等待时间
+	lockoutCountTTL = 24 * time.Hour   // 锁定次数的统计周期，用于计算翻倍的锁定时长
+)
+
+// LoginLockedError is returned when a login is rejected because of too many failures
+type LoginLockedError struct {
+	RetryAfter time.Duration
+}
+
+func (e *LoginLockedError) Error() string {
+	return fmt.Sprintf("too many failed login attempts, try again in %d seconds", int(e.RetryAfter.Seconds()+0.5))
+}
+
+// LoginGuard limits failed logins per username and per client IP.
+// Counters live in cache.Counters(): Redis so all replicas share them, falling back to
+// bounded per-process counters while Redis is unreachable instead of failing open.
+type LoginGuard struct {
+	store   cache.Cache   // 为空时使用 cache.Counters()
+	backoff time.Duration // 第二次失败后的等待时间，之后每次翻倍
+}
+
+var defaultLoginGuard = NewLoginGuard()
+
+// NewLoginGuard creates a LoginGuard on the shared counter cache
+func NewLoginGuard() *LoginGuard {
+	return &LoginGuard{backoff: time.Second}
+}
+
+// Check returns a LoginLockedError if the username or IP is locked out or backing off
+func (g *LoginGuard) Check(ctx context.Context, username, ip string) error {
+	var retryAfter time.Duration
+	for _, key := range []string{
+		"login_lock:user:" + normalizeUsername(username),
+		"login_lock:ip:" + ip,
+		"login_backoff:user:" + normalizeUsername(username),
+	} {
+		ttl, err := g.ttl(ctx, key)
+		if err != nil {
+			return err
+		}
+		if ttl > retryAfter {
+			retryAfter = ttl
+		}
+	}
+
+	if retryAfter > 0 {
+		return &LoginLockedError{RetryAfter: retryAfter}
+	}
+	return nil
+}
+
+// RecordFailure counts a failed login, applying exponential backoff and lockouts
+func (g *LoginGuard) RecordFailure(ctx context.Context, username, ip string) error {
+	username = normalizeUsername(username)
+	window := configDuration("login_guard.window", 15*time.Minute)
+
+	failures, err := g.incr(ctx, "login_fail:user:"+username, window)
+	if err != nil {
+		return err
+	}
+
+	ipFailures, err := g.incr(ctx, "login_fail:ip:"+ip, window)
+	if err != nil {
+		return err
+	}
+
+	maxAttempts := int64(configInt("login_guard.max_attempts", 5))
+	if failures >= maxAttempts {
+		if err := g.lock(ctx, "user", username, ip); err != nil {
+			return err
+		}
+	} else if failures > 1 && g.backoff > 0 {
+		// 第 n 次失败后需要等待 2^(n-2) 倍的基础时间才能再次尝试，达到上限后不再翻倍以免溢出
+		backoff := g.backoff
+		for i := int64(2); i < failures && backoff < maxBackoff; i++ {
+			backoff *= 2
+		}
+		if backoff > maxBackoff {
+			backoff = maxBackoff
+		}
+		if err := g.set(ctx, "login_backoff:user:"+username, backoff); err != nil {
+			return err
+		}
+	}
+
+	if ipFailures >= int64(configInt("login_guard.ip_max_attempts", 20)) {
+		if err := g.lock(ctx, "ip", ip, ip); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// RecordSuccess clears the username's failure counters after a successful login
+func (g *LoginGuard) RecordSuccess(ctx context.Context, username string) error {
+	username = normalizeUsername(username)
+	return g.del(ctx, "login_fail:user:"+username, "login_backoff:user:"+username)
+}
+
+// Unlock removes any lockout and failure history for a username
+func (g *LoginGuard) Unlock(ctx context.Context, username string) error {
+	username = normalizeUsername(username)
+	return g.del(ctx,
+		"login_lock:user:"+username,
+		"login_fail:user:"+username,
+		"login_backoff:user:"+username,
+		"login_lockouts:user:"+username,
+	)
+}
+
+// lock locks out a username or IP; each lockout within 24h doubles the duration
+func (g *LoginGuard) lock(ctx context.Context, kind, subject, ip string) error {
+	count, err := g.incr(ctx, "login_lockouts:"+kind+":"+subject, lockoutCountTTL)
+	if err != nil {
+		return err
+	}
+
+	duration := configDuration("login_guard.lockout", 5*time.Minute)
+	maxLockout := configDuration("login_guard.max_lockout", 24*time.Hour)
+	for i := int64(1); i < count && duration < maxLockout; i++ {
+		duration *= 2
+	}
+	if duration > maxLockout {
+		duration = maxLockout
+	}
+
+	if err := g.set(ctx, "login_lock:"+kind+":"+subject, duration); err != nil {
+		return err
+	}
+	if err := g.del(ctx, "login_fail:"+kind+":"+subject, "login_backoff:"+kind+":"+subject); err != nil {
+		return err
+	}
+
+	recordAudit(models.AuditLoginLockout, "", kind+":"+subject, ip,
+		fmt.Sprintf("locked for %s after repeated failed logins (lockout #%d)", duration, count))
+	return nil
+}
+
+// counters resolves the store lazily, since the shared cache is configured after startup
+func (g *LoginGuard) counters() cache.Cache {
+	if g.store != nil {
+		return g.store
+	}
+	return cache.Counters()
+}
+
+func (g *LoginGuard) incr(ctx context.Context, key string, ttl time.Duration) (int64, error) {
+	return g.counters().Incr(ctx, key, ttl)
+}
+
+// ttl returns how long a marker set by set has left to live
+func (g *LoginGuard) ttl(ctx context.Context, key string) (time.Duration, error) {
+	value, err := g.counters().Get(ctx, key)
+	if errors.Is(err, cache.ErrMiss) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	expiresAt, err := strconv.ParseInt(string(value), 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	if d := time.Until(time.UnixMilli(expiresAt)); d > 0 {
+		return d, nil
+	}
+	return 0, nil
+}
+
+// set stores a marker holding its own expiry time, so ttl works on any cache backend
+func (g *LoginGuard) set(ctx context.Context, key string, ttl time.Duration) error {
+	expiresAt := time.Now().Add(ttl).UnixMilli()
+	return g.counters().Set(ctx, key, []byte(strconv.FormatInt(expiresAt, 10)), ttl)
+}
+
+func (g *LoginGuard) del(ctx context.Context, keys ...string) error {
+	return g.counters().Delete(ctx, keys...)
+}
+
+func normalizeUsername(username string) string {
+	return strings.ToLower(strings.TrimSpace(username))
+}
+
+func configInt(key string, def int) int {
+	if v := viper.GetInt(key); v > 0 {
+		return v
+	}
+	return def
+}
+
+// configDuration reads a duration configured in seconds
+func configDuration(key string, def time.Duration) time.Duration {
+	if v := viper.GetInt(key); v > 0 {
+		return time.Duration(v) * time.Second
+	}
+	return def
+}
@@ -0,0 +1,198 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/samzong/share-ai-platform/internal/cache"
+	"github.com/samzong/share-ai-platform/internal/database"
+)
+
+func setupLoginGuard(t *testing.T, config map[string]int) *LoginGuard {
+	require.NoError(t, database.SetupTestDB())
+	t.Cleanup(database.TeardownTestDB)
+
+	for key, value := range config {
+		viper.Set(key, value)
+	}
+	t.Cleanup(func() {
+		for key := range config {
+			viper.Set(key, 0)
+		}
+	})
+
+	return &LoginGuard{store: cache.NewMemory(100)}
+}
+
+func recordFailures(t *testing.T, g *LoginGuard, n int, username, ip string) {
+	for i := 0; i < n; i++ {
+		require.NoError(t, g.RecordFailure(context.Background(), username, ip))
+	}
+}
+
+func TestLoginGuard(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   map[string]int
+		record   func(t *testing.T, g *LoginGuard)
+		username string
+		ip       string
+		locked   bool
+	}{
+		{
+			name: "below threshold",
+			record: func(t *testing.T, g *LoginGuard) {
+				recordFailures(t, g, 4, "alice", "10.0.0.1")
+			},
+			username: "alice",
+			ip:       "10.0.0.2",
+		},
+		{
+			name: "threshold locks the username from any IP",
+			record: func(t *testing.T, g *LoginGuard) {
+				recordFailures(t, g, 5, "alice", "10.0.0.1")
+			},
+			username: "alice",
+			ip:       "10.0.0.2",
+			locked:   true,
+		},
+		{
+			name: "username lockout does not affect other users",
+			record: func(t *testing.T, g *LoginGuard) {
+				recordFailures(t, g, 5, "alice", "10.0.0.1")
+			},
+			username: "bob",
+			ip:       "10.0.0.1",
+		},
+		{
+			name: "usernames are case-insensitive",
+			record: func(t *testing.T, g *LoginGuard) {
+				recordFailures(t, g, 3, "Alice", "10.0.0.1")
+				recordFailures(t, g, 2, " alice ", "10.0.0.1")
+			},
+			username: "ALICE",
+			ip:       "10.0.0.2",
+			locked:   true,
+		},
+		{
+			name:   "failures outside the window are forgotten",
+			config: map[string]int{"login_guard.window": 1},
+			record: func(t *testing.T, g *LoginGuard) {
+				recordFailures(t, g, 4, "alice", "10.0.0.1")
+				time.Sleep(1100 * time.Millisecond)
+				recordFailures(t, g, 1, "alice", "10.0.0.1")
+			},
+			username: "alice",
+			ip:       "10.0.0.2",
+		},
+		{
+			name: "success resets the count",
+			record: func(t *testing.T, g *LoginGuard) {
+				recordFailures(t, g, 4, "alice", "10.0.0.1")
+				require.NoError(t, g.RecordSuccess(context.Background(), "alice"))
+				recordFailures(t, g, 1, "alice", "10.0.0.1")
+			},
+			username: "alice",
+			ip:       "10.0.0.2",
+		},
+		{
+			name: "unlock lifts the lockout",
+			record: func(t *testing.T, g *LoginGuard) {
+				recordFailures(t, g, 5, "alice", "10.0.0.1")
+				require.NoError(t, g.Unlock(context.Background(), "alice"))
+			},
+			username: "alice",
+			ip:       "10.0.0.2",
+		},
+		{
+			name:   "IP threshold locks every username",
+			config: map[string]int{"login_guard.ip_max_attempts": 3},
+			record: func(t *testing.T, g *LoginGuard) {
+				for i := 0; i < 3; i++ {
+					recordFailures(t, g, 1, fmt.Sprintf("user%d", i), "10.0.0.1")
+				}
+			},
+			username: "carol",
+			ip:       "10.0.0.1",
+			locked:   true,
+		},
+		{
+			name:   "IP lockout does not affect other IPs",
+			config: map[string]int{"login_guard.ip_max_attempts": 3},
+			record: func(t *testing.T, g *LoginGuard) {
+				for i := 0; i < 3; i++ {
+					recordFailures(t, g, 1, fmt.Sprintf("user%d", i), "10.0.0.1")
+				}
+			},
+			username: "carol",
+			ip:       "10.0.0.2",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := setupLoginGuard(t, tt.config)
+			tt.record(t, g)
+
+			err := g.Check(context.Background(), tt.username, tt.ip)
+			if tt.locked {
+				var locked *LoginLockedError
+				require.True(t, errors.As(err, &locked), "expected lockout, got %v", err)
+				assert.Greater(t, locked.RetryAfter, time.Duration(0))
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestLoginGuard_RepeatedLockoutsDouble(t *testing.T) {
+	g := setupLoginGuard(t, map[string]int{"login_guard.lockout": 60})
+	ctx := context.Background()
+
+	retryAfter := func() time.Duration {
+		var locked *LoginLockedError
+		require.True(t, errors.As(g.Check(ctx, "alice", "10.0.0.1"), &locked))
+		return locked.RetryAfter
+	}
+
+	recordFailures(t, g, 5, "alice", "10.0.0.1")
+	assert.InDelta(t, time.Minute.Seconds(), retryAfter().Seconds(), 1)
+
+	// 锁定期间继续失败会再次锁定，时长翻倍
+	recordFailures(t, g, 5, "alice", "10.0.0.1")
+	assert.InDelta(t, (2 * time.Minute).Seconds(), retryAfter().Seconds(), 1)
+}
+
+func TestLoginGuard_Backoff(t *testing.T) {
+	g := setupLoginGuard(t, nil)
+	g.backoff = time.Second
+	ctx := context.Background()
+
+	recordFailures(t, g, 1, "alice", "10.0.0.1")
+	assert.NoError(t, g.Check(ctx, "alice", "10.0.0.1"))
+
+	recordFailures(t, g, 2, "alice", "10.0.0.1")
+	var locked *LoginLockedError
+	require.True(t, errors.As(g.Check(ctx, "alice", "10.0.0.1"), &locked))
+	assert.InDelta(t, (2 * time.Second).Seconds(), locked.RetryAfter.Seconds(), 0.5)
+}
+
+func TestLoginGuard_BackoffCappedWithManyAttempts(t *testing.T) {
+	g := setupLoginGuard(t, map[string]int{"login_guard.max_attempts": 100, "login_guard.ip_max_attempts": 1000})
+	g.backoff = time.Second
+	ctx := context.Background()
+
+	// 失败次数很多时等待时间保持在上限，不会因移位溢出而失去限制
+	recordFailures(t, g, 80, "alice", "10.0.0.1")
+	var locked *LoginLockedError
+	require.True(t, errors.As(g.Check(ctx, "alice", "10.0.0.1"), &locked))
+	assert.InDelta(t, maxBackoff.Seconds(), locked.RetryAfter.Seconds(), 0.5)
+}
@@ -0,0 +1,205 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+
+	"gorm.io/gorm"
+
+	"github.com/samzong/share-ai-platform/internal/authz"
+	"github.com/samzong/share-ai-platform/internal/database"
+	"github.com/samzong/share-ai-platform/internal/models"
+)
+
+type OrgService struct{}
+
+type CreateOrgRequest struct {
+	Name        string `json:"name" binding:"required,min=2,max=50"`
+	Description string `json:"description"`
+}
+
+type UpdateOrgPolicyRequest struct {
+	Require2FA *bool `json:"require_2fa" binding:"required"`
+}
+
+type AddOrgMemberRequest struct {
+	UserID string         `json:"user_id" binding:"required"`
+	Role   models.OrgRole `json:"role" binding:"required"`
+}
+
+type OrgMemberResponse struct {
+	UserID      string         `json:"user_id"`
+	Username    string         `json:"username"`
+	Role        models.OrgRole `json:"role"`
+	TOTPEnabled bool           `json:"totp_enabled"`
+}
+
+// NewOrgService creates a new OrgService
+func NewOrgService() *OrgService {
+	return &OrgService{}
+}
+
+// CreateOrg creates an organization and makes the creator its owner
+func (s *OrgService) CreateOrg(req *CreateOrgRequest, userID string) (*models.Organization, error) {
+	db := database.GetDB()
+
+	var existing models.Organization
+	if err := db.Where("name = ?", req.Name).First(&existing).Error; err == nil {
+		return nil, errors.New("organization name already exists")
+	}
+
+	org := &models.Organization{
+		Name:        req.Name,
+		Description: req.Description,
+		CreatedBy:   userID,
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(org).Error; err != nil {
+			return err
+		}
+		return tx.Create(&models.OrgMember{
+			OrgID:  org.ID,
+			UserID: userID,
+			Role:   models.OrgRoleOwner,
+		}).Error
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create organization: %v", err)
+	}
+
+	return org, nil
+}
+
+// GetOrg retrieves an organization by ID
+func (s *OrgService) GetOrg(orgID string) (*models.Organization, error) {
+	db := database.GetDB()
+
+	var org models.Organization
+	if err := db.First(&org, "id = ?", orgID).Error; err != nil {
+		return nil, errors.New("organization not found")
+	}
+	return &org, nil
+}
+
+// UpdatePolicy updates the organization's security policy
+func (s *OrgService) UpdatePolicy(orgID string, req *UpdateOrgPolicyRequest, userID string) (*models.Organization, error) {
+	db := database.GetDB()
+
+	org, err := s.GetOrg(orgID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := authz.Check(userID, models.PermOrgsManage, authz.Org(orgID)); err != nil {
+		return nil, err
+	}
+
+	// 开启策略前，操作者本人需要先启用两步验证，避免把自己锁在外面
+	if *req.Require2FA {
+		var user models.User
+		if err := db.First(&user, "id = ?", userID).Error; err != nil {
+			return nil, errors.New("user not found")
+		}
+		if !user.TOTPEnabled {
+			return nil, errors.New("enable two-factor authentication before requiring it for the organization")
+		}
+	}
+
+	if err := db.Model(org).Update("require_2fa", *req.Require2FA).Error; err != nil {
+		return nil, err
+	}
+	return org, nil
+}
+
+// ListMembers lists the members of an organization
+func (s *OrgService) ListMembers(orgID string, userID string) ([]OrgMemberResponse, error) {
+	db := database.GetDB()
+
+	if _, err := s.GetOrg(orgID); err != nil {
+		return nil, err
+	}
+
+	if err := authz.Check(userID, models.PermOrgMembersRead, authz.Org(orgID)); err != nil {
+		return nil, err
+	}
+
+	var members []OrgMemberResponse
+	err := db.Table("org_members").
+		Select("org_members.user_id, users.username, org_members.role, users.totp_enabled").
+		Joins("JOIN users ON users.id = org_members.user_id").
+		Where("org_members.org_id = ?", orgID).
+		Order("org_members.created_at ASC").
+		Scan(&members).Error
+	if err != nil {
+		return nil, err
+	}
+	return members, nil
+}
+
+// AddMember adds a user to the organization or changes their role
+func (s *OrgService) AddMember(orgID string, req *AddOrgMemberRequest, userID string) error {
+	if !models.IsValidOrgRole(req.Role) {
+		return errors.New("invalid role")
+	}
+
+	db := database.GetDB()
+
+	if _, err := s.GetOrg(orgID); err != nil {
+		return err
+	}
+
+	if err := authz.Check(userID, models.PermOrgMembersManage, authz.Org(orgID)); err != nil {
+		return err
+	}
+
+	var user models.User
+	if err := db.First(&user, "id = ?", req.UserID).Error; err != nil {
+		return errors.New("user not found")
+	}
+
+	member := models.OrgMember{OrgID: orgID, UserID: req.UserID}
+	return db.Where(member).Assign(models.OrgMember{Role: req.Role}).FirstOrCreate(&member).Error
+}
+
+// RemoveMember removes a user from the organization
+func (s *OrgService) RemoveMember(orgID string, memberID string, userID string) error {
+	db := database.GetDB()
+
+	if err := authz.Check(userID, models.PermOrgMembersManage, authz.Org(orgID)); err != nil {
+		return err
+	}
+
+	member, err := getOrgMember(db, orgID, memberID)
+	if err != nil {
+		return errors.New("member not found")
+	}
+
+	// 保证组织至少保留一个 owner
+	if member.Role == models.OrgRoleOwner {
+		var owners int64
+		if err := db.Model(&models.OrgMember{}).Where("org_id = ? AND role = ?", orgID, models.OrgRoleOwner).Count(&owners).Error; err != nil {
+			return err
+		}
+		if owners <= 1 {
+			return errors.New("cannot remove the last owner of the organization")
+		}
+	}
+
+	// 移出组织时一并移出组织内的用户组
+	return db.Transaction(func(tx *gorm.DB) error {
+		groups := tx.Model(&models.Group{}).Select("id").Where("org_id = ?", orgID)
+		if err := tx.Where("user_id = ? AND group_id IN (?)", memberID, groups).Delete(&models.GroupMember{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(member).Error
+	})
+}
+
+func getOrgMember(db *gorm.DB, orgID string, userID string) (*models.OrgMember, error) {
+	var member models.OrgMember
+	if err := db.First(&member, "org_id = ? AND user_id = ?", orgID, userID).Error; err != nil {
+		return nil, err
+	}
+	return &member, nil
+}
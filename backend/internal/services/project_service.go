@@ -0,0 +1,286 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"gorm.io/gorm"
+
+	"github.com/samzong/share-ai-platform/internal/authz"
+	"github.com/samzong/share-ai-platform/internal/database"
+	"github.com/samzong/share-ai-platform/internal/models"
+)
+
+type ProjectService struct{}
+
+type CreateProjectRequest struct {
+	Name        string `json:"name" binding:"required,min=2,max=50"`
+	Description string `json:"description"`
+	Visibility  string `json:"visibility" binding:"required,oneof=public private require_access"`
+}
+
+type UpdateProjectRequest struct {
+	Name        string  `json:"name" binding:"omitempty,min=2,max=50"`
+	Description *string `json:"description"`
+	Visibility  string  `json:"visibility" binding:"omitempty,oneof=public private require_access"`
+}
+
+type AddProjectMemberRequest struct {
+	UserID string             `json:"user_id" binding:"required"`
+	Role   models.ProjectRole `json:"role" binding:"required"`
+}
+
+type ProjectMemberResponse struct {
+	UserID   string             `json:"user_id"`
+	Username string             `json:"username"`
+	Role     models.ProjectRole `json:"role"`
+}
+
+// NewProjectService creates a new ProjectService
+func NewProjectService() *ProjectService {
+	return &ProjectService{}
+}
+
+// CreateProject creates a project in the org and makes the creator its maintainer
+func (s *ProjectService) CreateProject(orgID string, req *CreateProjectRequest, userID string) (*models.Project, error) {
+	db := database.GetDB()
+
+	var org models.Organization
+	if err := db.First(&org, "id = ?", orgID).Error; err != nil {
+		return nil, errors.New("organization not found")
+	}
+
+	if err := authz.Check(userID, models.PermProjectsCreate, authz.Org(orgID)); err != nil {
+		return nil, err
+	}
+
+	var count int64
+	if err := db.Model(&models.Project{}).Where("org_id = ? AND name = ?", orgID, req.Name).Count(&count).Error; err != nil {
+		return nil, err
+	}
+	if count > 0 {
+		return nil, errors.New("project name already exists in the organization")
+	}
+
+	project := &models.Project{
+		OrgID:       orgID,
+		Name:        req.Name,
+		Description: req.Description,
+		Visibility:  req.Visibility,
+		CreatedBy:   userID,
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(project).Error; err != nil {
+			return err
+		}
+		return tx.Create(&models.ProjectMember{
+			ProjectID: project.ID,
+			UserID:    userID,
+			Role:      models.ProjectRoleMaintainer,
+		}).Error
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create project: %v", err)
+	}
+
+	return project, nil
+}
+
+// ListProjects lists the projects of an org that the user can see.
+// Private projects are only listed for org readers and project members.
+func (s *ProjectService) ListProjects(orgID string, userID string) ([]models.Project, error) {
+	db := database.GetDB()
+
+	query := db.Where("org_id = ?", orgID)
+
+	viewer, err := newImageViewer(userID)
+	if err != nil {
+		return nil, err
+	}
+	if !viewer.allOrgs && !viewer.orgIDs[orgID] {
+		visible := db.Where("visibility <> ?", models.VisibilityPrivate)
+		if len(viewer.projectIDs) > 0 {
+			visible = visible.Or("id IN ?", mapKeys(viewer.projectIDs))
+		}
+		query = query.Where(visible)
+	}
+
+	var projects []models.Project
+	if err := query.Order("name ASC").Find(&projects).Error; err != nil {
+		return nil, err
+	}
+	return projects, nil
+}
+
+// GetProject retrieves a project of the org, hiding private projects from outsiders
+func (s *ProjectService) GetProject(orgID string, projectID string, userID string) (*models.Project, error) {
+	project, err := getProject(database.GetDB(), orgID, projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	if project.Visibility == models.VisibilityPrivate {
+		viewer, err := newImageViewer(userID)
+		if err != nil {
+			return nil, err
+		}
+		if !viewer.allOrgs && !viewer.orgIDs[orgID] && !viewer.projectIDs[project.ID] {
+			return nil, errors.New("project not found")
+		}
+	}
+	return project, nil
+}
+
+// UpdateProject updates a project's name, description or visibility
+func (s *ProjectService) UpdateProject(orgID string, projectID string, req *UpdateProjectRequest, userID string) (*models.Project, error) {
+	db := database.GetDB()
+
+	project, err := getProject(db, orgID, projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := authz.Check(userID, models.PermProjectsManage, authz.Project(project.ID)); err != nil {
+		return nil, err
+	}
+
+	if req.Name != "" && req.Name != project.Name {
+		var count int64
+		if err := db.Model(&models.Project{}).Where("org_id = ? AND name = ?", orgID, req.Name).Count(&count).Error; err != nil {
+			return nil, err
+		}
+		if count > 0 {
+			return nil, errors.New("project name already exists in the organization")
+		}
+		project.Name = req.Name
+	}
+	if req.Description != nil {
+		project.Description = *req.Description
+	}
+	if req.Visibility != "" {
+		project.Visibility = req.Visibility
+	}
+
+	if err := db.Save(project).Error; err != nil {
+		return nil, fmt.Errorf("failed to update project: %v", err)
+	}
+
+	// 项目可见性决定其中镜像的可见性
+	if req.Visibility != "" {
+		invalidateImageCache(context.Background())
+	}
+	return project, nil
+}
+
+// DeleteProject deletes an empty project and its memberships
+func (s *ProjectService) DeleteProject(orgID string, projectID string, userID string) error {
+	db := database.GetDB()
+
+	project, err := getProject(db, orgID, projectID)
+	if err != nil {
+		return err
+	}
+
+	if err := authz.Check(userID, models.PermProjectsManage, authz.Project(project.ID)); err != nil {
+		return err
+	}
+
+	var images int64
+	if err := db.Model(&models.Image{}).Where("project_id = ?", project.ID).Count(&images).Error; err != nil {
+		return err
+	}
+	if images > 0 {
+		return errors.New("project still contains images")
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("project_id = ?", project.ID).Delete(&models.ProjectMember{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("scope_type = ? AND scope_id = ?", models.ScopeProject, project.ID).Delete(&models.RoleBinding{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(project).Error
+	})
+}
+
+// ListMembers lists the members of a project
+func (s *ProjectService) ListMembers(orgID string, projectID string, userID string) ([]ProjectMemberResponse, error) {
+	db := database.GetDB()
+
+	project, err := s.GetProject(orgID, projectID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var members []ProjectMemberResponse
+	err = db.Table("project_members").
+		Select("project_members.user_id, users.username, project_members.role").
+		Joins("JOIN users ON users.id = project_members.user_id").
+		Where("project_members.project_id = ?", project.ID).
+		Order("project_members.created_at ASC").
+		Scan(&members).Error
+	if err != nil {
+		return nil, err
+	}
+	return members, nil
+}
+
+// AddMember adds a user to the project or changes their role
+func (s *ProjectService) AddMember(orgID string, projectID string, req *AddProjectMemberRequest, userID string) error {
+	if !models.IsValidProjectRole(req.Role) {
+		return errors.New("invalid role")
+	}
+
+	db := database.GetDB()
+
+	project, err := getProject(db, orgID, projectID)
+	if err != nil {
+		return err
+	}
+
+	if err := authz.Check(userID, models.PermProjectMembersManage, authz.Project(project.ID)); err != nil {
+		return err
+	}
+
+	var user models.User
+	if err := db.First(&user, "id = ?", req.UserID).Error; err != nil {
+		return errors.New("user not found")
+	}
+
+	member := models.ProjectMember{ProjectID: project.ID, UserID: req.UserID}
+	return db.Where(member).Assign(models.ProjectMember{Role: req.Role}).FirstOrCreate(&member).Error
+}
+
+// RemoveMember removes a user from the project
+func (s *ProjectService) RemoveMember(orgID string, projectID string, memberID string, userID string) error {
+	db := database.GetDB()
+
+	project, err := getProject(db, orgID, projectID)
+	if err != nil {
+		return err
+	}
+
+	if err := authz.Check(userID, models.PermProjectMembersManage, authz.Project(project.ID)); err != nil {
+		return err
+	}
+
+	result := db.Delete(&models.ProjectMember{}, "project_id = ? AND user_id = ?", project.ID, memberID)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("member not found")
+	}
+	return nil
+}
+
+func getProject(db *gorm.DB, orgID string, projectID string) (*models.Project, error) {
+	var project models.Project
+	if err := db.First(&project, "id = ? AND org_id = ?", projectID, orgID).Error; err != nil {
+		return nil, errors.New("project not found")
+	}
+	return &project, nil
+}
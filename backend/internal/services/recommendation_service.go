@@ -0,0 +1,342 @@
+package services
+
+import (
+	"context"
+	"log"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/samzong/share-ai-platform/internal/database"
+	"github.com/samzong/share-ai-platform/internal/models"
+	"github.com/samzong/share-ai-platform/internal/repository"
+)
+
+// 相似度各信号的权重，之和为 1
+const (
+	similarityStarWeight     = 0.4  // 被同一批用户收藏
+	similarityLabelWeight    = 0.25 // 标签重合
+	similarityCategoryWeight = 0.15 // 分类重合
+	similarityFamilyWeight   = 0.2  // 同一镜像仓库的不同版本
+)
+
+const (
+	// similarPerImage is how many similar images are stored per image. It is well above a
+	// page so that a page can still be filled after hiding images the viewer cannot see.
+	similarPerImage = 50
+	// maxSimilarityFanout skips users with more stars, and labels, categories and
+	// repositories with more images, when pairing images. Such broad signals say little
+	// about similarity and the number of pairs grows with the square of their size.
+	maxSimilarityFanout = 200
+	// recommendationSeeds is how many of the user's starred images recommendations start from
+	recommendationSeeds = 50
+
+	// recommendationPoolSize is how many of the best candidates are checked for visibility
+	recommendationPoolSize = 4 * similarPerImage
+
+	defaultSimilarLimit = 10
+	similarityLoadBatch = 500
+)
+
+type RecommendationService struct {
+	images       repository.ImageRepo
+	collections  repository.CollectionRepo
+	similarities repository.SimilarityRepo
+	imageService *ImageService
+	authorizer   Authorizer
+}
+
+type SimilarImagesRequest struct {
+	Limit int `form:"limit" binding:"omitempty,min=1,max=50"` // 返回数量，默认 10
+}
+
+type SimilarImageResponse struct {
+	ImageResponse
+	Score float64 `json:"score"` // 相似度得分，个性化推荐中为与各收藏镜像的得分之和
+}
+
+// imagePair is an unordered pair of images, the smaller ID first
+type imagePair struct{ a, b string }
+
+func newImagePair(a string, b string) imagePair {
+	if a > b {
+		a, b = b, a
+	}
+	return imagePair{a, b}
+}
+
+// pairSignals counts what a pair of images has in common
+type pairSignals struct {
+	stars      int
+	labels     int
+	categories int
+	family     bool
+}
+
+// NewRecommendationService creates a new RecommendationService backed by the database
+func NewRecommendationService() *RecommendationService {
+	return NewRecommendationServiceWith(repository.NewGorm(database.GetDB()), defaultAuthorizer, defaultEventRecorder())
+}
+
+// NewRecommendationServiceWith creates a RecommendationService on the given repositories and authorizer.
+// events is passed to the ImageService it uses to build image responses.
+func NewRecommendationServiceWith(repos *repository.Repositories, az Authorizer, events *EventRecorder) *RecommendationService {
+	return &RecommendationService{
+		images:       repos.Images,
+		collections:  repos.Collections,
+		similarities: repos.Similarities,
+		imageService: NewImageServiceWith(repos, az, events),
+		authorizer:   az,
+	}
+}
+
+// StartSimilarityRebuild recomputes the similar images every interval in the background
+func StartSimilarityRebuild(interval time.Duration) {
+	recommendations := NewRecommendationService()
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			if _, err := recommendations.Rebuild(); err != nil {
+				log.Printf("Error rebuilding similar images: %v", err)
+			}
+			<-ticker.C
+		}
+	}()
+}
+
+// Rebuild recomputes the similar images of every image from co-stars, shared labels and
+// categories and repository families, replacing the stored ones. It returns the number
+// of similarities stored.
+func (s *RecommendationService) Rebuild() (int, error) {
+	var images []models.Image
+	for offset := 0; ; offset += similarityLoadBatch {
+		page, _, err := s.images.List(repository.ImageFilter{
+			Scope:  repository.ImageScope{All: true},
+			Offset: offset,
+			Limit:  similarityLoadBatch,
+		})
+		if err != nil {
+			return 0, err
+		}
+		images = append(images, page...)
+		if len(page) < similarityLoadBatch {
+			break
+		}
+	}
+	collections, err := s.collections.CreatedSince(time.Time{})
+	if err != nil {
+		return 0, err
+	}
+
+	pairs := make(map[imagePair]*pairSignals)
+	signals := func(pair imagePair) *pairSignals {
+		p, ok := pairs[pair]
+		if !ok {
+			p = &pairSignals{}
+			pairs[pair] = p
+		}
+		return p
+	}
+	// forPairs calls fn for every pair within each group small enough to be meaningful
+	forPairs := func(groups map[string][]string, fn func(*pairSignals)) {
+		for _, ids := range groups {
+			if len(ids) > maxSimilarityFanout {
+				continue
+			}
+			for i := range ids {
+				for j := i + 1; j < len(ids); j++ {
+					fn(signals(newImagePair(ids[i], ids[j])))
+				}
+			}
+		}
+	}
+
+	known := make(map[string]bool, len(images))
+	labelCount := make(map[string]int, len(images))
+	categoryCount := make(map[string]int, len(images))
+	byLabel := make(map[string][]string)
+	byCategory := make(map[string][]string)
+	byFamily := make(map[string][]string)
+	for _, image := range images {
+		known[image.ID] = true
+		labelCount[image.ID] = len(image.Labels)
+		categoryCount[image.ID] = len(image.Categories)
+		for _, label := range image.Labels {
+			byLabel[label.ID] = append(byLabel[label.ID], image.ID)
+		}
+		for _, category := range image.Categories {
+			byCategory[category.ID] = append(byCategory[category.ID], image.ID)
+		}
+		family := strings.ToLower(image.Registry + "/" + image.Namespace + "/" + image.Repository)
+		byFamily[family] = append(byFamily[family], image.ID)
+	}
+
+	starCount := make(map[string]int)
+	byUser := make(map[string][]string)
+	for _, c := range collections {
+		if known[c.ImageID] {
+			starCount[c.ImageID]++
+			byUser[c.UserID] = append(byUser[c.UserID], c.ImageID)
+		}
+	}
+
+	forPairs(byUser, func(p *pairSignals) { p.stars++ })
+	forPairs(byLabel, func(p *pairSignals) { p.labels++ })
+	forPairs(byCategory, func(p *pairSignals) { p.categories++ })
+	forPairs(byFamily, func(p *pairSignals) { p.family = true })
+
+	similar := make(map[string][]models.ImageSimilarity)
+	for pair, p := range pairs {
+		score := similarityStarWeight*cosine(p.stars, starCount[pair.a], starCount[pair.b]) +
+			similarityLabelWeight*jaccard(p.labels, labelCount[pair.a], labelCount[pair.b]) +
+			similarityCategoryWeight*jaccard(p.categories, categoryCount[pair.a], categoryCount[pair.b])
+		if p.family {
+			score += similarityFamilyWeight
+		}
+		if score <= 0 {
+			continue
+		}
+		similar[pair.a] = append(similar[pair.a], models.ImageSimilarity{ImageID: pair.a, SimilarID: pair.b, Score: score})
+		similar[pair.b] = append(similar[pair.b], models.ImageSimilarity{ImageID: pair.b, SimilarID: pair.a, Score: score})
+	}
+
+	var rows []models.ImageSimilarity
+	for _, list := range similar {
+		sort.Slice(list, func(i, j int) bool {
+			if list[i].Score != list[j].Score {
+				return list[i].Score > list[j].Score
+			}
+			return list[i].SimilarID < list[j].SimilarID
+		})
+		if len(list) > similarPerImage {
+			list = list[:similarPerImage]
+		}
+		rows = append(rows, list...)
+	}
+	if err := s.similarities.Replace(rows); err != nil {
+		return 0, err
+	}
+	return len(rows), nil
+}
+
+// SimilarImages returns the images most similar to an image the user can see
+func (s *RecommendationService) SimilarImages(ctx context.Context, imageID string, req *SimilarImagesRequest, userID string) ([]SimilarImageResponse, error) {
+	similar, err := s.similarities.Similar([]string{imageID})
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]string, 0, len(similar)+1)
+	ids = append(ids, imageID)
+	for _, sim := range similar {
+		ids = append(ids, sim.SimilarID)
+	}
+	visible, err := s.imageService.visibleImages(ids, userID)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := visible[imageID]; !ok {
+		return nil, ErrImageNotFound
+	}
+
+	limit := req.Limit
+	if limit <= 0 {
+		limit = defaultSimilarLimit
+	}
+	response := make([]SimilarImageResponse, 0, limit)
+	for _, sim := range similar {
+		image, ok := visible[sim.SimilarID]
+		if !ok {
+			continue
+		}
+		response = append(response, SimilarImageResponse{ImageResponse: image, Score: sim.Score})
+		if len(response) == limit {
+			break
+		}
+	}
+	return response, nil
+}
+
+// Recommendations returns images similar to the ones the user starred that the user has
+// not starred yet, best match first
+func (s *RecommendationService) Recommendations(ctx context.Context, req *SimilarImagesRequest, userID string) ([]SimilarImageResponse, error) {
+	viewer, err := resolveImageViewer(s.authorizer, userID)
+	if err != nil {
+		return nil, err
+	}
+	starred, _, err := s.images.List(repository.ImageFilter{
+		Scope:     viewer.listScope(),
+		StarredBy: userID,
+		Limit:     recommendationSeeds,
+	})
+	if err != nil {
+		return nil, err
+	}
+	seeds := make([]string, len(starred))
+	for i, image := range starred {
+		seeds[i] = image.ID
+	}
+	similar, err := s.similarities.Similar(seeds)
+	if err != nil {
+		return nil, err
+	}
+
+	scores := make(map[string]float64)
+	for _, sim := range similar {
+		scores[sim.SimilarID] += sim.Score
+	}
+	ranked := make([]string, 0, len(scores))
+	for id := range scores {
+		ranked = append(ranked, id)
+	}
+	sort.Slice(ranked, func(i, j int) bool {
+		if scores[ranked[i]] != scores[ranked[j]] {
+			return scores[ranked[i]] > scores[ranked[j]]
+		}
+		return ranked[i] < ranked[j]
+	})
+	if len(ranked) > recommendationPoolSize {
+		ranked = ranked[:recommendationPoolSize]
+	}
+
+	visible, err := s.imageService.visibleImages(ranked, userID)
+	if err != nil {
+		return nil, err
+	}
+	limit := req.Limit
+	if limit <= 0 {
+		limit = defaultSimilarLimit
+	}
+	response := make([]SimilarImageResponse, 0, limit)
+	for _, id := range ranked {
+		// 已收藏的镜像不再推荐，包括超出参考范围的较早的收藏
+		image, ok := visible[id]
+		if !ok || image.IsStarred {
+			continue
+		}
+		response = append(response, SimilarImageResponse{ImageResponse: image, Score: scores[id]})
+		if len(response) == limit {
+			break
+		}
+	}
+	return response, nil
+}
+
+// cosine returns how much two sets of the given sizes sharing shared members overlap,
+// between 0 and 1
+func cosine(shared int, a int, b int) float64 {
+	if shared == 0 {
+		return 0
+	}
+	return float64(shared) / math.Sqrt(float64(a)*float64(b))
+}
+
+// jaccard returns the size of the intersection of two sets over the size of their union
+func jaccard(shared int, a int, b int) float64 {
+	if shared == 0 {
+		return 0
+	}
+	return float64(shared) / float64(a+b-shared)
+}
@@ -0,0 +1,87 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/samzong/share-ai-platform/internal/models"
+	"github.com/samzong/share-ai-platform/internal/repository"
+)
+
+func TestRecommendationService(t *testing.T) {
+	repos := repository.NewMemory()
+	az := newFakeAuthorizer()
+	az.admins["admin"] = true
+	service := NewRecommendationServiceWith(&repos.Repositories, az, NewEventRecorder(repos.Stats))
+	ctx := context.Background()
+
+	newImage := func(name string, repo string, visibility string, labels ...string) *models.Image {
+		image := &models.Image{OrgID: "org-1", Author: "author-1", Name: name, Registry: "docker.io",
+			Repository: repo, Tag: name, Visibility: visibility}
+		require.NoError(t, repos.Images.Create(image))
+		found, err := repos.Labels.FindOrCreate(labels)
+		require.NoError(t, err)
+		require.NoError(t, repos.Images.Update(image, found, nil))
+		return image
+	}
+	base := newImage("base", "pytorch", models.VisibilityPublic, "gpu", "llm")
+	newImage("sibling", "PyTorch", models.VisibilityPublic)
+	costarred := newImage("costarred", "vllm", models.VisibilityPublic)
+	labeled := newImage("labeled", "tgi", models.VisibilityPublic, "LLM", "gpu")
+	private := newImage("private", "secret", models.VisibilityPrivate)
+	newImage("unrelated", "nginx", models.VisibilityPublic, "web")
+
+	star := func(userID string, images ...*models.Image) {
+		for _, image := range images {
+			_, err := repos.Collections.Add(&models.Collection{UserID: userID, ImageID: image.ID})
+			require.NoError(t, err)
+		}
+	}
+	star("u1", base, costarred, private)
+	star("u2", base, costarred)
+	star("u3", labeled)
+
+	stored, err := service.Rebuild()
+	require.NoError(t, err)
+	assert.Positive(t, stored)
+
+	names := func(images []SimilarImageResponse) []string {
+		var names []string
+		for _, image := range images {
+			names = append(names, image.Name)
+		}
+		return names
+	}
+
+	similar, err := service.SimilarImages(ctx, base.ID, &SimilarImagesRequest{}, "")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"costarred", "labeled", "sibling"}, names(similar))
+	assert.InDelta(t, similarityStarWeight, similar[0].Score, 1e-9)
+
+	similar, err = service.SimilarImages(ctx, base.ID, &SimilarImagesRequest{Limit: 2}, "admin")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"costarred", "private"}, names(similar))
+
+	_, err = service.SimilarImages(ctx, private.ID, &SimilarImagesRequest{}, "")
+	assert.ErrorIs(t, err, ErrImageNotFound)
+
+	recommended, err := service.Recommendations(ctx, &SimilarImagesRequest{}, "u2")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"labeled", "sibling"}, names(recommended), "starred and hidden images must not be recommended")
+
+	recommended, err = service.Recommendations(ctx, &SimilarImagesRequest{}, "u3")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"base"}, names(recommended))
+
+	recommended, err = service.Recommendations(ctx, &SimilarImagesRequest{}, "nobody")
+	require.NoError(t, err)
+	assert.Empty(t, recommended)
+
+	require.NoError(t, repos.Images.Delete(costarred))
+	similar, err = service.SimilarImages(ctx, base.ID, &SimilarImagesRequest{}, "")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"labeled", "sibling"}, names(similar))
+}
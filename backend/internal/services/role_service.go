@@ -0,0 +1,257 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"gorm.io/gorm"
+
+	"github.com/samzong/share-ai-platform/internal/authz"
+	"github.com/samzong/share-ai-platform/internal/database"
+	"github.com/samzong/share-ai-platform/internal/models"
+)
+
+type RoleService struct{}
+
+type RoleResponse struct {
+	Name        models.Role         `json:"name"`
+	Description string              `json:"description"`
+	Permissions []models.Permission `json:"permissions"`
+	BuiltIn     bool                `json:"built_in"` // 内置角色不可修改或删除
+}
+
+type CreateRoleRequest struct {
+	Name        models.Role         `json:"name" binding:"required,min=2,max=50"`
+	Description string              `json:"description"`
+	Permissions []models.Permission `json:"permissions" binding:"required,min=1"`
+}
+
+type CreateRoleBindingRequest struct {
+	UserID  string      `json:"user_id" binding:"required_without=GroupID"`
+	GroupID string      `json:"group_id" binding:"required_without=UserID"`
+	Role    models.Role `json:"role" binding:"required"`
+}
+
+type PermissionsResponse struct {
+	Scope       authz.Scope         `json:"scope"`
+	Permissions []models.Permission `json:"permissions"`
+}
+
+// NewRoleService creates a new RoleService
+func NewRoleService() *RoleService {
+	return &RoleService{}
+}
+
+// ListRoles returns the built-in roles followed by custom roles
+func (s *RoleService) ListRoles() ([]RoleResponse, error) {
+	roles := make([]RoleResponse, 0, len(models.BuiltinRoles))
+	for name, perms := range models.BuiltinRoles {
+		roles = append(roles, RoleResponse{Name: name, Permissions: perms, BuiltIn: true})
+	}
+	sort.Slice(roles, func(i, j int) bool { return roles[i].Name < roles[j].Name })
+
+	var custom []models.RoleDefinition
+	if err := database.GetDB().Order("name ASC").Find(&custom).Error; err != nil {
+		return nil, err
+	}
+	for _, def := range custom {
+		roles = append(roles, RoleResponse{
+			Name:        def.Name,
+			Description: def.Description,
+			Permissions: def.Permissions,
+		})
+	}
+
+	return roles, nil
+}
+
+// CreateRole defines a custom role as a bundle of permissions
+func (s *RoleService) CreateRole(req *CreateRoleRequest, userID string) (*RoleResponse, error) {
+	if err := authz.Check(userID, models.PermRolesManage, authz.Global()); err != nil {
+		return nil, err
+	}
+
+	exists, err := authz.RoleExists(req.Name)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return nil, errors.New("role already exists")
+	}
+
+	for _, perm := range req.Permissions {
+		if !models.IsValidPermission(perm) {
+			return nil, fmt.Errorf("unknown permission: %s", perm)
+		}
+	}
+
+	def := &models.RoleDefinition{
+		Name:        req.Name,
+		Description: req.Description,
+		Permissions: req.Permissions,
+	}
+	if err := database.GetDB().Create(def).Error; err != nil {
+		return nil, fmt.Errorf("failed to create role: %v", err)
+	}
+
+	return &RoleResponse{Name: def.Name, Description: def.Description, Permissions: def.Permissions}, nil
+}
+
+// DeleteRole deletes a custom role together with its bindings.
+// Users holding it as their global role fall back to the default user role.
+func (s *RoleService) DeleteRole(name models.Role, userID string) error {
+	if err := authz.Check(userID, models.PermRolesManage, authz.Global()); err != nil {
+		return err
+	}
+
+	if _, ok := models.BuiltinRoles[name]; ok {
+		return errors.New("built-in roles cannot be deleted")
+	}
+
+	return database.GetDB().Transaction(func(tx *gorm.DB) error {
+		result := tx.Delete(&models.RoleDefinition{}, "name = ?", name)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errors.New("role not found")
+		}
+
+		if err := tx.Where("role = ?", name).Delete(&models.RoleBinding{}).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.User{}).Where("role = ?", name).Update("role", models.RoleUser).Error
+	})
+}
+
+// ListBindings lists the explicit role bindings in a scope
+func (s *RoleService) ListBindings(scope authz.Scope, userID string) ([]models.RoleBinding, error) {
+	if err := authz.Check(userID, models.PermRolesRead, scope); err != nil {
+		return nil, err
+	}
+
+	var bindings []models.RoleBinding
+	err := database.GetDB().
+		Where("scope_type = ? AND scope_id = ?", scope.Type, scope.ID).
+		Order("created_at ASC").
+		Find(&bindings).Error
+	if err != nil {
+		return nil, err
+	}
+	return bindings, nil
+}
+
+// CreateBinding grants a role to a user or group in a scope. The granter must hold
+// every permission of the role in that scope. Groups can only be bound in their own
+// org or its projects.
+func (s *RoleService) CreateBinding(scope authz.Scope, req *CreateRoleBindingRequest, granterID string) (*models.RoleBinding, error) {
+	db := database.GetDB()
+
+	if req.UserID != "" && req.GroupID != "" {
+		return nil, errors.New("bind the role to either a user or a group")
+	}
+
+	orgID := ""
+	switch scope.Type {
+	case models.ScopeOrg:
+		var org models.Organization
+		if err := db.First(&org, "id = ?", scope.ID).Error; err != nil {
+			return nil, errors.New("organization not found")
+		}
+		orgID = org.ID
+	case models.ScopeProject:
+		var project models.Project
+		if err := db.First(&project, "id = ?", scope.ID).Error; err != nil {
+			return nil, errors.New("project not found")
+		}
+		orgID = project.OrgID
+	}
+
+	exists, err := authz.RoleExists(req.Role)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, errors.New("invalid role")
+	}
+
+	canGrant, err := authz.CanGrant(granterID, req.Role, scope)
+	if err != nil {
+		return nil, err
+	}
+	if !canGrant {
+		return nil, fmt.Errorf("%w: cannot grant role %s", authz.ErrForbidden, req.Role)
+	}
+
+	if req.GroupID != "" {
+		if orgID == "" {
+			return nil, errors.New("groups can only be bound in an organization or project scope")
+		}
+		if _, err := getGroup(db, orgID, req.GroupID); err != nil {
+			return nil, err
+		}
+	} else {
+		var user models.User
+		if err := db.First(&user, "id = ?", req.UserID).Error; err != nil {
+			return nil, errors.New("user not found")
+		}
+	}
+
+	binding := models.RoleBinding{
+		UserID:    req.UserID,
+		GroupID:   req.GroupID,
+		Role:      req.Role,
+		ScopeType: scope.Type,
+		ScopeID:   scope.ID,
+	}
+	err = db.Where(&binding, "user_id", "group_id", "role", "scope_type", "scope_id").
+		Attrs(models.RoleBinding{CreatedBy: granterID}).
+		FirstOrCreate(&binding).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to create role binding: %v", err)
+	}
+
+	return &binding, nil
+}
+
+// DeleteBinding revokes a role binding in a scope
+func (s *RoleService) DeleteBinding(scope authz.Scope, bindingID string, userID string) error {
+	db := database.GetDB()
+
+	var binding models.RoleBinding
+	if err := db.First(&binding, "id = ? AND scope_type = ? AND scope_id = ?", bindingID, scope.Type, scope.ID).Error; err != nil {
+		return errors.New("role binding not found")
+	}
+
+	canGrant, err := authz.CanGrant(userID, binding.Role, scope)
+	if err != nil {
+		return err
+	}
+	if !canGrant {
+		return fmt.Errorf("%w: cannot revoke role %s", authz.ErrForbidden, binding.Role)
+	}
+
+	return db.Delete(&binding).Error
+}
+
+// MyPermissions returns the permissions the user holds in a scope
+func (s *RoleService) MyPermissions(scope authz.Scope, userID string) (*PermissionsResponse, error) {
+	perms, err := authz.Permissions(userID, scope)
+	if err != nil {
+		return nil, err
+	}
+
+	// 去重，保持稳定顺序
+	seen := make(map[models.Permission]bool)
+	unique := make([]models.Permission, 0, len(perms))
+	for _, perm := range perms {
+		if !seen[perm] {
+			seen[perm] = true
+			unique = append(unique, perm)
+		}
+	}
+	sort.Slice(unique, func(i, j int) bool { return unique[i] < unique[j] })
+
+	return &PermissionsResponse{Scope: scope, Permissions: unique}, nil
+}
@@ -0,0 +1,89 @@
+package services
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/samzong/share-ai-platform/internal/database"
+	"github.com/samzong/share-ai-platform/internal/models"
+)
+
+type StarReconcileService struct{}
+
+// StarDrift is an image whose stored star count differs from its number of collections
+type StarDrift struct {
+	ImageID string `json:"image_id"` // 镜像ID
+	Name    string `json:"name"`     // 镜像名称
+	Stars   int    `json:"stars"`    // images.stars 中记录的收藏数
+	Actual  int    `json:"actual"`   // collections 表中的实际收藏数
+}
+
+// StarReconcileReport summarizes a star reconciliation run
+type StarReconcileReport struct {
+	DryRun  bool        `json:"dry_run"` // 仅报告，不修正
+	Scanned int64       `json:"scanned"` // 检查的镜像数
+	Drifted []StarDrift `json:"drifted"` // 收藏数与实际不一致的镜像
+}
+
+// NewStarReconcileService creates a StarReconcileService
+func NewStarReconcileService() *StarReconcileService {
+	return &StarReconcileService{}
+}
+
+// StartStarReconcile runs the star reconciliation every interval in the background
+func StartStarReconcile(interval time.Duration) {
+	reconciler := NewStarReconcileService()
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			report, err := reconciler.Run(context.Background(), false)
+			if err != nil {
+				log.Printf("Error reconciling stars: %v", err)
+				continue
+			}
+			for _, d := range report.Drifted {
+				log.Printf("Star count of image %s (%s) drifted: stored %d, actual %d", d.ImageID, d.Name, d.Stars, d.Actual)
+			}
+		}
+	}()
+}
+
+// Run recomputes the star count of every image from the collections table and reports
+// the images whose stored count drifted. Unless dryRun is set the drifted counts are
+// corrected.
+func (s *StarReconcileService) Run(ctx context.Context, dryRun bool) (*StarReconcileReport, error) {
+	db := database.GetDB().WithContext(ctx)
+
+	report := &StarReconcileReport{DryRun: dryRun}
+	if err := db.Model(&models.Image{}).Count(&report.Scanned).Error; err != nil {
+		return nil, err
+	}
+
+	err := db.Table("images").
+		Select("images.id AS image_id, images.name, images.stars, COUNT(collections.image_id) AS actual").
+		Joins("LEFT JOIN collections ON collections.image_id = images.id").
+		Group("images.id, images.name, images.stars").
+		Having("images.stars <> COUNT(collections.image_id)").
+		Order("images.name").
+		Scan(&report.Drifted).Error
+	if err != nil {
+		return nil, err
+	}
+	if dryRun || len(report.Drifted) == 0 {
+		return report, nil
+	}
+
+	ids := make([]string, len(report.Drifted))
+	for i, d := range report.Drifted {
+		ids[i] = d.ImageID
+	}
+	// 以更新时的收藏数为准，而不是上面查询到的值，避免覆盖期间发生的收藏
+	counts := db.Model(&models.Collection{}).Select("COUNT(*)").Where("collections.image_id = images.id")
+	if err := db.Model(&models.Image{}).Where("id IN ?", ids).UpdateColumn("stars", counts).Error; err != nil {
+		return report, err
+	}
+	invalidateImageCache(ctx)
+	return report, nil
+}
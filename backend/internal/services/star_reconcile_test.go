@@ -0,0 +1,54 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/samzong/share-ai-platform/internal/database"
+	"github.com/samzong/share-ai-platform/internal/models"
+)
+
+func TestStarReconcile(t *testing.T) {
+	require.NoError(t, database.SetupTestDB())
+	defer database.TeardownTestDB()
+	db := database.GetDB()
+
+	newImage := func(name string, stars int, starredBy ...string) *models.Image {
+		image := &models.Image{OrgID: "org", Name: name, Author: "author", Registry: "docker.io",
+			Namespace: "library", Repository: name, Tag: "latest", Digest: "sha256:" + name,
+			Platform: "linux/amd64", Stars: stars}
+		require.NoError(t, db.Create(image).Error)
+		for _, userID := range starredBy {
+			require.NoError(t, db.Create(&models.Collection{UserID: userID, ImageID: image.ID}).Error)
+		}
+		return image
+	}
+	consistent := newImage("consistent", 1, "u1")
+	drifted := newImage("drifted", 5, "u1", "u2")
+
+	service := NewStarReconcileService()
+	report, err := service.Run(context.Background(), true)
+	require.NoError(t, err)
+	assert.EqualValues(t, 2, report.Scanned)
+	require.Len(t, report.Drifted, 1)
+	assert.Equal(t, StarDrift{ImageID: drifted.ID, Name: "drifted", Stars: 5, Actual: 2}, report.Drifted[0])
+
+	stars := func(image *models.Image) int {
+		var stored models.Image
+		require.NoError(t, db.First(&stored, "id = ?", image.ID).Error)
+		return stored.Stars
+	}
+	assert.Equal(t, 5, stars(drifted), "dry run must not change counts")
+
+	_, err = service.Run(context.Background(), false)
+	require.NoError(t, err)
+	assert.Equal(t, 2, stars(drifted))
+	assert.Equal(t, 1, stars(consistent))
+
+	report, err = service.Run(context.Background(), true)
+	require.NoError(t, err)
+	assert.Empty(t, report.Drifted)
+}
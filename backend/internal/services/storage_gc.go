@@ -0,0 +1,267 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+	"gorm.io/gorm"
+
+	"github.com/samzong/share-ai-platform/internal/database"
+	"github.com/samzong/share-ai-platform/internal/models"
+	"github.com/samzong/share-ai-platform/internal/repository"
+	"github.com/samzong/share-ai-platform/internal/storage"
+	"github.com/samzong/share-ai-platform/internal/utils"
+)
+
+// defaultGCGracePeriod 新上传的文件在此时间内不会被清理，避免误删尚未写入引用的上传
+const defaultGCGracePeriod = time.Hour
+
+// gcLockID 是存储清理使用的 Postgres advisory lock 键，多个副本同时清理时只有一个执行
+const gcLockID int64 = 0x5348415245474331 // "SHAREGC1"
+
+// ErrStorageGCRunning is returned when another process holds the storage GC lock
+var ErrStorageGCRunning = errors.New("storage GC is already running")
+
+type StorageGCService struct {
+	gracePeriod time.Duration
+}
+
+// MissingObject is a row that references a file which no longer exists in storage
+type MissingObject struct {
+	Table  string `json:"table"`  // 引用所在的表
+	ID     string `json:"id"`     // 引用行的 ID
+	Column string `json:"column"` // 引用列
+	Key    string `json:"key"`    // 缺失的存储对象键
+}
+
+// StorageGCReport summarizes a storage GC run
+type StorageGCReport struct {
+	DryRun   bool            `json:"dry_run"`  // 仅报告，不删除
+	Scanned  int             `json:"scanned"`  // 存储中的对象数
+	Orphaned []string        `json:"orphaned"` // 没有任何引用的对象
+	Missing  []MissingObject `json:"missing"`  // 指向不存在对象的引用
+	Stale    []string        `json:"stale"`    // 对象已不存在的跟踪记录
+}
+
+// NewStorageGCService creates a StorageGCService using storage.gc_grace_period
+func NewStorageGCService() *StorageGCService {
+	grace := defaultGCGracePeriod
+	if seconds := viper.GetInt("storage.gc_grace_period"); seconds > 0 {
+		grace = time.Duration(seconds) * time.Second
+	}
+	return &StorageGCService{gracePeriod: grace}
+}
+
+// StartStorageGC runs the storage GC every interval in the background. It may be enabled
+// on every replica: runs hold a database lock, and a replica that finds it taken skips its run.
+func StartStorageGC(interval time.Duration) {
+	gc := NewStorageGCService()
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			report, err := gc.Run(context.Background(), false)
+			if errors.Is(err, ErrStorageGCRunning) {
+				continue
+			}
+			if err != nil {
+				log.Printf("Error running storage GC: %v", err)
+				continue
+			}
+			if len(report.Orphaned) > 0 || len(report.Missing) > 0 || len(report.Stale) > 0 {
+				log.Printf("Storage GC removed %d orphaned objects, cleared %d missing references and %d stale records",
+					len(report.Orphaned), len(report.Missing), len(report.Stale))
+			}
+		}
+	}()
+}
+
+// Run compares the objects in storage with the rows referencing them. Unreferenced objects
+// older than the grace period are deleted, references to missing objects are cleared and
+// tracking records of missing objects are dropped. With dryRun nothing is changed.
+// Returns ErrStorageGCRunning if another process is running the GC.
+func (s *StorageGCService) Run(ctx context.Context, dryRun bool) (*StorageGCReport, error) {
+	db := database.GetDB()
+
+	var report *StorageGCReport
+	err := withGCLock(ctx, db, func() error {
+		var err error
+		report, err = s.run(ctx, db, dryRun)
+		return err
+	})
+	return report, err
+}
+
+func (s *StorageGCService) run(ctx context.Context, db *gorm.DB, dryRun bool) (*StorageGCReport, error) {
+	store, err := storage.Get()
+	if err != nil {
+		return nil, err
+	}
+	objects, err := store.List(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+	present := make(map[string]storage.ObjectInfo, len(objects))
+	for _, obj := range objects {
+		present[obj.Key] = obj
+	}
+
+	report := &StorageGCReport{DryRun: dryRun, Scanned: len(objects)}
+
+	referenced, err := s.collectReferences(db, present, report)
+	if err != nil {
+		return nil, err
+	}
+
+	trackedObjects := repository.NewStoredObjectRepo(db)
+	tracked, err := trackedObjects.List()
+	if err != nil {
+		return nil, err
+	}
+	trackedAt := make(map[string]time.Time, len(tracked))
+	for _, obj := range tracked {
+		trackedAt[obj.Key] = obj.CreatedAt
+		if _, ok := present[obj.Key]; !ok {
+			report.Stale = append(report.Stale, obj.Key)
+		}
+	}
+
+	cutoff := time.Now().Add(-s.gracePeriod)
+	for _, obj := range objects {
+		if referenced[obj.Key] || obj.ModTime.After(cutoff) {
+			continue
+		}
+		if at, ok := trackedAt[obj.Key]; ok && at.After(cutoff) {
+			continue
+		}
+		report.Orphaned = append(report.Orphaned, obj.Key)
+	}
+
+	if dryRun {
+		return report, nil
+	}
+
+	for _, key := range report.Orphaned {
+		if err := store.Delete(ctx, key); err != nil {
+			return report, fmt.Errorf("failed to delete %s: %v", key, err)
+		}
+	}
+	if err := trackedObjects.Untrack(append(report.Orphaned, report.Stale...)...); err != nil {
+		return report, err
+	}
+	for _, ref := range report.Missing {
+		if err := db.Table(ref.Table).Where("id = ? AND "+ref.Column+" = ?", ref.ID, ref.Key).Update(ref.Column, "").Error; err != nil {
+			return report, fmt.Errorf("failed to clear %s.%s of %s: %v", ref.Table, ref.Column, ref.ID, err)
+		}
+	}
+	return report, nil
+}
+
+// withGCLock runs fn while holding the storage GC advisory lock. The lock is taken without
+// waiting, since a run that overlaps another one has nothing left to do. SQLite databases
+// are local to one process and need no lock.
+func withGCLock(ctx context.Context, db *gorm.DB, fn func() error) error {
+	if db.Dialector.Name() != database.DriverPostgres {
+		return fn()
+	}
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+	// 会话级锁属于单个连接，加锁和解锁必须使用同一连接
+	conn, err := sqlDB.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	var locked bool
+	if err := conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1)", gcLockID).Scan(&locked); err != nil {
+		return fmt.Errorf("failed to acquire storage GC lock: %v", err)
+	}
+	if !locked {
+		return ErrStorageGCRunning
+	}
+	defer conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", gcLockID)
+	return fn()
+}
+
+// collectReferences returns the keys referenced by user avatars and image READMEs and
+// records references whose objects are missing
+func (s *StorageGCService) collectReferences(db *gorm.DB, present map[string]storage.ObjectInfo, report *StorageGCReport) (map[string]bool, error) {
+	referenced := make(map[string]bool)
+
+	var users []models.User
+	if err := db.Select("id", "avatar").Where("avatar <> ''").Find(&users).Error; err != nil {
+		return nil, err
+	}
+	for _, user := range users {
+		if isExternalURL(user.Avatar) {
+			continue
+		}
+		if !markReferenced(referenced, present, utils.AvatarKeys(user.Avatar)) {
+			report.Missing = append(report.Missing, MissingObject{Table: "users", ID: user.ID, Column: "avatar", Key: user.Avatar})
+		}
+	}
+
+	var images []models.Image
+	if err := db.Select("id", "readme_path").Where("readme_path <> ''").Find(&images).Error; err != nil {
+		return nil, err
+	}
+	for _, image := range images {
+		if !markReferenced(referenced, present, []string{image.ReadmePath}) {
+			report.Missing = append(report.Missing, MissingObject{Table: "images", ID: image.ID, Column: "readme_path", Key: image.ReadmePath})
+		}
+	}
+
+	return referenced, nil
+}
+
+// markReferenced marks keys as referenced and reports whether all of them exist
+func markReferenced(referenced map[string]bool, present map[string]storage.ObjectInfo, keys []string) bool {
+	complete := true
+	for _, key := range keys {
+		referenced[key] = true
+		if _, ok := present[key]; !ok {
+			complete = false
+		}
+	}
+	return complete
+}
+
+// trackObjects records newly stored objects, so that uploads whose referencing row is
+// never written are still known to the storage GC
+func trackObjects(objects repository.StoredObjectRepo, kind string, keys ...string) {
+	if err := objects.Track(kind, keys...); err != nil {
+		log.Printf("Error tracking stored objects %v: %v", keys, err)
+	}
+}
+
+// releaseObjects deletes objects that are no longer referenced. Failures are logged and
+// the objects are left for the storage GC.
+func releaseObjects(objects repository.StoredObjectRepo, keys ...string) {
+	var deleted []string
+	for _, key := range keys {
+		if key == "" || isExternalURL(key) {
+			continue
+		}
+		if err := utils.DeleteFile(key); err != nil {
+			log.Printf("Error deleting stored object %s, leaving it to the storage GC: %v", key, err)
+			continue
+		}
+		deleted = append(deleted, key)
+	}
+	if err := objects.Untrack(deleted...); err != nil {
+		log.Printf("Error removing tracking records: %v", err)
+	}
+}
+
+func isExternalURL(key string) bool {
+	return strings.HasPrefix(key, "http://") || strings.HasPrefix(key, "https://")
+}
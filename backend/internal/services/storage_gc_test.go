@@ -0,0 +1,169 @@
+package services
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/samzong/share-ai-platform/internal/database"
+	"github.com/samzong/share-ai-platform/internal/models"
+	"github.com/samzong/share-ai-platform/internal/repository"
+	"github.com/samzong/share-ai-platform/internal/storage"
+)
+
+// gcFixture holds the rows created by setupStorageGC
+type gcFixture struct {
+	user  *models.User
+	image *models.Image
+}
+
+// setupStorageGC stores files and rows covering every case the storage GC handles:
+//   - referenced/avatar.png and referenced/readme.md are referenced and older than the grace period
+//   - orphan/old.md is unreferenced and older than the grace period
+//   - orphan/new.md is unreferenced but within the grace period
+//   - tracked/recent.md is old on disk but was tracked within the grace period
+//   - tracked/old.md is old on disk and was tracked before the grace period
+//   - the user's avatar and the image's README reference missing files
+//   - missing/tracked.md is tracked but missing from storage
+func setupStorageGC(t *testing.T) gcFixture {
+	root := setupStorage(t)
+	require.NoError(t, database.SetupTestDB())
+	t.Cleanup(database.TeardownTestDB)
+	db := database.GetDB()
+
+	store, err := storage.Get()
+	require.NoError(t, err)
+	old := time.Now().Add(-2 * time.Hour)
+	put := func(key string, modTime time.Time) {
+		require.NoError(t, store.Put(context.Background(), key, strings.NewReader(key), int64(len(key)), "text/plain"))
+		require.NoError(t, os.Chtimes(filepath.Join(root, filepath.FromSlash(key)), modTime, modTime))
+	}
+	put("referenced/avatar.png", old)
+	put("referenced/readme.md", old)
+	put("orphan/old.md", old)
+	put("orphan/new.md", time.Now())
+	put("tracked/recent.md", old)
+	put("tracked/old.md", old)
+
+	objects := repository.NewStoredObjectRepo(db)
+	require.NoError(t, objects.Track(models.StoredObjectReadme, "tracked/recent.md", "tracked/old.md", "missing/tracked.md"))
+	require.NoError(t, db.Model(&models.StoredObject{}).Where("object_key = ?", "tracked/old.md").Update("created_at", old).Error)
+
+	var f gcFixture
+	f.user = &models.User{Username: "gcuser", Email: "gc@example.com", Password: "secret", Avatar: "missing/avatar.png"}
+	require.NoError(t, db.Create(f.user).Error)
+	require.NoError(t, db.Create(&models.User{Username: "keeper", Email: "keeper@example.com", Password: "secret", Avatar: "referenced/avatar.png"}).Error)
+
+	newImage := func(name, readme string) *models.Image {
+		image := &models.Image{OrgID: "org", Name: name, Author: "author", Registry: "docker.io",
+			Namespace: "library", Repository: name, Tag: "latest", Digest: "sha256:" + name,
+			Platform: "linux/amd64", ReadmePath: readme}
+		require.NoError(t, db.Create(image).Error)
+		return image
+	}
+	f.image = newImage("broken", "missing/readme.md")
+	newImage("kept", "referenced/readme.md")
+	return f
+}
+
+// storedObjectKeys lists the keys present in storage
+func storedObjectKeys(t *testing.T) []string {
+	store, err := storage.Get()
+	require.NoError(t, err)
+	objects, err := store.List(context.Background(), "")
+	require.NoError(t, err)
+	keys := make([]string, 0, len(objects))
+	for _, obj := range objects {
+		keys = append(keys, obj.Key)
+	}
+	return keys
+}
+
+// trackedObjectKeys lists the keys of the tracking records
+func trackedObjectKeys(t *testing.T) []string {
+	objects, err := repository.NewStoredObjectRepo(database.GetDB()).List()
+	require.NoError(t, err)
+	keys := make([]string, 0, len(objects))
+	for _, obj := range objects {
+		keys = append(keys, obj.Key)
+	}
+	return keys
+}
+
+func TestStorageGC_Run(t *testing.T) {
+	f := setupStorageGC(t)
+	db := database.GetDB()
+	gc := &StorageGCService{gracePeriod: time.Hour}
+
+	report, err := gc.Run(context.Background(), false)
+	require.NoError(t, err)
+	assert.False(t, report.DryRun)
+	assert.Equal(t, 6, report.Scanned)
+	assert.ElementsMatch(t, []string{"orphan/old.md", "tracked/old.md"}, report.Orphaned)
+	assert.Equal(t, []string{"missing/tracked.md"}, report.Stale)
+	assert.ElementsMatch(t, []MissingObject{
+		{Table: "users", ID: f.user.ID, Column: "avatar", Key: "missing/avatar.png"},
+		{Table: "images", ID: f.image.ID, Column: "readme_path", Key: "missing/readme.md"},
+	}, report.Missing)
+
+	// 宽限期内的文件和近期跟踪的文件保留，其余无引用文件被删除
+	assert.ElementsMatch(t, []string{
+		"referenced/avatar.png", "referenced/readme.md", "orphan/new.md", "tracked/recent.md",
+	}, storedObjectKeys(t))
+	assert.Equal(t, []string{"tracked/recent.md"}, trackedObjectKeys(t))
+
+	// 指向缺失文件的引用被清空
+	var user models.User
+	require.NoError(t, db.First(&user, "id = ?", f.user.ID).Error)
+	assert.Empty(t, user.Avatar)
+	var image models.Image
+	require.NoError(t, db.First(&image, "id = ?", f.image.ID).Error)
+	assert.Empty(t, image.ReadmePath)
+
+	// 再次执行没有可清理的内容
+	report, err = gc.Run(context.Background(), false)
+	require.NoError(t, err)
+	assert.Empty(t, report.Orphaned)
+	assert.Empty(t, report.Missing)
+	assert.Empty(t, report.Stale)
+}
+
+func TestStorageGC_DryRunChangesNothing(t *testing.T) {
+	f := setupStorageGC(t)
+	db := database.GetDB()
+	gc := &StorageGCService{gracePeriod: time.Hour}
+	files := storedObjectKeys(t)
+	tracked := trackedObjectKeys(t)
+
+	report, err := gc.Run(context.Background(), true)
+	require.NoError(t, err)
+	assert.True(t, report.DryRun)
+	assert.ElementsMatch(t, []string{"orphan/old.md", "tracked/old.md"}, report.Orphaned)
+	assert.Equal(t, []string{"missing/tracked.md"}, report.Stale)
+	assert.Len(t, report.Missing, 2)
+
+	assert.ElementsMatch(t, files, storedObjectKeys(t))
+	assert.ElementsMatch(t, tracked, trackedObjectKeys(t))
+	var user models.User
+	require.NoError(t, db.First(&user, "id = ?", f.user.ID).Error)
+	assert.Equal(t, "missing/avatar.png", user.Avatar)
+	var image models.Image
+	require.NoError(t, db.First(&image, "id = ?", f.image.ID).Error)
+	assert.Equal(t, "missing/readme.md", image.ReadmePath)
+}
+
+func TestStorageGC_GracePeriod(t *testing.T) {
+	setupStorageGC(t)
+
+	// 宽限期长于所有文件的年龄时不删除任何文件
+	report, err := (&StorageGCService{gracePeriod: 3 * time.Hour}).Run(context.Background(), false)
+	require.NoError(t, err)
+	assert.Empty(t, report.Orphaned)
+	assert.Len(t, storedObjectKeys(t), 6)
+}
@@ -0,0 +1,384 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/spf13/viper"
+
+	"github.com/samzong/share-ai-platform/internal/cache"
+	"github.com/samzong/share-ai-platform/internal/database"
+	"github.com/samzong/share-ai-platform/internal/repository"
+)
+
+// 热度权重：部署最能说明镜像被实际使用，收藏次之，访客最弱
+const (
+	trendingVisitorWeight = 1.0
+	trendingStarWeight    = 3.0
+	trendingDeployWeight  = 5.0
+)
+
+const (
+	// trendingPoolSize is how many of the top images a snapshot keeps. It is well above a
+	// page so that a page can still be filled after hiding images the viewer cannot see.
+	trendingPoolSize = 500
+	// trendingLabelsSize is how many labels each label feed keeps
+	trendingLabelsSize = 100
+
+	defaultTrendingWindow = "7d"
+	defaultTrendingLimit  = 20
+)
+
+// trendingWindow is the period activity counts in. Activity loses half of its weight
+// every half-life, so within the window recent activity ranks higher.
+type trendingWindow struct {
+	Period   time.Duration
+	HalfLife time.Duration
+}
+
+var trendingWindows = map[string]trendingWindow{
+	"24h": {Period: 24 * time.Hour, HalfLife: 6 * time.Hour},
+	"7d":  {Period: 7 * 24 * time.Hour, HalfLife: 36 * time.Hour},
+	"30d": {Period: 30 * 24 * time.Hour, HalfLife: 7 * 24 * time.Hour},
+}
+
+// decay returns the weight of activity that happened age ago
+func (w trendingWindow) decay(age time.Duration) float64 {
+	if age < 0 {
+		age = 0
+	}
+	return math.Pow(0.5, float64(age)/float64(w.HalfLife))
+}
+
+type TrendingService struct {
+	collections repository.CollectionRepo
+	stats       repository.StatsRepo
+	labels      repository.LabelRepo
+	images      *ImageService
+}
+
+type TrendingRequest struct {
+	Window string `form:"window" binding:"omitempty,oneof=24h 7d 30d"` // 统计窗口，默认 7d
+	Limit  int    `form:"limit" binding:"omitempty,min=1,max=100"`     // 返回数量，默认 20
+}
+
+type TrendingLabelRequest struct {
+	TrendingRequest
+	Feed string `form:"feed" binding:"omitempty,oneof=hot new"` // hot 按热度排序，new 按标签首次出现时间排序，默认 hot
+}
+
+type TrendingImageResponse struct {
+	ImageResponse
+	Score float64 `json:"score"` // 时间衰减后的热度
+}
+
+type TrendingLabelResponse struct {
+	Name      string    `json:"name"`       // 标签名称
+	Score     float64   `json:"score"`      // 窗口内带有该标签的公开镜像的热度之和
+	Images    int64     `json:"images"`     // 带有该标签的公开镜像数
+	CreatedAt time.Time `json:"created_at"` // 标签首次出现时间
+}
+
+// trendingSnapshot is the precomputed ranking of one window. Images cover every
+// visibility and are filtered per viewer when read; labels only count public images.
+type trendingSnapshot struct {
+	Window      string                  `json:"window"`
+	GeneratedAt time.Time               `json:"generated_at"`
+	Images      []trendingScore         `json:"images"`
+	HotLabels   []TrendingLabelResponse `json:"hot_labels"`
+	NewLabels   []TrendingLabelResponse `json:"new_labels"`
+}
+
+type trendingScore struct {
+	ImageID string  `json:"image_id"`
+	Score   float64 `json:"score"`
+}
+
+// NewTrendingService creates a new TrendingService backed by the database
+func NewTrendingService() *TrendingService {
+	return NewTrendingServiceWith(repository.NewGorm(database.GetDB()), defaultAuthorizer, defaultEventRecorder())
+}
+
+// NewTrendingServiceWith creates a TrendingService on the given repositories and authorizer.
+// events is passed to the ImageService it uses to build image responses.
+func NewTrendingServiceWith(repos *repository.Repositories, az Authorizer, events *EventRecorder) *TrendingService {
+	return &TrendingService{
+		collections: repos.Collections,
+		stats:       repos.Stats,
+		labels:      repos.Labels,
+		images:      NewImageServiceWith(repos, az, events),
+	}
+}
+
+// StartTrendingRefresh recomputes the trending rankings every interval in the background
+func StartTrendingRefresh(interval time.Duration) {
+	trending := NewTrendingService()
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			if err := trending.Refresh(context.Background()); err != nil {
+				log.Printf("Error refreshing trending rankings: %v", err)
+			}
+			<-ticker.C
+		}
+	}()
+}
+
+// Refresh recomputes the rankings of every window and stores them in the cache
+func (s *TrendingService) Refresh(ctx context.Context) error {
+	for window := range trendingWindows {
+		snapshot, err := s.compute(window)
+		if err != nil {
+			return err
+		}
+		data, err := json.Marshal(snapshot)
+		if err != nil {
+			return err
+		}
+		if err := cache.Default().Set(ctx, trendingCacheKey(window), data, trendingTTL()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// TrendingImages returns the hottest images of the window the user can see
+func (s *TrendingService) TrendingImages(ctx context.Context, req *TrendingRequest, userID string) ([]TrendingImageResponse, error) {
+	snapshot, err := s.snapshot(ctx, req.Window)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]string, len(snapshot.Images))
+	for i, scored := range snapshot.Images {
+		ids[i] = scored.ImageID
+	}
+	visible, err := s.images.visibleImages(ids, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	limit := trendingLimit(req.Limit)
+	response := make([]TrendingImageResponse, 0, limit)
+	for _, scored := range snapshot.Images {
+		image, ok := visible[scored.ImageID]
+		if !ok {
+			continue
+		}
+		response = append(response, TrendingImageResponse{ImageResponse: image, Score: scored.Score})
+		if len(response) == limit {
+			break
+		}
+	}
+	return response, nil
+}
+
+// TrendingLabels returns the hottest or the newest labels on public images in the window
+func (s *TrendingService) TrendingLabels(ctx context.Context, req *TrendingLabelRequest) ([]TrendingLabelResponse, error) {
+	snapshot, err := s.snapshot(ctx, req.Window)
+	if err != nil {
+		return nil, err
+	}
+
+	labels := snapshot.HotLabels
+	if req.Feed == "new" {
+		labels = snapshot.NewLabels
+	}
+	if limit := trendingLimit(req.Limit); len(labels) > limit {
+		labels = labels[:limit]
+	}
+	return labels, nil
+}
+
+// snapshot returns the cached ranking of the window, computing it on a miss
+func (s *TrendingService) snapshot(ctx context.Context, window string) (*trendingSnapshot, error) {
+	if window == "" {
+		window = defaultTrendingWindow
+	}
+	data, err := cache.GetOrLoad(ctx, trendingCacheKey(window), trendingTTL(), func() ([]byte, error) {
+		snapshot, err := s.compute(window)
+		if err != nil {
+			return nil, err
+		}
+		return json.Marshal(snapshot)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var snapshot trendingSnapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return nil, err
+	}
+	return &snapshot, nil
+}
+
+// compute scores every image with activity in the window and ranks the labels of the
+// public ones
+func (s *TrendingService) compute(window string) (*trendingSnapshot, error) {
+	w := trendingWindows[window]
+	now := time.Now()
+	since := now.Add(-w.Period)
+
+	scores := make(map[string]float64)
+	stars, err := s.collections.CreatedSince(since)
+	if err != nil {
+		return nil, err
+	}
+	for _, star := range stars {
+		scores[star.ImageID] += trendingStarWeight * w.decay(now.Sub(star.CreatedAt))
+	}
+
+	stats, err := s.stats.Since(since)
+	if err != nil {
+		return nil, err
+	}
+	for _, stat := range stats {
+		// 按天汇总的计数视为发生在当天正午，并限制在窗口之内
+		at := stat.Day.Add(12 * time.Hour)
+		if at.After(now) {
+			at = now
+		}
+		if at.Before(since) {
+			at = since
+		}
+		// 按当天不同访客计分而非浏览次数，同一访客反复刷新不会抬高热度
+		activity := trendingVisitorWeight*float64(stat.Visitors) + trendingDeployWeight*float64(stat.Deploys)
+		scores[stat.ImageID] += activity * w.decay(now.Sub(at))
+	}
+
+	ranked := make([]trendingScore, 0, len(scores))
+	for id, score := range scores {
+		ranked = append(ranked, trendingScore{ImageID: id, Score: score})
+	}
+	sort.Slice(ranked, func(i, j int) bool {
+		if ranked[i].Score != ranked[j].Score {
+			return ranked[i].Score > ranked[j].Score
+		}
+		return ranked[i].ImageID < ranked[j].ImageID
+	})
+	if len(ranked) > trendingPoolSize {
+		ranked = ranked[:trendingPoolSize]
+	}
+
+	hot, err := s.hotLabels(ranked)
+	if err != nil {
+		return nil, err
+	}
+	fresh, err := s.newLabels(since, hot)
+	if err != nil {
+		return nil, err
+	}
+
+	return &trendingSnapshot{
+		Window:      window,
+		GeneratedAt: now,
+		Images:      ranked,
+		HotLabels:   hot,
+		NewLabels:   fresh,
+	}, nil
+}
+
+// hotLabels sums the scores of the ranked images per label. Only public images count,
+// so the feed never reveals labels of images anonymous users cannot see.
+func (s *TrendingService) hotLabels(ranked []trendingScore) ([]TrendingLabelResponse, error) {
+	labels := []TrendingLabelResponse{}
+	if len(ranked) == 0 {
+		return labels, nil
+	}
+
+	ids := make([]string, len(ranked))
+	scores := make(map[string]float64, len(ranked))
+	for i, scored := range ranked {
+		ids[i] = scored.ImageID
+		scores[scored.ImageID] = scored.Score
+	}
+	images, _, err := s.images.images.List(repository.ImageFilter{Scope: repository.ImageScope{}, IDs: ids, Limit: len(ids)})
+	if err != nil {
+		return nil, err
+	}
+
+	byLabel := make(map[string]*TrendingLabelResponse)
+	for _, image := range images {
+		for _, label := range image.Labels {
+			entry, ok := byLabel[label.ID]
+			if !ok {
+				entry = &TrendingLabelResponse{Name: label.Name, CreatedAt: label.CreatedAt}
+				byLabel[label.ID] = entry
+			}
+			entry.Score += scores[image.ID]
+			entry.Images++
+		}
+	}
+	for _, entry := range byLabel {
+		labels = append(labels, *entry)
+	}
+	sort.Slice(labels, func(i, j int) bool {
+		if labels[i].Score != labels[j].Score {
+			return labels[i].Score > labels[j].Score
+		}
+		return labels[i].Name < labels[j].Name
+	})
+	if len(labels) > trendingLabelsSize {
+		labels = labels[:trendingLabelsSize]
+	}
+	return labels, nil
+}
+
+// newLabels returns the labels first seen in the window that public images carry,
+// newest first
+func (s *TrendingService) newLabels(since time.Time, hot []TrendingLabelResponse) ([]TrendingLabelResponse, error) {
+	usage, err := s.labels.Usage(repository.ImageScope{}, "")
+	if err != nil {
+		return nil, err
+	}
+	scores := make(map[string]float64, len(hot))
+	for _, label := range hot {
+		scores[label.Name] = label.Score
+	}
+
+	labels := []TrendingLabelResponse{}
+	for _, u := range usage {
+		if u.Label.CreatedAt.Before(since) {
+			continue
+		}
+		labels = append(labels, TrendingLabelResponse{
+			Name:      u.Label.Name,
+			Score:     scores[u.Label.Name],
+			Images:    u.Images,
+			CreatedAt: u.Label.CreatedAt,
+		})
+	}
+	sort.Slice(labels, func(i, j int) bool {
+		return labels[i].CreatedAt.After(labels[j].CreatedAt)
+	})
+	if len(labels) > trendingLabelsSize {
+		labels = labels[:trendingLabelsSize]
+	}
+	return labels, nil
+}
+
+func trendingCacheKey(window string) string {
+	return "trending:" + window
+}
+
+// trendingTTL keeps a snapshot until two refreshes have been missed, so that the feeds
+// still work when the refresh job is disabled
+func trendingTTL() time.Duration {
+	if interval := viper.GetInt("trending.refresh_interval"); interval > 0 {
+		return 2 * time.Duration(interval) * time.Second
+	}
+	return 10 * time.Minute
+}
+
+func trendingLimit(limit int) int {
+	if limit <= 0 {
+		return defaultTrendingLimit
+	}
+	return limit
+}
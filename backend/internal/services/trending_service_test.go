@@ -0,0 +1,83 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/samzong/share-ai-platform/internal/models"
+	"github.com/samzong/share-ai-platform/internal/repository"
+)
+
+func TestTrendingService(t *testing.T) {
+	repos := repository.NewMemory()
+	az := newFakeAuthorizer()
+	az.admins["admin"] = true
+	service := NewTrendingServiceWith(&repos.Repositories, az, NewEventRecorder(repos.Stats))
+	ctx := context.Background()
+	now := time.Now()
+
+	deployed := labelImage(t, repos, "deployed", models.VisibilityPublic, "llm")
+	starred := labelImage(t, repos, "starred", models.VisibilityPublic, "LLM", "vision")
+	viewed := createTestImage(t, repos, "viewed", models.VisibilityPublic)
+	stale := createTestImage(t, repos, "stale", models.VisibilityPublic)
+	private := labelImage(t, repos, "private", models.VisibilityPrivate, "secret")
+	refreshed := createTestImage(t, repos, "refreshed", models.VisibilityPublic)
+	createTestImage(t, repos, "idle", models.VisibilityPublic)
+
+	// record adds events from the given number of distinct anonymous visitors
+	record := func(image *models.Image, eventType string, at time.Time, visitors int) {
+		for i := 0; i < visitors; i++ {
+			visitor := fmt.Sprintf("visitor-%d", i)
+			require.NoError(t, repos.Stats.AddEvents([]models.ImageEvent{{ImageID: image.ID, Type: eventType, AnonymousID: &visitor, CreatedAt: at}}))
+		}
+	}
+	record(deployed, models.EventDeploy, now, 1)
+	_, err := repos.Collections.Add(&models.Collection{UserID: "u1", ImageID: starred.ID})
+	require.NoError(t, err)
+	record(viewed, models.EventView, now, 2)
+	record(stale, models.EventView, now.Add(-6*24*time.Hour), 10)
+	record(private, models.EventDeploy, now, 3)
+	// 同一访客反复浏览只按一个访客计分
+	for i := 0; i < 50; i++ {
+		record(refreshed, models.EventView, now, 1)
+	}
+	require.NoError(t, repos.Stats.Rollup(now.Add(-30*24*time.Hour)))
+	require.NoError(t, service.Refresh(ctx))
+
+	names := func(images []TrendingImageResponse) []string {
+		var names []string
+		for _, image := range images {
+			names = append(names, image.Name)
+		}
+		return names
+	}
+
+	images, err := service.TrendingImages(ctx, &TrendingRequest{}, "")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"deployed", "starred", "viewed", "refreshed", "stale"}, names(images))
+
+	images, err = service.TrendingImages(ctx, &TrendingRequest{Window: "7d", Limit: 2}, "admin")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"private", "deployed"}, names(images))
+
+	images, err = service.TrendingImages(ctx, &TrendingRequest{Window: "24h"}, "")
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"deployed", "starred", "viewed", "refreshed"}, names(images))
+
+	hot, err := service.TrendingLabels(ctx, &TrendingLabelRequest{})
+	require.NoError(t, err)
+	require.Len(t, hot, 2, "labels of private images must not be listed")
+	assert.Equal(t, "llm", hot[0].Name)
+	assert.EqualValues(t, 2, hot[0].Images)
+	assert.Equal(t, "vision", hot[1].Name)
+
+	fresh, err := service.TrendingLabels(ctx, &TrendingLabelRequest{Feed: "new"})
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"llm", "vision"}, []string{fresh[0].Name, fresh[1].Name})
+	assert.Len(t, fresh, 2)
+}
@@ -0,0 +1,366 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+	"gorm.io/gorm"
+
+	"github.com/samzong/share-ai-platform/internal/database"
+	"github.com/samzong/share-ai-platform/internal/middleware"
+	"github.com/samzong/share-ai-platform/internal/models"
+	"github.com/samzong/share-ai-platform/internal/repository"
+	"github.com/samzong/share-ai-platform/internal/utils"
+)
+
+const (
+	recoveryCodeCount    = 10 // 每次生成的恢复码数量
+	maxChallengeAttempts = 5  // 单个登录挑战允许的验证次数
+)
+
+var recoveryCodeEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+type TwoFactorService struct {
+	loginGuard *LoginGuard
+}
+
+type TwoFactorEnrollResponse struct {
+	Secret string `json:"secret"` // Base32 密钥，用于手动输入
+	URI    string `json:"uri"`    // otpauth:// URI，用于生成二维码
+}
+
+type TwoFactorCodeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+type TwoFactorDisableRequest struct {
+	Password string `json:"password" binding:"required"`
+	Code     string `json:"code" binding:"required"` // TOTP 验证码或恢复码
+}
+
+type TwoFactorLoginRequest struct {
+	ChallengeToken string `json:"challenge_token" binding:"required"`
+	Code           string `json:"code" binding:"required"` // TOTP 验证码或恢复码
+}
+
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"` // 明文恢复码，仅在生成时返回一次
+}
+
+// NewTwoFactorService creates a new TwoFactorService
+func NewTwoFactorService() *TwoFactorService {
+	return &TwoFactorService{loginGuard: defaultLoginGuard}
+}
+
+// Enroll generates a new pending TOTP secret for the user
+func (s *TwoFactorService) Enroll(userID string) (*TwoFactorEnrollResponse, error) {
+	db := database.GetDB()
+
+	var user models.User
+	if err := db.First(&user, "id = ?", userID).Error; err != nil {
+		return nil, errors.New("user not found")
+	}
+
+	if user.TOTPEnabled {
+		return nil, errors.New("two-factor authentication already enabled")
+	}
+
+	secret, err := utils.GenerateTOTPSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := db.Model(&user).Update("totp_secret", secret).Error; err != nil {
+		return nil, err
+	}
+
+	issuer := viper.GetString("auth.totp_issuer")
+	if issuer == "" {
+		issuer = "Share AI Platform"
+	}
+
+	return &TwoFactorEnrollResponse{
+		Secret: secret,
+		URI:    utils.TOTPURI(issuer, user.Username, secret),
+	}, nil
+}
+
+// Activate verifies the first code from the authenticator and enables 2FA
+func (s *TwoFactorService) Activate(userID string, code string) (*RecoveryCodesResponse, error) {
+	db := database.GetDB()
+
+	var user models.User
+	if err := db.First(&user, "id = ?", userID).Error; err != nil {
+		return nil, errors.New("user not found")
+	}
+
+	if user.TOTPEnabled {
+		return nil, errors.New("two-factor authentication already enabled")
+	}
+	if user.TOTPSecret == "" {
+		return nil, errors.New("two-factor enrollment not started")
+	}
+
+	step, ok := utils.ValidateTOTPCode(user.TOTPSecret, code, time.Now())
+	if !ok {
+		return nil, errors.New("invalid verification code")
+	}
+
+	var codes []string
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&user).Updates(map[string]interface{}{
+			"totp_enabled":   true,
+			"totp_last_step": step,
+		}).Error; err != nil {
+			return err
+		}
+
+		var err error
+		codes, err = replaceRecoveryCodes(tx, user.ID)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to enable two-factor authentication: %v", err)
+	}
+
+	return &RecoveryCodesResponse{RecoveryCodes: codes}, nil
+}
+
+// Disable turns off 2FA after re-checking the password and a second factor
+func (s *TwoFactorService) Disable(userID string, req *TwoFactorDisableRequest) error {
+	db := database.GetDB()
+
+	var user models.User
+	if err := db.First(&user, "id = ?", userID).Error; err != nil {
+		return errors.New("user not found")
+	}
+
+	if !user.TOTPEnabled {
+		return errors.New("two-factor authentication not enabled")
+	}
+
+	if err := user.ComparePassword(req.Password); err != nil {
+		return errors.New("invalid password")
+	}
+
+	if err := verifySecondFactor(db, &user, req.Code); err != nil {
+		return err
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&user).Updates(map[string]interface{}{
+			"totp_secret":    "",
+			"totp_enabled":   false,
+			"totp_last_step": 0,
+		}).Error; err != nil {
+			return err
+		}
+		return tx.Where("user_id = ?", user.ID).Delete(&models.RecoveryCode{}).Error
+	})
+}
+
+// RegenerateRecoveryCodes invalidates all existing recovery codes and issues new ones
+func (s *TwoFactorService) RegenerateRecoveryCodes(userID string, code string) (*RecoveryCodesResponse, error) {
+	db := database.GetDB()
+
+	var user models.User
+	if err := db.First(&user, "id = ?", userID).Error; err != nil {
+		return nil, errors.New("user not found")
+	}
+
+	if !user.TOTPEnabled {
+		return nil, errors.New("two-factor authentication not enabled")
+	}
+
+	// 只接受 TOTP 验证码，避免用恢复码生成新的恢复码
+	if err := verifyTOTP(db, &user, code); err != nil {
+		return nil, err
+	}
+
+	var codes []string
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		codes, err = replaceRecoveryCodes(tx, user.ID)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to regenerate recovery codes: %v", err)
+	}
+
+	return &RecoveryCodesResponse{RecoveryCodes: codes}, nil
+}
+
+// Login completes a two-step login by exchanging a challenge token and a code for an access token.
+// Wrong codes count as failed logins for the username, so new challenges cannot be used to keep guessing.
+func (s *TwoFactorService) Login(ctx context.Context, req *TwoFactorLoginRequest, clientIP string) (*UserResponse, error) {
+	claims, err := middleware.ParseChallengeToken(req.ChallengeToken)
+	if err != nil {
+		return nil, errors.New("invalid or expired challenge token")
+	}
+
+	db := database.GetDB()
+	tokens := repository.NewTokenRepo(db)
+	digest := middleware.TokenDigest(req.ChallengeToken)
+	expiresAt := time.Now().Add(middleware.ChallengeExpiration)
+
+	// 挑战令牌只能使用一次
+	revoked, err := tokens.IsRevoked(digest)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check challenge token: %v", err)
+	}
+	if revoked {
+		return nil, errors.New("invalid or expired challenge token")
+	}
+
+	// 限制单个挑战的尝试次数，防止暴力猜测验证码
+	attempts, err := tokens.CountAttempt(digest, claims.UserID, expiresAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to record verification attempt: %v", err)
+	}
+	if attempts > maxChallengeAttempts {
+		return nil, errors.New("too many verification attempts, please login again")
+	}
+
+	var user models.User
+	if err := db.First(&user, "id = ?", claims.UserID).Error; err != nil {
+		return nil, errors.New("user not found")
+	}
+
+	if !user.TOTPEnabled {
+		return nil, errors.New("two-factor authentication not enabled")
+	}
+
+	if err := s.loginGuard.Check(ctx, user.Username, clientIP); err != nil {
+		return nil, err
+	}
+
+	if err := verifySecondFactor(db, &user, req.Code); err != nil {
+		if err := s.loginGuard.RecordFailure(ctx, user.Username, clientIP); err != nil {
+			log.Printf("Error recording login failure for %s: %v", user.Username, err)
+		}
+		return nil, err
+	}
+
+	// 并发请求中只有第一个能用掉挑战令牌
+	first, err := tokens.Revoke(digest, user.ID, expiresAt)
+	if err != nil {
+		return nil, err
+	}
+	if !first {
+		return nil, errors.New("invalid or expired challenge token")
+	}
+
+	// 第二步验证通过后才清零失败计数
+	if err := s.loginGuard.RecordSuccess(ctx, user.Username); err != nil {
+		log.Printf("Error resetting login failures for %s: %v", user.Username, err)
+	}
+
+	token, err := middleware.GenerateToken(user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &UserResponse{
+		ID:          user.ID,
+		Username:    user.Username,
+		Email:       user.Email,
+		Nickname:    user.Nickname,
+		Avatar:      utils.GetFileURL(user.Avatar),
+		AvatarURLs:  utils.AvatarURLs(user.Avatar),
+		Role:        user.Role,
+		TOTPEnabled: user.TOTPEnabled,
+		Token:       token,
+	}, nil
+}
+
+// verifySecondFactor accepts either a TOTP code or an unused recovery code
+func verifySecondFactor(db *gorm.DB, user *models.User, code string) error {
+	if err := verifyTOTP(db, user, code); err == nil {
+		return nil
+	}
+
+	hash := hashRecoveryCode(code)
+	result := db.Model(&models.RecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", user.ID, hash).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("invalid verification code")
+	}
+	return nil
+}
+
+// verifyTOTP checks a TOTP code and records its time step so it cannot be replayed
+func verifyTOTP(db *gorm.DB, user *models.User, code string) error {
+	step, ok := utils.ValidateTOTPCode(user.TOTPSecret, code, time.Now())
+	if !ok {
+		return errors.New("invalid verification code")
+	}
+
+	result := db.Model(&models.User{}).
+		Where("id = ? AND totp_last_step < ?", user.ID, step).
+		Update("totp_last_step", step)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("verification code already used")
+	}
+	user.TOTPLastStep = step
+	return nil
+}
+
+// replaceRecoveryCodes deletes the user's recovery codes and stores a fresh hashed set
+func replaceRecoveryCodes(tx *gorm.DB, userID string) ([]string, error) {
+	if err := tx.Where("user_id = ?", userID).Delete(&models.RecoveryCode{}).Error; err != nil {
+		return nil, err
+	}
+
+	codes := make([]string, recoveryCodeCount)
+	records := make([]models.RecoveryCode, recoveryCodeCount)
+	for i := range codes {
+		code, err := generateRecoveryCode()
+		if err != nil {
+			return nil, err
+		}
+		codes[i] = code
+		records[i] = models.RecoveryCode{
+			UserID:   userID,
+			CodeHash: hashRecoveryCode(code),
+		}
+	}
+
+	if err := tx.Create(&records).Error; err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// generateRecoveryCode returns a random code formatted as xxxxx-xxxxx
+func generateRecoveryCode() (string, error) {
+	buf := make([]byte, 7)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate recovery code: %v", err)
+	}
+	code := strings.ToLower(recoveryCodeEncoding.EncodeToString(buf))[:10]
+	return code[:5] + "-" + code[5:], nil
+}
+
+// hashRecoveryCode normalizes a recovery code and returns its SHA-256 hash.
+// Recovery codes are random and high-entropy, so a fast hash is sufficient.
+func hashRecoveryCode(code string) string {
+	normalized := strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+	sum := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(sum[:])
+}
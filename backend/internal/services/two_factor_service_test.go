@@ -0,0 +1,108 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/samzong/share-ai-platform/internal/cache"
+	"github.com/samzong/share-ai-platform/internal/database"
+	"github.com/samzong/share-ai-platform/internal/models"
+	"github.com/samzong/share-ai-platform/internal/repository"
+	"github.com/samzong/share-ai-platform/internal/utils"
+)
+
+func TestTwoFactorService_BadCodesAcrossChallengesLockOut(t *testing.T) {
+	setupTest(t)
+	require.NoError(t, database.SetupTestDB())
+	defer database.TeardownTestDB()
+
+	ctx := context.Background()
+	guard := &LoginGuard{store: cache.NewMemory(100)}
+	users := NewUserServiceWith(repository.NewGorm(database.GetDB()), newFakeAuthorizer())
+	users.loginGuard = guard
+	twoFactor := &TwoFactorService{loginGuard: guard}
+
+	_, err := users.Register(&RegisterRequest{Username: "totpuser", Email: "totp@example.com", Password: "password123"})
+	require.NoError(t, err)
+	secret, err := utils.GenerateTOTPSecret()
+	require.NoError(t, err)
+	require.NoError(t, database.GetDB().Model(&models.User{}).
+		Where("username = ?", "totpuser").
+		Updates(map[string]interface{}{"totp_enabled": true, "totp_secret": secret}).Error)
+
+	login := &LoginRequest{Username: "totpuser", Password: "password123"}
+
+	// 每次都用正确密码换取新的挑战令牌，再提交错误的验证码
+	for i := 0; i < 5; i++ {
+		challenge, err := users.Login(ctx, login, "10.0.0.1")
+		require.NoError(t, err, "attempt %d", i+1)
+		require.True(t, challenge.TwoFactorRequired)
+
+		_, err = twoFactor.Login(ctx, &TwoFactorLoginRequest{ChallengeToken: challenge.ChallengeToken, Code: "not-a-code"}, "10.0.0.1")
+		assert.EqualError(t, err, "invalid verification code")
+	}
+
+	// 密码正确也无法再获取挑战令牌
+	_, err = users.Login(ctx, login, "10.0.0.1")
+	var locked *LoginLockedError
+	assert.True(t, errors.As(err, &locked), "expected lockout, got %v", err)
+
+	// 已签发的挑战令牌配合正确验证码同样被拒绝
+	require.NoError(t, guard.Unlock(ctx, "totpuser"))
+	challenge, err := users.Login(ctx, login, "10.0.0.1")
+	require.NoError(t, err)
+	require.NoError(t, guard.set(ctx, "login_lock:user:totpuser", time.Minute))
+	code, err := utils.GenerateTOTPCode(secret, time.Now())
+	require.NoError(t, err)
+	_, err = twoFactor.Login(ctx, &TwoFactorLoginRequest{ChallengeToken: challenge.ChallengeToken, Code: code}, "10.0.0.1")
+	assert.True(t, errors.As(err, &locked), "expected lockout, got %v", err)
+}
+
+func TestTwoFactorService_SuccessResetsFailures(t *testing.T) {
+	setupTest(t)
+	require.NoError(t, database.SetupTestDB())
+	defer database.TeardownTestDB()
+
+	ctx := context.Background()
+	guard := &LoginGuard{store: cache.NewMemory(100)}
+	users := NewUserServiceWith(repository.NewGorm(database.GetDB()), newFakeAuthorizer())
+	users.loginGuard = guard
+	twoFactor := &TwoFactorService{loginGuard: guard}
+
+	_, err := users.Register(&RegisterRequest{Username: "resetuser", Email: "reset@example.com", Password: "password123"})
+	require.NoError(t, err)
+	secret, err := utils.GenerateTOTPSecret()
+	require.NoError(t, err)
+	require.NoError(t, database.GetDB().Model(&models.User{}).
+		Where("username = ?", "resetuser").
+		Updates(map[string]interface{}{"totp_enabled": true, "totp_secret": secret}).Error)
+
+	login := &LoginRequest{Username: "resetuser", Password: "password123"}
+	for i := 0; i < 4; i++ {
+		challenge, err := users.Login(ctx, login, "10.0.0.2")
+		require.NoError(t, err)
+		_, err = twoFactor.Login(ctx, &TwoFactorLoginRequest{ChallengeToken: challenge.ChallengeToken, Code: "not-a-code"}, "10.0.0.2")
+		require.Error(t, err)
+	}
+
+	challenge, err := users.Login(ctx, login, "10.0.0.2")
+	require.NoError(t, err)
+	code, err := utils.GenerateTOTPCode(secret, time.Now())
+	require.NoError(t, err)
+	resp, err := twoFactor.Login(ctx, &TwoFactorLoginRequest{ChallengeToken: challenge.ChallengeToken, Code: code}, "10.0.0.2")
+	require.NoError(t, err)
+	assert.NotEmpty(t, resp.Token)
+
+	// 计数已清零，再失败一次不会触发锁定
+	challenge, err = users.Login(ctx, login, "10.0.0.2")
+	require.NoError(t, err)
+	_, err = twoFactor.Login(ctx, &TwoFactorLoginRequest{ChallengeToken: challenge.ChallengeToken, Code: "not-a-code"}, "10.0.0.2")
+	assert.EqualError(t, err, "invalid verification code")
+	_, err = users.Login(ctx, login, "10.0.0.2")
+	assert.NoError(t, err)
+}
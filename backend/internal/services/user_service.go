@@ -3,12 +3,19 @@ package services
 import (
 	"context"
 	"errors"
+	"fmt"
+	"log"
 	"mime/multipart"
 	"regexp"
+	"time"
 
+	"github.com/spf13/viper"
+
+	"github.com/samzong/share-ai-platform/internal/authz"
 	"github.com/samzong/share-ai-platform/internal/database"
 	"github.com/samzong/share-ai-platform/internal/middleware"
 	"github.com/samzong/share-ai-platform/internal/models"
+	"github.com/samzong/share-ai-platform/internal/repository"
 	"github.com/samzong/share-ai-platform/internal/utils"
 )
 
@@ -16,7 +23,13 @@ var (
 	emailRegex = regexp.MustCompile(`^[a-zA-Z0-9._%+\-]+@[a-zA-Z0-9.\-]+\.[a-zA-Z]{2,}$`)
 )
 
-type UserService struct{}
+type UserService struct {
+	users      repository.UserRepo
+	tokens     repository.TokenRepo
+	objects    repository.StoredObjectRepo
+	authorizer Authorizer
+	loginGuard *LoginGuard
+}
 
 type RegisterRequest struct {
 	Username string `json:"username" binding:"required,min=3"`
@@ -35,13 +48,19 @@ type UpdateProfileRequest struct {
 }
 
 type UserResponse struct {
-	ID       string      `json:"id"`
-	Username string      `json:"username"`
-	Email    string      `json:"email"`
-	Nickname string      `json:"nickname"`
-	Avatar   string      `json:"avatar"`
-	Role     models.Role `json:"role"`
-	Token    string      `json:"token,omitempty"`
+	ID          string            `json:"id"`
+	Username    string            `json:"username"`
+	Email       string            `json:"email"`
+	Nickname    string            `json:"nickname"`
+	Avatar      string            `json:"avatar"`
+	AvatarURLs  map[string]string `json:"avatar_urls,omitempty"` // 各尺寸头像地址，键为边长像素
+	Role        models.Role       `json:"role"`
+	TOTPEnabled bool              `json:"totp_enabled"`
+	Token       string            `json:"token,omitempty"`
+
+	// 启用两步验证时，登录只返回挑战令牌，需要调用 /auth/login/2fa 换取 Token
+	TwoFactorRequired bool   `json:"two_factor_required,omitempty"`
+	ChallengeToken    string `json:"challenge_token,omitempty"`
 }
 
 type ListUsersRequest struct {
@@ -49,9 +68,20 @@ type ListUsersRequest struct {
 	PageSize int `form:"page_size" binding:"required,min=1,max=100"`
 }
 
-// NewUserService creates a new UserService
+// NewUserService creates a new UserService backed by the database
 func NewUserService() *UserService {
-	return &UserService{}
+	return NewUserServiceWith(repository.NewGorm(database.GetDB()), defaultAuthorizer)
+}
+
+// NewUserServiceWith creates a UserService on the given repositories and authorizer
+func NewUserServiceWith(repos *repository.Repositories, az Authorizer) *UserService {
+	return &UserService{
+		users:      repos.Users,
+		tokens:     repos.Tokens,
+		objects:    repos.Objects,
+		authorizer: az,
+		loginGuard: defaultLoginGuard,
+	}
 }
 
 // Register creates a new user
@@ -66,16 +96,13 @@ func (s *UserService) Register(req *RegisterRequest) (*UserResponse, error) {
 		return nil, errors.New("password must be at least 6 characters")
 	}
 
-	db := database.GetDB()
-
 	// Check if username already exists
-	var existingUser models.User
-	if err := db.Where("username = ?", req.Username).First(&existingUser).Error; err == nil {
+	if _, err := s.users.FindByUsername(req.Username); err == nil {
 		return nil, errors.New("username already exists")
 	}
 
 	// Check if email already exists
-	if err := db.Where("email = ?", req.Email).First(&existingUser).Error; err == nil {
+	if _, err := s.users.FindByEmail(req.Email); err == nil {
 		return nil, errors.New("email already exists")
 	}
 
@@ -87,7 +114,7 @@ func (s *UserService) Register(req *RegisterRequest) (*UserResponse, error) {
 		Role:     models.RoleUser, // Default role
 	}
 
-	if err := db.Create(user).Error; err != nil {
+	if err := s.users.Create(user); err != nil {
 		return nil, err
 	}
 
@@ -98,29 +125,55 @@ func (s *UserService) Register(req *RegisterRequest) (*UserResponse, error) {
 	}
 
 	return &UserResponse{
-		ID:       user.ID,
-		Username: user.Username,
-		Email:    user.Email,
-		Nickname: user.Nickname,
-		Avatar:   utils.GetFileURL(user.Avatar),
-		Role:     user.Role,
-		Token:    token,
+		ID:         user.ID,
+		Username:   user.Username,
+		Email:      user.Email,
+		Nickname:   user.Nickname,
+		Avatar:     utils.GetFileURL(user.Avatar),
+		AvatarURLs: utils.AvatarURLs(user.Avatar),
+		Role:       user.Role,
+		Token:      token,
 	}, nil
 }
 
-// Login authenticates a user
-func (s *UserService) Login(req *LoginRequest) (*UserResponse, error) {
-	db := database.GetDB()
+// Login authenticates a user, rejecting attempts while the username or client IP is locked out
+func (s *UserService) Login(ctx context.Context, req *LoginRequest, clientIP string) (*UserResponse, error) {
+	if err := s.loginGuard.Check(ctx, req.Username, clientIP); err != nil {
+		return nil, err
+	}
 
-	var user models.User
-	if err := db.Where("username = ?", req.Username).First(&user).Error; err != nil {
+	user, err := s.users.FindByUsername(req.Username)
+	if err != nil {
+		s.recordLoginFailure(ctx, req.Username, clientIP)
 		return nil, errors.New("invalid username or password")
 	}
 
 	if err := user.ComparePassword(req.Password); err != nil {
+		s.recordLoginFailure(ctx, req.Username, clientIP)
 		return nil, errors.New("invalid username or password")
 	}
 
+	// Two-step login: issue a challenge token instead of an access token.
+	// Failure counters are kept until the second factor succeeds.
+	if user.TOTPEnabled {
+		challenge, err := middleware.GenerateChallengeToken(user.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		return &UserResponse{
+			ID:                user.ID,
+			Username:          user.Username,
+			TOTPEnabled:       true,
+			TwoFactorRequired: true,
+			ChallengeToken:    challenge,
+		}, nil
+	}
+
+	if err := s.loginGuard.RecordSuccess(ctx, req.Username); err != nil {
+		log.Printf("Error resetting login failures for %s: %v", req.Username, err)
+	}
+
 	// Generate token
 	token, err := middleware.GenerateToken(user.ID)
 	if err != nil {
@@ -128,51 +181,90 @@ func (s *UserService) Login(req *LoginRequest) (*UserResponse, error) {
 	}
 
 	return &UserResponse{
-		ID:       user.ID,
-		Username: user.Username,
-		Email:    user.Email,
-		Nickname: user.Nickname,
-		Avatar:   utils.GetFileURL(user.Avatar),
-		Role:     user.Role,
-		Token:    token,
+		ID:         user.ID,
+		Username:   user.Username,
+		Email:      user.Email,
+		Nickname:   user.Nickname,
+		Avatar:     utils.GetFileURL(user.Avatar),
+		AvatarURLs: utils.AvatarURLs(user.Avatar),
+		Role:       user.Role,
+		Token:      token,
 	}, nil
 }
 
+func (s *UserService) recordLoginFailure(ctx context.Context, username, clientIP string) {
+	if err := s.loginGuard.RecordFailure(ctx, username, clientIP); err != nil {
+		log.Printf("Error recording login failure for %s: %v", username, err)
+	}
+}
+
+// UnlockUser clears a user's login lockout
+func (s *UserService) UnlockUser(ctx context.Context, adminID string, userID string, clientIP string) error {
+	if err := s.authorizer.Check(adminID, models.PermUsersUnlock, authz.Global()); err != nil {
+		return err
+	}
+
+	user, err := s.users.FindByID(userID)
+	if err != nil {
+		return errors.New("user not found")
+	}
+
+	if err := s.loginGuard.Unlock(ctx, user.Username); err != nil {
+		return err
+	}
+
+	recordAudit(models.AuditLoginUnlock, adminID, "user:"+normalizeUsername(user.Username), clientIP, "login lockout cleared by admin")
+	return nil
+}
+
 // Logout invalidates a user's token
 func (s *UserService) Logout(ctx context.Context, userID string) error {
-	// 将token加入黑名单
+	// 吊销当前 token，直到其过期
 	token := middleware.GetTokenFromContext(ctx)
 	if token != "" {
-		return database.GetRedis().Set(ctx, "blacklist:"+token, userID, middleware.TokenExpiration).Err()
+		_, err := s.tokens.Revoke(middleware.TokenDigest(token), userID, time.Now().Add(middleware.TokenExpiration))
+		return err
 	}
 	return nil
 }
 
+// StartTokenCleanup deletes the state of expired tokens every interval in the background
+func StartTokenCleanup(interval time.Duration) {
+	tokens := repository.NewTokenRepo(database.GetDB())
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			if _, err := tokens.DeleteExpired(time.Now()); err != nil {
+				log.Printf("Error deleting expired token states: %v", err)
+			}
+		}
+	}()
+}
+
 // GetUserByID retrieves a user by ID
 func (s *UserService) GetUserByID(userID string) (*UserResponse, error) {
-	db := database.GetDB()
-
-	var user models.User
-	if err := db.First(&user, "id = ?", userID).Error; err != nil {
+	user, err := s.users.FindByID(userID)
+	if err != nil {
 		return nil, err
 	}
 
 	return &UserResponse{
-		ID:       user.ID,
-		Username: user.Username,
-		Email:    user.Email,
-		Nickname: user.Nickname,
-		Avatar:   utils.GetFileURL(user.Avatar),
-		Role:     user.Role,
+		ID:          user.ID,
+		Username:    user.Username,
+		Email:       user.Email,
+		Nickname:    user.Nickname,
+		Avatar:      utils.GetFileURL(user.Avatar),
+		AvatarURLs:  utils.AvatarURLs(user.Avatar),
+		Role:        user.Role,
+		TOTPEnabled: user.TOTPEnabled,
 	}, nil
 }
 
 // UpdateProfile updates user's profile information
 func (s *UserService) UpdateProfile(userID string, req *UpdateProfileRequest) (*UserResponse, error) {
-	db := database.GetDB()
-
-	var user models.User
-	if err := db.First(&user, "id = ?", userID).Error; err != nil {
+	user, err := s.users.FindByID(userID)
+	if err != nil {
 		return nil, errors.New("user not found")
 	}
 
@@ -182,31 +274,36 @@ func (s *UserService) UpdateProfile(userID string, req *UpdateProfileRequest) (*
 	}
 
 	// Handle avatar upload if provided
+	oldAvatar := user.Avatar
 	if req.Avatar != nil {
-		// Delete old avatar if exists
-		if user.Avatar != "" {
-			utils.DeleteFile(user.Avatar)
-		}
-
-		// Upload new avatar
-		avatarPath, err := utils.UploadFile(req.Avatar, "avatars")
+		// Upload new avatar; the old one is deleted once the user is saved
+		avatarPath, err := utils.UploadAvatar(req.Avatar)
 		if err != nil {
 			return nil, err
 		}
+		trackObjects(s.objects, models.StoredObjectAvatar, utils.AvatarKeys(avatarPath)...)
+		oldAvatar = user.Avatar
 		user.Avatar = avatarPath
 	}
 
-	if err := db.Save(&user).Error; err != nil {
+	if err := s.users.Save(user); err != nil {
+		if oldAvatar != user.Avatar {
+			releaseObjects(s.objects, utils.AvatarKeys(user.Avatar)...)
+		}
 		return nil, err
 	}
+	if oldAvatar != user.Avatar {
+		releaseObjects(s.objects, utils.AvatarKeys(oldAvatar)...)
+	}
 
 	return &UserResponse{
-		ID:       user.ID,
-		Username: user.Username,
-		Email:    user.Email,
-		Nickname: user.Nickname,
-		Avatar:   utils.GetFileURL(user.Avatar),
-		Role:     user.Role,
+		ID:         user.ID,
+		Username:   user.Username,
+		Email:      user.Email,
+		Nickname:   user.Nickname,
+		Avatar:     utils.GetFileURL(user.Avatar),
+		AvatarURLs: utils.AvatarURLs(user.Avatar),
+		Role:       user.Role,
 	}, nil
 }
 
@@ -216,83 +313,107 @@ func (s *UserService) UpdateUser(userID string, username string, email string) (
 		return nil, errors.New("invalid email format")
 	}
 
-	db := database.GetDB()
-	user := &models.User{}
-	if err := db.First(user, "id = ?", userID).Error; err != nil {
+	user, err := s.users.FindByID(userID)
+	if err != nil {
 		return nil, err
 	}
 
 	user.Username = username
 	user.Email = email
 
-	if err := db.Save(user).Error; err != nil {
+	if err := s.users.Save(user); err != nil {
 		return nil, err
 	}
 
 	return &UserResponse{
-		ID:       user.ID,
-		Username: user.Username,
-		Email:    user.Email,
-		Nickname: user.Nickname,
-		Avatar:   user.Avatar,
-		Role:     user.Role,
+		ID:         user.ID,
+		Username:   user.Username,
+		Email:      user.Email,
+		Nickname:   user.Nickname,
+		Avatar:     utils.GetFileURL(user.Avatar),
+		AvatarURLs: utils.AvatarURLs(user.Avatar),
+		Role:       user.Role,
 	}, nil
 }
 
-// UpdateUserRole updates a user's role (admin only)
+// UpdateUserRole updates a user's global role
 func (s *UserService) UpdateUserRole(adminID string, userID string, role models.Role) error {
-	db := database.GetDB()
-	// 验证管理员权限
-	admin := &models.User{}
-	if err := db.First(admin, "id = ?", adminID).Error; err != nil {
+	// 验证操作者权限
+	admin, err := s.users.FindByID(adminID)
+	if err != nil {
 		return err
 	}
-	if admin.Role != models.RoleAdmin {
-		return errors.New("permission denied: requires admin role")
+	if err := s.authorizer.Check(admin.ID, models.PermRolesBind, authz.Global()); err != nil {
+		return err
+	}
+	if viper.GetBool("auth.require_admin_2fa") && !admin.TOTPEnabled {
+		return errors.New("permission denied: two-factor authentication required for admins")
+	}
+	if adminID == userID {
+		return errors.New("cannot change your own role")
 	}
 
-	// 更新用户角色
-	user := &models.User{}
-	if err := db.First(user, "id = ?", userID).Error; err != nil {
+	exists, err := s.authorizer.RoleExists(role)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return errors.New("invalid role")
+	}
+
+	user, err := s.users.FindByID(userID)
+	if err != nil {
+		return err
+	}
+
+	// 不能授予超出自身权限的角色
+	canGrant, err := s.authorizer.CanGrant(admin.ID, role, authz.Global())
+	if err != nil {
+		return err
+	}
+	if !canGrant {
+		return fmt.Errorf("%w: cannot grant role %s", authz.ErrForbidden, role)
+	}
+
+	// 同样不能撤销超出自身权限的角色，例如降级管理员
+	canRevoke, err := s.authorizer.CanGrant(admin.ID, user.Role, authz.Global())
+	if err != nil {
 		return err
 	}
+	if !canRevoke {
+		return fmt.Errorf("%w: cannot revoke role %s", authz.ErrForbidden, user.Role)
+	}
 
+	// 更新用户角色
 	user.Role = role
-	if err := db.Save(user).Error; err != nil {
+	if err := s.users.Save(user); err != nil {
 		return err
 	}
 
 	return nil
 }
 
-// ListUsers returns a paginated list of users (admin only)
+// ListUsers returns a paginated list of users
 func (s *UserService) ListUsers(req *ListUsersRequest) (*struct {
 	Total int64          `json:"total"`
 	Users []UserResponse `json:"users"`
 }, error) {
-	db := database.GetDB()
-	var users []models.User
-	var total int64
-
 	offset := (req.Page - 1) * req.PageSize
-
-	if err := db.Model(&models.User{}).Count(&total).Error; err != nil {
-		return nil, err
-	}
-
-	if err := db.Offset(offset).Limit(req.PageSize).Find(&users).Error; err != nil {
+	users, total, err := s.users.List(offset, req.PageSize)
+	if err != nil {
 		return nil, err
 	}
 
 	userResponses := make([]UserResponse, len(users))
 	for i, user := range users {
 		userResponses[i] = UserResponse{
-			ID:       user.ID,
-			Username: user.Username,
-			Email:    user.Email,
-			Nickname: user.Nickname,
-			Avatar:   user.Avatar,
-			Role:     user.Role,
+			ID:         user.ID,
+			Username:   user.Username,
+			Email:      user.Email,
+			Nickname:   user.Nickname,
+			Avatar:     utils.GetFileURL(user.Avatar),
+			AvatarURLs: utils.AvatarURLs(user.Avatar),
+			Role:       user.Role,
 		}
 	}
 
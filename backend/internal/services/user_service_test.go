@@ -1,34 +1,83 @@
 package services
 
 import (
+	"bytes"
+	"context"
+	"image"
+	"image/png"
+	"mime/multipart"
+	"net/http/httptest"
+	"slices"
 	"testing"
 
-	"github.com/samzong/share-ai-platform/internal/database"
-	"github.com/samzong/share-ai-platform/internal/models"
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/viper"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/samzong/share-ai-platform/internal/authz"
+	"github.com/samzong/share-ai-platform/internal/middleware"
+	"github.com/samzong/share-ai-platform/internal/models"
+	"github.com/samzong/share-ai-platform/internal/repository"
+	"github.com/samzong/share-ai-platform/internal/utils"
 )
 
-func setupTest(t *testing.T) (*UserService, func()) {
-	db := database.SetupTestDB()
+// fakeAuthorizer grants every permission everywhere to admins and nothing to other users,
+// except roles.bind for binders, who may only grant their listed roles
+type fakeAuthorizer struct {
+	admins  map[string]bool
+	binders map[string][]models.Role
+}
 
-	// Auto migrate the schema
-	err := db.AutoMigrate(&models.User{})
-	assert.NoError(t, err)
+func newFakeAuthorizer() *fakeAuthorizer {
+	return &fakeAuthorizer{admins: make(map[string]bool), binders: make(map[string][]models.Role)}
+}
 
-	// Clear all records
-	err = db.Exec("TRUNCATE TABLE users RESTART IDENTITY CASCADE").Error
-	assert.NoError(t, err)
+func (a *fakeAuthorizer) Check(userID string, perm models.Permission, scope authz.Scope) error {
+	if a.admins[userID] {
+		return nil
+	}
+	if perm == models.PermRolesBind && a.binders[userID] != nil {
+		return nil
+	}
+	return authz.ErrForbidden
+}
 
-	service := NewUserService()
+func (a *fakeAuthorizer) CanGrant(userID string, role models.Role, scope authz.Scope) (bool, error) {
+	return a.admins[userID] || slices.Contains(a.binders[userID], role), nil
+}
 
-	return service, func() {
-		database.CleanupTestDB(db)
-	}
+func (a *fakeAuthorizer) RoleExists(role models.Role) (bool, error) {
+	return role == models.RoleUser || role == models.RoleAdmin, nil
+}
+
+func (a *fakeAuthorizer) OrgsWithPermission(userID string, perm models.Permission) (bool, []string, error) {
+	return a.admins[userID], nil, nil
+}
+
+func (a *fakeAuthorizer) ProjectsWithPermission(userID string, perm models.Permission) ([]string, error) {
+	return nil, nil
+}
+
+func (a *fakeAuthorizer) GroupIDs(userID string) ([]string, error) {
+	return nil, nil
+}
+
+func setupTest(t *testing.T) (*UserService, *repository.Memory, *fakeAuthorizer) {
+	viper.Set("server.jwt_algorithm", "HS256")
+	viper.Set("server.jwt_secret", "test-secret")
+	t.Cleanup(func() {
+		viper.Set("server.jwt_algorithm", "")
+		viper.Set("server.jwt_secret", "")
+	})
+
+	repos := repository.NewMemory()
+	az := newFakeAuthorizer()
+	return NewUserServiceWith(&repos.Repositories, az), repos, az
 }
 
 func TestUserService_Register(t *testing.T) {
-	service, cleanup := setupTest(t)
-	defer cleanup()
+	service, _, _ := setupTest(t)
 
 	tests := []struct {
 		name    string
@@ -44,6 +93,15 @@ func TestUserService_Register(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name: "duplicate username",
+			req: &RegisterRequest{
+				Username: "testuser",
+				Email:    "other@example.com",
+				Password: "password123",
+			},
+			wantErr: true,
+		},
 		{
 			name: "invalid email",
 			req: &RegisterRequest{
@@ -83,8 +141,7 @@ func TestUserService_Register(t *testing.T) {
 }
 
 func TestUserService_Login(t *testing.T) {
-	service, cleanup := setupTest(t)
-	defer cleanup()
+	service, _, _ := setupTest(t)
 
 	// First register a user
 	registerReq := &RegisterRequest{
@@ -128,7 +185,7 @@ func TestUserService_Login(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			resp, err := service.Login(tt.req)
+			resp, err := service.Login(context.Background(), tt.req, "127.0.0.1")
 			if tt.wantErr {
 				assert.Error(t, err)
 				assert.Nil(t, resp)
@@ -143,10 +200,7 @@ func TestUserService_Login(t *testing.T) {
 }
 
 func TestUserService_UpdateUserRole(t *testing.T) {
-	service, cleanup := setupTest(t)
-	defer cleanup()
-
-	db := database.GetDB()
+	service, repos, az := setupTest(t)
 
 	// Register an admin user
 	adminReq := &RegisterRequest{
@@ -156,6 +210,11 @@ func TestUserService_UpdateUserRole(t *testing.T) {
 	}
 	adminResp, err := service.Register(adminReq)
 	assert.NoError(t, err)
+	az.admins[adminResp.ID] = true
+	adminUser, err := repos.Users.FindByID(adminResp.ID)
+	assert.NoError(t, err)
+	adminUser.Role = models.RoleAdmin
+	assert.NoError(t, repos.Users.Save(adminUser))
 
 	// Register a normal user
 	userReq := &RegisterRequest{
@@ -166,15 +225,12 @@ func TestUserService_UpdateUserRole(t *testing.T) {
 	userResp, err := service.Register(userReq)
 	assert.NoError(t, err)
 
-	// Set admin role directly in the database
-	err = db.Model(&models.User{}).Where("id = ?", adminResp.ID).Update("role", models.RoleAdmin).Error
+	// A user who may bind only the user role
+	binderResp, err := service.Register(&RegisterRequest{Username: "binder", Email: "binder@example.com", Password: "binder123"})
 	assert.NoError(t, err)
-
-	// Verify admin role was set correctly
-	var adminUser models.User
-	err = db.First(&adminUser, "id = ?", adminResp.ID).Error
+	az.binders[binderResp.ID] = []models.Role{models.RoleUser}
+	peerResp, err := service.Register(&RegisterRequest{Username: "peer", Email: "peer@example.com", Password: "peer123"})
 	assert.NoError(t, err)
-	assert.Equal(t, models.RoleAdmin, adminUser.Role)
 
 	tests := []struct {
 		name    string
@@ -211,21 +267,108 @@ func TestUserService_UpdateUserRole(t *testing.T) {
 			newRole: "invalid_role",
 			wantErr: true,
 		},
+		{
+			name:    "binder can set a role it holds",
+			adminID: binderResp.ID,
+			userID:  peerResp.ID,
+			newRole: models.RoleUser,
+			wantErr: false,
+		},
+		{
+			name:    "binder cannot grant a role beyond its own",
+			adminID: binderResp.ID,
+			userID:  peerResp.ID,
+			newRole: models.RoleAdmin,
+			wantErr: true,
+		},
+		{
+			name:    "binder cannot demote an admin",
+			adminID: binderResp.ID,
+			userID:  adminResp.ID,
+			newRole: models.RoleUser,
+			wantErr: true,
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			err := service.UpdateUserRole(tt.adminID, tt.userID, tt.newRole)
+			before, err := repos.Users.FindByID(tt.userID)
+			assert.NoError(t, err)
+
+			err = service.UpdateUserRole(tt.adminID, tt.userID, tt.newRole)
 			if tt.wantErr {
 				assert.Error(t, err)
+				// A rejected change leaves the role untouched
+				user, err := repos.Users.FindByID(tt.userID)
+				assert.NoError(t, err)
+				assert.Equal(t, before.Role, user.Role)
 			} else {
 				assert.NoError(t, err)
 				// Verify the role was actually changed
-				var user models.User
-				err := db.First(&user, "id = ?", tt.userID).Error
+				user, err := repos.Users.FindByID(tt.userID)
 				assert.NoError(t, err)
 				assert.Equal(t, tt.newRole, user.Role)
 			}
 		})
 	}
 }
+
+func TestUserService_LogoutRevokesToken(t *testing.T) {
+	service, repos, _ := setupTest(t)
+
+	c := &gin.Context{}
+	c.Set("token", "access-token")
+	assert.NoError(t, service.Logout(c, "user-1"))
+
+	revoked, err := repos.Tokens.IsRevoked(middleware.TokenDigest("access-token"))
+	assert.NoError(t, err)
+	assert.True(t, revoked)
+}
+
+// avatarUpload returns a multipart PNG upload as received by the profile handler
+func avatarUpload(t *testing.T) *multipart.FileHeader {
+	var img bytes.Buffer
+	require.NoError(t, png.Encode(&img, image.NewRGBA(image.Rect(0, 0, 64, 64))))
+
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+	part, err := w.CreateFormFile("avatar", "me.png")
+	require.NoError(t, err)
+	part.Write(img.Bytes())
+	w.Close()
+
+	req := httptest.NewRequest("POST", "/", &body)
+	req.Header.Set("Content-Type", w.FormDataContentType())
+	require.NoError(t, req.ParseMultipartForm(utils.MaxFileSize))
+	return req.MultipartForm.File["avatar"][0]
+}
+
+func TestUserService_UpdateProfileReplacesAvatar(t *testing.T) {
+	service, repos, _ := setupTest(t)
+	setupStorage(t)
+
+	user := &models.User{Username: "avataruser", Email: "avatar@example.com", Password: "secret"}
+	require.NoError(t, repos.Users.Create(user))
+
+	_, err := service.UpdateProfile(user.ID, &UpdateProfileRequest{Avatar: avatarUpload(t)})
+	require.NoError(t, err)
+	user, err = repos.Users.FindByID(user.ID)
+	require.NoError(t, err)
+	first := utils.AvatarKeys(user.Avatar)
+	require.NotEmpty(t, first)
+	assert.ElementsMatch(t, first, storedKeys(t, repos))
+
+	// 新头像替换旧头像，旧文件的所有尺寸被删除并取消跟踪
+	_, err = service.UpdateProfile(user.ID, &UpdateProfileRequest{Avatar: avatarUpload(t)})
+	require.NoError(t, err)
+	user, err = repos.Users.FindByID(user.ID)
+	require.NoError(t, err)
+	second := utils.AvatarKeys(user.Avatar)
+	assert.ElementsMatch(t, second, storedKeys(t, repos))
+	for _, key := range first {
+		assertStored(t, key, false)
+	}
+	for _, key := range second {
+		assertStored(t, key, true)
+	}
+}
@@ -0,0 +1,188 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"mime"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Local stores objects as files under a root directory. It only works with a
+// single replica or a shared volume.
+type Local struct {
+	root    string
+	baseURL string
+	signer  *URLSigner
+}
+
+// NewLocal creates a filesystem backend rooted at root. Objects are served under baseURL.
+func NewLocal(root, baseURL string) *Local {
+	if baseURL == "" {
+		baseURL = "/uploads"
+	}
+	return &Local{root: root, baseURL: strings.TrimRight(baseURL, "/")}
+}
+
+func (l *Local) path(key string) (string, error) {
+	key, err := cleanKey(key)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(l.root, filepath.FromSlash(key)), nil
+}
+
+// Put writes the object to a temporary file and renames it into place
+func (l *Local) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	dst, err := l.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return fmt.Errorf("failed to create upload directory: %v", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(dst), ".upload-*")
+	if err != nil {
+		return fmt.Errorf("failed to create destination file: %v", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, r); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write file: %v", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write file: %v", err)
+	}
+	return os.Rename(tmp.Name(), dst)
+}
+
+// Get opens the object's file
+func (l *Local) Get(ctx context.Context, key string) (io.ReadCloser, *ObjectInfo, error) {
+	info, err := l.Stat(ctx, key)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	path, _ := l.path(key)
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, nil, translateFSError(err)
+	}
+	return f, info, nil
+}
+
+// Delete removes the object's file
+func (l *Local) Delete(ctx context.Context, key string) error {
+	path, err := l.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// WithSigner makes Presign return HMAC-signed expiring URLs for private objects
+func (l *Local) WithSigner(signer *URLSigner) *Local {
+	l.signer = signer
+	return l
+}
+
+// Presign returns the URL the object is served under. Public objects are served without
+// expiry; private objects get a signed URL valid until expiry when a signer is set.
+func (l *Local) Presign(ctx context.Context, key string, expiry time.Duration) (string, error) {
+	key, err := cleanKey(key)
+	if err != nil {
+		return "", err
+	}
+	return objectURL(l.baseURL, l.signer, key, expiry), nil
+}
+
+// Stat returns the object's size and modification time. The content type is
+// derived from the file extension.
+func (l *Local) Stat(ctx context.Context, key string) (*ObjectInfo, error) {
+	path, err := l.path(key)
+	if err != nil {
+		return nil, err
+	}
+
+	fi, err := os.Stat(path)
+	if err != nil {
+		return nil, translateFSError(err)
+	}
+	if fi.IsDir() {
+		return nil, ErrNotFound
+	}
+
+	contentType := mime.TypeByExtension(filepath.Ext(path))
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+
+	return &ObjectInfo{
+		Key:         key,
+		Size:        fi.Size(),
+		ContentType: contentType,
+		ModTime:     fi.ModTime(),
+	}, nil
+}
+
+// List walks the root directory, skipping temporary files of in-progress uploads
+func (l *Local) List(ctx context.Context, prefix string) ([]ObjectInfo, error) {
+	var objects []ObjectInfo
+	err := filepath.WalkDir(l.root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) && path == l.root {
+				return fs.SkipAll
+			}
+			return err
+		}
+		if d.IsDir() || strings.HasPrefix(d.Name(), ".upload-") {
+			return nil
+		}
+
+		rel, err := filepath.Rel(l.root, path)
+		if err != nil {
+			return err
+		}
+		key := filepath.ToSlash(rel)
+		if !strings.HasPrefix(key, prefix) {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		contentType := mime.TypeByExtension(filepath.Ext(path))
+		if contentType == "" {
+			contentType = "application/octet-stream"
+		}
+		objects = append(objects, ObjectInfo{
+			Key:         key,
+			Size:        info.Size(),
+			ContentType: contentType,
+			ModTime:     info.ModTime(),
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list objects: %v", err)
+	}
+	return objects, nil
+}
+
+func translateFSError(err error) error {
+	if errors.Is(err, fs.ErrNotExist) {
+		return ErrNotFound
+	}
+	return err
+}
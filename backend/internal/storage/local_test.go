@@ -0,0 +1,62 @@
+package storage
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocalStorage(t *testing.T) {
+	ctx := context.Background()
+	s := NewLocal(t.TempDir(), "http://localhost:8080/uploads/")
+
+	require.NoError(t, s.Put(ctx, "avatars/a.png", strings.NewReader("png"), 3, "image/png"))
+
+	info, err := s.Stat(ctx, "avatars/a.png")
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), info.Size)
+	assert.Equal(t, "image/png", info.ContentType)
+
+	r, _, err := s.Get(ctx, "avatars/a.png")
+	require.NoError(t, err)
+	body, _ := io.ReadAll(r)
+	r.Close()
+	assert.Equal(t, "png", string(body))
+
+	require.NoError(t, s.Put(ctx, "readme/r.md", strings.NewReader("# r"), 3, "text/markdown"))
+	objects, err := s.List(ctx, "avatars/")
+	require.NoError(t, err)
+	require.Len(t, objects, 1)
+	assert.Equal(t, "avatars/a.png", objects[0].Key)
+
+	url, err := s.Presign(ctx, "avatars/a.png", 0)
+	require.NoError(t, err)
+	assert.Equal(t, "http://localhost:8080/uploads/avatars/a.png", url)
+
+	require.NoError(t, s.Delete(ctx, "avatars/a.png"))
+	_, err = s.Stat(ctx, "avatars/a.png")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	// 删除不存在的对象不报错
+	assert.NoError(t, s.Delete(ctx, "avatars/a.png"))
+}
+
+func TestLocalStorageRejectsTraversal(t *testing.T) {
+	ctx := context.Background()
+	s := NewLocal(t.TempDir(), "")
+
+	for _, key := range []string{"../etc/passwd", "avatars/../../x", "", "a//b"} {
+		assert.Error(t, s.Put(ctx, key, strings.NewReader("x"), 1, "text/plain"), key)
+	}
+}
+
+func TestLocalListMissingRoot(t *testing.T) {
+	s := NewLocal(t.TempDir()+"/missing", "")
+	objects, err := s.List(context.Background(), "")
+	assert.NoError(t, err)
+	assert.Empty(t, objects)
+}
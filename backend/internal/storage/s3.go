@@ -0,0 +1,154 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+// S3Config configures an S3-compatible backend such as AWS S3 or MinIO
+type S3Config struct {
+	Endpoint  string // 例如 s3.amazonaws.com 或 localhost:9000，不含协议
+	Region    string
+	Bucket    string
+	AccessKey string
+	SecretKey string
+	UseSSL    bool
+	BaseURL   string // 文件对外访问地址，由本服务的 /uploads 从存储桶读取后返回
+}
+
+// S3 stores objects in a bucket of an S3-compatible service. Downloads go through this
+// service, so the bucket endpoint never has to be reachable by clients.
+type S3 struct {
+	client  *minio.Client
+	bucket  string
+	baseURL string
+	signer  *URLSigner
+}
+
+// NewS3 creates an S3-compatible backend. The bucket must already exist.
+func NewS3(cfg S3Config) (*S3, error) {
+	if cfg.Endpoint == "" || cfg.Bucket == "" {
+		return nil, errors.New("s3 storage requires an endpoint and a bucket")
+	}
+	if cfg.Region == "" {
+		cfg.Region = "us-east-1"
+	}
+	if cfg.BaseURL == "" {
+		cfg.BaseURL = "/uploads"
+	}
+
+	client, err := minio.New(cfg.Endpoint, &minio.Options{
+		Creds:  credentials.NewStaticV4(cfg.AccessKey, cfg.SecretKey, ""),
+		Secure: cfg.UseSSL,
+		Region: cfg.Region,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create s3 client: %v", err)
+	}
+	return &S3{client: client, bucket: cfg.Bucket, baseURL: strings.TrimRight(cfg.BaseURL, "/")}, nil
+}
+
+// WithSigner makes Presign return HMAC-signed expiring URLs for private objects
+func (s *S3) WithSigner(signer *URLSigner) *S3 {
+	s.signer = signer
+	return s
+}
+
+// Put uploads the object
+func (s *S3) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	key, err := cleanKey(key)
+	if err != nil {
+		return err
+	}
+	_, err = s.client.PutObject(ctx, s.bucket, key, r, size, minio.PutObjectOptions{ContentType: contentType})
+	if err != nil {
+		return fmt.Errorf("failed to upload object: %v", err)
+	}
+	return nil
+}
+
+// Get downloads the object
+func (s *S3) Get(ctx context.Context, key string) (io.ReadCloser, *ObjectInfo, error) {
+	info, err := s.Stat(ctx, key)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	obj, err := s.client.GetObject(ctx, s.bucket, info.Key, minio.GetObjectOptions{})
+	if err != nil {
+		return nil, nil, translateS3Error(err)
+	}
+	return obj, info, nil
+}
+
+// Delete removes the object
+func (s *S3) Delete(ctx context.Context, key string) error {
+	key, err := cleanKey(key)
+	if err != nil {
+		return err
+	}
+	if err := s.client.RemoveObject(ctx, s.bucket, key, minio.RemoveObjectOptions{}); err != nil {
+		return translateS3Error(err)
+	}
+	return nil
+}
+
+// Presign returns the URL the object is served under by this service. Public objects
+// are served without expiry; private objects get a signed URL valid until expiry.
+func (s *S3) Presign(ctx context.Context, key string, expiry time.Duration) (string, error) {
+	key, err := cleanKey(key)
+	if err != nil {
+		return "", err
+	}
+	return objectURL(s.baseURL, s.signer, key, expiry), nil
+}
+
+// Stat returns the object's metadata
+func (s *S3) Stat(ctx context.Context, key string) (*ObjectInfo, error) {
+	key, err := cleanKey(key)
+	if err != nil {
+		return nil, err
+	}
+	info, err := s.client.StatObject(ctx, s.bucket, key, minio.StatObjectOptions{})
+	if err != nil {
+		return nil, translateS3Error(err)
+	}
+	return &ObjectInfo{
+		Key:         key,
+		Size:        info.Size,
+		ContentType: info.ContentType,
+		ModTime:     info.LastModified,
+	}, nil
+}
+
+// List returns the bucket's objects under prefix
+func (s *S3) List(ctx context.Context, prefix string) ([]ObjectInfo, error) {
+	var objects []ObjectInfo
+	for obj := range s.client.ListObjects(ctx, s.bucket, minio.ListObjectsOptions{Prefix: prefix, Recursive: true}) {
+		if obj.Err != nil {
+			return nil, fmt.Errorf("failed to list objects: %v", obj.Err)
+		}
+		objects = append(objects, ObjectInfo{
+			Key:         obj.Key,
+			Size:        obj.Size,
+			ContentType: obj.ContentType,
+			ModTime:     obj.LastModified,
+		})
+	}
+	return objects, nil
+}
+
+func translateS3Error(err error) error {
+	switch minio.ToErrorResponse(err).Code {
+	case "NoSuchKey", "NotFound":
+		return ErrNotFound
+	}
+	return err
+}
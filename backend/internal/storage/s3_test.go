@@ -0,0 +1,195 @@
+package storage
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/xml"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeS3 is a minimal in-process S3 server supporting path-style object PUT/GET/HEAD/DELETE
+type fakeS3 struct {
+	mu      sync.Mutex
+	objects map[string]fakeObject
+}
+
+type fakeObject struct {
+	data        []byte
+	contentType string
+	modTime     time.Time
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	key := strings.TrimPrefix(r.URL.Path, "/")
+	if r.Method == http.MethodGet && r.URL.Query().Get("list-type") == "2" {
+		f.list(w, strings.TrimSuffix(key, "/"), r.URL.Query().Get("prefix"))
+		return
+	}
+
+	switch r.Method {
+	case http.MethodPut:
+		data, err := readS3Body(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		f.objects[key] = fakeObject{data: data, contentType: r.Header.Get("Content-Type"), modTime: time.Now()}
+		w.Header().Set("ETag", `"fake"`)
+	case http.MethodGet, http.MethodHead:
+		obj, ok := f.objects[key]
+		if !ok {
+			w.Header().Set("Content-Type", "application/xml")
+			w.WriteHeader(http.StatusNotFound)
+			if r.Method == http.MethodGet {
+				io.WriteString(w, `<Error><Code>NoSuchKey</Code><Message>not found</Message></Error>`)
+			}
+			return
+		}
+		w.Header().Set("Content-Type", obj.contentType)
+		w.Header().Set("Content-Length", strconv.Itoa(len(obj.data)))
+		w.Header().Set("Last-Modified", obj.modTime.UTC().Format(http.TimeFormat))
+		w.Header().Set("ETag", `"fake"`)
+		if r.Method == http.MethodGet {
+			w.Write(obj.data)
+		}
+	case http.MethodDelete:
+		delete(f.objects, key)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (f *fakeS3) list(w http.ResponseWriter, bucket string, prefix string) {
+	type content struct {
+		Key          string
+		LastModified string
+		ETag         string
+		Size         int
+		StorageClass string
+	}
+	result := struct {
+		XMLName     xml.Name `xml:"ListBucketResult"`
+		Name        string
+		Prefix      string
+		KeyCount    int
+		MaxKeys     int
+		IsTruncated bool
+		Contents    []content
+	}{Name: bucket, Prefix: prefix, MaxKeys: 1000}
+
+	for key, obj := range f.objects {
+		name := strings.TrimPrefix(key, bucket+"/")
+		if name == key || !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		result.Contents = append(result.Contents, content{
+			Key:          name,
+			LastModified: obj.modTime.UTC().Format("2006-01-02T15:04:05.000Z"),
+			ETag:         `"fake"`,
+			Size:         len(obj.data),
+			StorageClass: "STANDARD",
+		})
+	}
+	result.KeyCount = len(result.Contents)
+
+	w.Header().Set("Content-Type", "application/xml")
+	xml.NewEncoder(w).Encode(result)
+}
+
+// readS3Body decodes aws-chunked streaming uploads used by the client over plain HTTP
+func readS3Body(r *http.Request) ([]byte, error) {
+	if !strings.HasPrefix(r.Header.Get("X-Amz-Content-Sha256"), "STREAMING-") {
+		return io.ReadAll(r.Body)
+	}
+
+	var out bytes.Buffer
+	br := bufio.NewReader(r.Body)
+	for {
+		line, err := br.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		sizeHex := strings.SplitN(strings.TrimSpace(line), ";", 2)[0]
+		size, err := strconv.ParseInt(sizeHex, 16, 64)
+		if err != nil {
+			return nil, err
+		}
+		if size == 0 {
+			return out.Bytes(), nil
+		}
+		if _, err := io.CopyN(&out, br, size); err != nil {
+			return nil, err
+		}
+		br.ReadString('\n')
+	}
+}
+
+func TestS3Storage(t *testing.T) {
+	server := httptest.NewServer(&fakeS3{objects: make(map[string]fakeObject)})
+	defer server.Close()
+
+	s, err := NewS3(S3Config{
+		Endpoint:  strings.TrimPrefix(server.URL, "http://"),
+		Bucket:    "uploads",
+		AccessKey: "test",
+		SecretKey: "testsecret",
+		BaseURL:   "https://hub.example.com/uploads",
+	})
+	require.NoError(t, err)
+	signer := NewURLSigner("secret")
+	s.WithSigner(signer)
+
+	ctx := context.Background()
+	content := "# README"
+	require.NoError(t, s.Put(ctx, "readme/r.md", strings.NewReader(content), int64(len(content)), "text/markdown"))
+
+	info, err := s.Stat(ctx, "readme/r.md")
+	require.NoError(t, err)
+	assert.Equal(t, int64(len(content)), info.Size)
+	assert.Equal(t, "text/markdown", info.ContentType)
+
+	r, _, err := s.Get(ctx, "readme/r.md")
+	require.NoError(t, err)
+	body, _ := io.ReadAll(r)
+	r.Close()
+	assert.Equal(t, content, string(body))
+
+	objects, err := s.List(ctx, "readme/")
+	require.NoError(t, err)
+	require.Len(t, objects, 1)
+	assert.Equal(t, "readme/r.md", objects[0].Key)
+	assert.Equal(t, int64(len(content)), objects[0].Size)
+
+	// 下载地址指向本服务而不是存储桶，只有私有对象带签名
+	public, err := s.Presign(ctx, "avatars/a.png", time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, "https://hub.example.com/uploads/avatars/a.png", public)
+
+	private, err := s.Presign(ctx, "readme/r.md", time.Minute)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(private, "https://hub.example.com/uploads/readme/r.md?"))
+	assert.NotContains(t, private, server.URL)
+	u, err := url.Parse(private)
+	require.NoError(t, err)
+	assert.NoError(t, signer.Verify("readme/r.md", u.Query()))
+
+	require.NoError(t, s.Delete(ctx, "readme/r.md"))
+	_, err = s.Stat(ctx, "readme/r.md")
+	assert.ErrorIs(t, err, ErrNotFound)
+}
@@ -0,0 +1,72 @@
+package storage
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrInvalidSignature is returned for missing, forged or expired signed URLs
+	ErrInvalidSignature = errors.New("invalid or expired signature")
+
+	// PrivatePrefixes 这些前缀下的对象只能通过签名地址下载，例如私有镜像的 README
+	PrivatePrefixes = []string{"readme/"}
+)
+
+// IsPrivateKey reports whether the object may only be downloaded with a signed URL
+func IsPrivateKey(key string) bool {
+	key = strings.TrimLeft(key, "/")
+	for _, prefix := range PrivatePrefixes {
+		if strings.HasPrefix(key, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// URLSigner signs download URLs of objects served by this service with an HMAC over the
+// object key and expiry time
+type URLSigner struct {
+	secret []byte
+}
+
+// NewURLSigner creates a signer with the given secret
+func NewURLSigner(secret string) *URLSigner {
+	return &URLSigner{secret: []byte(secret)}
+}
+
+func (s *URLSigner) signature(key string, expires int64) string {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte(key))
+	mac.Write([]byte{'\n'})
+	mac.Write([]byte(strconv.FormatInt(expires, 10)))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Sign returns the query parameters that grant access to key until expires
+func (s *URLSigner) Sign(key string, expires time.Time) url.Values {
+	exp := expires.Unix()
+	return url.Values{
+		"expires":   {strconv.FormatInt(exp, 10)},
+		"signature": {s.signature(key, exp)},
+	}
+}
+
+// Verify checks the expires and signature query parameters of a signed URL for key
+func (s *URLSigner) Verify(key string, query url.Values) error {
+	exp, err := strconv.ParseInt(query.Get("expires"), 10, 64)
+	if err != nil || time.Now().Unix() > exp {
+		return ErrInvalidSignature
+	}
+	expected := s.signature(key, exp)
+	if !hmac.Equal([]byte(expected), []byte(query.Get("signature"))) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+package storage
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestURLSigner(t *testing.T) {
+	signer := NewURLSigner("secret")
+	query := signer.Sign("readme/a.md", time.Now().Add(time.Minute))
+
+	assert.NoError(t, signer.Verify("readme/a.md", query))
+	assert.ErrorIs(t, signer.Verify("readme/b.md", query), ErrInvalidSignature)
+	assert.ErrorIs(t, NewURLSigner("other").Verify("readme/a.md", query), ErrInvalidSignature)
+	assert.ErrorIs(t, signer.Verify("readme/a.md", url.Values{}), ErrInvalidSignature)
+
+	expired := signer.Sign("readme/a.md", time.Now().Add(-time.Second))
+	assert.ErrorIs(t, signer.Verify("readme/a.md", expired), ErrInvalidSignature)
+}
+
+func TestLocalPresignSignsPrivateKeys(t *testing.T) {
+	ctx := context.Background()
+	signer := NewURLSigner("secret")
+	s := NewLocal(t.TempDir(), "https://hub.example.com/uploads").WithSigner(signer)
+
+	public, err := s.Presign(ctx, "avatars/a.png", time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, "https://hub.example.com/uploads/avatars/a.png", public)
+
+	private, err := s.Presign(ctx, "readme/a.md", time.Minute)
+	require.NoError(t, err)
+	u, err := url.Parse(private)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(private, "https://hub.example.com/uploads/readme/a.md?"))
+	assert.NoError(t, signer.Verify("readme/a.md", u.Query()))
+}
+
+func TestInitRequiresSigningSecret(t *testing.T) {
+	storageOnce = sync.Once{}
+	viper.Set("storage.url_signing_secret", "")
+	t.Cleanup(func() { storageOnce = sync.Once{} })
+
+	assert.EqualError(t, Init(), "storage.url_signing_secret or STORAGE_URL_SIGNING_SECRET must be set")
+}
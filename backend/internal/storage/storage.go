@@ -0,0 +1,176 @@
+// Package storage stores uploaded files such as avatars and READMEs behind a
+// backend-agnostic interface, so that every replica sees the same objects.
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// ErrNotFound is returned when an object does not exist
+var ErrNotFound = errors.New("object not found")
+
+const (
+	DriverLocal = "local"
+	DriverS3    = "s3"
+
+	// DefaultPresignExpiry 是预签名地址的默认有效期
+	DefaultPresignExpiry = 15 * time.Minute
+)
+
+// ObjectInfo describes a stored object
+type ObjectInfo struct {
+	Key         string
+	Size        int64
+	ContentType string
+	ModTime     time.Time
+}
+
+// Storage is an object store addressed by slash-separated keys such as "avatars/x.png"
+type Storage interface {
+	// Put stores the content of r under key, replacing any existing object
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	// Get opens the object for reading. The caller must close the reader.
+	Get(ctx context.Context, key string) (io.ReadCloser, *ObjectInfo, error)
+	// Delete removes the object. Deleting a missing object is not an error.
+	Delete(ctx context.Context, key string) error
+	// Presign returns a URL that grants read access to the object until expiry
+	Presign(ctx context.Context, key string, expiry time.Duration) (string, error)
+	// Stat returns the object's metadata without reading its content
+	Stat(ctx context.Context, key string) (*ObjectInfo, error)
+	// List returns every object whose key starts with prefix
+	List(ctx context.Context, prefix string) ([]ObjectInfo, error)
+}
+
+var (
+	defaultStorage Storage
+	defaultSigner  *URLSigner
+	storageOnce    sync.Once
+	storageErr     error
+)
+
+// Init creates the storage backend configured under "storage". It is safe to call more than once.
+func Init() error {
+	storageOnce.Do(func() {
+		// 没有固定密钥时签名地址可被伪造或在重启后失效，因此拒绝启动。
+		// 密钥也可通过环境变量提供，避免写入配置文件
+		viper.BindEnv("storage.url_signing_secret", "STORAGE_URL_SIGNING_SECRET")
+		secret := viper.GetString("storage.url_signing_secret")
+		if secret == "" {
+			storageErr = errors.New("storage.url_signing_secret or STORAGE_URL_SIGNING_SECRET must be set")
+			return
+		}
+		defaultSigner = NewURLSigner(secret)
+		defaultStorage, storageErr = New(viper.GetString("storage.driver"), defaultSigner)
+		if storageErr == nil {
+			log.Printf("Using %s storage backend", driverName(viper.GetString("storage.driver")))
+		}
+	})
+	return storageErr
+}
+
+// Get returns the configured storage backend, initializing it on first use
+func Get() (Storage, error) {
+	if err := Init(); err != nil {
+		return nil, err
+	}
+	return defaultStorage, nil
+}
+
+// Signer returns the signer for download URLs served by this service
+func Signer() (*URLSigner, error) {
+	if err := Init(); err != nil {
+		return nil, err
+	}
+	return defaultSigner, nil
+}
+
+// PresignExpiry returns how long signed download URLs stay valid
+func PresignExpiry() time.Duration {
+	if seconds := viper.GetInt("storage.signed_url_expiry"); seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	return DefaultPresignExpiry
+}
+
+// PublicURL returns the externally reachable base URL of this service, without a trailing slash
+func PublicURL() string {
+	return strings.TrimRight(viper.GetString("server.public_url"), "/")
+}
+
+// New creates a storage backend of the given driver from configuration. Objects of every
+// backend are downloaded through this service's /uploads endpoint; the signer is used for
+// private objects.
+func New(driver string, signer *URLSigner) (Storage, error) {
+	switch driverName(driver) {
+	case DriverLocal:
+		root := viper.GetString("storage.local.root")
+		if root == "" {
+			root = "uploads"
+		}
+		baseURL := viper.GetString("storage.local.base_url")
+		if baseURL == "" {
+			baseURL = PublicURL() + "/uploads"
+		}
+		return NewLocal(root, baseURL).WithSigner(signer), nil
+	case DriverS3:
+		s, err := NewS3(S3Config{
+			Endpoint:  viper.GetString("storage.s3.endpoint"),
+			Region:    viper.GetString("storage.s3.region"),
+			Bucket:    viper.GetString("storage.s3.bucket"),
+			AccessKey: viper.GetString("storage.s3.access_key"),
+			SecretKey: viper.GetString("storage.s3.secret_key"),
+			UseSSL:    viper.GetBool("storage.s3.use_ssl"),
+			BaseURL:   PublicURL() + "/uploads",
+		})
+		if err != nil {
+			return nil, err
+		}
+		return s.WithSigner(signer), nil
+	default:
+		return nil, fmt.Errorf("unknown storage driver: %s", driver)
+	}
+}
+
+// objectURL returns the URL an object is served under by this service. Public objects
+// are served without expiry; private objects get a signed URL valid until expiry when
+// a signer is set.
+func objectURL(baseURL string, signer *URLSigner, key string, expiry time.Duration) string {
+	u := baseURL + "/" + key
+	if signer == nil || !IsPrivateKey(key) {
+		return u
+	}
+	if expiry <= 0 {
+		expiry = DefaultPresignExpiry
+	}
+	return u + "?" + signer.Sign(key, time.Now().Add(expiry)).Encode()
+}
+
+func driverName(driver string) string {
+	if driver == "" {
+		return DriverLocal
+	}
+	return strings.ToLower(driver)
+}
+
+// cleanKey rejects keys that could escape the storage root
+func cleanKey(key string) (string, error) {
+	key = strings.TrimLeft(key, "/")
+	if key == "" {
+		return "", errors.New("empty object key")
+	}
+	for _, part := range strings.Split(key, "/") {
+		if part == "" || part == "." || part == ".." {
+			return "", fmt.Errorf("invalid object key: %s", key)
+		}
+	}
+	return key, nil
+}
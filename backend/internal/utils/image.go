@@ -0,0 +1,192 @@
+package utils
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"path"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"golang.org/x/image/draw"
+
+	"github.com/samzong/share-ai-platform/internal/storage"
+)
+
+const (
+	// MaxImageDimension 限制上传图片的宽高，避免解码超大图片耗尽内存
+	MaxImageDimension = 4096
+)
+
+// AvatarSizes 头像变体的边长（像素），最后一个为默认尺寸
+var AvatarSizes = []int{64, 256}
+
+var imageExts = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/gif":  ".gif",
+}
+
+// avatarKeyPattern matches avatar variant keys such as avatars/<id>/256.png
+var avatarKeyPattern = regexp.MustCompile(`^(avatars/[0-9]{8}_[0-9a-f-]{36})/([0-9]+)(\.[a-z]+)$`)
+
+var errInvalidImage = errors.New("file is not a valid image")
+
+// decodeImage reads an uploaded image, identifying its type from magic bytes rather than the
+// client-sent Content-Type, and fully decodes it so malformed or polyglot files are rejected
+func decodeImage(file *multipart.FileHeader) (image.Image, string, error) {
+	if file.Size > MaxFileSize {
+		return nil, "", fmt.Errorf("file size exceeds maximum limit of %d bytes", MaxFileSize)
+	}
+
+	src, err := file.Open()
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to open uploaded file: %v", err)
+	}
+	defer src.Close()
+
+	data, err := io.ReadAll(io.LimitReader(src, MaxFileSize+1))
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read uploaded file: %v", err)
+	}
+	if len(data) > MaxFileSize {
+		return nil, "", fmt.Errorf("file size exceeds maximum limit of %d bytes", MaxFileSize)
+	}
+
+	contentType := http.DetectContentType(data)
+	if !AllowedImageTypes[contentType] {
+		return nil, "", fmt.Errorf("file type not allowed")
+	}
+
+	config, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil || "image/"+format != contentType {
+		return nil, "", errInvalidImage
+	}
+	if config.Width <= 0 || config.Height <= 0 || config.Width > MaxImageDimension || config.Height > MaxImageDimension {
+		return nil, "", fmt.Errorf("image dimensions must not exceed %dx%d", MaxImageDimension, MaxImageDimension)
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", errInvalidImage
+	}
+	return img, contentType, nil
+}
+
+// encodeImage re-encodes img, which drops EXIF and any trailing data of the original file.
+// GIFs are re-encoded as PNG so only the first frame is kept.
+func encodeImage(img image.Image, contentType string) ([]byte, string, error) {
+	var buf bytes.Buffer
+	var err error
+	switch contentType {
+	case "image/jpeg":
+		err = jpeg.Encode(&buf, img, &jpeg.Options{Quality: 85})
+	default:
+		contentType = "image/png"
+		err = png.Encode(&buf, img)
+	}
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to encode image: %v", err)
+	}
+	return buf.Bytes(), contentType, nil
+}
+
+// squareThumbnail center-crops img to a square and scales it to size x size
+func squareThumbnail(img image.Image, size int) image.Image {
+	b := img.Bounds()
+	side := b.Dx()
+	if b.Dy() < side {
+		side = b.Dy()
+	}
+	crop := image.Rect(0, 0, side, side).Add(image.Pt(b.Min.X+(b.Dx()-side)/2, b.Min.Y+(b.Dy()-side)/2))
+
+	dst := image.NewRGBA(image.Rect(0, 0, size, size))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), img, crop, draw.Src, nil)
+	return dst
+}
+
+// UploadAvatar validates an uploaded avatar, renders a square variant for each of AvatarSizes
+// and stores them. It returns the key of the default (largest) variant.
+func UploadAvatar(file *multipart.FileHeader) (string, error) {
+	img, contentType, err := decodeImage(file)
+	if err != nil {
+		return "", err
+	}
+
+	store, err := storage.Get()
+	if err != nil {
+		return "", fmt.Errorf("failed to initialize storage: %v", err)
+	}
+
+	dir := path.Join("avatars", fmt.Sprintf("%s_%s", time.Now().Format("20060102"), uuid.New().String()))
+	var key string
+	for _, size := range AvatarSizes {
+		data, variantType, err := encodeImage(squareThumbnail(img, size), contentType)
+		if err != nil {
+			return "", err
+		}
+		key = path.Join(dir, strconv.Itoa(size)+imageExts[variantType])
+		if err := store.Put(context.Background(), key, bytes.NewReader(data), int64(len(data)), variantType); err != nil {
+			DeleteAvatar(key)
+			return "", fmt.Errorf("failed to store file: %v", err)
+		}
+	}
+	return key, nil
+}
+
+// AvatarVariantKey returns the key of the size variant of an avatar. Avatars uploaded before
+// variants existed only have the original file, which is returned for every size.
+func AvatarVariantKey(key string, size int) string {
+	m := avatarKeyPattern.FindStringSubmatch(key)
+	if m == nil {
+		return key
+	}
+	return m[1] + "/" + strconv.Itoa(size) + m[3]
+}
+
+// AvatarURLs returns the URL of every avatar variant keyed by size
+func AvatarURLs(key string) map[string]string {
+	if key == "" {
+		return nil
+	}
+	urls := make(map[string]string, len(AvatarSizes))
+	for _, size := range AvatarSizes {
+		urls[strconv.Itoa(size)] = GetFileURL(AvatarVariantKey(key, size))
+	}
+	return urls
+}
+
+// AvatarKeys returns the keys of every stored variant of an avatar
+func AvatarKeys(key string) []string {
+	if key == "" {
+		return nil
+	}
+	if !avatarKeyPattern.MatchString(key) {
+		return []string{key}
+	}
+	keys := make([]string, len(AvatarSizes))
+	for i, size := range AvatarSizes {
+		keys[i] = AvatarVariantKey(key, size)
+	}
+	return keys
+}
+
+// DeleteAvatar deletes every variant of an avatar
+func DeleteAvatar(key string) error {
+	for _, k := range AvatarKeys(key) {
+		if err := DeleteFile(k); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,103 @@
+package utils
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/color"
+	"image/png"
+	"mime/multipart"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/samzong/share-ai-platform/internal/storage"
+)
+
+func fileHeader(t *testing.T, filename string, contentType string, data []byte) *multipart.FileHeader {
+	t.Helper()
+
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+	header := make(map[string][]string)
+	header["Content-Disposition"] = []string{`form-data; name="file"; filename="` + filename + `"`}
+	header["Content-Type"] = []string{contentType}
+	part, err := w.CreatePart(header)
+	require.NoError(t, err)
+	part.Write(data)
+	w.Close()
+
+	req := httptest.NewRequest("POST", "/", &body)
+	req.Header.Set("Content-Type", w.FormDataContentType())
+	require.NoError(t, req.ParseMultipartForm(MaxFileSize))
+	return req.MultipartForm.File["file"][0]
+}
+
+func pngBytes(t *testing.T, width, height int) []byte {
+	t.Helper()
+
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for x := 0; x < width; x++ {
+		img.Set(x, 0, color.RGBA{R: 255, A: 255})
+	}
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, img))
+	return buf.Bytes()
+}
+
+func TestDecodeImageSniffsContent(t *testing.T) {
+	// 声明为 PNG 的 HTML 文件
+	_, _, err := decodeImage(fileHeader(t, "a.png", "image/png", []byte("<html><script>alert(1)</script></html>")))
+	assert.Error(t, err)
+
+	// 文件头正确但内容损坏
+	data := pngBytes(t, 8, 8)
+	_, _, err = decodeImage(fileHeader(t, "a.png", "image/png", data[:40]))
+	assert.Error(t, err)
+
+	// 声明为 JPEG 的 PNG 以实际类型为准
+	_, contentType, err := decodeImage(fileHeader(t, "a.jpg", "image/jpeg", data))
+	require.NoError(t, err)
+	assert.Equal(t, "image/png", contentType)
+}
+
+func TestUploadAvatarStoresVariants(t *testing.T) {
+	viper.Set("storage.local.root", t.TempDir())
+	viper.Set("storage.local.base_url", "/uploads")
+	viper.Set("storage.url_signing_secret", "test-secret")
+	store, err := storage.Get()
+	require.NoError(t, err)
+
+	// 追加在图片后的数据在重新编码后被丢弃
+	data := append(pngBytes(t, 300, 200), []byte("<?php echo 1; ?>")...)
+	key, err := UploadAvatar(fileHeader(t, "me.png", "image/png", data))
+	require.NoError(t, err)
+	assert.Regexp(t, `^avatars/\d{8}_[0-9a-f-]{36}/256\.png$`, key)
+
+	for _, size := range AvatarSizes {
+		r, _, err := store.Get(context.Background(), AvatarVariantKey(key, size))
+		require.NoError(t, err)
+		var buf bytes.Buffer
+		buf.ReadFrom(r)
+		r.Close()
+
+		assert.NotContains(t, buf.String(), "<?php")
+		img, err := png.Decode(&buf)
+		require.NoError(t, err)
+		assert.Equal(t, image.Rect(0, 0, size, size), img.Bounds())
+	}
+
+	urls := AvatarURLs(key)
+	assert.Equal(t, "/uploads/"+AvatarVariantKey(key, 64), urls["64"])
+	assert.Equal(t, "/uploads/"+key, urls["256"])
+
+	require.NoError(t, DeleteAvatar(key))
+	_, err = store.Stat(context.Background(), AvatarVariantKey(key, 64))
+	assert.ErrorIs(t, err, storage.ErrNotFound)
+
+	// 旧头像没有尺寸变体
+	assert.Equal(t, "avatars/20240101_x.png", AvatarVariantKey("avatars/20240101_x.png", 64))
+}
@@ -0,0 +1,107 @@
+package utils
+
+import (
+	"bytes"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/microcosm-cc/bluemonday"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+	"github.com/yuin/goldmark/parser"
+	goldmarkhtml "github.com/yuin/goldmark/renderer/html"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+var markdown = goldmark.New(
+	goldmark.WithExtensions(extension.GFM),
+	goldmark.WithParserOptions(parser.WithAutoHeadingID()),
+	// 允许 README 中的原始 HTML，渲染后统一由 sanitizer 过滤
+	goldmark.WithRendererOptions(goldmarkhtml.WithUnsafe()),
+)
+
+var markdownPolicy = func() *bluemonday.Policy {
+	p := bluemonday.UGCPolicy()
+	p.AllowAttrs("class").Matching(regexp.MustCompile(`^language-[\w+#-]+$`)).OnElements("code")
+	p.AllowAttrs("id").Matching(regexp.MustCompile(`^[\w-]+$`)).OnElements("h1", "h2", "h3", "h4", "h5", "h6")
+	p.AllowAttrs("type", "checked", "disabled").OnElements("input")
+	return p
+}()
+
+// RenderMarkdown renders markdown to sanitized HTML. Relative link and image targets are
+// resolved against baseURL; without a base, relative images are dropped and relative links
+// are unlinked since they would otherwise point into this site.
+func RenderMarkdown(source []byte, baseURL string) (string, error) {
+	var buf bytes.Buffer
+	if err := markdown.Convert(source, &buf); err != nil {
+		return "", fmt.Errorf("failed to render markdown: %v", err)
+	}
+	sanitized := markdownPolicy.SanitizeBytes(buf.Bytes())
+
+	var base *url.URL
+	if baseURL != "" {
+		u, err := url.Parse(baseURL)
+		if err != nil || !u.IsAbs() {
+			return "", fmt.Errorf("invalid readme base url: %s", baseURL)
+		}
+		// 以目录为基准解析，避免丢失最后一级路径
+		if !strings.HasSuffix(u.Path, "/") {
+			u.Path += "/"
+		}
+		base = u
+	}
+
+	return rewriteRelativeURLs(sanitized, base)
+}
+
+func rewriteRelativeURLs(fragment []byte, base *url.URL) (string, error) {
+	context := &html.Node{Type: html.ElementNode, Data: "div", DataAtom: atom.Div}
+	nodes, err := html.ParseFragment(bytes.NewReader(fragment), context)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse rendered markdown: %v", err)
+	}
+
+	var out bytes.Buffer
+	for _, n := range nodes {
+		rewriteNode(n, base)
+		if err := html.Render(&out, n); err != nil {
+			return "", err
+		}
+	}
+	return out.String(), nil
+}
+
+func rewriteNode(n *html.Node, base *url.URL) {
+	if n.Type == html.ElementNode {
+		switch n.Data {
+		case "img":
+			rewriteAttr(n, "src", base)
+		case "a":
+			rewriteAttr(n, "href", base)
+		}
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		rewriteNode(c, base)
+	}
+}
+
+func rewriteAttr(n *html.Node, key string, base *url.URL) {
+	for i, attr := range n.Attr {
+		if attr.Key != key {
+			continue
+		}
+		target, err := url.Parse(attr.Val)
+		if err != nil || target.IsAbs() || strings.HasPrefix(attr.Val, "//") || strings.HasPrefix(attr.Val, "#") {
+			return
+		}
+		if base != nil {
+			n.Attr[i].Val = base.ResolveReference(target).String()
+			return
+		}
+		n.Attr = append(n.Attr[:i], n.Attr[i+1:]...)
+		return
+	}
+}
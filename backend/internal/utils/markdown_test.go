@@ -0,0 +1,39 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenderMarkdownSanitizes(t *testing.T) {
+	out, err := RenderMarkdown([]byte("# Title\n\n<script>alert(1)</script>\n\n[x](javascript:alert(1))\n\n<img src=x onerror=alert(1)>"), "")
+	require.NoError(t, err)
+
+	assert.Contains(t, out, `<h1 id="title">Title</h1>`)
+	assert.NotContains(t, out, "<script")
+	assert.NotContains(t, out, "javascript:")
+	assert.NotContains(t, out, "onerror")
+}
+
+func TestRenderMarkdownRewritesRelativeURLs(t *testing.T) {
+	source := []byte("![arch](docs/arch.png)\n\n[guide](docs/guide.md) [top](#title) [site](https://example.com)\n\n<img src=\"logo.png\">")
+
+	out, err := RenderMarkdown(source, "https://raw.example.com/org/repo/main")
+	require.NoError(t, err)
+	assert.Contains(t, out, `src="https://raw.example.com/org/repo/main/docs/arch.png"`)
+	assert.Contains(t, out, `href="https://raw.example.com/org/repo/main/docs/guide.md"`)
+	assert.Contains(t, out, `src="https://raw.example.com/org/repo/main/logo.png"`)
+	assert.Contains(t, out, `href="#title"`)
+	assert.Contains(t, out, `href="https://example.com"`)
+
+	out, err = RenderMarkdown(source, "")
+	require.NoError(t, err)
+	assert.NotContains(t, out, "docs/arch.png")
+	assert.NotContains(t, out, "docs/guide.md")
+	assert.Contains(t, out, `href="#title"`)
+
+	_, err = RenderMarkdown(source, "not a url")
+	assert.Error(t, err)
+}
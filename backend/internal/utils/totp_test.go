@@ -0,0 +1,64 @@
+package utils
+
+import (
+	"encoding/base32"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// RFC 6238 附录 B 的 SHA1 测试向量（取后 6 位）
+var rfcSecret = base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString([]byte("12345678901234567890"))
+
+func TestGenerateTOTPCode(t *testing.T) {
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+	}
+
+	for _, tt := range tests {
+		code, err := GenerateTOTPCode(rfcSecret, time.Unix(tt.unix, 0))
+		assert.NoError(t, err)
+		assert.Equal(t, tt.want, code)
+	}
+}
+
+func TestValidateTOTPCode(t *testing.T) {
+	now := time.Unix(1234567890, 0)
+	code, err := GenerateTOTPCode(rfcSecret, now)
+	assert.NoError(t, err)
+
+	step, ok := ValidateTOTPCode(rfcSecret, code, now)
+	assert.True(t, ok)
+	assert.Equal(t, now.Unix()/TOTPPeriod, step)
+
+	// 允许一个周期的时钟偏移
+	_, ok = ValidateTOTPCode(rfcSecret, code, now.Add(TOTPPeriod*time.Second))
+	assert.True(t, ok)
+
+	// 超出偏移范围的验证码无效
+	_, ok = ValidateTOTPCode(rfcSecret, code, now.Add(3*TOTPPeriod*time.Second))
+	assert.False(t, ok)
+
+	_, ok = ValidateTOTPCode(rfcSecret, "12345", now)
+	assert.False(t, ok)
+	_, ok = ValidateTOTPCode("not base32!", code, now)
+	assert.False(t, ok)
+}
+
+func TestTOTPURI(t *testing.T) {
+	secret, err := GenerateTOTPSecret()
+	assert.NoError(t, err)
+
+	uri := TOTPURI("Share AI Platform", "alice", secret)
+	assert.True(t, strings.HasPrefix(uri, "otpauth://totp/Share%20AI%20Platform:alice?"))
+	assert.Contains(t, uri, "secret="+secret)
+	assert.Contains(t, uri, "digits=6")
+}
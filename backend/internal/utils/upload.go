@@ -1,19 +1,27 @@
 package utils
 
 import (
+	"bytes"
+	"context"
 	"fmt"
+	"io"
 	"mime/multipart"
-	"os"
+	"path"
 	"path/filepath"
 	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/google/uuid"
+
+	"github.com/samzong/share-ai-platform/internal/storage"
 )
 
 const (
-	UploadDir   = "uploads"
-	MaxFileSize = 5 << 20 // 5MB
+	MaxFileSize   = 5 << 20 // 5MB
+	MaxReadmeSize = 1 << 20 // 1MB
+
+	ReadmeContentType = "text/markdown; charset=utf-8"
 )
 
 var AllowedImageTypes = map[string]bool{
@@ -22,80 +30,128 @@ var AllowedImageTypes = map[string]bool{
 	"image/gif":  true,
 }
 
-// UploadFile handles file upload and returns the file path
+// AllowedReadmeExts 浏览器通常以 application/octet-stream 上传 .md，因此按扩展名判断
+var AllowedReadmeExts = map[string]bool{
+	".md":       true,
+	".markdown": true,
+	".txt":      true,
+}
+
+// UploadFile validates an uploaded image by its content, re-encodes it to strip metadata
+// and stores it in the configured storage backend, returning its key
 func UploadFile(file *multipart.FileHeader, subDir string) (string, error) {
-	// Check file size
-	if file.Size > MaxFileSize {
-		return "", fmt.Errorf("file size exceeds maximum limit of %d bytes", MaxFileSize)
+	img, contentType, err := decodeImage(file)
+	if err != nil {
+		return "", err
 	}
 
-	// Check file type
-	if !AllowedImageTypes[file.Header.Get("Content-Type")] {
-		return "", fmt.Errorf("file type not allowed")
+	data, contentType, err := encodeImage(img, contentType)
+	if err != nil {
+		return "", err
 	}
+	return putFile(subDir, imageExts[contentType], bytes.NewReader(data), int64(len(data)), contentType)
+}
 
-	// Create upload directory if it doesn't exist
-	uploadPath := filepath.Join(UploadDir, subDir)
-	if err := os.MkdirAll(uploadPath, 0755); err != nil {
-		return "", fmt.Errorf("failed to create upload directory: %v", err)
+// UploadReadme validates an uploaded markdown file and stores it, returning its key
+func UploadReadme(file *multipart.FileHeader) (string, error) {
+	if file.Size > MaxReadmeSize {
+		return "", fmt.Errorf("readme size exceeds maximum limit of %d bytes", MaxReadmeSize)
+	}
+	if !AllowedReadmeExts[strings.ToLower(filepath.Ext(file.Filename))] {
+		return "", fmt.Errorf("readme must be a markdown file")
 	}
 
-	// Generate unique filename
-	ext := filepath.Ext(file.Filename)
-	filename := fmt.Sprintf("%s_%s%s",
-		time.Now().Format("20060102"),
-		uuid.New().String(),
-		ext,
-	)
-
-	// Save file
-	dst := filepath.Join(uploadPath, filename)
 	src, err := file.Open()
 	if err != nil {
 		return "", fmt.Errorf("failed to open uploaded file: %v", err)
 	}
 	defer src.Close()
 
-	out, err := os.Create(dst)
+	content, err := io.ReadAll(io.LimitReader(src, MaxReadmeSize+1))
 	if err != nil {
-		return "", fmt.Errorf("failed to create destination file: %v", err)
+		return "", fmt.Errorf("failed to read uploaded file: %v", err)
 	}
-	defer out.Close()
+	return SaveReadme(string(content))
+}
 
-	// Copy the uploaded file to the destination file
-	buffer := make([]byte, 1024)
-	for {
-		n, err := src.Read(buffer)
-		if err != nil {
-			break
-		}
-		out.Write(buffer[:n])
+// SaveReadme stores inline markdown content and returns its key
+func SaveReadme(content string) (string, error) {
+	if len(content) > MaxReadmeSize {
+		return "", fmt.Errorf("readme size exceeds maximum limit of %d bytes", MaxReadmeSize)
 	}
+	if !utf8.ValidString(content) {
+		return "", fmt.Errorf("readme must be UTF-8 text")
+	}
+	return putFile("readme", ".md", strings.NewReader(content), int64(len(content)), ReadmeContentType)
+}
 
-	// Return the relative file path
-	return filepath.Join(subDir, filename), nil
+// ReadFile reads a stored file of at most maxSize bytes
+func ReadFile(ctx context.Context, key string, maxSize int64) ([]byte, error) {
+	store, err := storage.Get()
+	if err != nil {
+		return nil, err
+	}
+	r, _, err := store.Get(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return io.ReadAll(io.LimitReader(r, maxSize))
 }
 
-// GetFileURL returns the full URL for a file path
-func GetFileURL(path string) string {
-	if path == "" {
+// putFile stores content under a unique key in subDir
+func putFile(subDir string, ext string, r io.Reader, size int64, contentType string) (string, error) {
+	store, err := storage.Get()
+	if err != nil {
+		return "", fmt.Errorf("failed to initialize storage: %v", err)
+	}
+
+	// Generate unique key
+	key := path.Join(subDir, fmt.Sprintf("%s_%s%s",
+		time.Now().Format("20060102"),
+		uuid.New().String(),
+		ext,
+	))
+
+	if err := store.Put(context.Background(), key, r, size, contentType); err != nil {
+		return "", fmt.Errorf("failed to store file: %v", err)
+	}
+	return key, nil
+}
+
+// GetFileURL is the single builder for file URLs in API responses. Private objects such as
+// READMEs get a signed URL that expires after storage.signed_url_expiry.
+func GetFileURL(key string) string {
+	if key == "" {
 		return ""
 	}
-	if strings.HasPrefix(path, "http://") || strings.HasPrefix(path, "https://") {
-		return path
+	if strings.HasPrefix(key, "http://") || strings.HasPrefix(key, "https://") {
+		return key
 	}
-	// TODO: 从配置中获取基础URL
-	baseURL := "http://localhost:8080"
-	return fmt.Sprintf("%s/uploads/%s", baseURL, path)
+
+	store, err := storage.Get()
+	if err != nil {
+		return ""
+	}
+	url, err := store.Presign(context.Background(), key, storage.PresignExpiry())
+	if err != nil {
+		return ""
+	}
+	return url
 }
 
-// DeleteFile deletes a file from the upload directory
-func DeleteFile(path string) error {
-	if path == "" {
+// DeleteFile deletes a file from the storage backend
+func DeleteFile(key string) error {
+	if key == "" {
 		return nil
 	}
-	if strings.HasPrefix(path, "http://") || strings.HasPrefix(path, "https://") {
+	if strings.HasPrefix(key, "http://") || strings.HasPrefix(key, "https://") {
 		return nil
 	}
-	return os.Remove(filepath.Join(UploadDir, path))
+
+	store, err := storage.Get()
+	if err != nil {
+		return err
+	}
+	return store.Delete(context.Background(), key)
 }
@@ -1,5 +1,6 @@
 export interface ContainerImage {
   id: string;
+  project_id?: string;
   name: string;
   description: string;
   author: string;
@@ -10,8 +11,10 @@ export interface ContainerImage {
   digest: string;
   size: number;
   readme_path: string;
+  readme_url: string;
   stars: number;
-  visibility: "public" | "private";
+  visibility: "public" | "private" | "require_access";
+  has_access: boolean;
   platform: string;
   labels: Label[];
   created_at: string;
@@ -35,3 +38,9 @@ export interface ImageListRequest {
   page_size?: number;
   search?: string;
 }
+
+export interface ImageReadme {
+  image_id: string;
+  markdown: string;
+  html: string;
+}
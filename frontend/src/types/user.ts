@@ -4,6 +4,7 @@ export interface User {
   email: string;
   nickname: string;
   avatar: string;
+  avatar_urls?: Record<string, string>;
   role: "user" | "admin";
 }
 